@@ -20,3 +20,11 @@ func GetViperBoolIfSet(viper *viper.Viper, key string) *bool {
 		return nil
 	}
 }
+
+func GetViperInt32IfSet(viper *viper.Viper, key string) *int32 {
+	if viper.IsSet(key) {
+		return lib.NewInt32Ptr(viper.GetInt32(key))
+	} else {
+		return nil
+	}
+}
@@ -24,6 +24,8 @@ func defineApplicationCreateOrUpdateFlags(flags *pflag.FlagSet, creating bool) {
 	flags.String("display-name", "", "human-friendly display name"+requiredAtCreationIndicator)
 	flags.String("proposal-state", "draft", "'draft', 'final' or 'abandon'")
 	flags.Bool("enabled", true, "whether to enable this application")
+	flags.Int32("release-expiry-minutes", 0, "cancel releases that remain in progress for longer than this many minutes (0 = never)")
+	flags.Int32("override-confirmation-minutes", 0, "require release overrides to be confirmed by someone else within this many minutes (0 = no confirmation needed)")
 }
 
 func applicationCreateOrUpdateCmd_createVersionInput(viper *viper.Viper) json.ApplicationVersionInput {
@@ -31,8 +33,10 @@ func applicationCreateOrUpdateCmd_createVersionInput(viper *viper.Viper) json.Ap
 		ReviewableVersionInputBase: json.ReviewableVersionInputBase{
 			ProposalState: proposalstateinput.Input(viper.GetString("proposal-state")),
 		},
-		DisplayName: cli.GetViperStringIfSet(viper, "display-name"),
-		Enabled:     cli.GetViperBoolIfSet(viper, "enabled"),
+		DisplayName:                 cli.GetViperStringIfSet(viper, "display-name"),
+		Enabled:                     cli.GetViperBoolIfSet(viper, "enabled"),
+		ReleaseExpiryMinutes:        cli.GetViperInt32IfSet(viper, "release-expiry-minutes"),
+		OverrideConfirmationMinutes: cli.GetViperInt32IfSet(viper, "override-confirmation-minutes"),
 	}
 }
 
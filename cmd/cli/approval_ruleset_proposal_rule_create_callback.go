@@ -0,0 +1,108 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// approvalRulesetProposalRuleCreateCallbackCmd represents the 'approval-ruleset proposal rule create-callback' command
+var approvalRulesetProposalRuleCreateCallbackCmd = &cobra.Command{
+	Use:   "create-callback",
+	Short: "Create a callback rule",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return approvalRulesetProposalRuleCreateCallbackCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func approvalRulesetProposalRuleCreateCallbackCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := approvalRulesetProposalRuleCreateCallbackCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	ruleset, err := approvalRulesetProposalCmd_getRuleset(viper, config, state)
+	if err != nil {
+		return err
+	}
+	rules, err := approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+	rules = append(rules, approvalRulesetProposalRuleCreateCallbackCmd_buildRuleDefinition(viper))
+	ruleset, err = approvalRulesetProposalRuleCmd_patchRules(viper, config, state, rules)
+	if err != nil {
+		return err
+	}
+	rules, err = approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+
+	output, err := encjson.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	cli.PrintSeparatorln(printer)
+	cli.PrintCelebrationlnf(printer, "Rule created!")
+
+	return nil
+}
+
+func approvalRulesetProposalRuleCreateCallbackCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"approval-ruleset-id", "proposal-id", "url"},
+	})
+}
+
+func approvalRulesetProposalRuleCreateCallbackCmd_buildRuleDefinition(viper *viper.Viper) map[string]interface{} {
+	result := map[string]interface{}{
+		"type":             "callback",
+		"enabled":          viper.GetBool("enabled"),
+		"evaluation_order": viper.GetInt("evaluation-order"),
+		"url":              viper.GetString("url"),
+	}
+	if username := viper.GetString("username"); len(username) > 0 {
+		result["username"] = username
+	}
+	if password := viper.GetString("password"); len(password) > 0 {
+		result["password"] = password
+	}
+	if timeoutMinutes := viper.GetInt("timeout-minutes"); timeoutMinutes > 0 {
+		result["timeout_minutes"] = timeoutMinutes
+	}
+	return result
+}
+
+func init() {
+	cmd := approvalRulesetProposalRuleCreateCallbackCmd
+	flags := cmd.Flags()
+	approvalRulesetProposalRuleCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("approval-ruleset-id", "", "approval ruleset ID (required)")
+	flags.String("proposal-id", "", "proposal ID (required)")
+	flags.Bool("enabled", true, "whether to enable this rule")
+	flags.Int("evaluation-order", 0, "rules with a lower evaluation order are evaluated first")
+	flags.String("url", "", "URL to POST jobs to (required)")
+	flags.String("username", "", "HTTP basic authentication username")
+	flags.String("password", "", "HTTP basic authentication password")
+	flags.Int("timeout-minutes", 0, "how long to wait for a callback before failing the rule (default 60)")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// approvalRulesetProposalRuleCreateDependencyCmd represents the 'approval-ruleset proposal rule create-dependency' command
+var approvalRulesetProposalRuleCreateDependencyCmd = &cobra.Command{
+	Use:   "create-dependency",
+	Short: "Create a dependency rule",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return approvalRulesetProposalRuleCreateDependencyCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func approvalRulesetProposalRuleCreateDependencyCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := approvalRulesetProposalRuleCreateDependencyCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	ruleset, err := approvalRulesetProposalCmd_getRuleset(viper, config, state)
+	if err != nil {
+		return err
+	}
+	rules, err := approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+	rules = append(rules, approvalRulesetProposalRuleCreateDependencyCmd_buildRuleDefinition(viper))
+	ruleset, err = approvalRulesetProposalRuleCmd_patchRules(viper, config, state, rules)
+	if err != nil {
+		return err
+	}
+	rules, err = approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+
+	output, err := encjson.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	cli.PrintSeparatorln(printer)
+	cli.PrintCelebrationlnf(printer, "Rule created!")
+
+	return nil
+}
+
+func approvalRulesetProposalRuleCreateDependencyCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"approval-ruleset-id", "proposal-id", "dependency-application-id"},
+	})
+}
+
+func approvalRulesetProposalRuleCreateDependencyCmd_buildRuleDefinition(viper *viper.Viper) map[string]interface{} {
+	result := map[string]interface{}{
+		"type":                      "dependency",
+		"enabled":                   viper.GetBool("enabled"),
+		"evaluation_order":          viper.GetInt("evaluation-order"),
+		"dependency_application_id": viper.GetString("dependency-application-id"),
+		"same_source_identity":      viper.GetBool("same-source-identity"),
+	}
+	if maxAgeHours := viper.GetInt("max-age-hours"); maxAgeHours > 0 {
+		result["max_age_hours"] = maxAgeHours
+	}
+	return result
+}
+
+func init() {
+	cmd := approvalRulesetProposalRuleCreateDependencyCmd
+	flags := cmd.Flags()
+	approvalRulesetProposalRuleCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("approval-ruleset-id", "", "approval ruleset ID (required)")
+	flags.String("proposal-id", "", "proposal ID (required)")
+	flags.Bool("enabled", true, "whether to enable this rule")
+	flags.Int("evaluation-order", 0, "rules with a lower evaluation order are evaluated first")
+	flags.String("dependency-application-id", "", "ID of the application that must have an approved release (required)")
+	flags.Int("max-age-hours", 0, "only consider dependency releases created within this many hours")
+	flags.Bool("same-source-identity", false, "only consider dependency releases with the same source identity")
+}
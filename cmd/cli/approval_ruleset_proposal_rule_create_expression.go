@@ -0,0 +1,95 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// approvalRulesetProposalRuleCreateExpressionCmd represents the 'approval-ruleset proposal rule create-expression' command
+var approvalRulesetProposalRuleCreateExpressionCmd = &cobra.Command{
+	Use:   "create-expression",
+	Short: "Create an expression rule",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return approvalRulesetProposalRuleCreateExpressionCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func approvalRulesetProposalRuleCreateExpressionCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := approvalRulesetProposalRuleCreateExpressionCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	ruleset, err := approvalRulesetProposalCmd_getRuleset(viper, config, state)
+	if err != nil {
+		return err
+	}
+	rules, err := approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+	rules = append(rules, approvalRulesetProposalRuleCreateExpressionCmd_buildRuleDefinition(viper))
+	ruleset, err = approvalRulesetProposalRuleCmd_patchRules(viper, config, state, rules)
+	if err != nil {
+		return err
+	}
+	rules, err = approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+
+	output, err := encjson.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	cli.PrintSeparatorln(printer)
+	cli.PrintCelebrationlnf(printer, "Rule created!")
+
+	return nil
+}
+
+func approvalRulesetProposalRuleCreateExpressionCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"approval-ruleset-id", "proposal-id", "expression"},
+	})
+}
+
+func approvalRulesetProposalRuleCreateExpressionCmd_buildRuleDefinition(viper *viper.Viper) map[string]interface{} {
+	return map[string]interface{}{
+		"type":             "expression",
+		"enabled":          viper.GetBool("enabled"),
+		"evaluation_order": viper.GetInt("evaluation-order"),
+		"expression":       viper.GetString("expression"),
+	}
+}
+
+func init() {
+	cmd := approvalRulesetProposalRuleCreateExpressionCmd
+	flags := cmd.Flags()
+	approvalRulesetProposalRuleCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("approval-ruleset-id", "", "approval ruleset ID (required)")
+	flags.String("proposal-id", "", "proposal ID (required)")
+	flags.Bool("enabled", true, "whether to enable this rule")
+	flags.Int("evaluation-order", 0, "rules with a lower evaluation order are evaluated first")
+	flags.String("expression", "", "CEL expression that must evaluate to true (required)")
+}
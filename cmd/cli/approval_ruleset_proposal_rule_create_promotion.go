@@ -0,0 +1,97 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// approvalRulesetProposalRuleCreatePromotionCmd represents the 'approval-ruleset proposal rule create-promotion' command
+var approvalRulesetProposalRuleCreatePromotionCmd = &cobra.Command{
+	Use:   "create-promotion",
+	Short: "Create a promotion rule",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return approvalRulesetProposalRuleCreatePromotionCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func approvalRulesetProposalRuleCreatePromotionCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := approvalRulesetProposalRuleCreatePromotionCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	ruleset, err := approvalRulesetProposalCmd_getRuleset(viper, config, state)
+	if err != nil {
+		return err
+	}
+	rules, err := approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+	rules = append(rules, approvalRulesetProposalRuleCreatePromotionCmd_buildRuleDefinition(viper))
+	ruleset, err = approvalRulesetProposalRuleCmd_patchRules(viper, config, state, rules)
+	if err != nil {
+		return err
+	}
+	rules, err = approvalRulesetProposalRuleCmd_getRules(ruleset)
+	if err != nil {
+		return err
+	}
+
+	output, err := encjson.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	cli.PrintSeparatorln(printer)
+	cli.PrintCelebrationlnf(printer, "Rule created!")
+
+	return nil
+}
+
+func approvalRulesetProposalRuleCreatePromotionCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"approval-ruleset-id", "proposal-id", "source-application-id"},
+	})
+}
+
+func approvalRulesetProposalRuleCreatePromotionCmd_buildRuleDefinition(viper *viper.Viper) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                  "promotion",
+		"enabled":               viper.GetBool("enabled"),
+		"evaluation_order":      viper.GetInt("evaluation-order"),
+		"source_application_id": viper.GetString("source-application-id"),
+		"min_soak_hours":        viper.GetInt("min-soak-hours"),
+	}
+}
+
+func init() {
+	cmd := approvalRulesetProposalRuleCreatePromotionCmd
+	flags := cmd.Flags()
+	approvalRulesetProposalRuleCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("approval-ruleset-id", "", "approval ruleset ID (required)")
+	flags.String("proposal-id", "", "proposal ID (required)")
+	flags.Bool("enabled", true, "whether to enable this rule")
+	flags.Int("evaluation-order", 0, "rules with a lower evaluation order are evaluated first")
+	flags.String("source-application-id", "", "ID of the application on which the same source identity must have been approved (required)")
+	flags.Int("min-soak-hours", 0, "minimum number of hours since approval on the source application")
+}
@@ -73,8 +73,10 @@ func approvalRulesetProposalRuleCreateScheduleCmd_checkConfig(viper *viper.Viper
 
 func approvalRulesetProposalRuleCreateScheduleCmd_buildRuleDefinition(viper *viper.Viper) map[string]interface{} {
 	result := map[string]interface{}{
-		"type":    "schedule",
-		"enabled": viper.GetBool("enabled"),
+		"type":               "schedule",
+		"enabled":            viper.GetBool("enabled"),
+		"evaluation_order":   viper.GetInt("evaluation-order"),
+		"defer_until_window": viper.GetBool("defer-until-window"),
 	}
 
 	maybeAddString := func(resultKey string, viperKey string) {
@@ -86,9 +88,11 @@ func approvalRulesetProposalRuleCreateScheduleCmd_buildRuleDefinition(viper *vip
 
 	maybeAddString("begin_time", "begin-time")
 	maybeAddString("end_time", "end-time")
+	maybeAddString("time_ranges", "time-ranges")
 	maybeAddString("days_of_week", "days-of-week")
 	maybeAddString("days_of_month", "days-of-month")
 	maybeAddString("months-of-year", "months-of-year")
+	maybeAddString("time_zone", "time-zone")
 
 	return result
 }
@@ -103,9 +107,13 @@ func init() {
 	flags.String("approval-ruleset-id", "", "approval ruleset ID (required)")
 	flags.String("proposal-id", "", "proposal ID (required)")
 	flags.Bool("enabled", true, "whether to enable this rule")
+	flags.Int("evaluation-order", 0, "rules with a lower evaluation order are evaluated first")
 	flags.String("begin-time", "", "schedule begin time")
 	flags.String("end-time", "", "schedule end time")
+	flags.String("time-ranges", "", "space-separated schedule time ranges, e.g. '22:00-02:00 09:00-12:00'")
 	flags.String("days-of-week", "", "schedule days of week")
 	flags.String("days-of-month", "", "schedule days of month")
 	flags.String("months-of-year", "", "schedule months of year")
+	flags.Bool("defer-until-window", false, "keep releases created outside the schedule in progress until the next time window begins, instead of rejecting them")
+	flags.String("time-zone", "", "IANA time zone in which the schedule is interpreted, e.g. Europe/Amsterdam (default: the organization's default time zone)")
 }
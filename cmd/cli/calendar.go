@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// calendarCmd represents the 'calendar' command
+var calendarCmd = &cobra.Command{
+	Use:   "calendar",
+	Short: "Manage calendars",
+}
+
+func init() {
+	rootCmd.AddCommand(calendarCmd)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// calendarCreateCmd represents the 'calendar create' command
+var calendarCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a calendar",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return calendarCreateCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func calendarCreateCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := calendarCreateCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var result map[string]interface{}
+	resp, err := req.
+		SetBody(calendarCreateCmd_createBody(viper)).
+		SetResult(&result).
+		Post("/calendars")
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error creating calendar: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	cli.PrintSeparatorln(printer)
+	cli.PrintCelebrationlnf(printer, "Calendar created!")
+	cli.PrintTiplnf(printer, "To import entries from an .ics file, use `sqedule calendar import`")
+
+	return nil
+}
+
+func calendarCreateCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"id", "display-name"},
+	})
+}
+
+func calendarCreateCmd_createBody(viper *viper.Viper) json.CalendarInput {
+	return json.CalendarInput{
+		ID:          lib.NewStringPtr(viper.GetString("id")),
+		DisplayName: cli.GetViperStringIfSet(viper, "display-name"),
+		TimeZone:    cli.GetViperStringIfSet(viper, "time-zone"),
+	}
+}
+
+func init() {
+	cmd := calendarCreateCmd
+	flags := cmd.Flags()
+	calendarCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("id", "", "a machine-friendly identifier (required)")
+	flags.String("display-name", "", "human-friendly display name (required)")
+	flags.String("time-zone", "", "IANA time zone in which all-day entries are interpreted, e.g. Europe/Amsterdam (default: the organization's default time zone)")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// calendarDeleteCmd represents the 'calendar delete' command
+var calendarDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a calendar",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return calendarDeleteCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func calendarDeleteCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := calendarDeleteCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	resp, err := req.
+		Delete(fmt.Sprintf("/calendars/%s",
+			url.PathEscape(viper.GetString("id"))))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error deleting calendar: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	cli.PrintCelebrationlnf(printer, "Calendar deleted!")
+
+	return nil
+}
+
+func calendarDeleteCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"id"},
+	})
+}
+
+func init() {
+	cmd := calendarDeleteCmd
+	flags := cmd.Flags()
+	calendarCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("id", "", "calendar ID (required)")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// calendarDescribeCmd represents the 'calendar describe' command
+var calendarDescribeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Describe a calendar",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return calendarDescribeCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func calendarDescribeCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := calendarDescribeCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	resp, err := req.
+		SetResult(&result).
+		Get(fmt.Sprintf("/calendars/%s",
+			url.PathEscape(viper.GetString("id"))))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error describing calendar: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func calendarDescribeCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"id"},
+	})
+}
+
+func init() {
+	cmd := calendarDescribeCmd
+	flags := cmd.Flags()
+	calendarCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("id", "", "calendar ID (required)")
+}
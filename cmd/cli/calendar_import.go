@@ -0,0 +1,93 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// calendarImportCmd represents the 'calendar import' command
+var calendarImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import calendar entries from an iCalendar (.ics) file",
+	Long: "Import calendar entries from an iCalendar (.ics) file.\n\n" +
+		"All existing entries in the calendar are replaced by the events in the file.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return calendarImportCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func calendarImportCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := calendarImportCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	data, err := ioutil.ReadFile(viper.GetString("file"))
+	if err != nil {
+		return fmt.Errorf("Error reading %s: %w", viper.GetString("file"), err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	resp, err := req.
+		SetHeader("Content-Type", "text/calendar").
+		SetBody(data).
+		SetResult(&result).
+		Put(fmt.Sprintf("/calendars/%s/entries",
+			url.PathEscape(viper.GetString("id"))))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error importing calendar entries: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	cli.PrintSeparatorln(printer)
+	cli.PrintCelebrationlnf(printer, "Calendar entries imported!")
+
+	return nil
+}
+
+func calendarImportCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"id", "file"},
+	})
+}
+
+func init() {
+	cmd := calendarImportCmd
+	flags := cmd.Flags()
+	calendarCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.String("id", "", "calendar ID (required)")
+	flags.String("file", "", "path to an iCalendar (.ics) file (required)")
+}
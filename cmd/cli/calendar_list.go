@@ -0,0 +1,65 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// calendarListCmd represents the 'calendar list' command
+var calendarListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List calendars",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return calendarListCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func calendarListCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	resp, err := req.
+		SetResult(&result).
+		Get("/calendars")
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error listing calendars: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func init() {
+	cmd := calendarListCmd
+	flags := cmd.Flags()
+	calendarCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+}
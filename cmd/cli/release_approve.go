@@ -0,0 +1,107 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// releaseApproveCmd represents the 'release approve' command
+var releaseApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Manually approve a release",
+	Long: "Manually approves a release, on behalf of a manual approval rule that the release is bound to.\n\n" +
+		"Only needed if the release is bound to a manual approval rule.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseManualApprovalCmd_run(viper.GetViper(), mocking.RealPrinter{}, true)
+	},
+}
+
+func releaseManualApprovalCmd_run(viper *viper.Viper, printer mocking.IPrinter, approved bool) error {
+	err := releaseManualApprovalCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var outcome map[string]interface{}
+	resp, err := req.
+		SetBody(releaseManualApprovalCmd_createBody(viper, approved)).
+		SetResult(&outcome).
+		Post(fmt.Sprintf("/applications/%s/releases/%d/manual-approvals",
+			url.PathEscape(viper.GetString("application-id")),
+			viper.GetUint("release-id")))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		if approved {
+			return fmt.Errorf("Error approving release: %s", cli.GetApiErrorMessage(resp))
+		}
+		return fmt.Errorf("Error rejecting release: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(outcome, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func releaseManualApprovalCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"application-id"},
+		UintNonZero:    []string{"release-id"},
+	})
+}
+
+func releaseManualApprovalCmd_createBody(viper *viper.Viper, approved bool) json.ReleaseManualApprovalInput {
+	result := json.ReleaseManualApprovalInput{
+		Approved: &approved,
+		Comments: lib.NonEmptyStringOrNil(viper.GetString("comments")),
+	}
+	if ruleID := viper.GetUint64("rule-id"); ruleID != 0 {
+		result.RuleID = &ruleID
+	}
+	return result
+}
+
+func releaseManualApprovalCmd_defineFlags(flags *pflag.FlagSet) {
+	cli.DefineServerFlags(flags)
+
+	flags.StringP("application-id", "a", "", "ID of application in which the release is located (required)")
+	flags.Uint("release-id", 0, "ID of release (required)")
+	flags.Uint64("rule-id", 0, "ID of the manual approval rule. Only required if the release is bound to multiple manual approval rules")
+	flags.String("comments", "", "Comments explaining this decision")
+}
+
+func init() {
+	cmd := releaseApproveCmd
+	releaseCmd.AddCommand(cmd)
+	releaseManualApprovalCmd_defineFlags(cmd.Flags())
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"net/http"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	viperPkg "github.com/spf13/viper"
+)
+
+var _ = Describe("release approve/reject", func() {
+	const serverBaseURL = "http://server"
+	const appID = "app1"
+
+	var viper *viperPkg.Viper
+	var printer mocking.FakePrinter
+	var input json.ReleaseManualApprovalInput
+
+	BeforeEach(func() {
+		httpmock.Reset()
+		mockAuthToken()
+		printer = mocking.FakePrinter{}
+		input = json.ReleaseManualApprovalInput{}
+
+		viper = viperPkg.New()
+		viper.Set("server-base-url", serverBaseURL)
+		viper.Set("application-id", appID)
+		viper.Set("release-id", 1)
+
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/manual-approvals", func(req *http.Request) (*http.Response, error) {
+			Expect(encjson.NewDecoder(req.Body).Decode(&input)).To(Succeed())
+			resp, err := httpmock.NewJsonResponse(201, json.ManualApprovalRuleOutcome{})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+	})
+
+	It("approves a release", func() {
+		viper.Set("comments", "looks good")
+
+		err := releaseManualApprovalCmd_run(viper, &printer, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Approved).ToNot(BeNil())
+		Expect(*input.Approved).To(BeTrue())
+		Expect(input.Comments).ToNot(BeNil())
+		Expect(*input.Comments).To(Equal("looks good"))
+		Expect(input.RuleID).To(BeNil())
+	})
+
+	It("rejects a release", func() {
+		viper.Set("rule-id", 2)
+
+		err := releaseManualApprovalCmd_run(viper, &printer, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Approved).ToNot(BeNil())
+		Expect(*input.Approved).To(BeFalse())
+		Expect(input.RuleID).ToNot(BeNil())
+		Expect(*input.RuleID).To(Equal(uint64(2)))
+	})
+})
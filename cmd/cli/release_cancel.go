@@ -0,0 +1,91 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseCancelCmd represents the 'release cancel' command
+var releaseCancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel a release",
+	Long: "Cancels a release that is still in progress. Its approval rules are no longer evaluated.\n\n" +
+		"Fails if the release has already been approved, rejected or cancelled.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseCancelCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func releaseCancelCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := releaseCancelCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var release map[string]interface{}
+	resp, err := req.
+		SetBody(json.ReleaseCancelInput{
+			Reason: lib.NonEmptyStringOrNil(viper.GetString("reason")),
+		}).
+		SetResult(&release).
+		Post(fmt.Sprintf("/applications/%s/releases/%d/cancel",
+			url.PathEscape(viper.GetString("application-id")),
+			viper.GetUint("release-id")))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error cancelling release: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(release, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func releaseCancelCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"application-id"},
+		UintNonZero:    []string{"release-id"},
+	})
+}
+
+func init() {
+	cmd := releaseCancelCmd
+	flags := cmd.Flags()
+	releaseCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.StringP("application-id", "a", "", "ID of application in which the release is located (required)")
+	flags.Uint("release-id", 0, "ID of release (required)")
+	flags.String("reason", "", "Reason for cancelling this release")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"net/http"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	viperPkg "github.com/spf13/viper"
+)
+
+var _ = Describe("release cancel", func() {
+	const serverBaseURL = "http://server"
+	const appID = "app1"
+
+	var viper *viperPkg.Viper
+	var printer mocking.FakePrinter
+	var input json.ReleaseCancelInput
+
+	BeforeEach(func() {
+		httpmock.Reset()
+		mockAuthToken()
+		printer = mocking.FakePrinter{}
+		input = json.ReleaseCancelInput{}
+
+		viper = viperPkg.New()
+		viper.Set("server-base-url", serverBaseURL)
+		viper.Set("application-id", appID)
+		viper.Set("release-id", 1)
+	})
+
+	It("cancels a release", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/cancel", func(req *http.Request) (*http.Response, error) {
+			Expect(encjson.NewDecoder(req.Body).Decode(&input)).To(Succeed())
+			resp, err := httpmock.NewJsonResponse(200, map[string]interface{}{"id": 1, "state": "cancelled"})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+		viper.Set("reason", "pipeline aborted")
+
+		err := releaseCancelCmd_run(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Reason).ToNot(BeNil())
+		Expect(*input.Reason).To(Equal("pipeline aborted"))
+		Expect(printer.String()).To(ContainSubstring(`"state": "cancelled"`))
+	})
+
+	It("reports an error if the release has already been finalized", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/cancel", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(422, map[string]interface{}{"error": "This release has already been finalized"})
+		})
+
+		err := releaseCancelCmd_run(viper, &printer)
+		Expect(err).To(MatchError("Error cancelling release: This release has already been finalized"))
+	})
+})
@@ -0,0 +1,86 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseConfirmOverrideCmd represents the 'release confirm-override' command
+var releaseConfirmOverrideCmd = &cobra.Command{
+	Use:   "confirm-override",
+	Short: "Confirm someone else's release override",
+	Long: "Confirms an override that someone else requested with 'sqedule release override', " +
+		"thereby approving the release. Only needed for applications that require override confirmation.\n\n" +
+		"Fails if the confirmation deadline has passed, or if you requested the override yourself.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseConfirmOverrideCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func releaseConfirmOverrideCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := releaseConfirmOverrideCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var release map[string]interface{}
+	resp, err := req.
+		SetResult(&release).
+		Post(fmt.Sprintf("/applications/%s/releases/%d/override/confirm",
+			url.PathEscape(viper.GetString("application-id")),
+			viper.GetUint("release-id")))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error confirming release override: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(release, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func releaseConfirmOverrideCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"application-id"},
+		UintNonZero:    []string{"release-id"},
+	})
+}
+
+func init() {
+	cmd := releaseConfirmOverrideCmd
+	flags := cmd.Flags()
+	releaseCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.StringP("application-id", "a", "", "ID of application in which the release is located (required)")
+	flags.Uint("release-id", 0, "ID of release (required)")
+}
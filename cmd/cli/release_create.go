@@ -2,8 +2,10 @@ package main
 
 import (
 	encjson "encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"os"
 
 	"github.com/fullstaq-labs/sqedule/cli"
 	"github.com/fullstaq-labs/sqedule/lib"
@@ -24,6 +26,17 @@ var releaseCreateCmd = &cobra.Command{
 			return err
 		}
 
+		if viper.GetBool("dry-run") {
+			state, err := releaseCreateCmd_runDryRun(viper.GetViper(), mocking.RealPrinter{})
+			if err != nil {
+				return err
+			}
+			if state == releasestate.Rejected {
+				os.Exit(40)
+			}
+			return nil
+		}
+
 		return releaseCreateCmd_run(viper.GetViper(), mocking.RealPrinter{}, false)
 	},
 }
@@ -88,6 +101,63 @@ func releaseCreateCmd_run(viper *viper.Viper, printer mocking.IPrinter, testing
 	return nil
 }
 
+// releaseCreateCmd_runDryRun asks the server how the release would be evaluated, without
+// creating it.
+func releaseCreateCmd_runDryRun(viper *viper.Viper, printer mocking.IPrinter) (releasestate.State, error) {
+	err := releaseCreateCmd_checkConfig(viper)
+	if err != nil {
+		return releasestate.InProgress, err
+	}
+	if viper.GetBool("wait") {
+		return releasestate.InProgress, errors.New("--dry-run and --wait cannot be used together")
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return releasestate.InProgress, fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return releasestate.InProgress, err
+	}
+
+	body, err := releaseCreateCmd_createBody(viper)
+	if err != nil {
+		return releasestate.InProgress, err
+	}
+
+	var result map[string]interface{}
+	resp, err := req.
+		SetBody(body).
+		SetResult(&result).
+		Post(fmt.Sprintf("/applications/%s/releases/dry-run",
+			url.PathEscape(viper.GetString("application-id"))))
+	if err != nil {
+		return releasestate.InProgress, err
+	}
+	if resp.IsError() {
+		return releasestate.InProgress, fmt.Errorf("Error evaluating release: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return releasestate.InProgress, fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	resultState, _ := result["state"].(string)
+	cli.PrintSeparatorln(printer)
+	printer.PrintMessagef("The release would be in state: %s\n", resultState)
+	if notEvaluatedRules, _ := result["not_evaluated_rules"].([]interface{}); len(notEvaluatedRules) > 0 {
+		printer.PrintMessagef("%d rule(s) were not evaluated, because that requires contacting an external system\n",
+			len(notEvaluatedRules))
+	}
+
+	return releasestate.State(resultState), nil
+}
+
 func releaseCreateCmd_checkConfig(viper *viper.Viper) error {
 	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
 		StringNonEmpty: []string{"application-id"},
@@ -122,5 +192,6 @@ func init() {
 	flags.String("metadata", "", "Metadata (JSON object)")
 	flags.String("comments", "", "Comments to add to the release")
 	flags.BoolP("wait", "w", false, "Wait until the release's approval state is final")
+	flags.Bool("dry-run", false, "Only show how the release would be evaluated, without creating it. Exits with code 40 if it would be rejected")
 	releaseWaitCmd_defineFlagsSharedWithCreateCmd(flags)
 }
@@ -69,4 +69,49 @@ var _ = Describe("release create", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(printer.String()).To(ContainSubstring("Waiting for the release's approval state to become final"))
 	})
+
+	It("evaluates the release without creating it if --dry-run is set", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/dry-run", func(req *http.Request) (*http.Response, error) {
+			resp, err := httpmock.NewJsonResponse(200, json.ReleaseDryRunResult{
+				State: string(releasestate.Rejected),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+
+		viper.Set("dry-run", true)
+
+		state, err := releaseCreateCmd_runDryRun(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state).To(Equal(releasestate.Rejected))
+		Expect(printer.String()).To(ContainSubstring("The release would be in state: rejected"))
+	})
+
+	It("mentions the rules that a dry run did not evaluate", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/dry-run", func(req *http.Request) (*http.Response, error) {
+			resp, err := httpmock.NewJsonResponse(200, json.ReleaseDryRunResult{
+				State: string(releasestate.InProgress),
+				NotEvaluatedRules: []json.ApprovalRuleEnum{
+					{Rule: json.HTTPApiApprovalRule{ApprovalRuleBase: json.ApprovalRuleBase{Type: "http_api", ID: 1}}},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+
+		viper.Set("dry-run", true)
+
+		state, err := releaseCreateCmd_runDryRun(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state).To(Equal(releasestate.InProgress))
+		Expect(printer.String()).To(ContainSubstring("1 rule(s) were not evaluated"))
+	})
+
+	It("refuses to combine --dry-run with --wait", func() {
+		viper.Set("dry-run", true)
+		viper.Set("wait", true)
+
+		_, err := releaseCreateCmd_runDryRun(viper, &printer)
+		Expect(err).To(MatchError(ContainSubstring("cannot be used together")))
+	})
 })
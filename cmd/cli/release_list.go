@@ -0,0 +1,122 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseListCmd represents the 'release list' command
+var releaseListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List releases",
+	Long: "Lists releases, newest first. Use the flags to filter and sort the releases.\n\n" +
+		"Times are RFC 3339 timestamps (e.g. 2021-03-08T12:00:00Z) or dates (e.g. 2021-03-08, meaning midnight UTC). " +
+		"'after' flags include releases at exactly that time, 'before' flags exclude them.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseListCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func releaseListCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	query, err := releaseListCmd_createQuery(viper)
+	if err != nil {
+		return err
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var path string
+	if appID := viper.GetString("application-id"); len(appID) > 0 {
+		path = fmt.Sprintf("/applications/%s/releases", url.PathEscape(appID))
+	} else {
+		path = "/releases"
+	}
+
+	var result interface{}
+	resp, err := req.
+		SetQueryParamsFromValues(query).
+		SetResult(&result).
+		Get(path)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error listing releases: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func releaseListCmd_createQuery(viper *viper.Viper) (url.Values, error) {
+	query := url.Values{}
+
+	for _, state := range viper.GetStringSlice("state") {
+		query.Add("state", state)
+	}
+	for _, name := range []string{"created-after", "created-before", "finalized-after", "finalized-before",
+		"source-identity-prefix", "sort"} {
+
+		if value := viper.GetString(name); len(value) > 0 {
+			query.Set(strings.ReplaceAll(name, "-", "_"), value)
+		}
+	}
+	if viper.IsSet("overridden") {
+		query.Set("overridden", fmt.Sprint(viper.GetBool("overridden")))
+	}
+	if metadataText := viper.GetString("metadata"); len(metadataText) > 0 {
+		var metadata map[string]interface{}
+		err := encjson.Unmarshal([]byte(metadataText), &metadata)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing metadata as JSON object: %w", err)
+		}
+		query.Set("metadata", metadataText)
+	}
+
+	return query, nil
+}
+
+func init() {
+	cmd := releaseListCmd
+	flags := cmd.Flags()
+	releaseCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.StringP("application-id", "a", "", "Only list releases of this application")
+	flags.StringSlice("state", nil, "Only list releases in one of these states (in_progress, approved, rejected, cancelled). May be specified multiple times, or as a comma-separated list")
+	flags.String("created-after", "", "Only list releases created at or after this time")
+	flags.String("created-before", "", "Only list releases created before this time")
+	flags.String("finalized-after", "", "Only list releases finalized at or after this time")
+	flags.String("finalized-before", "", "Only list releases finalized before this time")
+	flags.String("source-identity-prefix", "", "Only list releases whose source identity starts with this string")
+	flags.String("metadata", "", "Only list releases whose metadata contains this JSON object")
+	flags.Bool("overridden", false, "Only list releases that were (or, if false, weren't) approved through an override")
+	flags.String("sort", "", "Sort order: created_at, -created_at (default), finalized_at or -finalized_at")
+}
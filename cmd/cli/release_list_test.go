@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	viperPkg "github.com/spf13/viper"
+)
+
+var _ = Describe("release list", func() {
+	const serverBaseURL = "http://server"
+
+	var viper *viperPkg.Viper
+	var printer mocking.FakePrinter
+
+	BeforeEach(func() {
+		httpmock.Reset()
+		mockAuthToken()
+		printer = mocking.FakePrinter{}
+
+		viper = viperPkg.New()
+		viper.Set("server-base-url", serverBaseURL)
+	})
+
+	It("passes filters as query parameters", func() {
+		var query map[string][]string
+		httpmock.RegisterResponder("GET", serverBaseURL+"/v1/applications/app1/releases", func(req *http.Request) (*http.Response, error) {
+			query = req.URL.Query()
+			resp, err := httpmock.NewJsonResponse(200, map[string]interface{}{
+				"items": []interface{}{map[string]interface{}{"id": 1, "state": "rejected"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+		viper.Set("application-id", "app1")
+		viper.Set("state", []string{"rejected", "cancelled"})
+		viper.Set("created-after", "2021-03-01")
+		viper.Set("source-identity-prefix", "v2.")
+		viper.Set("metadata", `{"env":"prod"}`)
+		viper.Set("sort", "-finalized_at")
+
+		err := releaseListCmd_run(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(query).To(HaveKeyWithValue("state", []string{"rejected", "cancelled"}))
+		Expect(query).To(HaveKeyWithValue("created_after", []string{"2021-03-01"}))
+		Expect(query).To(HaveKeyWithValue("source_identity_prefix", []string{"v2."}))
+		Expect(query).To(HaveKeyWithValue("metadata", []string{`{"env":"prod"}`}))
+		Expect(query).To(HaveKeyWithValue("sort", []string{"-finalized_at"}))
+		Expect(query).ToNot(HaveKey("finalized_after"))
+		Expect(printer.String()).To(ContainSubstring(`"state": "rejected"`))
+	})
+
+	It("refuses metadata that isn't a JSON object", func() {
+		viper.Set("metadata", "env=prod")
+
+		err := releaseListCmd_run(viper, &printer)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Error parsing metadata as JSON object"))
+	})
+})
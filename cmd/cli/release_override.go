@@ -0,0 +1,98 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseOverrideCmd represents the 'release override' command
+var releaseOverrideCmd = &cobra.Command{
+	Use:   "override",
+	Short: "Force a release to be approved",
+	Long: "Approves a release that is still in progress or that was rejected, regardless of its approval rules. " +
+		"Meant for emergencies, such as deploying a hotfix during a deployment freeze. Only organization admins, " +
+		"admins and change managers may override releases.\n\n" +
+		"If the release's application requires override confirmation, then the release keeps its state until " +
+		"someone else confirms the override with 'sqedule release confirm-override'.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseOverrideCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func releaseOverrideCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := releaseOverrideCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var release map[string]interface{}
+	resp, err := req.
+		SetBody(json.ReleaseOverrideInput{
+			Justification: lib.NewStringPtr(viper.GetString("justification")),
+		}).
+		SetResult(&release).
+		Post(fmt.Sprintf("/applications/%s/releases/%d/override",
+			url.PathEscape(viper.GetString("application-id")),
+			viper.GetUint("release-id")))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error overriding release: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(release, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+	if resp.StatusCode() == http.StatusAccepted {
+		printer.PrintMessageln("The override must be confirmed by someone else before the release is approved.")
+	}
+
+	return nil
+}
+
+func releaseOverrideCmd_checkConfig(viper *viper.Viper) error {
+	return cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"application-id", "justification"},
+		UintNonZero:    []string{"release-id"},
+	})
+}
+
+func init() {
+	cmd := releaseOverrideCmd
+	flags := cmd.Flags()
+	releaseCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.StringP("application-id", "a", "", "ID of application in which the release is located (required)")
+	flags.Uint("release-id", 0, "ID of release (required)")
+	flags.String("justification", "", "Why this release's approval rules are overridden (required)")
+}
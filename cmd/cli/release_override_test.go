@@ -0,0 +1,68 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"net/http"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	viperPkg "github.com/spf13/viper"
+)
+
+var _ = Describe("release override", func() {
+	const serverBaseURL = "http://server"
+	const appID = "app1"
+
+	var viper *viperPkg.Viper
+	var printer mocking.FakePrinter
+	var input json.ReleaseOverrideInput
+
+	BeforeEach(func() {
+		httpmock.Reset()
+		mockAuthToken()
+		printer = mocking.FakePrinter{}
+		input = json.ReleaseOverrideInput{}
+
+		viper = viperPkg.New()
+		viper.Set("server-base-url", serverBaseURL)
+		viper.Set("application-id", appID)
+		viper.Set("release-id", 1)
+	})
+
+	It("overrides a release", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/override", func(req *http.Request) (*http.Response, error) {
+			Expect(encjson.NewDecoder(req.Body).Decode(&input)).To(Succeed())
+			resp, err := httpmock.NewJsonResponse(200, map[string]interface{}{"id": 1, "state": "approved", "overridden": true})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+		viper.Set("justification", "hotfix for incident 42")
+
+		err := releaseOverrideCmd_run(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Justification).ToNot(BeNil())
+		Expect(*input.Justification).To(Equal("hotfix for incident 42"))
+		Expect(printer.String()).To(ContainSubstring(`"overridden": true`))
+		Expect(printer.String()).ToNot(ContainSubstring("must be confirmed"))
+	})
+
+	It("mentions when the override must be confirmed", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/override", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(202, map[string]interface{}{"id": 1, "state": "in_progress", "overridden": false})
+		})
+		viper.Set("justification", "hotfix")
+
+		err := releaseOverrideCmd_run(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(printer.String()).To(ContainSubstring("must be confirmed by someone else"))
+	})
+
+	It("requires a justification", func() {
+		err := releaseOverrideCmd_run(viper, &printer)
+		Expect(err).To(MatchError("Configuration required: justification"))
+	})
+})
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseRejectCmd represents the 'release reject' command
+var releaseRejectCmd = &cobra.Command{
+	Use:   "reject",
+	Short: "Manually reject a release",
+	Long: "Manually rejects a release, on behalf of a manual approval rule that the release is bound to.\n\n" +
+		"Only needed if the release is bound to a manual approval rule.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseManualApprovalCmd_run(viper.GetViper(), mocking.RealPrinter{}, false)
+	},
+}
+
+func init() {
+	cmd := releaseRejectCmd
+	releaseCmd.AddCommand(cmd)
+	releaseManualApprovalCmd_defineFlags(cmd.Flags())
+}
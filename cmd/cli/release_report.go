@@ -0,0 +1,104 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/deploymentstate"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseReportCmd represents the 'release report' command
+var releaseReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report a release's deployment state",
+	Long: "Reports the deployment state of an approved release. Call this from your deployment pipeline, " +
+		"so that Sqedule knows whether approved releases were actually deployed.\n\n" +
+		"Valid states are 'deploying', 'deployed', 'deploy_failed' and 'rolled_back'.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		return releaseReportCmd_run(viper.GetViper(), mocking.RealPrinter{})
+	},
+}
+
+func releaseReportCmd_run(viper *viper.Viper, printer mocking.IPrinter) error {
+	err := releaseReportCmd_checkConfig(viper)
+	if err != nil {
+		return err
+	}
+
+	config := cli.LoadConfigFromViper(viper)
+	state, err := cli.LoadStateFromFilesystem()
+	if err != nil {
+		return fmt.Errorf("Error loading state: %w", err)
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return err
+	}
+
+	var release map[string]interface{}
+	resp, err := req.
+		SetBody(json.ReleaseDeploymentInput{
+			State:    lib.NewStringPtr(viper.GetString("state")),
+			Comments: lib.NonEmptyStringOrNil(viper.GetString("comments")),
+		}).
+		SetResult(&release).
+		Post(fmt.Sprintf("/applications/%s/releases/%d/deployment",
+			url.PathEscape(viper.GetString("application-id")),
+			viper.GetUint("release-id")))
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("Error reporting release deployment state: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	output, err := encjson.MarshalIndent(release, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error formatting result as JSON: %w", err)
+	}
+	printer.PrintOutputln(string(output))
+
+	return nil
+}
+
+func releaseReportCmd_checkConfig(viper *viper.Viper) error {
+	err := cli.RequireConfigOptions(viper, cli.ConfigRequirementSpec{
+		StringNonEmpty: []string{"application-id", "state"},
+		UintNonZero:    []string{"release-id"},
+	})
+	if err != nil {
+		return err
+	}
+
+	if !deploymentstate.State(viper.GetString("state")).IsValid() {
+		return fmt.Errorf("Configuration state must be one of %v", deploymentstate.All)
+	}
+
+	return nil
+}
+
+func init() {
+	cmd := releaseReportCmd
+	flags := cmd.Flags()
+	releaseCmd.AddCommand(cmd)
+
+	cli.DefineServerFlags(flags)
+
+	flags.StringP("application-id", "a", "", "ID of application in which the release is located (required)")
+	flags.Uint("release-id", 0, "ID of release (required)")
+	flags.String("state", "", "Deployment state: deploying, deployed, deploy_failed or rolled_back (required)")
+	flags.String("comments", "", "Comments about this deployment, e.g. a link to the pipeline job")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	encjson "encoding/json"
+	"net/http"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	viperPkg "github.com/spf13/viper"
+)
+
+var _ = Describe("release report", func() {
+	const serverBaseURL = "http://server"
+	const appID = "app1"
+
+	var viper *viperPkg.Viper
+	var printer mocking.FakePrinter
+	var input json.ReleaseDeploymentInput
+
+	BeforeEach(func() {
+		httpmock.Reset()
+		mockAuthToken()
+		printer = mocking.FakePrinter{}
+		input = json.ReleaseDeploymentInput{}
+
+		viper = viperPkg.New()
+		viper.Set("server-base-url", serverBaseURL)
+		viper.Set("application-id", appID)
+		viper.Set("release-id", 1)
+	})
+
+	It("reports a release's deployment state", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/deployment", func(req *http.Request) (*http.Response, error) {
+			Expect(encjson.NewDecoder(req.Body).Decode(&input)).To(Succeed())
+			resp, err := httpmock.NewJsonResponse(200, map[string]interface{}{"id": 1, "state": "approved", "deployment_state": "deployed"})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+		viper.Set("state", "deployed")
+		viper.Set("comments", "pipeline job 123")
+
+		err := releaseReportCmd_run(viper, &printer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.State).ToNot(BeNil())
+		Expect(*input.State).To(Equal("deployed"))
+		Expect(input.Comments).ToNot(BeNil())
+		Expect(*input.Comments).To(Equal("pipeline job 123"))
+		Expect(printer.String()).To(ContainSubstring(`"deployment_state": "deployed"`))
+	})
+
+	It("refuses unknown states", func() {
+		viper.Set("state", "foo")
+
+		err := releaseReportCmd_run(viper, &printer)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Configuration state must be one of"))
+	})
+
+	It("reports an error if the server refuses the state", func() {
+		httpmock.RegisterResponder("POST", serverBaseURL+"/v1/applications/"+appID+"/releases/1/deployment", func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(422, map[string]interface{}{"error": "Only approved releases can be deployed"})
+		})
+		viper.Set("state", "deployed")
+
+		err := releaseReportCmd_run(viper, &printer)
+		Expect(err).To(MatchError("Error reporting release deployment state: Only approved releases can be deployed"))
+	})
+})
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	encjson "encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fullstaq-labs/sqedule/cli"
@@ -54,34 +58,29 @@ func releaseWaitCmd_run(viper *viper.Viper, printer mocking.IPrinter, clock mock
 		return releasestate.InProgress, fmt.Errorf("Error loading state: %w", err)
 	}
 
-	var lastSleepDuration time.Duration
-	if invokedByCreate {
-		lastSleepDuration = releaseWaitCmd_sleep(viper, clock, time.Duration(0))
-	}
-
 	deadline, err := releaseWaitCmd_getDeadline(viper, clock)
 	if err != nil {
 		return releasestate.InProgress, err
 	}
 
-	for {
-		req, err := cli.NewApiRequest(config, state)
-		if err != nil {
-			return releasestate.InProgress, err
-		}
+	releaseState, streamed, err := releaseWaitCmd_waitUsingStream(viper, config, state, printer, deadline)
+	if err != nil || streamed {
+		return releaseState, err
+	}
+
+	// Streaming isn't available (e.g. because the server is too old, or because a proxy
+	// interrupted the stream), so poll instead.
+
+	var lastSleepDuration time.Duration
+	if invokedByCreate {
+		lastSleepDuration = releaseWaitCmd_sleep(viper, clock, time.Duration(0))
+	}
 
-		var release json.ReleaseWithAssociations
-		resp, err := req.
-			SetResult(&release).
-			Get(fmt.Sprintf("/applications/%s/releases/%s",
-				url.PathEscape(viper.GetString("application-id")),
-				url.PathEscape(strconv.FormatUint(uint64(viper.GetUint("release-id")), 10))))
+	for {
+		release, err := releaseWaitCmd_getRelease(viper, config, state)
 		if err != nil {
 			return releasestate.InProgress, err
 		}
-		if resp.IsError() {
-			return releasestate.InProgress, fmt.Errorf("Error querying release: %s", cli.GetApiErrorMessage(resp))
-		}
 
 		printer.PrintMessagef("Current state: %v\n", release.State)
 		if release.ApprovalStatusIsFinal() {
@@ -94,6 +93,110 @@ func releaseWaitCmd_run(viper *viper.Viper, printer mocking.IPrinter, clock mock
 	}
 }
 
+// releaseWaitCmd_waitUsingStream waits until the release's state is final, by streaming the
+// release's events from the server. If streaming isn't possible, then it returns `streamed` = false
+// and no error, in which case the caller should fall back to polling.
+func releaseWaitCmd_waitUsingStream(viper *viper.Viper, config cli.Config, state cli.State, printer mocking.IPrinter,
+	deadline time.Time) (releaseState releasestate.State, streamed bool, err error) {
+
+	reqCtx := context.Background()
+	if (deadline != time.Time{}) {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithDeadline(reqCtx, deadline)
+		defer cancel()
+	}
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return releasestate.InProgress, false, err
+	}
+
+	// Open the stream before querying the current state, so that no state changes are missed.
+	resp, err := req.
+		SetContext(reqCtx).
+		SetDoNotParseResponse(true).
+		SetHeader("Accept", "text/event-stream").
+		Get(releaseWaitCmd_getReleasePath(viper) + "/events/stream")
+	if err != nil {
+		if reqCtx.Err() == context.DeadlineExceeded {
+			return releasestate.InProgress, true, errors.New("Timeout")
+		}
+		return releasestate.InProgress, false, nil
+	}
+	body := resp.RawBody()
+	defer body.Close()
+	if resp.IsError() || !strings.HasPrefix(resp.Header().Get("Content-Type"), "text/event-stream") {
+		return releasestate.InProgress, false, nil
+	}
+
+	release, err := releaseWaitCmd_getRelease(viper, config, state)
+	if err != nil {
+		return releasestate.InProgress, true, err
+	}
+	printer.PrintMessagef("Current state: %v\n", release.State)
+	if release.ApprovalStatusIsFinal() {
+		return releasestate.State(release.State), true, nil
+	}
+
+	var eventName, data string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case len(line) == 0:
+			if eventName == "release" {
+				var notification json.ReleaseNotification
+				if err = encjson.Unmarshal([]byte(data), &notification); err != nil {
+					return releasestate.InProgress, true, fmt.Errorf("Error parsing release event: %w", err)
+				}
+				if notification.State != nil {
+					printer.PrintMessagef("Current state: %v\n", *notification.State)
+					if releasestate.State(*notification.State).IsFinal() {
+						return releasestate.State(*notification.State), true, nil
+					}
+				}
+			}
+			eventName, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+
+	if reqCtx.Err() == context.DeadlineExceeded {
+		return releasestate.InProgress, true, errors.New("Timeout")
+	}
+	return releasestate.InProgress, false, nil
+}
+
+func releaseWaitCmd_getRelease(viper *viper.Viper, config cli.Config, state cli.State) (json.ReleaseWithAssociations, error) {
+	var release json.ReleaseWithAssociations
+
+	req, err := cli.NewApiRequest(config, state)
+	if err != nil {
+		return release, err
+	}
+
+	resp, err := req.
+		SetResult(&release).
+		Get(releaseWaitCmd_getReleasePath(viper))
+	if err != nil {
+		return release, err
+	}
+	if resp.IsError() {
+		return release, fmt.Errorf("Error querying release: %s", cli.GetApiErrorMessage(resp))
+	}
+
+	return release, nil
+}
+
+func releaseWaitCmd_getReleasePath(viper *viper.Viper) string {
+	return fmt.Sprintf("/applications/%s/releases/%s",
+		url.PathEscape(viper.GetString("application-id")),
+		url.PathEscape(strconv.FormatUint(uint64(viper.GetUint("release-id")), 10)))
+}
+
 func releaseWaitCmd_checkConfig(viper *viper.Viper, fromCreateCmd bool) error {
 	var uintNonZeroOptions []string
 	if !fromCreateCmd {
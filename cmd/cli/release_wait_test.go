@@ -71,6 +71,39 @@ var _ = Describe("release wait", func() {
 		Expect(output).To(ContainSubstring("Current state: approved"))
 	})
 
+	It("waits until the approval status is final using the event stream", func() {
+		url := fmt.Sprintf("%s/v1/applications/%s/releases/%d", serverBaseURL, appID, releaseID)
+		httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+			resp, err := httpmock.NewJsonResponse(200, json.ReleaseWithAssociations{
+				Release: json.Release{
+					ID:    releaseID,
+					State: string(releasestate.InProgress),
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return resp, nil
+		})
+		httpmock.RegisterResponder("GET", url+"/events/stream", func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, ": keep-alive\n\n"+
+				"event:release\n"+
+				`data:{"application_id":"app1","release_id":1,"event_type":"rule_processed","event_id":2,"state":null}`+"\n\n"+
+				"event:release\n"+
+				`data:{"application_id":"app1","release_id":1,"event_type":null,"event_id":null,"state":"approved"}`+"\n\n")
+			resp.Header.Set("Content-Type", "text/event-stream")
+			return resp, nil
+		})
+
+		startTime := clock.Now()
+		state, err := releaseWaitCmd_run(viper, &printer, &clock, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state).To(Equal(releasestate.Approved))
+		Expect(clock.Now()).To(Equal(startTime), "Should not sleep")
+
+		output := printer.String()
+		Expect(output).To(ContainSubstring("Current state: in_progress"))
+		Expect(output).To(ContainSubstring("Current state: approved"))
+	})
+
 	It("times out when the approval status doesn't become final", func() {
 		url := fmt.Sprintf("%s/v1/applications/%s/releases/%d", serverBaseURL, appID, releaseID)
 		httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
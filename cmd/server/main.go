@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	gormlogger "gorm.io/gorm/logger"
@@ -15,6 +17,10 @@ import (
 
 var logger = gormlogger.Default.LogMode(gormlogger.Info)
 
+// defaultShutdownTimeout is how long 'run' and 'worker' wait for in-flight work when shutting down,
+// by default. Kubernetes sends SIGKILL 30 seconds after SIGTERM, by default.
+const defaultShutdownTimeout = 25 * time.Second
+
 var rootFlags struct {
 	cfgFile  *string
 	logLevel *string
@@ -88,6 +94,23 @@ func createLoggerWithLevel(logLevel string) (gormlogger.Interface, error) {
 	}
 }
 
+// waitWithContext waits until `wg` is done, or until `ctx` is done, whichever comes first.
+// Returns whether `wg` is done.
+func waitWithContext(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 func initGlobalLogger() {
 	newLogger, err := createLoggerWithLevel(*rootFlags.logLevel)
 	if err != nil {
@@ -3,7 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/fullstaq-labs/sqedule/cli"
 	"github.com/fullstaq-labs/sqedule/server/approvalrulesprocessing"
@@ -13,6 +18,7 @@ import (
 	"github.com/fullstaq-labs/sqedule/server/dbutils"
 	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
 	"github.com/fullstaq-labs/sqedule/server/httpapi"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/releasestream"
 	"github.com/fullstaq-labs/sqedule/server/webuiassetsserving"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
@@ -69,13 +75,20 @@ var runCmd = &cobra.Command{
 			gin.SetMode(gin.ReleaseMode)
 		}
 		engine := gin.Default()
+		processingCtx, stopProcessing := context.WithCancel(context.Background())
+		defer stopProcessing()
+		streamingCtx, stopStreaming := context.WithCancel(context.Background())
+		defer stopStreaming()
 		ctx := httpapi.Context{
 			Db:              db,
 			WaitGroup:       &sync.WaitGroup{},
 			DevelopmentMode: viper.GetBool("dev"),
 			CorsOrigin:      viper.GetString("cors-origin"),
+
+			AutoProcessReleaseInBackground: viper.GetBool("process-releases"),
+			BackgroundProcessingContext:    processingCtx,
+			ReleaseStreamBroker:            releasestream.NewBroker(db),
 		}
-		defer ctx.WaitGroup.Wait()
 
 		err = ctx.SetupRouter(engine, logger)
 		if err != nil {
@@ -93,15 +106,89 @@ var runCmd = &cobra.Command{
 			}
 		}
 
-		err = approvalrulesprocessing.ProcessAllPendingReleasesInBackground(ctx.Db, ctx.WaitGroup)
-		if err != nil {
-			return fmt.Errorf("Error processing pending releases in the background: %w", err)
+		if ctx.AutoProcessReleaseInBackground {
+			err = approvalrulesprocessing.ProcessAllPendingReleasesInBackground(processingCtx, ctx.Db, ctx.WaitGroup)
+			if err != nil {
+				return fmt.Errorf("Error processing pending releases in the background: %w", err)
+			}
+
+			// Picks up releases that are scheduled for processing, but which aren't processed by
+			// the process that scheduled them: retries, releases scheduled by other instances, and
+			// releases left behind by instances that died.
+			worker := approvalrulesprocessing.Worker{
+				Db:            ctx.Db,
+				SweepInterval: viper.GetDuration("sweep-interval"),
+			}
+			ctx.WaitGroup.Add(1)
+			go func() {
+				defer ctx.WaitGroup.Done()
+				worker.Run(processingCtx)
+			}()
+		}
+
+		ctx.WaitGroup.Add(1)
+		go func() {
+			defer ctx.WaitGroup.Done()
+			ctx.ReleaseStreamBroker.Run(streamingCtx)
+		}()
+
+		signalCtx, stopSignalHandling := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stopSignalHandling()
+
+		server := &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", viper.GetString("bind"), viper.GetInt("port")),
+			Handler: engine,
+		}
+		// Shutdown() doesn't wait for long-lived connections such as event streams, so end them.
+		server.RegisterOnShutdown(stopStreaming)
+		serverErr := make(chan error, 1)
+		go func() {
+			serverErr <- server.ListenAndServe()
+		}()
+
+		select {
+		case err = <-serverErr:
+			// The server failed to start.
+			stopStreaming()
+			stopProcessing()
+			ctx.WaitGroup.Wait()
+			return err
+		case <-signalCtx.Done():
+			stopSignalHandling()
 		}
 
-		return engine.Run(fmt.Sprintf("%s:%d", viper.GetString("bind"), viper.GetInt("port")))
+		return runCmd_shutdown(server, ctx.WaitGroup, stopProcessing, viper.GetDuration("shutdown-timeout"))
 	},
 }
 
+// runCmd_shutdown cancels release processing through `stopProcessing`, stops accepting new HTTP
+// requests, then waits at most `timeout` for in-flight HTTP requests and release processing to end.
+//
+// Cancelling makes Engine.Run() abort its outbound HTTP API rule and callback rule requests, and
+// return before processing the next rule type, after releasing its advisory locks. The interrupted
+// job is then scheduled to run immediately, so that another instance or worker claims it. Releases that are
+// waiting for a deferred rule are scheduled to be resumed when that rule becomes eligible.
+func runCmd_shutdown(server *http.Server, wg *sync.WaitGroup, stopProcessing context.CancelFunc, timeout time.Duration) error {
+	logger.Info(context.Background(), "Shutting down; waiting at most %s for in-flight requests and release processing", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stopProcessing()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Warn(context.Background(), "Error shutting down HTTP server: %s", err.Error())
+	}
+
+	if !waitWithContext(shutdownCtx, wg) {
+		logger.Warn(context.Background(), "Timed out waiting for release processing; "+
+			"interrupted releases will be resumed by another instance or after restart")
+		return nil
+	}
+
+	logger.Info(context.Background(), "Shutdown complete")
+	return nil
+}
+
 func runCmd_createDefaultOrg(viper *viper.Viper, db *gorm.DB, logger gormlogger.Interface) error {
 	// When removing this function, don't forget to also update the corresponding code in
 	// - server/httpapi/auth/middleware_org_member_lookup.go, run()
@@ -164,4 +251,7 @@ func init() {
 	flags.Bool("auto-db-migrate", true, "automatically migrate database schema")
 	flags.Bool("dev", false, "run in development mode")
 	flags.String("webui-assets-path", "", "serve web UI assets from the given path")
+	flags.Bool("process-releases", true, "process releases in this process (disable when using 'sqedule-server worker')")
+	flags.Duration("sweep-interval", approvalrulesprocessing.DefaultWorkerSweepInterval, "how often to check for pending releases that nobody is processing")
+	flags.Duration("shutdown-timeout", defaultShutdownTimeout, "how long to wait for in-flight requests and release processing when shutting down")
 }
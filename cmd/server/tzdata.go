@@ -0,0 +1,5 @@
+package main
+
+// Embed the IANA time zone database, so that schedule approval rules
+// work even on systems without one.
+import _ "time/tzdata"
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/cli"
+	"github.com/fullstaq-labs/sqedule/server/approvalrulesprocessing"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// workerCmd represents the 'worker' command
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run a worker that processes releases",
+	Long: `Processes releases in the background, independently of the HTTP server.
+
+Run the HTTP server with --process-releases=false to let workers process all releases.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := viper.BindPFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		err = workerCmd_checkConfig(viper.GetViper())
+		if err != nil {
+			return err
+		}
+		if viper.GetInt("concurrency") <= 0 {
+			return errors.New("--concurrency must be greater than 0")
+		}
+
+		dbLogger, err := createLoggerWithLevel(viper.GetString("db-log-level"))
+		if err != nil {
+			return fmt.Errorf("Error initializing logger: %w", err)
+		}
+
+		db, err := dbutils.EstablishDatabaseConnection(
+			viper.GetString("db-type"),
+			viper.GetString("db-connection"),
+			&gorm.Config{
+				Logger: dbLogger,
+			})
+		if err != nil {
+			return fmt.Errorf("Error establishing database connection: %w", err)
+		}
+
+		// Releases that were being processed by a worker (or a server) that died, have no
+		// scheduled run. Schedule those whose claim has expired, so that they're picked up.
+		n, err := dbmodels.ScheduleAbandonedReleaseBackgroundJobs(db, time.Now())
+		if err != nil {
+			return fmt.Errorf("Error scheduling abandoned releases: %w", err)
+		}
+		if n > 0 {
+			logger.Info(context.Background(), "Scheduled %d abandoned releases for processing", n)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		worker := approvalrulesprocessing.Worker{
+			Db:            db,
+			Concurrency:   viper.GetInt("concurrency"),
+			PollInterval:  viper.GetDuration("poll-interval"),
+			SweepInterval: viper.GetDuration("sweep-interval"),
+		}
+		logger.Info(context.Background(), "Worker started with concurrency %d", worker.Concurrency)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.Run(ctx)
+		}()
+
+		<-ctx.Done()
+		stop()
+		timeout := viper.GetDuration("shutdown-timeout")
+		logger.Info(context.Background(), "Shutting down; waiting at most %s for release processing", timeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if !waitWithContext(shutdownCtx, &wg) {
+			// PostgreSQL rolls back the interrupted releases' open transactions and releases their
+			// advisory locks when we exit. Their background jobs are picked up again by a periodic sweep.
+			logger.Warn(context.Background(), "Timed out waiting for release processing; "+
+				"interrupted releases will be resumed by another worker or after restart")
+			return nil
+		}
+		logger.Info(context.Background(), "Worker stopped")
+		return nil
+	},
+}
+
+func workerCmd_checkConfig(viper *viper.Viper) error {
+	spec := cli.ConfigRequirementSpec{}
+	defineDatabaseConnectionConfigRequirementSpec(&spec)
+	return cli.RequireConfigOptions(viper, spec)
+}
+
+func init() {
+	cmd := workerCmd
+	flags := cmd.Flags()
+	rootCmd.AddCommand(cmd)
+
+	defineDatabaseConnectionFlags(cmd)
+
+	flags.Int("concurrency", approvalrulesprocessing.DefaultWorkerConcurrency, "maximum number of releases to process concurrently")
+	flags.Duration("poll-interval", approvalrulesprocessing.DefaultWorkerPollInterval, "how often to check for releases to process")
+	flags.Duration("sweep-interval", approvalrulesprocessing.DefaultWorkerSweepInterval, "how often to check for pending releases that nobody is processing")
+	flags.Duration("shutdown-timeout", defaultShutdownTimeout, "how long to wait for release processing when shutting down")
+}
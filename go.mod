@@ -7,7 +7,10 @@ require (
 	github.com/gin-contrib/cors v1.3.1
 	github.com/gin-gonic/gin v1.7.1
 	github.com/go-resty/resty/v2 v2.4.0
+	github.com/google/cel-go v0.12.6
+	github.com/google/go-cmp v0.5.8 // indirect
 	github.com/gookit/color v1.3.1
+	github.com/jackc/pgx/v4 v4.11.0
 	github.com/jarcoal/httpmock v1.0.8
 	github.com/matthewhartstonge/argon2 v0.1.4
 	github.com/mattn/go-isatty v0.0.12
@@ -17,7 +20,13 @@ require (
 	github.com/spf13/cobra v1.1.1
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.1
-	github.com/stretchr/testify v1.5.1
+	github.com/stretchr/objx v0.5.1 // indirect
+	github.com/stretchr/testify v1.8.2
+	golang.org/x/crypto v0.10.0 // indirect
+	golang.org/x/net v0.11.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
 	gorm.io/datatypes v1.0.1
 	gorm.io/driver/postgres v1.1.0
 	gorm.io/gorm v1.21.10
@@ -1,12 +1,19 @@
 package mocking
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // IClock is a swappable clock interface so that during testing
 // the time can be mocked.
 type IClock interface {
 	Now() time.Time
 	Sleep(d time.Duration)
+
+	// SleepContext is like Sleep, but returns early if `ctx` is done.
+	// Returns whether the full duration has been slept.
+	SleepContext(ctx context.Context, d time.Duration) bool
 }
 
 // RealClock is an IClock which returns the real time.
@@ -20,6 +27,18 @@ func (_ RealClock) Sleep(d time.Duration) {
 	time.Sleep(d)
 }
 
+func (_ RealClock) SleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 // FakeClock is an IClock which returns the embedded time value
 // instead of the real time.
 type FakeClock struct {
@@ -33,5 +52,13 @@ func (c FakeClock) Now() time.Time {
 }
 
 func (c *FakeClock) Sleep(d time.Duration) {
-	c.Value.Add(d)
+	c.Value = c.Value.Add(d)
+}
+
+func (c *FakeClock) SleepContext(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	c.Sleep(d)
+	return true
 }
@@ -28,6 +28,20 @@ func NewUint32Ptr(val uint32) *uint32 {
 	return &val
 }
 
+// NewInt32Ptr allows creating an int32 pointer in 1 line.
+//
+// Before:
+//
+//   var minutes int32 = 60
+//   doSomething(&minutes)
+//
+// After:
+//
+//   doSomething(lib.NewInt32Ptr(60))
+func NewInt32Ptr(val int32) *int32 {
+	return &val
+}
+
 // NewStringPtr allows creating a string pointer in 1 line.
 //
 // Before:
@@ -0,0 +1,287 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+)
+
+// ApprovalRuleTypeDefinition describes everything that the server needs to know about a rule
+// type: how to store it (dbmodels.ApprovalRuleTypeDefinition), how to convert it from and to
+// JSON (json.ApprovalRuleTypeCodec) and how to evaluate it (ApprovalRuleProcessor).
+//
+// Rule types that aren't built in implement this interface, and are registered with
+// RegisterApprovalRuleType(). They can embed dbmodels.BasicApprovalRuleTypeDefinition and
+// json.BasicApprovalRuleTypeCodec so that only ApprovalRuleProcessor is left to implement.
+type ApprovalRuleTypeDefinition interface {
+	dbmodels.ApprovalRuleTypeDefinition
+	json.ApprovalRuleTypeCodec
+	ApprovalRuleProcessor
+}
+
+// ApprovalRuleProcessor evaluates the rules of a specific ApprovalRuleType.
+type ApprovalRuleProcessor interface {
+	Type() dbmodels.ApprovalRuleType
+
+	// FetchPreviousOutcomes loads the outcomes that were recorded for this type's rules during
+	// previous runs. The result is passed as-is to ProcessRules().
+	FetchPreviousOutcomes(engine *Engine) (interface{}, error)
+
+	// ProcessRules processes the rules of this type in `stage`, skipping rules that already
+	// have an outcome. It returns the resulting Release state (see
+	// DetermineReleaseStateFromOutcome() and DetermineReleaseStateAfterProcessingRules()),
+	// and the number of rules that have an outcome. For each rule that didn't have an outcome
+	// yet, it must record one along with a ReleaseRuleProcessedEvent (see
+	// `Engine.CreateRuleProcessedEvent()`). Processors that wait for external systems must
+	// stop waiting when `ctx` is cancelled.
+	ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+		nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error)
+}
+
+// builtinApprovalRuleProcessors are run in this order for each evaluation stage, except for the
+// quota rule processor: see `Engine.processRules()`.
+var builtinApprovalRuleProcessors = []ApprovalRuleProcessor{
+	manualApprovalRuleProcessor{},
+	scheduleRuleProcessor{},
+	calendarRuleProcessor{},
+	dependencyRuleProcessor{},
+	expressionRuleProcessor{},
+	promotionRuleProcessor{},
+	httpAPIRuleProcessor{},
+	callbackRuleProcessor{},
+	quotaRuleProcessor{},
+}
+
+// RegisterApprovalRuleType adds support for a rule type that isn't built in. It must be called
+// from an `init()` function, and panics if a rule type with the same name is already supported.
+func RegisterApprovalRuleType(definition ApprovalRuleTypeDefinition) {
+	dbmodels.AddApprovalRuleTypeDefinition(definition)
+}
+
+// approvalRuleProcessors returns the processors of all supported rule types: first the built-in
+// ones, then the registered ones in order of registration. It panics if a supported rule type
+// has no processor.
+func approvalRuleProcessors() []ApprovalRuleProcessor {
+	result := make([]ApprovalRuleProcessor, 0, len(dbmodels.ApprovalRuleTypeDefinitions()))
+	result = append(result, builtinApprovalRuleProcessors...)
+
+	for _, definition := range dbmodels.ApprovalRuleTypeDefinitions() {
+		if isBuiltinApprovalRuleType(definition.Type()) {
+			continue
+		}
+		processor, ok := definition.(ApprovalRuleProcessor)
+		if !ok {
+			panic("Bug: approval rule type " + string(definition.Type()) + " has no processor")
+		}
+		result = append(result, processor)
+	}
+	return result
+}
+
+func isBuiltinApprovalRuleType(ruleType dbmodels.ApprovalRuleType) bool {
+	for _, processor := range builtinApprovalRuleProcessors {
+		if processor.Type() == ruleType {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// ******** manualApprovalRuleProcessor ********
+//
+
+type manualApprovalRuleProcessor struct{}
+
+func (manualApprovalRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.ManualApprovalRuleType
+}
+
+func (manualApprovalRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchManualApprovalRulePreviousOutcomes()
+}
+
+func (manualApprovalRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processManualApprovalRules(stage, previousOutcomes.(map[uint64][]dbmodels.ManualApprovalRuleOutcome), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** scheduleRuleProcessor ********
+//
+
+type scheduleRuleProcessor struct{}
+
+func (scheduleRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.ScheduleApprovalRuleType
+}
+
+func (scheduleRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchScheduleRulePreviousOutcomes()
+}
+
+func (scheduleRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processScheduleRules(stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** calendarRuleProcessor ********
+//
+
+type calendarRuleProcessor struct{}
+
+func (calendarRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.CalendarApprovalRuleType
+}
+
+func (calendarRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchCalendarRulePreviousOutcomes()
+}
+
+func (calendarRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processCalendarRules(stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** dependencyRuleProcessor ********
+//
+
+type dependencyRuleProcessor struct{}
+
+func (dependencyRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.DependencyApprovalRuleType
+}
+
+func (dependencyRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchDependencyRulePreviousOutcomes()
+}
+
+func (dependencyRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processDependencyRules(stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** expressionRuleProcessor ********
+//
+
+type expressionRuleProcessor struct{}
+
+func (expressionRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.ExpressionApprovalRuleType
+}
+
+func (expressionRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchExpressionRulePreviousOutcomes()
+}
+
+func (expressionRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processExpressionRules(stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** promotionRuleProcessor ********
+//
+
+type promotionRuleProcessor struct{}
+
+func (promotionRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.PromotionApprovalRuleType
+}
+
+func (promotionRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchPromotionRulePreviousOutcomes()
+}
+
+func (promotionRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processPromotionRules(stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** httpAPIRuleProcessor ********
+//
+
+type httpAPIRuleProcessor struct{}
+
+func (httpAPIRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.HTTPApiApprovalRuleType
+}
+
+func (httpAPIRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchHTTPApiRulePreviousOutcomes()
+}
+
+func (httpAPIRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processHTTPApiRules(ctx, stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** callbackRuleProcessor ********
+//
+
+type callbackRuleProcessor struct{}
+
+type callbackRulePreviousOutcomes struct {
+	outcomes map[uint64]bool
+	requests map[uint64]dbmodels.CallbackApprovalRuleRequest
+}
+
+func (callbackRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.CallbackApprovalRuleType
+}
+
+func (callbackRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	var result callbackRulePreviousOutcomes
+	var err error
+
+	result.outcomes, err = engine.fetchCallbackRulePreviousOutcomes()
+	if err != nil {
+		return nil, err
+	}
+	result.requests, err = engine.fetchCallbackRuleRequests()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (callbackRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	previous := previousOutcomes.(callbackRulePreviousOutcomes)
+	return engine.processCallbackRules(ctx, stage, previous.outcomes, previous.requests, nAlreadyProcessed, totalRules)
+}
+
+//
+// ******** quotaRuleProcessor ********
+//
+
+type quotaRuleProcessor struct{}
+
+func (quotaRuleProcessor) Type() dbmodels.ApprovalRuleType {
+	return dbmodels.QuotaApprovalRuleType
+}
+
+func (quotaRuleProcessor) FetchPreviousOutcomes(engine *Engine) (interface{}, error) {
+	return engine.fetchQuotaRulePreviousOutcomes()
+}
+
+func (quotaRuleProcessor) ProcessRules(ctx context.Context, engine *Engine, stage dbmodels.ApprovalRulesetContents, previousOutcomes interface{},
+	nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	return engine.processQuotaRules(stage, previousOutcomes.(map[uint64]bool), nAlreadyProcessed, totalRules)
+}
@@ -21,61 +21,173 @@ const (
 	backgroundProcessingRetryMaxAttempts = 10
 )
 
-func ProcessInBackground(db *gorm.DB, organizationID string, job dbmodels.ReleaseBackgroundJob, wg *sync.WaitGroup) error {
+// ProcessInBackground processes the job in a new goroutine, which is tracked by `wg`.
+// If the Release is deferred until a later time, then the goroutine sleeps until then.
+// When `ctx` is done, the goroutine stops sleeping and schedules the job to be resumed
+// by a Worker instead.
+func ProcessInBackground(ctx context.Context, db *gorm.DB, organizationID string, job dbmodels.ReleaseBackgroundJob, wg *sync.WaitGroup) error {
 	wg.Add(1)
 	//nolint:errcheck
-	go realProcessInBackground(db, organizationID, job, wg, mocking.RealClock{}, false)
+	go realProcessInBackground(ctx, db, organizationID, job, wg, mocking.RealClock{}, false)
 	return nil
 }
 
-func realProcessInBackground(db *gorm.DB, organizationID string, job dbmodels.ReleaseBackgroundJob, wg *sync.WaitGroup, clock mocking.IClock, fakeError bool) error {
-	var lastSleepDuration time.Duration
-	var retryCount uint
-	var err error
-
+// realProcessInBackground processes the job. If the Release is deferred until a later time, then
+// it sleeps until then and processes the job again. If processing fails, then the failure is
+// recorded in the job by processReleaseBackgroundJobOnce().
+//
+// If `ctx` is done while sleeping, then the job is scheduled to run at the time that the
+// Release becomes eligible, so that a Worker resumes processing it.
+func realProcessInBackground(ctx context.Context, db *gorm.DB, organizationID string, job dbmodels.ReleaseBackgroundJob, wg *sync.WaitGroup, clock mocking.IClock, fakeError bool) error {
 	if wg != nil {
 		defer wg.Done()
 	}
 
 	for {
-		engine := Engine{Db: db, OrganizationID: organizationID, ReleaseBackgroundJob: job}
-		if fakeError {
-			err = errors.New("fake error")
-		} else {
-			err = engine.Run()
+		nextEligibleAt, deferred, err := processReleaseBackgroundJobOnce(ctx, db, organizationID, &job, clock, fakeError)
+		if err != nil || !deferred {
+			return err
 		}
-		if err == nil {
-			return nil
+
+		db.Logger.Info(context.Background(), "Release %s is deferred until %s; will resume processing then",
+			job.Release.Description(), nextEligibleAt)
+		// Keep the job claimed while we wait, so that it isn't rescheduled as abandoned. Should
+		// this process die, then the claim expires shortly after the Release becomes eligible.
+		err = job.ExtendClaim(db, nextEligibleAt.Add(dbmodels.ReleaseBackgroundJobClaimDuration))
+		if err != nil {
+			err = fmt.Errorf("Error extending claim on release background job for release %s: %w",
+				job.Release.Description(), err)
+			db.Logger.Error(context.Background(), "%s", err.Error())
+			return err
+		}
+		if !clock.SleepContext(ctx, nextEligibleAt.Sub(clock.Now())) {
+			db.Logger.Info(context.Background(), "Stopped waiting for release %s; scheduling it to be processed at %s",
+				job.Release.Description(), nextEligibleAt)
+			err = job.ScheduleRun(db, nextEligibleAt)
+			if err != nil {
+				err = fmt.Errorf("Error scheduling release %s to be processed at %s: %w",
+					job.Release.Description(), nextEligibleAt, err)
+				db.Logger.Error(context.Background(), "%s", err.Error())
+			}
+			return err
 		}
 
-		if retryCount == backgroundProcessingRetryMaxAttempts {
-			db.Logger.Error(context.Background(), "Error processing release %s. Already retried %d times, so will no longer retry. Error: %s",
-				job.Release.Description(), retryCount, err.Error())
+		reloadedJob, err := dbmodels.FindReleaseBackgroundJob(db.Preload("Release"), organizationID, job.ApplicationID, job.ReleaseID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The release has been finalized in the meantime.
+			return nil
+		}
+		if err != nil {
+			err = fmt.Errorf("Error reloading release background job: %w", err)
+			logRecordBackgroundProcessingFailureError(db, job,
+				recordBackgroundProcessingFailure(db, &job, err, clock))
 			return err
 		}
+		job = reloadedJob
+	}
+}
 
-		retryCount++
-		lastSleepDuration = calcRetrySleepDuration(lastSleepDuration)
-		db.Logger.Error(context.Background(), "Error processing release %s. Will retry (attempt %d/%d) in %.0f seconds. Error: %s",
-			job.Release.Description(), retryCount, backgroundProcessingRetryMaxAttempts, lastSleepDuration.Seconds(), err.Error())
-		clock.Sleep(lastSleepDuration)
+// processReleaseBackgroundJobOnce makes a single attempt at processing the job. If the attempt
+// fails, then the failure is recorded in the job, and a retry is scheduled which is picked up by
+// a Worker. After backgroundProcessingRetryMaxAttempts retries,
+// the job is marked as dead instead.
+//
+// If `ctx` is cancelled during the attempt, then that doesn't count as a failure: the job is
+// scheduled to run immediately instead, so that another process claims it.
+//
+// If the Release is deferred until a later time, then that time is returned, and the second
+// return value is true.
+func processReleaseBackgroundJobOnce(ctx context.Context, db *gorm.DB, organizationID string, job *dbmodels.ReleaseBackgroundJob, clock mocking.IClock, fakeError bool) (time.Time, bool, error) {
+	var err error
+
+	engine := Engine{Db: db, OrganizationID: organizationID, ReleaseBackgroundJob: *job, Clock: clock}
+	if fakeError {
+		err = errors.New("fake error")
+	} else {
+		err = engine.Run(ctx)
+	}
+	if err != nil && ctx.Err() != nil {
+		db.Logger.Info(context.Background(), "%s; scheduling it to be processed again", err.Error())
+		if scheduleErr := job.ScheduleRun(db, clock.Now()); scheduleErr != nil {
+			db.Logger.Error(context.Background(), "Error scheduling release %s to be processed again: %s",
+				job.Release.Description(), scheduleErr.Error())
+		}
+		return time.Time{}, false, err
+	}
+	if err == nil && job.Attempts > 0 {
+		// If the job was finalized then it no longer exists, in which case this is a no-op.
+		err = job.ResetRetryState(db)
+		if err != nil {
+			err = fmt.Errorf("Error resetting retry state of release background job: %w", err)
+		}
+	}
+	if err != nil {
+		logRecordBackgroundProcessingFailureError(db, *job,
+			recordBackgroundProcessingFailure(db, job, err, clock))
+		return time.Time{}, false, err
+	}
+
+	nextEligibleAt, deferred := engine.NextEligibleTime()
+	if !deferred {
+		// The Release has either been finalized (in which case the job no longer exists, and this
+		// is a no-op), or it awaits further input, whose arrival schedules a new run.
+		err = job.Unclaim(db)
+		if err != nil {
+			err = fmt.Errorf("Error releasing claim on release background job for release %s: %w",
+				job.Release.Description(), err)
+			db.Logger.Error(context.Background(), "%s", err.Error())
+			return time.Time{}, false, err
+		}
+	}
+	return nextEligibleAt, deferred, nil
+}
+
+func logRecordBackgroundProcessingFailureError(db *gorm.DB, job dbmodels.ReleaseBackgroundJob, err error) {
+	if err != nil {
+		db.Logger.Error(context.Background(), "Error recording failure of release background job for release %s: %s",
+			job.Release.Description(), err.Error())
 	}
 }
 
-func calcRetrySleepDuration(lastDuration time.Duration) time.Duration {
+func recordBackgroundProcessingFailure(db *gorm.DB, job *dbmodels.ReleaseBackgroundJob, failure error, clock mocking.IClock) error {
+	now := clock.Now()
+
+	if job.Attempts >= backgroundProcessingRetryMaxAttempts {
+		db.Logger.Error(context.Background(), "Error processing release %s. Already retried %d times, so will no longer retry. Error: %s",
+			job.Release.Description(), job.Attempts, failure.Error())
+		return job.RecordFailure(db, failure, now, nil)
+	}
+
+	retryDelay := calcRetryDelay(job.Attempts + 1)
+	nextRunAt := now.Add(retryDelay)
+	db.Logger.Error(context.Background(), "Error processing release %s. Will retry (attempt %d/%d) in %.0f seconds. Error: %s",
+		job.Release.Description(), job.Attempts+1, backgroundProcessingRetryMaxAttempts, retryDelay.Seconds(), failure.Error())
+	return job.RecordFailure(db, failure, now, &nextRunAt)
+}
+
+// calcRetryDelay returns how long to wait before the given retry attempt (starting at 1).
+// The delay doubles with every attempt, and is randomized a bit so that jobs which failed
+// at the same time don't all retry at the same time.
+func calcRetryDelay(attempt uint32) time.Duration {
+	var base time.Duration = backgroundProcessingRetryMinDuration
+	for i := uint32(1); i < attempt && base < backgroundProcessingRetryMaxDuration; i++ {
+		base *= 2
+	}
 	jitter := rand.Int63n(int64(backgroundProcessingRetryJitter)) - (int64(backgroundProcessingRetryJitter) / 2)
-	return time.Duration(lib.AddAndClamp(uint64(lastDuration*2), jitter,
+	return time.Duration(lib.AddAndClamp(uint64(base), jitter,
 		uint64(backgroundProcessingRetryMinDuration), uint64(backgroundProcessingRetryMaxDuration)))
 }
 
-func ProcessAllPendingReleasesInBackground(db *gorm.DB, wg *sync.WaitGroup) error {
-	jobs, err := dbmodels.FindUnlockedReleaseBackgroundJobs(db)
+// ProcessAllPendingReleasesInBackground claims all abandoned ReleaseBackgroundJobs (see
+// dbmodels.ClaimAbandonedReleaseBackgroundJobs), and processes each of them in the background.
+func ProcessAllPendingReleasesInBackground(ctx context.Context, db *gorm.DB, wg *sync.WaitGroup) error {
+	jobs, err := dbmodels.ClaimAbandonedReleaseBackgroundJobs(db, time.Now())
 	if err != nil {
 		return fmt.Errorf("Error querying release background jobs: %w", err)
 	}
 
 	for _, job := range jobs {
-		err = ProcessInBackground(db, job.OrganizationID, job, wg)
+		err = ProcessInBackground(ctx, db, job.OrganizationID, job, wg)
 		if err != nil {
 			return fmt.Errorf("Error processing release %s in background: %w", job.Release.Description(), err)
 		}
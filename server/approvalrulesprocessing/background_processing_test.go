@@ -2,9 +2,12 @@ package approvalrulesprocessing
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/fullstaq-labs/sqedule/lib/mocking"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
@@ -52,8 +55,31 @@ var _ = Describe("Background processing", func() {
 			Expect(txerr).ToNot(HaveOccurred())
 		})
 
+		It("leaves the job claimable when processing is interrupted", func() {
+			clock.Value = time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := realProcessInBackground(ctx, db, org1.ID, job, nil, &clock, false)
+			Expect(err).To(MatchError(context.Canceled))
+
+			var release dbmodels.Release
+			Expect(db.First(&release).Error).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.InProgress))
+
+			job, err = dbmodels.FindReleaseBackgroundJob(db, org1.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Attempts).To(BeNumerically("==", 0))
+			Expect(job.LastError.Valid).To(BeFalse())
+
+			jobs, err := dbmodels.ClaimDueReleaseBackgroundJobs(db, clock.Now(), 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobs).To(HaveLen(1))
+			Expect(jobs[0].ReleaseID).To(Equal(job.ReleaseID))
+		})
+
 		It("processes a ReleaseBackgroundJob in the background", func() {
-			err := realProcessInBackground(db, org1.ID, job, nil, &clock, false)
+			err := realProcessInBackground(context.Background(), db, org1.ID, job, nil, &clock, false)
 			Expect(err).ToNot(HaveOccurred())
 
 			var count int64
@@ -66,25 +92,147 @@ var _ = Describe("Background processing", func() {
 			Expect(release.State).To(Equal(releasestate.Approved))
 		})
 
-		It("retries on error", func() {
+		It("schedules a retry on error", func() {
 			buffer := bytes.NewBuffer([]byte{})
 			db.Logger = logger.New(log.New(buffer, "\n", log.LstdFlags), logger.Config{LogLevel: logger.Warn})
+			clock.Value = time.Date(2021, time.March, 5, 12, 0, 0, 0, time.UTC)
 
-			err := realProcessInBackground(db, org1.ID, job, nil, &clock, true)
+			err := realProcessInBackground(context.Background(), db, org1.ID, job, nil, &clock, true)
 			Expect(err).To(HaveOccurred())
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Will retry (attempt 1/%d)", backgroundProcessingRetryMaxAttempts)))
+
+			job, err = dbmodels.FindReleaseBackgroundJob(db, org1.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.State()).To(Equal(dbmodels.ReleaseBackgroundJobRetrying))
+			Expect(job.Attempts).To(BeNumerically("==", 1))
+			Expect(job.LastError.String).To(Equal("fake error"))
+			Expect(job.NextRunAt.Time).To(BeTemporally(">=", clock.Value.Add(backgroundProcessingRetryMinDuration)))
+			Expect(job.NextRunAt.Time).To(BeTemporally("<=", clock.Value.Add(backgroundProcessingRetryMaxDuration)))
+		})
+
+		It("marks the job as dead after too many retries", func() {
+			buffer := bytes.NewBuffer([]byte{})
+			db.Logger = logger.New(log.New(buffer, "\n", log.LstdFlags), logger.Config{LogLevel: logger.Warn})
+
+			for i := 0; i <= backgroundProcessingRetryMaxAttempts; i++ {
+				err := realProcessInBackground(context.Background(), db, org1.ID, job, nil, &clock, true)
+				Expect(err).To(HaveOccurred())
+
+				job, err = dbmodels.FindReleaseBackgroundJob(db.Preload("Release"), org1.ID, job.ApplicationID, job.ReleaseID)
+				Expect(err).ToNot(HaveOccurred())
+			}
 
 			log := buffer.String()
 			Expect(log).To(ContainSubstring(fmt.Sprintf("Will retry (attempt %[1]d/%[1]d)", backgroundProcessingRetryMaxAttempts)))
-			Expect(log).NotTo(ContainSubstring(fmt.Sprintf("Will retry (attempt %d/)", backgroundProcessingRetryMaxAttempts+1)))
+			Expect(log).NotTo(ContainSubstring(fmt.Sprintf("Will retry (attempt %d/", backgroundProcessingRetryMaxAttempts+1)))
 			Expect(log).To(ContainSubstring(fmt.Sprintf("Already retried %d times, so will no longer retry", backgroundProcessingRetryMaxAttempts)))
+
+			Expect(job.State()).To(Equal(dbmodels.ReleaseBackgroundJobDead))
+			Expect(job.Attempts).To(BeNumerically("==", backgroundProcessingRetryMaxAttempts+1))
+			Expect(job.NextRunAt.Valid).To(BeFalse())
+		})
+
+		It("resets the retry state after a successful attempt", func() {
+			err := realProcessInBackground(context.Background(), db, org1.ID, job, nil, &clock, true)
+			Expect(err).To(HaveOccurred())
+			job, err = dbmodels.FindReleaseBackgroundJob(db.Preload("Release"), org1.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = realProcessInBackground(context.Background(), db, org1.ID, job, nil, &clock, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			var release dbmodels.Release
+			Expect(db.First(&release).Error).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Approved))
+		})
+	})
+
+	Describe("ProcessInBackground with a deferred schedule rule", func() {
+		var job dbmodels.ReleaseBackgroundJob
+
+		BeforeEach(func() {
+			txerr := db.Transaction(func(tx *gorm.DB) error {
+				app, err := dbmodels.CreateMockApplicationWith1Version(tx, org1, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				// Friday evening
+				release, err := dbmodels.CreateMockReleaseWithInProgressState(tx, org1, app, func(release *dbmodels.Release) {
+					release.CreatedAt = time.Date(2021, time.March, 5, 16, 55, 0, 0, time.UTC)
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				ruleset, err := dbmodels.CreateMockApprovalRulesetWith1Version(tx, org1, "ruleset1", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockReleaseRulesetBindingWithEnforcingMode(tx, org1, release,
+					ruleset, *ruleset.Version, *ruleset.Version.Adjustment, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockScheduleApprovalRuleWholeDay(tx, org1, ruleset.Version.ID,
+					*ruleset.Version.Adjustment, func(rule *dbmodels.ScheduleApprovalRule) {
+						rule.BeginTime = sql.NullString{String: "9:00", Valid: true}
+						rule.EndTime = sql.NullString{String: "16:30", Valid: true}
+						rule.DaysOfWeek = sql.NullString{String: "mon tue wed thu fri", Valid: true}
+						rule.TimeZone = sql.NullString{String: "UTC", Valid: true}
+						rule.DeferUntilWindow = true
+					})
+				Expect(err).ToNot(HaveOccurred())
+
+				job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, org1, app, release, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(txerr).ToNot(HaveOccurred())
+
+			clock.Value = time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)
+		})
+
+		It("waits until the next schedule window, then approves the release", func() {
+			buffer := bytes.NewBuffer([]byte{})
+			db.Logger = logger.New(log.New(buffer, "\n", log.LstdFlags), logger.Config{LogLevel: logger.Info})
+
+			err := realProcessInBackground(context.Background(), db, org1.ID, job, nil, &clock, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("is deferred until 2021-03-08 09:00:00 +0000 UTC"))
+			Expect(clock.Now()).To(Equal(time.Date(2021, time.March, 8, 9, 0, 0, 0, time.UTC)))
+
+			var release dbmodels.Release
+			Expect(db.First(&release).Error).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Approved))
+			Expect(release.NextEligibleAt.Valid).To(BeFalse())
+		})
+
+		It("schedules the job to be resumed at the next schedule window when shutting down", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cancellingClock := cancelOnSleepClock{FakeClock: clock, cancel: cancel}
+
+			err := realProcessInBackground(ctx, db, org1.ID, job, nil, &cancellingClock, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cancellingClock.Now()).To(Equal(time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)))
+
+			var release dbmodels.Release
+			Expect(db.First(&release).Error).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.InProgress))
+
+			job, err = dbmodels.FindReleaseBackgroundJob(db, org1.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.NextRunAt.Valid).To(BeTrue())
+			Expect(job.NextRunAt.Time).To(BeTemporally("==", time.Date(2021, time.March, 8, 9, 0, 0, 0, time.UTC)))
 		})
 	})
 
 	Describe("ProcessAllPendingReleasesInBackground", func() {
 		var org2 dbmodels.Organization
 
-		createMockReleaseBackgroundJob := func(tx *gorm.DB, org dbmodels.Organization, jobLockSubID uint32) dbmodels.ReleaseBackgroundJob {
-			app, err := dbmodels.CreateMockApplicationWith1Version(tx, org, nil, nil)
+		var awaitingJob dbmodels.ReleaseBackgroundJob
+
+		createMockReleaseBackgroundJob := func(tx *gorm.DB, org dbmodels.Organization, jobLockSubID uint32, claimedUntil sql.NullTime) dbmodels.ReleaseBackgroundJob {
+			app, err := dbmodels.CreateMockApplicationWith1Version(tx, org, func(app *dbmodels.Application) {
+				app.ID = fmt.Sprintf("app%d", jobLockSubID)
+			}, nil)
 			Expect(err).ToNot(HaveOccurred())
 
 			release, err := dbmodels.CreateMockReleaseWithInProgressState(tx, org, app, nil)
@@ -92,6 +240,7 @@ var _ = Describe("Background processing", func() {
 
 			job, err := dbmodels.CreateMockReleaseBackgroundJob(tx, org, app, release, func(job *dbmodels.ReleaseBackgroundJob) {
 				job.LockSubID = jobLockSubID
+				job.ClaimedUntil = claimedUntil
 			})
 			Expect(err).ToNot(HaveOccurred())
 
@@ -106,31 +255,46 @@ var _ = Describe("Background processing", func() {
 					org.DisplayName = "Org 2"
 				})
 				Expect(err).ToNot(HaveOccurred())
-				createMockReleaseBackgroundJob(tx, org1, 1)
-				createMockReleaseBackgroundJob(tx, org2, 2)
+				expired := sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+				createMockReleaseBackgroundJob(tx, org1, 1, expired)
+				createMockReleaseBackgroundJob(tx, org2, 2, expired)
+				awaitingJob = createMockReleaseBackgroundJob(tx, org1, 3, sql.NullTime{})
 				return nil
 			})
 			Expect(txerr).ToNot(HaveOccurred())
 		})
 
-		It("processes all ReleaseBackgroundJobs in the background", func() {
+		It("processes all abandoned ReleaseBackgroundJobs in the background", func() {
 			var wg sync.WaitGroup
 
-			err := ProcessAllPendingReleasesInBackground(db, &wg)
+			err := ProcessAllPendingReleasesInBackground(context.Background(), db, &wg)
 			Expect(err).ToNot(HaveOccurred())
 
 			wg.Wait()
 
-			var count int64
-			err = db.Model(dbmodels.ReleaseBackgroundJob{}).Count(&count).Error
-			Expect(err).ToNot(HaveOccurred())
-			Expect(count).To(BeNumerically("==", 0))
+			var jobs []dbmodels.ReleaseBackgroundJob
+			Expect(db.Find(&jobs).Error).ToNot(HaveOccurred())
+			Expect(jobs).To(HaveLen(1))
+			Expect(jobs[0].ReleaseID).To(Equal(awaitingJob.ReleaseID))
 
 			var releases []dbmodels.Release
-			Expect(db.Find(&releases).Error).ToNot(HaveOccurred())
-			Expect(releases).To(HaveLen(2))
+			Expect(db.Order("application_id").Find(&releases).Error).ToNot(HaveOccurred())
+			Expect(releases).To(HaveLen(3))
 			Expect(releases[0].State).To(Equal(releasestate.Approved))
 			Expect(releases[1].State).To(Equal(releasestate.Approved))
+			Expect(releases[2].State).To(Equal(releasestate.InProgress))
 		})
 	})
 })
+
+// cancelOnSleepClock is a FakeClock that cancels a context as soon as something sleeps on it,
+// so that tests can simulate a shutdown that happens while waiting for a deferred Release.
+type cancelOnSleepClock struct {
+	mocking.FakeClock
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnSleepClock) SleepContext(ctx context.Context, d time.Duration) bool {
+	c.cancel()
+	return c.FakeClock.SleepContext(ctx, d)
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Engine processes a Release based on its bound ApprovalRules.
@@ -20,27 +22,91 @@ type Engine struct {
 	Db                   *gorm.DB
 	OrganizationID       string
 	ReleaseBackgroundJob dbmodels.ReleaseBackgroundJob
+
+	// Clock is used to determine the current time. If nil, then the real clock is used.
+	Clock mocking.IClock
+
+	// nextEligibleAt is the earliest time at which a deferred rule should be re-evaluated.
+	// It's zero if no rules were deferred during the last Run().
+	nextEligibleAt time.Time
+
+	// dryRun is set by DryRun(). It suppresses finalizing the Release, sending callback jobs
+	// and making HTTP API rule requests.
+	dryRun bool
+
+	// notEvaluatedRules are the rules that the last DryRun() skipped, because evaluating them
+	// requires contacting an external system.
+	notEvaluatedRules []dbmodels.IApprovalRule
 }
 
 var errTemporary = errors.New("temporary error, retry later")
 
-func (engine *Engine) Run() error {
-	locktx, err := engine.lock()
+// Run processes the Release's rules, and finalizes the Release if they produce a final verdict.
+//
+// When `ctx` is cancelled, then rule processing is interrupted (in-flight HTTP API rule and
+// callback rule requests are aborted) and Run() returns an error that wraps `ctx.Err()`. Outcomes that were recorded
+// before that are kept, so that a next run resumes where this one left off.
+func (engine *Engine) Run(ctx context.Context) error {
+	engine.nextEligibleAt = time.Time{}
+
+	locktx, err := engine.lock(ctx)
 	if err != nil {
+		if ctx.Err() != nil {
+			return engine.interruptedError(ctx)
+		}
 		return fmt.Errorf("Error acquiring lock: %w", err)
 	}
+	// unlock() doesn't use `ctx`, so even if we're interrupted, the advisory locks are released
+	// and the lock transaction is rolled back before we return.
 	defer engine.unlock(locktx)
 
-	rulesetContents, err := engine.loadRules()
+	// Another process may have finalized the Release while we were waiting for the lock.
+	_, err = dbmodels.FindReleaseBackgroundJob(engine.Db, engine.OrganizationID,
+		engine.ReleaseBackgroundJob.ApplicationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		engine.Db.Logger.Info(context.Background(), "Release %s has already been finalized; nothing to do",
+			engine.ReleaseBackgroundJob.Release.Description())
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("Error loading rules: %w", err)
+		return fmt.Errorf("Error reloading release background job: %w", err)
 	}
 
-	resultState, err := engine.processRules(rulesetContents)
+	rulesetContents, err := engine.loadEnabledRules()
+	if err != nil {
+		return err
+	}
+
+	if len(rulesetContents.RulesOfType(dbmodels.QuotaApprovalRuleType)) > 0 {
+		// Quota rules count the Application's approved Releases, so no other Release of the
+		// same Application may be finalized until we're done.
+		err = engine.lockApplication(locktx)
+		if err != nil {
+			return fmt.Errorf("Error acquiring application lock: %w", err)
+		}
+		defer engine.unlockApplication(locktx)
+	}
+
+	resultState, err := engine.processRules(ctx, rulesetContents)
+	if err != nil && ctx.Err() != nil {
+		return engine.interruptedError(ctx)
+	}
 	if err != nil {
 		// Error message already mentions the fact that it's about processing rules.
 		return err
 	}
+	if !resultState.IsFinal() {
+		// Some rules are still awaiting input (e.g. manual approvals) or a schedule window.
+		// Keep the job around so that processing can be resumed later.
+		engine.Db.Logger.Info(context.Background(), "Release %s is awaiting further input; not finalizing yet",
+			engine.ReleaseBackgroundJob.Release.Description())
+		err = engine.saveNextEligibleTime()
+		if err != nil {
+			return fmt.Errorf("Error recording next eligible time of release %s: %w",
+				engine.ReleaseBackgroundJob.Release.Description(), err)
+		}
+		return nil
+	}
 
 	err = engine.finalizeJob(resultState)
 	if err != nil {
@@ -50,86 +116,194 @@ func (engine *Engine) Run() error {
 	return nil
 }
 
-func (engine *Engine) processRules(rulesetContents dbmodels.ApprovalRulesetContents) (releasestate.State, error) {
-	var finalResultState releasestate.State = releasestate.InProgress
-	var finalError error
-	var resultState releasestate.State
-	var nprocessed uint = 0
-	var n uint
+// Expire cancels the Release because it remained in progress for too long. A
+// ReleaseCancelledEvent is recorded with ReleaseCancelledEventExpiredReason as reason.
+// Returns false if the Release was finalized in the meantime, in which case nothing is done.
+func (engine *Engine) Expire() (bool, error) {
+	locktx, err := engine.lock(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("Error acquiring lock: %w", err)
+	}
+	defer engine.unlock(locktx)
 
-	manualApprovalRulePreviousOutcomes, err := engine.fetchManualApprovalRulePreviousOutcomes()
+	job, err := dbmodels.FindReleaseBackgroundJob(engine.Db.Preload("Release"), engine.OrganizationID,
+		engine.ReleaseBackgroundJob.ApplicationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return releasestate.Rejected, fmt.Errorf("Error loading state: %w", err)
+		return false, fmt.Errorf("Error reloading release background job: %w", err)
 	}
-	scheduleRulePreviousOutcomes, err := engine.fetchScheduleRulePreviousOutcomes()
+	engine.ReleaseBackgroundJob = job
+
+	var cancelled bool
+	err = engine.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		_, cancelled, err = dbmodels.CancelRelease(tx, &engine.ReleaseBackgroundJob.Release,
+			sql.NullString{String: dbmodels.ReleaseCancelledEventExpiredReason, Valid: true}, engine.now())
+		return err
+	})
 	if err != nil {
-		return releasestate.Rejected, fmt.Errorf("Error loading state: %w", err)
+		return false, fmt.Errorf("Error cancelling release %s: %w", job.Release.Description(), err)
 	}
-	httpAPIRulePreviousOutcomes, err := engine.fetchHTTPApiRulePreviousOutcomes()
+	return cancelled, nil
+}
+
+// DryRun evaluates the Release's rules like Run() does, but without finalizing the Release,
+// without recording its next eligible time, and without sending jobs for callback rules.
+// It does record events and rule outcomes, so it must be called with a `Db` that is a
+// transaction which is rolled back afterwards.
+//
+// Unlike Run(), it doesn't acquire any locks: the Release only exists within that uncommitted
+// transaction, so no other process can process it concurrently.
+//
+// Rules that require contacting an external system (callback rules and HTTP API rules) are
+// not evaluated: they're left without an outcome, and are returned by NotEvaluatedRules().
+// This way, the caller's transaction isn't held open while waiting for outbound requests.
+func (engine *Engine) DryRun(ctx context.Context) (releasestate.State, error) {
+	engine.nextEligibleAt = time.Time{}
+	engine.notEvaluatedRules = nil
+	engine.dryRun = true
+	defer func() {
+		engine.dryRun = false
+	}()
+
+	rulesetContents, err := engine.loadEnabledRules()
+	if err != nil {
+		return releasestate.Rejected, err
+	}
+
+	// Error message already mentions the fact that it's about processing rules.
+	return engine.processRules(ctx, rulesetContents)
+}
+
+// NextEligibleTime returns the time at which the Release should be processed again, because
+// a rule was deferred until then by the last Run(). The second return value is false if no
+// rules were deferred.
+func (engine Engine) NextEligibleTime() (time.Time, bool) {
+	return engine.nextEligibleAt, !engine.nextEligibleAt.IsZero()
+}
+
+// NotEvaluatedRules returns the rules that the last DryRun() didn't evaluate.
+func (engine Engine) NotEvaluatedRules() []dbmodels.IApprovalRule {
+	return engine.notEvaluatedRules
+}
+
+// previousRuleOutcomes contains the outcomes that were recorded for the Release's rules
+// during previous runs, indexed by rule type. See `ApprovalRuleProcessor.FetchPreviousOutcomes()`.
+type previousRuleOutcomes map[dbmodels.ApprovalRuleType]interface{}
+
+func (engine *Engine) fetchPreviousRuleOutcomes(processors []ApprovalRuleProcessor) (previousRuleOutcomes, error) {
+	result := make(previousRuleOutcomes, len(processors))
+	for _, processor := range processors {
+		outcomes, err := processor.FetchPreviousOutcomes(engine)
+		if err != nil {
+			return nil, err
+		}
+		result[processor.Type()] = outcomes
+	}
+	return result, nil
+}
+
+// processRules processes the rules in stages, ordered by their EvaluationOrder (see
+// `ApprovalRulesetContents.EvaluationStages()`). A stage is only processed once all rules in
+// the previous stages have an outcome. Quota rules don't take part in this: they're always
+// processed last (see processQuotaRules()).
+//
+// Processing stops with `ctx.Err()` as soon as `ctx` is cancelled.
+func (engine *Engine) processRules(ctx context.Context, rulesetContents dbmodels.ApprovalRulesetContents) (releasestate.State, error) {
+	var nprocessed uint = 0
+	var nstaged uint = 0
+	var totalRules uint = rulesetContents.NumRules()
+
+	processors := approvalRuleProcessors()
+	previousOutcomes, err := engine.fetchPreviousRuleOutcomes(processors)
 	if err != nil {
 		return releasestate.Rejected, fmt.Errorf("Error loading state: %w", err)
 	}
 
-	err = engine.Db.Transaction(func(tx *gorm.DB) error {
-		if !finalResultState.IsFinal() {
-			// Process manual approval rules
-			resultState, n, err = engine.processManualApprovalRules(rulesetContents, manualApprovalRulePreviousOutcomes, nprocessed)
-			if err != nil {
-				finalResultState = releasestate.Rejected
-				// Error message already mentions the fact that it's about processing rules.
-				finalError = err
-				return nil
-			}
-			nprocessed += n
-			if resultState.IsFinal() {
-				finalResultState = resultState
-			}
-		}
-
-		if !finalResultState.IsFinal() {
-			// Process schedule rules
-			resultState, n, err = engine.processScheduleRules(rulesetContents, scheduleRulePreviousOutcomes, nprocessed)
-			if err != nil {
-				finalResultState = releasestate.Rejected
-				// Error message already mentions the fact that it's about processing rules.
-				finalError = err
-				return nil
-			}
-			nprocessed += n
-			if resultState.IsFinal() {
-				finalResultState = resultState
-			}
+	var stagedContents dbmodels.ApprovalRulesetContents
+	for _, rule := range rulesetContents.Rules {
+		if rule.Type() != dbmodels.QuotaApprovalRuleType {
+			stagedContents.Rules = append(stagedContents.Rules, rule)
+		}
+	}
+	for _, stage := range stagedContents.EvaluationStages() {
+		resultState, n, err := engine.processStage(ctx, stage, processors, previousOutcomes, nprocessed, totalRules)
+		if err != nil {
+			// Error message already mentions the fact that it's about processing rules.
+			return releasestate.Rejected, err
+		}
+		nprocessed += n
+		nstaged += stage.NumRules()
+		if resultState.IsFinal() {
+			return resultState, nil
+		}
+		if nprocessed < nstaged {
+			// Some rules in this stage are pending, so later stages must wait.
+			return releasestate.InProgress, nil
 		}
+	}
 
-		return nil
-	})
+	// Process quota rules. These must come last: see processQuotaRules().
+	if err = ctx.Err(); err != nil {
+		return releasestate.Rejected, err
+	}
+	resultState, n, err := quotaRuleProcessor{}.ProcessRules(ctx, engine, rulesetContents,
+		previousOutcomes[dbmodels.QuotaApprovalRuleType], nprocessed, totalRules)
 	if err != nil {
+		// Error message already mentions the fact that it's about processing rules.
 		return releasestate.Rejected, err
 	}
-	if finalError != nil {
-		return releasestate.Rejected, finalError
+	nprocessed += n
+	if resultState.IsFinal() {
+		return resultState, nil
 	}
 
-	if !finalResultState.IsFinal() {
-		// Process HTTP API rules
-		resultState, n, err = engine.processHTTPApiRules(rulesetContents, httpAPIRulePreviousOutcomes, nprocessed)
+	if nprocessed < totalRules {
+		// Some rules are pending.
+		return releasestate.InProgress, nil
+	}
+	panic("Bug: none of the rule processors returned a final result state")
+}
+
+// processStage processes the rules in a single evaluation stage, by running the processors
+// of the rule types that occur in this stage. It returns the number of rules that have an outcome.
+//
+// The processors run one after another, in the order of `processors`. Only the HTTP API rule
+// processor evaluates its rules concurrently (see evaluateHTTPApiRules()). Of the other
+// processors, only the callback rule processor contacts an external system, and it doesn't
+// wait for the verdict.
+func (engine *Engine) processStage(ctx context.Context, stage dbmodels.ApprovalRulesetContents, processors []ApprovalRuleProcessor,
+	previousOutcomes previousRuleOutcomes, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	var nprocessed uint = 0
+
+	for _, processor := range processors {
+		if len(stage.RulesOfType(processor.Type())) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return releasestate.Rejected, nprocessed, err
+		}
+
+		resultState, n, err := processor.ProcessRules(ctx, engine, stage, previousOutcomes[processor.Type()],
+			nAlreadyProcessed+nprocessed, totalRules)
 		if err != nil {
 			// Error message already mentions the fact that it's about processing rules.
-			return releasestate.Rejected, err
+			return releasestate.Rejected, nprocessed, err
 		}
 		nprocessed += n
 		if resultState.IsFinal() {
-			finalResultState = resultState
+			return resultState, nprocessed, nil
 		}
 	}
 
-	if !finalResultState.IsFinal() {
-		panic("Bug: none of the rule processors returned a final result state")
-	}
-	return finalResultState, finalError
+	return releasestate.InProgress, nprocessed, nil
 }
 
-func (engine Engine) lock() (*gorm.DB, error) {
+// lock acquires the Release's advisory lock. Waiting for the lock is aborted when `ctx` is cancelled.
+func (engine Engine) lock(ctx context.Context) (*gorm.DB, error) {
 	// Go's database connections are automatically pooled. We reserve a transaction here (to be passed to unlock())
 	// in order to ensure that we release the lock from the same database connection.
 	tx := engine.Db.Begin()
@@ -137,8 +311,10 @@ func (engine Engine) lock() (*gorm.DB, error) {
 		return nil, fmt.Errorf("Error starting a transaction: %w", tx.Error)
 	}
 
-	tx2 := tx.Exec("SELECT pg_advisory_lock(?) AS result", engine.getPostgresAdvisoryLockID())
+	// Only this statement uses `ctx`: unlock() must still work on `tx` after `ctx` is cancelled.
+	tx2 := tx.WithContext(ctx).Exec("SELECT pg_advisory_lock(?) AS result", engine.getPostgresAdvisoryLockID())
 	if tx2.Error != nil {
+		tx.Rollback()
 		return nil, tx2.Error
 	}
 
@@ -159,6 +335,40 @@ func (engine Engine) unlock(locktx *gorm.DB) {
 		engine.Db.Logger.Warn(context.Background(), "Error releasing advisory lock %d: database returned false",
 			engine.getPostgresAdvisoryLockID())
 	}
+
+	// Ends the transaction that lock() reserved, which returns its connection to the pool.
+	// Nothing was written through it.
+	if err := locktx.Rollback().Error; err != nil {
+		engine.Db.Logger.Warn(context.Background(), "Error ending lock transaction: %s", err.Error())
+	}
+}
+
+// lockApplication acquires an advisory lock on the Application that the Release belongs to.
+// Like lock(), this lock is bound to the database connection of `locktx`.
+func (engine Engine) lockApplication(locktx *gorm.DB) error {
+	return locktx.Exec("SELECT pg_advisory_lock(?, hashtext(?)) AS result",
+		dbmodels.ApplicationReleasesPostgresLockClassID, engine.getApplicationLockKey()).Error
+}
+
+func (engine Engine) unlockApplication(locktx *gorm.DB) {
+	var result struct {
+		Result bool
+	}
+	tx := locktx.Raw("SELECT pg_advisory_unlock(?, hashtext(?)) AS result",
+		dbmodels.ApplicationReleasesPostgresLockClassID, engine.getApplicationLockKey()).Scan(&result)
+	if tx.Error != nil {
+		engine.Db.Logger.Warn(context.Background(), "Error releasing advisory lock for application %s: %s",
+			engine.ReleaseBackgroundJob.ApplicationID, tx.Error.Error())
+	}
+
+	if !result.Result {
+		engine.Db.Logger.Warn(context.Background(), "Error releasing advisory lock for application %s: database returned false",
+			engine.ReleaseBackgroundJob.ApplicationID)
+	}
+}
+
+func (engine Engine) getApplicationLockKey() string {
+	return engine.OrganizationID + "/" + engine.ReleaseBackgroundJob.ApplicationID
 }
 
 func (engine Engine) getPostgresAdvisoryLockID() uint64 {
@@ -170,25 +380,136 @@ func (engine Engine) loadRules() (dbmodels.ApprovalRulesetContents, error) {
 		engine.ReleaseBackgroundJob.ApplicationID, engine.ReleaseBackgroundJob.ReleaseID)
 }
 
+// loadEnabledRules loads the rules that must be evaluated, and records which rules
+// were skipped because they're disabled.
+func (engine Engine) loadEnabledRules() (dbmodels.ApprovalRulesetContents, error) {
+	rulesetContents, err := engine.loadRules()
+	if err != nil {
+		return dbmodels.ApprovalRulesetContents{}, fmt.Errorf("Error loading rules: %w", err)
+	}
+
+	rulesetContents, skippedRules := rulesetContents.WithoutDisabledRules()
+	err = engine.recordSkippedRules(skippedRules)
+	if err != nil {
+		return dbmodels.ApprovalRulesetContents{}, fmt.Errorf("Error recording skipped rules: %w", err)
+	}
+
+	return rulesetContents, nil
+}
+
+// skippedRuleKey uniquely identifies a rule among all rule types.
+type skippedRuleKey struct {
+	ruleType string
+	ruleID   uint64
+}
+
+// recordSkippedRules creates a ReleaseRuleSkippedEvent for each given rule, unless one
+// was already created during a previous run.
+func (engine Engine) recordSkippedRules(skippedRules []dbmodels.SkippedApprovalRule) error {
+	if len(skippedRules) == 0 {
+		return nil
+	}
+
+	previousEvents, err := dbmodels.FindReleaseRuleSkippedEvents(engine.Db, engine.OrganizationID,
+		engine.ReleaseBackgroundJob.ApplicationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return err
+	}
+	alreadyRecorded := make(map[skippedRuleKey]bool)
+	for _, event := range previousEvents {
+		if event.ApprovalRuleType.Valid && event.ApprovalRuleID.Valid {
+			alreadyRecorded[skippedRuleKey{event.ApprovalRuleType.String, uint64(event.ApprovalRuleID.Int64)}] = true
+		}
+	}
+
+	for _, skipped := range skippedRules {
+		if alreadyRecorded[skippedRuleKey{string(skipped.Type), skipped.Rule.ID}] {
+			continue
+		}
+
+		event := dbmodels.ReleaseRuleSkippedEvent{
+			ReleaseEvent: dbmodels.ReleaseEvent{
+				BaseModel: dbmodels.BaseModel{
+					OrganizationID: engine.OrganizationID,
+				},
+				ReleaseID:     engine.ReleaseBackgroundJob.ReleaseID,
+				ApplicationID: engine.ReleaseBackgroundJob.ApplicationID,
+			},
+			ApprovalRuleType: sql.NullString{String: string(skipped.Type), Valid: true},
+			ApprovalRuleID:   sql.NullInt64{Int64: int64(skipped.Rule.ID), Valid: true},
+			Reason:           skipped.Reason,
+		}
+		tx := engine.Db.Omit(clause.Associations).Create(&event)
+		if tx.Error != nil {
+			return tx.Error
+		}
+		err = dbmodels.NotifyReleaseEventCreated(engine.Db, event.ReleaseEvent, dbmodels.ReleaseRuleSkippedEventType)
+		if err != nil {
+			return err
+		}
+
+		engine.Db.Logger.Info(context.Background(), "Skipped %s rule: org=%s, ID=%d, reason=%s",
+			skipped.Type, engine.OrganizationID, skipped.Rule.ID, skipped.Reason)
+	}
+
+	return nil
+}
+
 func (engine *Engine) finalizeJob(resultState releasestate.State) error {
-	return engine.Db.Transaction(func(tx *gorm.DB) error {
-		now := time.Now()
-		release := &engine.ReleaseBackgroundJob.Release
-		release.State = resultState
-		release.FinalizedAt = sql.NullTime{Time: now, Valid: true}
-		savetx := tx.Model(release).Updates(map[string]interface{}{
-			"state":        resultState,
-			"finalized_at": now,
-		})
-		if savetx.Error != nil {
-			return savetx.Error
-		}
-
-		return tx.Delete(&engine.ReleaseBackgroundJob).Error
+	var finalized bool
+	err := engine.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		finalized, err = dbmodels.FinalizeRelease(tx, &engine.ReleaseBackgroundJob.Release, resultState, engine.now())
+		return err
 	})
+	if err != nil {
+		return err
+	}
+	if !finalized {
+		// The Release was finalized by someone else while we were processing it, e.g. because
+		// it was cancelled. Don't overwrite that.
+		engine.Db.Logger.Info(context.Background(), "Release %s was finalized while processing it; leaving its state alone",
+			engine.ReleaseBackgroundJob.Release.Description())
+	}
+	return nil
+}
+
+func (engine Engine) now() time.Time {
+	if engine.Clock == nil {
+		return time.Now()
+	}
+	return engine.Clock.Now()
+}
+
+// interruptedError returns the error with which Run() reports that `ctx` was cancelled.
+func (engine Engine) interruptedError(ctx context.Context) error {
+	return fmt.Errorf("Processing of release %s was interrupted: %w",
+		engine.ReleaseBackgroundJob.Release.Description(), ctx.Err())
 }
 
-func (engine Engine) createRuleProcessedEvent(resultState releasestate.State, ignoredError bool) (dbmodels.ReleaseRuleProcessedEvent, error) {
+// deferUntil records that a rule should be re-evaluated at time `t`.
+func (engine *Engine) deferUntil(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if engine.nextEligibleAt.IsZero() || t.Before(engine.nextEligibleAt) {
+		engine.nextEligibleAt = t
+	}
+}
+
+func (engine *Engine) saveNextEligibleTime() error {
+	release := &engine.ReleaseBackgroundJob.Release
+	if engine.nextEligibleAt.IsZero() {
+		release.NextEligibleAt = sql.NullTime{}
+	} else {
+		release.NextEligibleAt = sql.NullTime{Time: engine.nextEligibleAt, Valid: true}
+	}
+	return engine.Db.Model(release).Update("next_eligible_at", release.NextEligibleAt).Error
+}
+
+// CreateRuleProcessedEvent records that a rule was processed, with the given resulting Release
+// state. The rule's outcome must be associated with the returned event.
+func (engine Engine) CreateRuleProcessedEvent(resultState releasestate.State, ignoredError bool) (dbmodels.ReleaseRuleProcessedEvent, error) {
 	event := dbmodels.ReleaseRuleProcessedEvent{
 		ReleaseEvent: dbmodels.ReleaseEvent{
 			BaseModel: dbmodels.BaseModel{
@@ -204,6 +525,10 @@ func (engine Engine) createRuleProcessedEvent(resultState releasestate.State, ig
 	if tx.Error != nil {
 		return dbmodels.ReleaseRuleProcessedEvent{}, tx.Error
 	}
+	err := dbmodels.NotifyReleaseEventCreated(engine.Db, event.ReleaseEvent, dbmodels.ReleaseRuleProcessedEventType)
+	if err != nil {
+		return dbmodels.ReleaseRuleProcessedEvent{}, err
+	}
 
 	return event, nil
 }
@@ -215,11 +540,16 @@ func maybeFormatRuleProcessingError(err error, format string, a ...interface{})
 	return fmt.Errorf(format, a...)
 }
 
-func isLastRule(nAlreadyProcessed uint, nprocessed uint, totalRules uint) bool {
+// IsLastRule returns whether the rule that was just processed is the last of all the Release's
+// rules. `nprocessed` includes that rule.
+func IsLastRule(nAlreadyProcessed uint, nprocessed uint, totalRules uint) bool {
 	return nAlreadyProcessed+nprocessed == totalRules
 }
 
-func determineReleaseStateFromOutcome(ruleProcessedSuccessfully bool, mode approvalrulesetbindingmode.Mode, isLastRule bool) (state releasestate.State, ignoredError bool) {
+// DetermineReleaseStateFromOutcome returns the Release state that results from a rule's outcome,
+// taking into account the mode with which the rule's ruleset is bound. `ignoredError` is true if
+// the rule failed, but the failure doesn't reject the Release.
+func DetermineReleaseStateFromOutcome(ruleProcessedSuccessfully bool, mode approvalrulesetbindingmode.Mode, isLastRule bool) (state releasestate.State, ignoredError bool) {
 	if ruleProcessedSuccessfully || mode == approvalrulesetbindingmode.Permissive {
 		if isLastRule {
 			return releasestate.Approved, !ruleProcessedSuccessfully
@@ -0,0 +1,162 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/icalendar"
+)
+
+func (engine Engine) fetchCalendarRulePreviousOutcomes() (map[uint64]bool, error) {
+	outcomes, err := dbmodels.FindCalendarApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexCalendarRuleOutcomes(outcomes), nil
+}
+
+func (engine Engine) processCalendarRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+	var nprocessed uint = 0
+	var organization dbmodels.Organization
+	var err error
+
+	if len(rulesetContents.RulesOfType(dbmodels.CalendarApprovalRuleType)) > 0 {
+		organization, err = dbmodels.FindOrganizationByID(engine.Db, engine.OrganizationID)
+		if err != nil {
+			return releasestate.Rejected, nprocessed, fmt.Errorf("Error loading organization: %w", err)
+		}
+	}
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.CalendarApprovalRuleType) {
+		rule := *r.(*dbmodels.CalendarApprovalRule)
+		success, outcomeAlreadyRecorded, blockingEntry, err := engine.processCalendarRule(rule, organization, previousOutcomes)
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(err, "Error processing calendar rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, err)
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed calendar rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		if !outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createCalendarRuleOutcome(rule, event, success, blockingEntry)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording calendar approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
+
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+		nprocessed, nil
+}
+
+// processCalendarRule checks whether the Release's creation time falls inside one of the
+// entries of the rule's Calendar. If so, then the rule fails and that entry is returned.
+func (engine Engine) processCalendarRule(rule dbmodels.CalendarApprovalRule, organization dbmodels.Organization, previousOutcomes map[uint64]bool) (bool, bool, *dbmodels.CalendarEntry, error) {
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return success, true, nil, nil
+	}
+
+	calendar, err := dbmodels.FindCalendar(engine.Db, engine.OrganizationID, rule.CalendarID)
+	if err != nil {
+		return false, false, nil, fmt.Errorf("Error loading calendar '%s': %w", rule.CalendarID, err)
+	}
+
+	location, err := calendar.Location(organization)
+	if err != nil {
+		return false, false, nil, err
+	}
+
+	releaseTime := engine.ReleaseBackgroundJob.Release.CreatedAt
+	entries, err := dbmodels.FindCalendarEntriesPossiblyContainingTime(engine.Db, engine.OrganizationID, calendar.ID, releaseTime)
+	if err != nil {
+		return false, false, nil, fmt.Errorf("Error loading entries of calendar '%s': %w", calendar.ID, err)
+	}
+
+	for i, entry := range entries {
+		contained, err := calendarEntryContainsTime(entry, releaseTime, location)
+		if err != nil {
+			return false, false, nil, fmt.Errorf("Calendar '%s', entry %d: %w", calendar.ID, entry.ID, err)
+		}
+		if contained {
+			return false, false, &entries[i], nil
+		}
+	}
+
+	return true, false, nil, nil
+}
+
+// calendarEntryContainsTime checks whether `t` falls within the given CalendarEntry, or within
+// one of its recurrences. All-day entries are matched against the date of `t` in `location`.
+func calendarEntryContainsTime(entry dbmodels.CalendarEntry, t time.Time, location *time.Location) (bool, error) {
+	var begin, end, point time.Time
+
+	if entry.AllDay {
+		localTime := t.In(location)
+		point = time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, time.UTC)
+		begin = entry.BeginAt.UTC()
+		end = entry.EndAt.UTC()
+	} else {
+		point = t
+		begin = entry.BeginAt.In(location)
+		end = entry.EndAt.In(location)
+	}
+
+	if !entry.RecurrenceRule.Valid {
+		return !point.Before(begin) && point.Before(end), nil
+	}
+
+	recurrenceRule, err := icalendar.ParseRecurrenceRule(entry.RecurrenceRule.String)
+	if err != nil {
+		return false, fmt.Errorf("Error parsing recurrence rule '%s': %w", entry.RecurrenceRule.String, err)
+	}
+	return recurrenceRule.Contains(begin, end, point), nil
+}
+
+func (engine Engine) createCalendarRuleOutcome(rule dbmodels.CalendarApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, blockingEntry *dbmodels.CalendarEntry) error {
+	outcome := dbmodels.CalendarApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		CalendarApprovalRuleID: rule.ApprovalRule.ID,
+	}
+	if blockingEntry != nil {
+		outcome.BlockingEntrySummary.String = blockingEntry.Summary
+		outcome.BlockingEntrySummary.Valid = true
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func indexCalendarRuleOutcomes(outcomes []dbmodels.CalendarApprovalRuleOutcome) map[uint64]bool {
+	result := make(map[uint64]bool)
+	for _, outcome := range outcomes {
+		result[outcome.CalendarApprovalRuleID] = outcome.Success
+	}
+	return result
+}
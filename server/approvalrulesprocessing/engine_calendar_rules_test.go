@@ -0,0 +1,251 @@
+package approvalrulesprocessing
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test processCalendarRules()
+
+type ProcessCalendarRulesTestContext struct {
+	db               *gorm.DB
+	org              dbmodels.Organization
+	app              dbmodels.Application
+	release          dbmodels.Release
+	enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+	calendar         dbmodels.Calendar
+	job              dbmodels.ReleaseBackgroundJob
+	engine           Engine
+	rulesetContents  dbmodels.ApprovalRulesetContents
+}
+
+func setupProcessCalendarRulesTest() (ProcessCalendarRulesTestContext, error) {
+	var ctx ProcessCalendarRulesTestContext
+	var err error
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessCalendarRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, func(org *dbmodels.Organization) {
+			org.DefaultTimeZone = sql.NullString{String: "UTC", Valid: true}
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, func(release *dbmodels.Release) {
+			release.CreatedAt = time.Date(2020, time.December, 25, 12, 0, 0, 0, time.UTC)
+		})
+		if err != nil {
+			return err
+		}
+
+		_, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.calendar, err = dbmodels.CreateMockCalendar(tx, ctx.org, "holidays", nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessCalendarRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessCalendarRulesTestContext) addRule() error {
+	rule, err := dbmodels.CreateMockCalendarApprovalRule(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		ctx.calendar, nil)
+	if err != nil {
+		return err
+	}
+	rule.BindingMode = approvalrulesetbindingmode.Enforcing
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return nil
+}
+
+func TestProcessCalendarRulesSuccess(t *testing.T) {
+	ctx, err := setupProcessCalendarRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = dbmodels.CreateMockCalendarEntryAllDay(ctx.db, ctx.calendar,
+		time.Date(2020, time.December, 24, 0, 0, 0, 0, time.UTC), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule()) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processCalendarRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.CalendarApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, outcome.Success)
+	assert.False(t, outcome.BlockingEntrySummary.Valid)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessCalendarRulesBlocked(t *testing.T) {
+	ctx, err := setupProcessCalendarRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = dbmodels.CreateMockCalendarEntryAllDay(ctx.db, ctx.calendar,
+		time.Date(2019, time.December, 25, 0, 0, 0, 0, time.UTC),
+		func(entry *dbmodels.CalendarEntry) {
+			entry.Summary = "Christmas"
+			entry.RecurrenceRule = sql.NullString{String: "FREQ=YEARLY;INTERVAL=1", Valid: true}
+		})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule()) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processCalendarRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var event dbmodels.ReleaseRuleProcessedEvent
+	var outcome dbmodels.CalendarApprovalRuleOutcome
+	err = ctx.db.Take(&event).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, releasestate.Rejected, event.ResultState)
+	assert.False(t, outcome.Success)
+	assert.Equal(t, "Christmas", outcome.BlockingEntrySummary.String)
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+// Test calendarEntryContainsTime()
+
+func TestCalendarEntryContainsTimeAllDay(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, err) {
+		return
+	}
+	entry := dbmodels.CalendarEntry{
+		AllDay:  true,
+		BeginAt: time.Date(2021, time.March, 9, 0, 0, 0, 0, time.UTC),
+		EndAt:   time.Date(2021, time.March, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	inputs := map[time.Time]bool{
+		// Tuesday 02:00 UTC is still Monday evening in New York
+		time.Date(2021, time.March, 9, 2, 0, 0, 0, time.UTC): false,
+		time.Date(2021, time.March, 9, 6, 0, 0, 0, time.UTC): true,
+		// Wednesday 02:00 UTC is still Tuesday evening in New York
+		time.Date(2021, time.March, 10, 2, 0, 0, 0, time.UTC): true,
+		time.Date(2021, time.March, 10, 6, 0, 0, 0, time.UTC): false,
+	}
+	for releaseTime, expected := range inputs {
+		result, err := calendarEntryContainsTime(entry, releaseTime, newYork)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
+		}
+	}
+}
+
+func TestCalendarEntryContainsTimeDateTime(t *testing.T) {
+	entry := dbmodels.CalendarEntry{
+		BeginAt: time.Date(2021, time.March, 9, 10, 0, 0, 0, time.UTC),
+		EndAt:   time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC),
+	}
+
+	inputs := map[time.Time]bool{
+		time.Date(2021, time.March, 9, 9, 59, 0, 0, time.UTC):  false,
+		time.Date(2021, time.March, 9, 10, 0, 0, 0, time.UTC):  true,
+		time.Date(2021, time.March, 9, 11, 59, 0, 0, time.UTC): true,
+		time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC):  false,
+	}
+	for releaseTime, expected := range inputs {
+		result, err := calendarEntryContainsTime(entry, releaseTime, time.UTC)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
+		}
+	}
+}
+
+func TestCalendarEntryContainsTimeRecurring(t *testing.T) {
+	entry := dbmodels.CalendarEntry{
+		AllDay:         true,
+		BeginAt:        time.Date(2020, time.December, 24, 0, 0, 0, 0, time.UTC),
+		EndAt:          time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC),
+		RecurrenceRule: sql.NullString{String: "FREQ=YEARLY;INTERVAL=1", Valid: true},
+	}
+
+	inputs := map[time.Time]bool{
+		time.Date(2023, time.December, 23, 12, 0, 0, 0, time.UTC): false,
+		time.Date(2023, time.December, 31, 12, 0, 0, 0, time.UTC): true,
+		time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC):   true,
+		time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC):   false,
+	}
+	for releaseTime, expected := range inputs {
+		result, err := calendarEntryContainsTime(entry, releaseTime, time.UTC)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
+		}
+	}
+}
+
+func TestCalendarEntryContainsTimeInvalidRecurrenceRule(t *testing.T) {
+	entry := dbmodels.CalendarEntry{
+		RecurrenceRule: sql.NullString{String: "FREQ=SECONDLY", Valid: true},
+	}
+	_, err := calendarEntryContainsTime(entry, time.Now(), time.UTC)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Error parsing recurrence rule")
+	}
+}
@@ -0,0 +1,263 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	encjson "encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"gorm.io/gorm/clause"
+)
+
+// A CallbackApprovalRule is processed in two steps. First, the engine POSTs a job to the rule's
+// URL, along with a one-time callback token, and records a CallbackApprovalRuleRequest. The rule
+// is then pending until the external system reports the result by calling
+// `POST /v1/rule-callbacks/:token` (see `controllers.CreateRuleCallback`), or until the request
+// expires. The next time the engine processes the Release, it records the outcome.
+
+const callbackRuleTokenBytes = 32
+
+// callbackRuleRequestBody is the body that is POSTed to a CallbackApprovalRule's URL.
+type callbackRuleRequestBody struct {
+	ApplicationID string       `json:"application_id"`
+	Release       json.Release `json:"release"`
+
+	// CallbackToken is the one-time token with which the external system reports the result.
+	CallbackToken string `json:"callback_token"`
+
+	// CallbackPath is the path, relative to the Sqedule server's base URL, to POST the result to.
+	CallbackPath string    `json:"callback_path"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (engine Engine) fetchCallbackRulePreviousOutcomes() (map[uint64]bool, error) {
+	outcomes, err := dbmodels.FindCallbackApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexCallbackRuleOutcomes(outcomes), nil
+}
+
+func (engine Engine) fetchCallbackRuleRequests() (map[uint64]dbmodels.CallbackApprovalRuleRequest, error) {
+	requests, err := dbmodels.FindCallbackApprovalRuleRequests(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexCallbackRuleRequests(requests), nil
+}
+
+func (engine *Engine) processCallbackRules(ctx context.Context, rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool,
+	requests map[uint64]dbmodels.CallbackApprovalRuleRequest, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+
+	var nprocessed uint = 0
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.CallbackApprovalRuleType) {
+		rule := *r.(*dbmodels.CallbackApprovalRule)
+		decided, success, outcomeAlreadyRecorded, message, expiresAt, err := engine.processCallbackRule(ctx, rule, previousOutcomes, requests)
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(err, "Error processing callback rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, err)
+		}
+		if !decided && engine.dryRun {
+			engine.notEvaluatedRules = append(engine.notEvaluatedRules, r)
+			continue
+		}
+		if !decided {
+			engine.Db.Logger.Info(context.Background(),
+				"Callback rule still awaiting callback: org=%s, ID=%d, expiresAt=%s",
+				engine.OrganizationID, rule.ID, expiresAt)
+			engine.deferUntil(expiresAt)
+			continue
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed callback rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		if !outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createCallbackRuleOutcome(rule, event, success, message)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording callback approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
+
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+		nprocessed, nil
+}
+
+// processCallbackRule sends the rule's job if that hasn't been done yet, and checks whether the
+// external system has called back. `decided` is false while the callback is still awaited, in
+// which case `expiresAt` is the time at which the rule times out.
+func (engine Engine) processCallbackRule(ctx context.Context, rule dbmodels.CallbackApprovalRule, previousOutcomes map[uint64]bool,
+	requests map[uint64]dbmodels.CallbackApprovalRuleRequest) (decided bool, success bool, outcomeAlreadyRecorded bool,
+	message sql.NullString, expiresAt time.Time, err error) {
+
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return true, success, true, sql.NullString{}, time.Time{}, nil
+	}
+
+	request, exists := requests[rule.ID]
+	if !exists && engine.dryRun {
+		// Contacting the external system is a side effect that a dry run must not have.
+		return false, false, false, sql.NullString{}, time.Time{}, nil
+	}
+	if !exists {
+		request, err = engine.sendCallbackRuleJob(ctx, rule)
+		if err != nil {
+			return false, false, false, sql.NullString{}, time.Time{}, err
+		}
+	}
+
+	if request.CompletedAt.Valid {
+		return true, request.Success.Bool, false, request.Message, time.Time{}, nil
+	}
+	if !engine.now().Before(request.ExpiresAt) {
+		return true, false, false,
+			sql.NullString{String: fmt.Sprintf("No callback received within %d minutes", rule.TimeoutMinutes), Valid: true},
+			time.Time{}, nil
+	}
+	return false, false, false, sql.NullString{}, request.ExpiresAt, nil
+}
+
+// sendCallbackRuleJob records a new CallbackApprovalRuleRequest and POSTs the job to the rule's URL.
+// If the job could not be delivered, then the request is immediately completed as a failure.
+//
+// If `ctx` is cancelled while delivering, then the request is deleted again and `ctx.Err()` is
+// returned, so that the job is sent again during the next run.
+func (engine Engine) sendCallbackRuleJob(ctx context.Context, rule dbmodels.CallbackApprovalRule) (dbmodels.CallbackApprovalRuleRequest, error) {
+	token, err := generateCallbackRuleToken()
+	if err != nil {
+		return dbmodels.CallbackApprovalRuleRequest{}, fmt.Errorf("Error generating callback token: %w", err)
+	}
+
+	request := dbmodels.CallbackApprovalRuleRequest{
+		BaseModel: dbmodels.BaseModel{
+			OrganizationID: engine.OrganizationID,
+		},
+		ApplicationID:          engine.ReleaseBackgroundJob.ApplicationID,
+		ReleaseID:              engine.ReleaseBackgroundJob.ReleaseID,
+		CallbackApprovalRuleID: rule.ID,
+		TokenHash:              dbmodels.HashCallbackToken(token),
+		ExpiresAt:              engine.now().Add(time.Duration(rule.TimeoutMinutes) * time.Minute),
+	}
+	err = engine.Db.Omit(clause.Associations).Create(&request).Error
+	if err != nil {
+		return dbmodels.CallbackApprovalRuleRequest{}, fmt.Errorf("Error recording callback approval rule request: %w", err)
+	}
+
+	failureMessage := engine.deliverCallbackRuleJob(ctx, rule, token, request.ExpiresAt)
+	if ctx.Err() != nil {
+		err = engine.Db.Delete(&request).Error
+		if err != nil {
+			return dbmodels.CallbackApprovalRuleRequest{}, fmt.Errorf("Error deleting interrupted callback approval rule request: %w", err)
+		}
+		return dbmodels.CallbackApprovalRuleRequest{}, ctx.Err()
+	}
+	if len(failureMessage) > 0 {
+		engine.Db.Logger.Warn(context.Background(),
+			"Error sending callback rule job: org=%s, ID=%d: %s",
+			engine.OrganizationID, rule.ID, failureMessage)
+		_, err = dbmodels.CompleteCallbackApprovalRuleRequest(engine.Db, &request, false,
+			sql.NullString{String: failureMessage, Valid: true}, engine.now())
+		if err != nil {
+			return dbmodels.CallbackApprovalRuleRequest{}, fmt.Errorf("Error recording callback approval rule request: %w", err)
+		}
+	}
+
+	return request, nil
+}
+
+// deliverCallbackRuleJob POSTs the job to the rule's URL. It returns a non-empty
+// failure message if the external system didn't accept the job.
+func (engine Engine) deliverCallbackRuleJob(ctx context.Context, rule dbmodels.CallbackApprovalRule, token string, expiresAt time.Time) string {
+	client, err := createRuleHTTPClient(rule.TLSCaCertificate)
+	if err != nil {
+		return err.Error()
+	}
+
+	requestBody, err := encjson.Marshal(callbackRuleRequestBody{
+		ApplicationID: engine.ReleaseBackgroundJob.ApplicationID,
+		Release:       json.CreateFromDbRelease(engine.ReleaseBackgroundJob.Release),
+		CallbackToken: token,
+		CallbackPath:  "/v1/rule-callbacks/" + token,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return fmt.Sprintf("Error encoding request body: %s", err.Error())
+	}
+
+	response, err := performRuleHTTPRequest(ctx, client, rule.URL, rule.Username, rule.Password, requestBody)
+	if err != nil {
+		return fmt.Sprintf("Error sending job to %s: %s", rule.URL, err.Error())
+	}
+	if !httpResponseCodeIsSuccessful(response.Code) {
+		return fmt.Sprintf("Error sending job to %s: server responded with HTTP code %d", rule.URL, response.Code)
+	}
+	return ""
+}
+
+func generateCallbackRuleToken() (string, error) {
+	buf := make([]byte, callbackRuleTokenBytes)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (engine Engine) createCallbackRuleOutcome(rule dbmodels.CallbackApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, message sql.NullString) error {
+	outcome := dbmodels.CallbackApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		CallbackApprovalRuleID: rule.ApprovalRule.ID,
+		Message:                message,
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func indexCallbackRuleOutcomes(outcomes []dbmodels.CallbackApprovalRuleOutcome) map[uint64]bool {
+	result := make(map[uint64]bool)
+	for _, outcome := range outcomes {
+		result[outcome.CallbackApprovalRuleID] = outcome.Success
+	}
+	return result
+}
+
+func indexCallbackRuleRequests(requests []dbmodels.CallbackApprovalRuleRequest) map[uint64]dbmodels.CallbackApprovalRuleRequest {
+	result := make(map[uint64]dbmodels.CallbackApprovalRuleRequest)
+	for _, request := range requests {
+		result[request.CallbackApprovalRuleID] = request
+	}
+	return result
+}
@@ -0,0 +1,257 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"database/sql"
+	encjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test processCallbackRules()
+
+type ProcessCallbackRulesTestContext struct {
+	db               *gorm.DB
+	org              dbmodels.Organization
+	app              dbmodels.Application
+	release          dbmodels.Release
+	enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+	job              dbmodels.ReleaseBackgroundJob
+	engine           Engine
+	rulesetContents  dbmodels.ApprovalRulesetContents
+}
+
+var callbackRulesTestNow = time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func setupProcessCallbackRulesTest() (ProcessCallbackRulesTestContext, error) {
+	var ctx ProcessCallbackRulesTestContext
+	var err error
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessCallbackRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, nil)
+		if err != nil {
+			return err
+		}
+
+		_, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessCallbackRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+		Clock:                &mocking.FakeClock{Value: callbackRulesTestNow},
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessCallbackRulesTestContext) addRule(url string) (dbmodels.CallbackApprovalRule, error) {
+	rule, err := dbmodels.CreateMockCallbackApprovalRule(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		url, nil)
+	if err != nil {
+		return dbmodels.CallbackApprovalRule{}, err
+	}
+	rule.BindingMode = ctx.enforcingBinding.Version.Adjustment.Mode
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return rule, nil
+}
+
+func (ctx *ProcessCallbackRulesTestContext) process() (releasestate.State, uint, error) {
+	requests, err := ctx.engine.fetchCallbackRuleRequests()
+	if err != nil {
+		return releasestate.Rejected, 0, err
+	}
+	return ctx.engine.processCallbackRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, requests, 0, ctx.rulesetContents.NumRules())
+}
+
+func TestProcessCallbackRulesSendsJobAndAwaitsCallback(t *testing.T) {
+	ctx, err := setupProcessCallbackRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var requestBody callbackRuleRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encjson.NewDecoder(r.Body).Decode(&requestBody) //nolint:errcheck
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.process()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var request dbmodels.CallbackApprovalRuleRequest
+	err = ctx.db.Take(&request).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var count int64
+	err = ctx.db.Model(&dbmodels.CallbackApprovalRuleOutcome{}).Count(&count).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expectedExpiresAt := callbackRulesTestNow.Add(60 * time.Minute)
+	nextEligibleAt, deferred := ctx.engine.NextEligibleTime()
+	assert.Equal(t, ctx.app.ID, requestBody.ApplicationID)
+	assert.NotEmpty(t, requestBody.CallbackToken)
+	assert.Equal(t, "/v1/rule-callbacks/"+requestBody.CallbackToken, requestBody.CallbackPath)
+	assert.Equal(t, dbmodels.HashCallbackToken(requestBody.CallbackToken), request.TokenHash)
+	assert.False(t, request.CompletedAt.Valid)
+	assert.Equal(t, expectedExpiresAt, request.ExpiresAt.UTC())
+	assert.True(t, deferred)
+	assert.Equal(t, expectedExpiresAt, nextEligibleAt.UTC())
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+}
+
+func TestProcessCallbackRulesCallbackReceived(t *testing.T) {
+	ctx, err := setupProcessCallbackRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	rule, err := ctx.addRule("http://localhost")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = dbmodels.CreateMockCallbackApprovalRuleRequest(ctx.db, ctx.release, rule, "token",
+		func(request *dbmodels.CallbackApprovalRuleRequest) {
+			request.ExpiresAt = callbackRulesTestNow.Add(time.Hour)
+			request.CompletedAt = sql.NullTime{Time: callbackRulesTestNow, Valid: true}
+			request.Success = sql.NullBool{Bool: true, Valid: true}
+			request.Message = sql.NullString{String: "No vulnerabilities found", Valid: true}
+		})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.process()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.CallbackApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, outcome.Success)
+	assert.Equal(t, "No vulnerabilities found", outcome.Message.String)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessCallbackRulesTimeout(t *testing.T) {
+	ctx, err := setupProcessCallbackRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	rule, err := ctx.addRule("http://localhost")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = dbmodels.CreateMockCallbackApprovalRuleRequest(ctx.db, ctx.release, rule, "token",
+		func(request *dbmodels.CallbackApprovalRuleRequest) {
+			request.ExpiresAt = callbackRulesTestNow.Add(-time.Minute)
+		})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.process()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.CallbackApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, outcome.Success)
+	assert.Equal(t, "No callback received within 60 minutes", outcome.Message.String)
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessCallbackRulesDeliveryFailure(t *testing.T) {
+	ctx, err := setupProcessCallbackRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.process()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.CallbackApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, outcome.Success)
+	assert.Contains(t, outcome.Message.String, "HTTP code 500")
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
@@ -0,0 +1,156 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+)
+
+// dependencyRuleRecheckInterval is how often a pending dependency rule checks whether
+// a matching dependency Release has been approved in the meantime.
+const dependencyRuleRecheckInterval = time.Minute
+
+func (engine Engine) fetchDependencyRulePreviousOutcomes() (map[uint64]bool, error) {
+	outcomes, err := dbmodels.FindDependencyApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexDependencyRuleOutcomes(outcomes), nil
+}
+
+func (engine *Engine) processDependencyRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+	var nprocessed uint = 0
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.DependencyApprovalRuleType) {
+		rule := *r.(*dbmodels.DependencyApprovalRule)
+		success, outcomeAlreadyRecorded, dependencyRelease, nextEligibleAt, err := engine.processDependencyRule(rule, previousOutcomes)
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(err, "Error processing dependency rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, err)
+		}
+		if !nextEligibleAt.IsZero() {
+			engine.Db.Logger.Info(context.Background(),
+				"Dependency rule deferred until a dependency release may be approved: org=%s, ID=%d, nextEligibleAt=%s",
+				engine.OrganizationID, rule.ID, nextEligibleAt)
+			engine.deferUntil(nextEligibleAt)
+			continue
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed dependency rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		if !outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createDependencyRuleOutcome(rule, event, success, dependencyRelease)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording dependency approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
+
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+		nprocessed, nil
+}
+
+// processDependencyRule looks for an approved Release of the rule's dependency Application
+// that satisfies the rule's conditions. If one exists, then the rule succeeds and the most
+// recent such Release is returned.
+//
+// If none exists yet, then the rule stays pending: no outcome is returned, but `nextEligibleAt`
+// is set to the time at which to check again, because the dependency Release is typically
+// still in progress. The rule only fails once `MaxAgeHours` have passed since the Release being
+// processed was created. Rules bound in permissive mode are never deferred, because their
+// failures are ignored anyway.
+func (engine Engine) processDependencyRule(rule dbmodels.DependencyApprovalRule, previousOutcomes map[uint64]bool) (success bool, outcomeAlreadyRecorded bool, dependencyRelease *dbmodels.Release, nextEligibleAt time.Time, err error) {
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return success, true, nil, time.Time{}, nil
+	}
+
+	release := engine.ReleaseBackgroundJob.Release
+	now := engine.now()
+	tx := engine.Db.Where("state = ?", releasestate.Approved)
+	if rule.MaxAgeHours.Valid {
+		minCreatedAt := now.Add(-time.Duration(rule.MaxAgeHours.Int32) * time.Hour)
+		tx = tx.Where("created_at >= ?", minCreatedAt)
+	}
+	if rule.SameSourceIdentity {
+		if !release.SourceIdentity.Valid {
+			// There's nothing to compare against, so no dependency Release can ever match.
+			return false, false, nil, time.Time{}, nil
+		}
+		tx = tx.Where("source_identity = ?", release.SourceIdentity.String)
+	}
+
+	dependencyReleases, err := dbmodels.FindReleases(tx.Order("created_at DESC").Limit(1),
+		engine.OrganizationID, rule.DependencyApplicationID)
+	if err != nil {
+		return false, false, nil, time.Time{}, fmt.Errorf("Error loading releases of application '%s': %w",
+			rule.DependencyApplicationID, err)
+	}
+	if len(dependencyReleases) > 0 {
+		return true, false, &dependencyReleases[0], time.Time{}, nil
+	}
+
+	if rule.BindingMode == approvalrulesetbindingmode.Permissive {
+		return false, false, nil, time.Time{}, nil
+	}
+	nextEligibleAt = now.Add(dependencyRuleRecheckInterval)
+	if rule.MaxAgeHours.Valid {
+		deadline := release.CreatedAt.Add(time.Duration(rule.MaxAgeHours.Int32) * time.Hour)
+		if !now.Before(deadline) {
+			return false, false, nil, time.Time{}, nil
+		}
+		if deadline.Before(nextEligibleAt) {
+			nextEligibleAt = deadline
+		}
+	}
+	return false, false, nil, nextEligibleAt, nil
+}
+
+func (engine Engine) createDependencyRuleOutcome(rule dbmodels.DependencyApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, dependencyRelease *dbmodels.Release) error {
+	outcome := dbmodels.DependencyApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		DependencyApprovalRuleID: rule.ApprovalRule.ID,
+	}
+	if dependencyRelease != nil {
+		outcome.DependencyReleaseID.Int64 = int64(dependencyRelease.ID)
+		outcome.DependencyReleaseID.Valid = true
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func indexDependencyRuleOutcomes(outcomes []dbmodels.DependencyApprovalRuleOutcome) map[uint64]bool {
+	result := make(map[uint64]bool)
+	for _, outcome := range outcomes {
+		result[outcome.DependencyApprovalRuleID] = outcome.Success
+	}
+	return result
+}
@@ -0,0 +1,292 @@
+package approvalrulesprocessing
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test processDependencyRules()
+
+type ProcessDependencyRulesTestContext struct {
+	db               *gorm.DB
+	org              dbmodels.Organization
+	app              dbmodels.Application
+	dependencyApp    dbmodels.Application
+	release          dbmodels.Release
+	enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+	job              dbmodels.ReleaseBackgroundJob
+	engine           Engine
+	rulesetContents  dbmodels.ApprovalRulesetContents
+}
+
+func setupProcessDependencyRulesTest() (ProcessDependencyRulesTestContext, error) {
+	var ctx ProcessDependencyRulesTestContext
+	var err error
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessDependencyRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.dependencyApp, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, func(app *dbmodels.Application) {
+			app.ID = "migrations"
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, func(release *dbmodels.Release) {
+			release.SourceIdentity = sql.NullString{String: "v1.2", Valid: true}
+			release.CreatedAt = time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+		})
+		if err != nil {
+			return err
+		}
+
+		_, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessDependencyRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+		Clock:                &mocking.FakeClock{Value: time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)},
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessDependencyRulesTestContext) addRule(customizeFunc func(rule *dbmodels.DependencyApprovalRule)) error {
+	rule, err := dbmodels.CreateMockDependencyApprovalRule(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		ctx.dependencyApp, customizeFunc)
+	if err != nil {
+		return err
+	}
+	rule.BindingMode = approvalrulesetbindingmode.Enforcing
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return nil
+}
+
+func (ctx *ProcessDependencyRulesTestContext) addDependencyRelease(state releasestate.State, createdAt time.Time, sourceIdentity string) (dbmodels.Release, error) {
+	return dbmodels.CreateMockReleaseWithInProgressState(ctx.db, ctx.org, ctx.dependencyApp, func(release *dbmodels.Release) {
+		release.State = state
+		release.CreatedAt = createdAt
+		release.SourceIdentity = sql.NullString{String: sourceIdentity, Valid: true}
+	})
+}
+
+func TestProcessDependencyRulesSuccess(t *testing.T) {
+	ctx, err := setupProcessDependencyRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addDependencyRelease(releasestate.Approved, time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC), "v1.1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	dependencyRelease, err := ctx.addDependencyRelease(releasestate.Approved, time.Date(2021, time.March, 10, 11, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addDependencyRelease(releasestate.Rejected, time.Date(2021, time.March, 10, 11, 30, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(nil)) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.DependencyApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, outcome.Success)
+	assert.Equal(t, int64(dependencyRelease.ID), outcome.DependencyReleaseID.Int64)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessDependencyRulesNoApprovedReleaseYet(t *testing.T) {
+	ctx, err := setupProcessDependencyRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	dependencyRelease, err := ctx.addDependencyRelease(releasestate.InProgress, time.Date(2021, time.March, 10, 11, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(nil)) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var count int64
+	err = ctx.db.Model(&dbmodels.DependencyApprovalRuleOutcome{}).Count(&count).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	nextEligibleAt, deferred := ctx.engine.NextEligibleTime()
+	assert.True(t, deferred)
+	assert.Equal(t, time.Date(2021, time.March, 10, 12, 1, 0, 0, time.UTC), nextEligibleAt.UTC())
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+
+	err = ctx.db.Model(&dependencyRelease).Update("state", releasestate.Approved).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	resultState, nprocessed, err = ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessDependencyRulesPermissiveNotDeferred(t *testing.T) {
+	ctx, err := setupProcessDependencyRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(nil)) {
+		return
+	}
+	ctx.rulesetContents.Rules[0].(*dbmodels.DependencyApprovalRule).BindingMode = approvalrulesetbindingmode.Permissive
+
+	resultState, nprocessed, err := ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.DependencyApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, deferred := ctx.engine.NextEligibleTime()
+	assert.False(t, deferred)
+	assert.False(t, outcome.Success)
+	assert.False(t, outcome.DependencyReleaseID.Valid)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessDependencyRulesMaxAge(t *testing.T) {
+	ctx, err := setupProcessDependencyRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addDependencyRelease(releasestate.Approved, time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.addRule(func(rule *dbmodels.DependencyApprovalRule) {
+		rule.MaxAgeHours = sql.NullInt32{Int32: 12, Valid: true}
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The dependency release is too old, but a newer one may still be approved.
+	resultState, nprocessed, err := ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, deferred := ctx.engine.NextEligibleTime()
+	assert.True(t, deferred)
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+
+	// Once MaxAgeHours have passed since the release was created, the rule fails.
+	ctx.engine.Clock = &mocking.FakeClock{Value: time.Date(2021, time.March, 11, 0, 0, 0, 0, time.UTC)}
+	resultState, nprocessed, err = ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessDependencyRulesSameSourceIdentity(t *testing.T) {
+	ctx, err := setupProcessDependencyRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addDependencyRelease(releasestate.Approved, time.Date(2021, time.March, 10, 11, 0, 0, 0, time.UTC), "v1.1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.addRule(func(rule *dbmodels.DependencyApprovalRule) {
+		rule.SameSourceIdentity = true
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processDependencyRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+
+	dependencyRelease, err := ctx.addDependencyRelease(releasestate.Approved, time.Date(2021, time.March, 10, 10, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	success, _, matchingRelease, _, err := ctx.engine.processDependencyRule(*ctx.rulesetContents.Rules[0].(*dbmodels.DependencyApprovalRule), map[uint64]bool{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, success)
+	if assert.NotNil(t, matchingRelease) {
+		assert.Equal(t, dependencyRelease.ID, matchingRelease.ID)
+	}
+}
@@ -0,0 +1,114 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/expression"
+)
+
+func (engine Engine) fetchExpressionRulePreviousOutcomes() (map[uint64]bool, error) {
+	outcomes, err := dbmodels.FindExpressionApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexExpressionRuleOutcomes(outcomes), nil
+}
+
+func (engine Engine) processExpressionRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+	var nprocessed uint = 0
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.ExpressionApprovalRuleType) {
+		rule := *r.(*dbmodels.ExpressionApprovalRule)
+		success, outcomeAlreadyRecorded, failureMessage := engine.processExpressionRule(rule, previousOutcomes)
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed expression rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		if !outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createExpressionRuleOutcome(rule, event, success, failureMessage)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording expression approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
+
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+		nprocessed, nil
+}
+
+// processExpressionRule evaluates the rule's expression against the Release. An expression
+// that can't be compiled or evaluated (e.g. because it refers to a metadata key that the Release
+// doesn't have) causes the rule to fail rather than being treated as a processing error,
+// because retrying won't change the outcome.
+func (engine Engine) processExpressionRule(rule dbmodels.ExpressionApprovalRule, previousOutcomes map[uint64]bool) (success bool, outcomeAlreadyRecorded bool, failureMessage string) {
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return success, true, ""
+	}
+
+	program, err := expression.Compile(rule.Expression)
+	if err != nil {
+		return false, false, "Error compiling expression: " + err.Error()
+	}
+
+	release := engine.ReleaseBackgroundJob.Release
+	result, err := program.Evaluate(expression.Variables{
+		ApplicationID:  release.ApplicationID,
+		Metadata:       release.Metadata,
+		SourceIdentity: release.SourceIdentity.String,
+		Comments:       release.Comments.String,
+	})
+	if err != nil {
+		return false, false, "Error evaluating expression: " + err.Error()
+	}
+	if !result {
+		return false, false, "Expression evaluated to false"
+	}
+	return true, false, ""
+}
+
+func (engine Engine) createExpressionRuleOutcome(rule dbmodels.ExpressionApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, failureMessage string) error {
+	outcome := dbmodels.ExpressionApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		ExpressionApprovalRuleID: rule.ApprovalRule.ID,
+	}
+	if len(failureMessage) > 0 {
+		outcome.FailureMessage.String = failureMessage
+		outcome.FailureMessage.Valid = true
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func indexExpressionRuleOutcomes(outcomes []dbmodels.ExpressionApprovalRuleOutcome) map[uint64]bool {
+	result := make(map[uint64]bool)
+	for _, outcome := range outcomes {
+		result[outcome.ExpressionApprovalRuleID] = outcome.Success
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package approvalrulesprocessing
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Test processExpressionRules()
+
+type ProcessExpressionRulesTestContext struct {
+	db               *gorm.DB
+	org              dbmodels.Organization
+	app              dbmodels.Application
+	release          dbmodels.Release
+	enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+	job              dbmodels.ReleaseBackgroundJob
+	engine           Engine
+	rulesetContents  dbmodels.ApprovalRulesetContents
+}
+
+func setupProcessExpressionRulesTest() (ProcessExpressionRulesTestContext, error) {
+	var ctx ProcessExpressionRulesTestContext
+	var err error
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessExpressionRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, func(release *dbmodels.Release) {
+			release.SourceIdentity = sql.NullString{String: "v1.2.3", Valid: true}
+			release.Metadata = datatypes.JSONMap{
+				"environment":  "prod",
+				"tests_passed": true,
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		_, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessExpressionRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessExpressionRulesTestContext) addRule(expression string) error {
+	rule, err := dbmodels.CreateMockExpressionApprovalRule(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		expression, nil)
+	if err != nil {
+		return err
+	}
+	rule.BindingMode = approvalrulesetbindingmode.Enforcing
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return nil
+}
+
+func (ctx *ProcessExpressionRulesTestContext) process(t *testing.T) (releasestate.State, dbmodels.ExpressionApprovalRuleOutcome, bool) {
+	resultState, nprocessed, err := ctx.engine.processExpressionRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return "", dbmodels.ExpressionApprovalRuleOutcome{}, false
+	}
+	assert.Equal(t, uint(1), nprocessed)
+
+	var outcome dbmodels.ExpressionApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return "", dbmodels.ExpressionApprovalRuleOutcome{}, false
+	}
+	return resultState, outcome, true
+}
+
+func TestProcessExpressionRulesSuccess(t *testing.T) {
+	ctx, err := setupProcessExpressionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.addRule(`metadata.environment == "prod" && metadata.tests_passed == true && source_identity.matches("^v[0-9]+")`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, outcome, ok := ctx.process(t)
+	if !ok {
+		return
+	}
+	assert.True(t, outcome.Success)
+	assert.False(t, outcome.FailureMessage.Valid)
+	assert.Equal(t, releasestate.Approved, resultState)
+}
+
+func TestProcessExpressionRulesFalse(t *testing.T) {
+	ctx, err := setupProcessExpressionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(`metadata.environment == "staging"`)) {
+		return
+	}
+
+	resultState, outcome, ok := ctx.process(t)
+	if !ok {
+		return
+	}
+	assert.False(t, outcome.Success)
+	assert.Equal(t, "Expression evaluated to false", outcome.FailureMessage.String)
+	assert.Equal(t, releasestate.Rejected, resultState)
+}
+
+func TestProcessExpressionRulesEvaluationError(t *testing.T) {
+	ctx, err := setupProcessExpressionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(`metadata.region == "eu"`)) {
+		return
+	}
+
+	resultState, outcome, ok := ctx.process(t)
+	if !ok {
+		return
+	}
+	assert.False(t, outcome.Success)
+	assert.Contains(t, outcome.FailureMessage.String, "no such key: region")
+	assert.Equal(t, releasestate.Rejected, resultState)
+}
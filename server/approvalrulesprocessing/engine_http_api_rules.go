@@ -1,30 +1,294 @@
 package approvalrulesprocessing
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	encjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/retrypolicy"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+)
+
+const (
+	httpApiRuleRequestTimeout   = 30 * time.Second
+	httpApiRuleMaxResponseBytes = 1024 * 1024
 )
 
+// httpApiRuleRetryInterval is the base time to wait between two attempts
+// of an HTTP API rule whose RetryPolicy is RetryOnFail. The wait time
+// grows linearly with the number of attempts. It's a variable so that
+// tests can shorten it.
+var httpApiRuleRetryInterval = 2 * time.Second
+
+// httpApiRuleRequestBody is the body that is POSTed to an HTTPApiApprovalRule's URL.
+type httpApiRuleRequestBody struct {
+	ApplicationID string       `json:"application_id"`
+	Release       json.Release `json:"release"`
+}
+
+// httpApiRuleResponse describes the result of calling an HTTPApiApprovalRule's URL.
+// If no HTTP response was received at all (e.g. because of a connection error),
+// then Code is 0 and Body contains the error message.
+type httpApiRuleResponse struct {
+	Code        uint16
+	ContentType string
+	Body        []byte
+}
+
 func (engine Engine) fetchHTTPApiRulePreviousOutcomes() (map[uint64]bool, error) {
-	// TODO
-	// outcomes, err := dbmodels.FindAllHTTPApiApprovalRuleOutcomes(engine.Db, engine.Organization.ID, engine.Release.ID)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	outcomes := make([]dbmodels.HTTPApiApprovalRuleOutcome, 0)
+	outcomes, err := dbmodels.FindHTTPApiApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
 
 	return indexHTTPApiRuleOutcomes(outcomes), nil
 }
 
-func (engine Engine) processHTTPApiRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint) (releasestate.State, uint, error) {
+// httpApiRuleResult is the result of evaluating a single HTTPApiApprovalRule.
+type httpApiRuleResult struct {
+	success                bool
+	outcomeAlreadyRecorded bool
+	response               httpApiRuleResponse
+	err                    error
+}
+
+func (engine *Engine) processHTTPApiRules(ctx context.Context, rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
 	var nprocessed uint = 0
 
-	// TODO
+	var rules []dbmodels.HTTPApiApprovalRule
+	for _, r := range rulesetContents.RulesOfType(dbmodels.HTTPApiApprovalRuleType) {
+		rule := *r.(*dbmodels.HTTPApiApprovalRule)
+		if _, exists := previousOutcomes[rule.ID]; !exists && engine.dryRun {
+			// A dry run must not contact the external system, nor hold its transaction
+			// open while waiting for it.
+			engine.notEvaluatedRules = append(engine.notEvaluatedRules, r)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	results := engine.evaluateHTTPApiRules(ctx, rules, previousOutcomes)
+	if err := ctx.Err(); err != nil {
+		// Evaluation was cancelled by our caller, not because a rule failed. The rules will
+		// be evaluated again during the next run.
+		return releasestate.Rejected, nprocessed, err
+	}
+	for i, rule := range rules {
+		result := results[i]
+		if errors.Is(result.err, context.Canceled) {
+			// Evaluation was cancelled because another rule failed in enforcing mode.
+			// That rule's outcome will be recorded later in this loop.
+			continue
+		}
+		if result.err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(result.err, "Error processing HTTP API rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, result.err)
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(result.success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed HTTP API rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, result.success, ignoredError, resultState)
+		if !result.outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createHTTPApiRuleOutcome(rule, event, result.success, result.response)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording HTTP API approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
 
-	return determineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, rulesetContents.NumRules()),
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
 		nprocessed, nil
 }
 
+// evaluateHTTPApiRules calls the URLs of the given rules concurrently. As soon as a rule
+// fails in enforcing mode, or `parentCtx` is cancelled, the evaluation of the other rules
+// is cancelled: their results will have a `context.Canceled` error.
+//
+// The returned results are in the same order as `rules`.
+func (engine Engine) evaluateHTTPApiRules(parentCtx context.Context, rules []dbmodels.HTTPApiApprovalRule, previousOutcomes map[uint64]bool) []httpApiRuleResult {
+	results := make([]httpApiRuleResult, len(rules))
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, rule := range rules {
+		wg.Add(1)
+		go func(i int, rule dbmodels.HTTPApiApprovalRule) {
+			defer wg.Done()
+
+			var result httpApiRuleResult
+			result.success, result.outcomeAlreadyRecorded, result.response, result.err =
+				engine.processHTTPApiRule(ctx, rule, previousOutcomes)
+			results[i] = result
+
+			if result.err == nil && !result.success && rule.BindingMode == approvalrulesetbindingmode.Enforcing {
+				cancel()
+			}
+		}(i, rule)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (engine Engine) processHTTPApiRule(ctx context.Context, rule dbmodels.HTTPApiApprovalRule, previousOutcomes map[uint64]bool) (bool, bool, httpApiRuleResponse, error) {
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return success, true, httpApiRuleResponse{}, nil
+	}
+
+	client, err := createRuleHTTPClient(rule.TLSCaCertificate)
+	if err != nil {
+		return false, false, httpApiRuleResponse{}, err
+	}
+
+	requestBody, err := encjson.Marshal(httpApiRuleRequestBody{
+		ApplicationID: engine.ReleaseBackgroundJob.ApplicationID,
+		Release:       json.CreateFromDbRelease(engine.ReleaseBackgroundJob.Release),
+	})
+	if err != nil {
+		return false, false, httpApiRuleResponse{}, fmt.Errorf("Error encoding request body: %w", err)
+	}
+
+	var maxAttempts uint = 1
+	if rule.RetryPolicy == retrypolicy.RetryOnFail && rule.RetryLimit > 0 {
+		maxAttempts += uint(rule.RetryLimit)
+	}
+
+	var response httpApiRuleResponse
+	for attempt := uint(1); attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(time.Duration(attempt-1) * httpApiRuleRetryInterval):
+			case <-ctx.Done():
+				return false, false, httpApiRuleResponse{}, ctx.Err()
+			}
+		}
+
+		response, err = performRuleHTTPRequest(ctx, client, rule.URL, rule.Username, rule.Password, requestBody)
+		if ctx.Err() != nil {
+			return false, false, httpApiRuleResponse{}, ctx.Err()
+		}
+		if err != nil {
+			engine.Db.Logger.Warn(context.Background(),
+				"Error calling HTTP API rule: org=%s, ID=%d, attempt=%d/%d: %s",
+				engine.OrganizationID, rule.ID, attempt, maxAttempts, err.Error())
+			response = httpApiRuleResponse{Body: []byte(err.Error())}
+			continue
+		}
+		if httpResponseCodeIsSuccessful(response.Code) {
+			return true, false, response, nil
+		}
+
+		engine.Db.Logger.Warn(context.Background(),
+			"HTTP API rule returned an unsuccessful response: org=%s, ID=%d, attempt=%d/%d, code=%d",
+			engine.OrganizationID, rule.ID, attempt, maxAttempts, response.Code)
+	}
+
+	return false, false, response, nil
+}
+
+// createRuleHTTPClient creates an HTTP client for calling the URL of an HTTPApiApprovalRule
+// or a CallbackApprovalRule.
+func createRuleHTTPClient(tlsCaCertificate sql.NullString) (*http.Client, error) {
+	client := &http.Client{Timeout: httpApiRuleRequestTimeout}
+
+	if tlsCaCertificate.Valid && len(tlsCaCertificate.String) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(tlsCaCertificate.String)) {
+			return nil, errors.New("Error parsing TLS CA certificate: no valid PEM certificates found")
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+func performRuleHTTPRequest(ctx context.Context, client *http.Client, url string, username sql.NullString, password sql.NullString, requestBody []byte) (httpApiRuleResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
+	if err != nil {
+		return httpApiRuleResponse{}, fmt.Errorf("Error creating HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if username.Valid || password.Valid {
+		req.SetBasicAuth(username.String, password.String)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return httpApiRuleResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpApiRuleMaxResponseBytes))
+	if err != nil {
+		return httpApiRuleResponse{}, fmt.Errorf("Error reading HTTP response body: %w", err)
+	}
+
+	return httpApiRuleResponse{
+		Code:        uint16(resp.StatusCode),
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}, nil
+}
+
+func httpResponseCodeIsSuccessful(code uint16) bool {
+	return code >= 200 && code < 300
+}
+
+func (engine Engine) createHTTPApiRuleOutcome(rule dbmodels.HTTPApiApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, response httpApiRuleResponse) error {
+	outcome := dbmodels.HTTPApiApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		HTTPApiApprovalRuleID: rule.ApprovalRule.ID,
+		ResponseCode:          response.Code,
+		ResponseContentType:   response.ContentType,
+		ResponseBody:          response.Body,
+	}
+	if outcome.ResponseBody == nil {
+		outcome.ResponseBody = []byte{}
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
 func indexHTTPApiRuleOutcomes(outcomes []dbmodels.HTTPApiApprovalRuleOutcome) map[uint64]bool {
 	result := make(map[uint64]bool)
 	for _, outcome := range outcomes {
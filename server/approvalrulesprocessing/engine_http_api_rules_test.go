@@ -0,0 +1,505 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	encjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/retrypolicy"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test processHTTPApiRules()
+
+type ProcessHTTPApiRulesTestContext struct {
+	db                *gorm.DB
+	org               dbmodels.Organization
+	app               dbmodels.Application
+	release           dbmodels.Release
+	permissiveBinding dbmodels.ApplicationApprovalRulesetBinding
+	enforcingBinding  dbmodels.ApplicationApprovalRulesetBinding
+	job               dbmodels.ReleaseBackgroundJob
+	engine            Engine
+	rulesetContents   dbmodels.ApprovalRulesetContents
+}
+
+func setupProcessHTTPApiRulesTest() (ProcessHTTPApiRulesTestContext, error) {
+	var ctx ProcessHTTPApiRulesTestContext
+	var err error
+
+	httpApiRuleRetryInterval = 0
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessHTTPApiRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.permissiveBinding, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessHTTPApiRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessHTTPApiRulesTestContext) addRule(binding dbmodels.ApplicationApprovalRulesetBinding, url string,
+	customizeFunc func(rule *dbmodels.HTTPApiApprovalRule)) (dbmodels.HTTPApiApprovalRule, error) {
+
+	rule, err := dbmodels.CreateMockHTTPApiApprovalRule(ctx.db, ctx.org,
+		binding.ApprovalRuleset.Version.ID,
+		*binding.ApprovalRuleset.Version.Adjustment,
+		url, customizeFunc)
+	if err != nil {
+		return dbmodels.HTTPApiApprovalRule{}, err
+	}
+	rule.BindingMode = binding.Version.Adjustment.Mode
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return rule, nil
+}
+
+func TestProcessHTTPApiRulesSuccess(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var requestBody httpApiRuleRequestBody
+	var username, password string
+	var hasAuth bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, hasAuth = r.BasicAuth()
+		encjson.NewDecoder(r.Body).Decode(&requestBody) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok":true}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, server.URL, func(r *dbmodels.HTTPApiApprovalRule) {
+		r.Username.String = "user"
+		r.Username.Valid = true
+		r.Password.String = "pass"
+		r.Password.Valid = true
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var event dbmodels.ReleaseRuleProcessedEvent
+	var outcome dbmodels.HTTPApiApprovalRuleOutcome
+
+	err = ctx.db.Take(&event).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, hasAuth)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+	assert.Equal(t, ctx.app.ID, requestBody.ApplicationID)
+	assert.Equal(t, ctx.release.ID, requestBody.Release.ID)
+	assert.Equal(t, releasestate.Approved, event.ResultState)
+	assert.False(t, event.IgnoredError)
+	assert.True(t, outcome.Success)
+	assert.Equal(t, uint16(200), outcome.ResponseCode)
+	assert.Equal(t, "application/json", outcome.ResponseContentType)
+	assert.Equal(t, `{"ok":true}`, string(outcome.ResponseBody))
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessHTTPApiRulesError(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var numRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("not compliant")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, server.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var event dbmodels.ReleaseRuleProcessedEvent
+	var outcome dbmodels.HTTPApiApprovalRuleOutcome
+
+	err = ctx.db.Take(&event).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 1, numRequests)
+	assert.Equal(t, releasestate.Rejected, event.ResultState)
+	assert.False(t, outcome.Success)
+	assert.Equal(t, uint16(403), outcome.ResponseCode)
+	assert.Equal(t, "text/plain", outcome.ResponseContentType)
+	assert.Equal(t, "not compliant", string(outcome.ResponseBody))
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessHTTPApiRulesPermissiveMode(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	rule, err := ctx.addRule(ctx.permissiveBinding, server.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, approvalrulesetbindingmode.Permissive, rule.BindingMode)
+
+	resultState, nprocessed, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var event dbmodels.ReleaseRuleProcessedEvent
+	err = ctx.db.Take(&event).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, releasestate.Approved, event.ResultState)
+	assert.True(t, event.IgnoredError)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessHTTPApiRulesRetryOnFail(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var numRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		if numRequests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, server.URL, func(r *dbmodels.HTTPApiApprovalRule) {
+		r.RetryPolicy = retrypolicy.RetryOnFail
+		r.RetryLimit = 2
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, _, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.HTTPApiApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 3, numRequests)
+	assert.True(t, outcome.Success)
+	assert.Equal(t, uint16(204), outcome.ResponseCode)
+	assert.Equal(t, releasestate.Approved, resultState)
+}
+
+func TestProcessHTTPApiRulesRetryLimitExceeded(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var numRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, server.URL, func(r *dbmodels.HTTPApiApprovalRule) {
+		r.RetryPolicy = retrypolicy.RetryOnFail
+		r.RetryLimit = 2
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, _, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 3, numRequests)
+	assert.Equal(t, releasestate.Rejected, resultState)
+}
+
+func TestProcessHTTPApiRulesConnectionError(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, _, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.HTTPApiApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, outcome.Success)
+	assert.Equal(t, uint16(0), outcome.ResponseCode)
+	assert.NotEmpty(t, outcome.ResponseBody)
+	assert.Equal(t, releasestate.Rejected, resultState)
+}
+
+func TestProcessHTTPApiRulesInvalidCaCertificate(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = ctx.addRule(ctx.enforcingBinding, "https://localhost", func(r *dbmodels.HTTPApiApprovalRule) {
+		r.TLSCaCertificate.String = "not a certificate"
+		r.TLSCaCertificate.Valid = true
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, _, err = ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if assert.Error(t, err) {
+		assert.Regexp(t, "TLS CA certificate", err.Error())
+	}
+}
+
+func TestProcessHTTPApiRulesRerun(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var numRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, server.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, _, err = ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+	outcomes, err := dbmodels.FindHTTPApiApprovalRuleOutcomes(ctx.db, ctx.org.ID, ctx.release.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Equal(t, 1, len(outcomes)) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, indexHTTPApiRuleOutcomes(outcomes), 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var numProcessedEvents, numOutcomes int64
+	err = ctx.db.Model(&dbmodels.ReleaseRuleProcessedEvent{}).Count(&numProcessedEvents).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.db.Model(&dbmodels.HTTPApiApprovalRuleOutcome{}).Count(&numOutcomes).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 1, numRequests)
+	assert.Equal(t, int64(1), numProcessedEvents)
+	assert.Equal(t, int64(1), numOutcomes)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessHTTPApiRulesConcurrently(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Each request only completes once all requests have been received,
+	// so this test only finishes if the rules are evaluated concurrently.
+	var received sync.WaitGroup
+	received.Add(3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Done()
+		received.Wait()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err = ctx.addRule(ctx.enforcingBinding, server.URL, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	resultState, nprocessed, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var numOutcomes int64
+	err = ctx.db.Model(&dbmodels.HTTPApiApprovalRuleOutcome{}).Count(&numOutcomes).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, int64(3), numOutcomes)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(3), nprocessed)
+}
+
+func TestProcessHTTPApiRulesEnforcingFailureCancelsOthers(t *testing.T) {
+	ctx, err := setupProcessHTTPApiRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slowServer.Close()
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failingServer.Close()
+
+	_, err = ctx.addRule(ctx.enforcingBinding, slowServer.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	failingRule, err := ctx.addRule(ctx.enforcingBinding, failingServer.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	startTime := time.Now()
+	resultState, nprocessed, err := ctx.engine.processHTTPApiRules(context.Background(), ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+	duration := time.Since(startTime)
+
+	outcomes, err := dbmodels.FindHTTPApiApprovalRuleOutcomes(ctx.db, ctx.org.ID, ctx.release.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Equal(t, 1, len(outcomes)) {
+		return
+	}
+
+	assert.Less(t, duration, 5*time.Second)
+	assert.Equal(t, failingRule.ID, outcomes[0].HTTPApiApprovalRuleID)
+	assert.False(t, outcomes[0].Success)
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
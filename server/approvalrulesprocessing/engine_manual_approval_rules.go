@@ -1,34 +1,142 @@
 package approvalrulesprocessing
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/server/authz"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalpolicy"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
 )
 
-func (engine Engine) fetchManualApprovalRulePreviousOutcomes() (map[uint64]bool, error) {
-	// TODO
-	// outcomes, err := dbmodels.FindAllManualApprovalRuleOutcomes(engine.Db, engine.Organization.ID, engine.Release.ID)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	outcomes := make([]dbmodels.ManualApprovalRuleOutcome, 0)
+// A ManualApprovalRuleOutcome is not recorded by the engine, but by an organization member
+// who approves or rejects a Release (see `controllers.CreateReleaseManualApproval`). So a
+// ManualApprovalRule can have multiple outcomes, one per organization member. The engine
+// evaluates these outcomes against the rule's ApprovalPolicy. As long as the policy is
+// neither satisfied nor violated, the rule is considered to be pending and the Release
+// remains in progress.
+
+func (engine Engine) fetchManualApprovalRulePreviousOutcomes() (map[uint64][]dbmodels.ManualApprovalRuleOutcome, error) {
+	outcomes, err := dbmodels.FindManualApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
 
 	return indexManualApprovalRuleOutcomes(outcomes), nil
 }
 
-func (engine Engine) processManualApprovalRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint) (releasestate.State, uint, error) {
+func (engine Engine) processManualApprovalRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64][]dbmodels.ManualApprovalRuleOutcome, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
 	var nprocessed uint = 0
 
-	// TODO
+	for _, r := range rulesetContents.RulesOfType(dbmodels.ManualApprovalRuleType) {
+		rule := *r.(*dbmodels.ManualApprovalRule)
+		decided, success, decidingOutcome, err := engine.processManualApprovalRule(rule, previousOutcomes[rule.ID])
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(err, "Error processing manual approval rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, err)
+		}
+		if !decided {
+			engine.Db.Logger.Info(context.Background(),
+				"Manual approval rule still awaiting approval: org=%s, ID=%d", engine.OrganizationID, rule.ID)
+			continue
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed manual approval rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		err = engine.updateManualApprovalRuleProcessedEvent(decidingOutcome, resultState, ignoredError)
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				fmt.Errorf("Error recording release event: %w", err)
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
 
-	return determineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, rulesetContents.NumRules()),
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
 		nprocessed, nil
 }
 
-func indexManualApprovalRuleOutcomes(outcomes []dbmodels.ManualApprovalRuleOutcome) map[uint64]bool {
-	result := make(map[uint64]bool)
+// processManualApprovalRule evaluates the outcomes of a ManualApprovalRule against its ApprovalPolicy.
+// `decided` is false when the rule still awaits more approvals. Otherwise, `success` specifies whether
+// the rule succeeded, and `decidingOutcome` is the outcome that caused the decision.
+func (engine Engine) processManualApprovalRule(rule dbmodels.ManualApprovalRule, outcomes []dbmodels.ManualApprovalRuleOutcome) (decided bool, success bool, decidingOutcome dbmodels.ManualApprovalRuleOutcome, err error) {
+	var requiredApprovals uint
+
+	switch rule.ApprovalPolicy {
+	case approvalpolicy.Any:
+		requiredApprovals = 1
+	case approvalpolicy.All:
+		numApprovers, err := dbmodels.CountOrganizationMembersWithRoles(engine.Db, engine.OrganizationID, authz.ReleaseManualApproverRoles)
+		if err != nil {
+			return false, false, dbmodels.ManualApprovalRuleOutcome{},
+				fmt.Errorf("Error counting organization members who are allowed to approve releases: %w", err)
+		}
+		requiredApprovals = uint(numApprovers)
+		if requiredApprovals == 0 {
+			requiredApprovals = 1
+		}
+	case approvalpolicy.Minimum:
+		if !rule.Minimum.Valid || rule.Minimum.Int32 < 1 {
+			return false, false, dbmodels.ManualApprovalRuleOutcome{},
+				fmt.Errorf("Approval policy '%s' requires a minimum of at least 1", rule.ApprovalPolicy)
+		}
+		requiredApprovals = uint(rule.Minimum.Int32)
+	default:
+		return false, false, dbmodels.ManualApprovalRuleOutcome{},
+			fmt.Errorf("Unsupported approval policy '%s'", rule.ApprovalPolicy)
+	}
+
+	decided, success, decidingIndex := evaluateManualApprovalRuleOutcomes(outcomes, requiredApprovals)
+	if !decided {
+		return false, false, dbmodels.ManualApprovalRuleOutcome{}, nil
+	}
+	return true, success, outcomes[decidingIndex], nil
+}
+
+// evaluateManualApprovalRuleOutcomes walks through the outcomes, ordered from oldest to
+// newest. A single rejection fails the rule. Otherwise, the rule succeeds as soon as
+// `requiredApprovals` approvals have been recorded.
+func evaluateManualApprovalRuleOutcomes(outcomes []dbmodels.ManualApprovalRuleOutcome, requiredApprovals uint) (decided bool, success bool, decidingIndex int) {
+	var approvals uint
+
+	for i, outcome := range outcomes {
+		if !outcome.Success {
+			return true, false, i
+		}
+
+		approvals++
+		if approvals >= requiredApprovals {
+			return true, true, i
+		}
+	}
+
+	return false, false, -1
+}
+
+// updateManualApprovalRuleProcessedEvent updates the ReleaseRuleProcessedEvent that belongs
+// to the outcome that decided a ManualApprovalRule. When that outcome was recorded, the
+// result state was not yet known.
+func (engine Engine) updateManualApprovalRuleProcessedEvent(outcome dbmodels.ManualApprovalRuleOutcome, resultState releasestate.State, ignoredError bool) error {
+	return engine.Db.
+		Model(&dbmodels.ReleaseRuleProcessedEvent{}).
+		Where("organization_id = ? AND id = ?", engine.OrganizationID, outcome.ReleaseRuleProcessedEventID).
+		Updates(map[string]interface{}{
+			"result_state":  resultState,
+			"ignored_error": ignoredError,
+		}).
+		Error
+}
+
+func indexManualApprovalRuleOutcomes(outcomes []dbmodels.ManualApprovalRuleOutcome) map[uint64][]dbmodels.ManualApprovalRuleOutcome {
+	result := make(map[uint64][]dbmodels.ManualApprovalRuleOutcome)
 	for _, outcome := range outcomes {
-		result[outcome.ManualApprovalRuleID] = outcome.Success
+		result[outcome.ManualApprovalRuleID] = append(result[outcome.ManualApprovalRuleID], outcome)
 	}
 	return result
 }
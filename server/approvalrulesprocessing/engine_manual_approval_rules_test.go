@@ -0,0 +1,51 @@
+package approvalrulesprocessing
+
+import (
+	"testing"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test evaluateManualApprovalRuleOutcomes()
+
+func makeManualApprovalRuleOutcomes(successes ...bool) []dbmodels.ManualApprovalRuleOutcome {
+	result := make([]dbmodels.ManualApprovalRuleOutcome, 0, len(successes))
+	for _, success := range successes {
+		result = append(result, dbmodels.ManualApprovalRuleOutcome{
+			ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{Success: success},
+		})
+	}
+	return result
+}
+
+func TestEvaluateManualApprovalRuleOutcomesNoOutcomes(t *testing.T) {
+	decided, _, _ := evaluateManualApprovalRuleOutcomes(makeManualApprovalRuleOutcomes(), 1)
+	assert.False(t, decided)
+}
+
+func TestEvaluateManualApprovalRuleOutcomesEnoughApprovals(t *testing.T) {
+	decided, success, decidingIndex := evaluateManualApprovalRuleOutcomes(makeManualApprovalRuleOutcomes(true, true, true), 2)
+	assert.True(t, decided)
+	assert.True(t, success)
+	assert.Equal(t, 1, decidingIndex)
+}
+
+func TestEvaluateManualApprovalRuleOutcomesNotEnoughApprovals(t *testing.T) {
+	decided, _, _ := evaluateManualApprovalRuleOutcomes(makeManualApprovalRuleOutcomes(true, true), 3)
+	assert.False(t, decided)
+}
+
+func TestEvaluateManualApprovalRuleOutcomesRejection(t *testing.T) {
+	decided, success, decidingIndex := evaluateManualApprovalRuleOutcomes(makeManualApprovalRuleOutcomes(true, false), 3)
+	assert.True(t, decided)
+	assert.False(t, success)
+	assert.Equal(t, 1, decidingIndex)
+}
+
+func TestEvaluateManualApprovalRuleOutcomesFirstOutcomeDecides(t *testing.T) {
+	decided, success, decidingIndex := evaluateManualApprovalRuleOutcomes(makeManualApprovalRuleOutcomes(true, false), 1)
+	assert.True(t, decided)
+	assert.True(t, success)
+	assert.Equal(t, 0, decidingIndex)
+}
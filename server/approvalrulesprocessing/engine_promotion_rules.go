@@ -0,0 +1,149 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+)
+
+func (engine Engine) fetchPromotionRulePreviousOutcomes() (map[uint64]bool, error) {
+	outcomes, err := dbmodels.FindPromotionApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexPromotionRuleOutcomes(outcomes), nil
+}
+
+func (engine *Engine) processPromotionRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+	var nprocessed uint = 0
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.PromotionApprovalRuleType) {
+		rule := *r.(*dbmodels.PromotionApprovalRule)
+		success, outcomeAlreadyRecorded, sourceRelease, nextEligibleAt, err := engine.processPromotionRule(rule, previousOutcomes)
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(err, "Error processing promotion rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, err)
+		}
+		if !nextEligibleAt.IsZero() {
+			engine.Db.Logger.Info(context.Background(),
+				"Promotion rule deferred until minimum soak time has passed: org=%s, ID=%d, nextEligibleAt=%s",
+				engine.OrganizationID, rule.ID, nextEligibleAt)
+			engine.deferUntil(nextEligibleAt)
+			continue
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		engine.Db.Logger.Info(context.Background(),
+			"Processed promotion rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		if !outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createPromotionRuleOutcome(rule, event, success, sourceRelease)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording promotion approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
+
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+		nprocessed, nil
+}
+
+// processPromotionRule looks for a Release of the rule's source Application with the same
+// SourceIdentity as the Release being processed. Only approved Releases that weren't followed
+// by a rejected Release (with that same SourceIdentity) are considered. Of those, the earliest
+// one is picked, and it must have been approved at least `MinSoakHours` ago.
+//
+// If such a Release exists but hasn't soaked long enough yet, then the rule stays pending:
+// no outcome is returned, but `nextEligibleAt` is set to the time at which the soak time has
+// passed. Rules bound in permissive mode are never deferred, because their failures are ignored anyway.
+func (engine Engine) processPromotionRule(rule dbmodels.PromotionApprovalRule, previousOutcomes map[uint64]bool) (success bool, outcomeAlreadyRecorded bool, sourceRelease *dbmodels.Release, nextEligibleAt time.Time, err error) {
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return success, true, nil, time.Time{}, nil
+	}
+
+	release := engine.ReleaseBackgroundJob.Release
+	if !release.SourceIdentity.Valid {
+		return false, false, nil, time.Time{}, nil
+	}
+
+	rejectedReleases, err := dbmodels.FindReleases(
+		engine.Db.Where("state = ? AND source_identity = ?", releasestate.Rejected, release.SourceIdentity.String).
+			Order("created_at DESC").Limit(1),
+		engine.OrganizationID, rule.SourceApplicationID)
+	if err != nil {
+		return false, false, nil, time.Time{}, fmt.Errorf("Error loading releases of application '%s': %w",
+			rule.SourceApplicationID, err)
+	}
+
+	tx := engine.Db.Where("state = ? AND source_identity = ?", releasestate.Approved, release.SourceIdentity.String)
+	if len(rejectedReleases) > 0 {
+		tx = tx.Where("created_at > ?", rejectedReleases[0].CreatedAt)
+	}
+	approvedReleases, err := dbmodels.FindReleases(tx.Order("finalized_at").Limit(1),
+		engine.OrganizationID, rule.SourceApplicationID)
+	if err != nil {
+		return false, false, nil, time.Time{}, fmt.Errorf("Error loading releases of application '%s': %w",
+			rule.SourceApplicationID, err)
+	}
+	if len(approvedReleases) == 0 {
+		return false, false, nil, time.Time{}, nil
+	}
+
+	sourceRelease = &approvedReleases[0]
+	soakedAt := sourceRelease.FinalizedAt.Time.Add(time.Duration(rule.MinSoakHours) * time.Hour)
+	if engine.now().Before(soakedAt) {
+		if rule.BindingMode == approvalrulesetbindingmode.Permissive {
+			return false, false, nil, time.Time{}, nil
+		}
+		return false, false, nil, soakedAt, nil
+	}
+	return true, false, sourceRelease, time.Time{}, nil
+}
+
+func (engine Engine) createPromotionRuleOutcome(rule dbmodels.PromotionApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, sourceRelease *dbmodels.Release) error {
+	outcome := dbmodels.PromotionApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		PromotionApprovalRuleID: rule.ApprovalRule.ID,
+	}
+	if sourceRelease != nil {
+		outcome.SourceReleaseID.Int64 = int64(sourceRelease.ID)
+		outcome.SourceReleaseID.Valid = true
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func indexPromotionRuleOutcomes(outcomes []dbmodels.PromotionApprovalRuleOutcome) map[uint64]bool {
+	result := make(map[uint64]bool)
+	for _, outcome := range outcomes {
+		result[outcome.PromotionApprovalRuleID] = outcome.Success
+	}
+	return result
+}
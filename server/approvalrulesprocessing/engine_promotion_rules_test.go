@@ -0,0 +1,239 @@
+package approvalrulesprocessing
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test processPromotionRules()
+
+type ProcessPromotionRulesTestContext struct {
+	db               *gorm.DB
+	org              dbmodels.Organization
+	app              dbmodels.Application
+	sourceApp        dbmodels.Application
+	release          dbmodels.Release
+	enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+	job              dbmodels.ReleaseBackgroundJob
+	engine           Engine
+	rulesetContents  dbmodels.ApprovalRulesetContents
+}
+
+func setupProcessPromotionRulesTest() (ProcessPromotionRulesTestContext, error) {
+	var ctx ProcessPromotionRulesTestContext
+	var err error
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessPromotionRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.sourceApp, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, func(app *dbmodels.Application) {
+			app.ID = "staging"
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, func(release *dbmodels.Release) {
+			release.SourceIdentity = sql.NullString{String: "v1.2", Valid: true}
+		})
+		if err != nil {
+			return err
+		}
+
+		_, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessPromotionRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+		Clock:                &mocking.FakeClock{Value: time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)},
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessPromotionRulesTestContext) addRule(customizeFunc func(rule *dbmodels.PromotionApprovalRule)) error {
+	rule, err := dbmodels.CreateMockPromotionApprovalRule(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		ctx.sourceApp, customizeFunc)
+	if err != nil {
+		return err
+	}
+	rule.BindingMode = approvalrulesetbindingmode.Enforcing
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return nil
+}
+
+func (ctx *ProcessPromotionRulesTestContext) addSourceRelease(state releasestate.State, createdAt time.Time, sourceIdentity string) (dbmodels.Release, error) {
+	return dbmodels.CreateMockReleaseWithInProgressState(ctx.db, ctx.org, ctx.sourceApp, func(release *dbmodels.Release) {
+		release.State = state
+		release.CreatedAt = createdAt
+		if state.IsFinal() {
+			release.FinalizedAt = sql.NullTime{Time: createdAt, Valid: true}
+		}
+		release.SourceIdentity = sql.NullString{String: sourceIdentity, Valid: true}
+	})
+}
+
+func TestProcessPromotionRulesSuccess(t *testing.T) {
+	ctx, err := setupProcessPromotionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	sourceRelease, err := ctx.addSourceRelease(releasestate.Approved, time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addSourceRelease(releasestate.Approved, time.Date(2021, time.March, 10, 11, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.addRule(func(rule *dbmodels.PromotionApprovalRule) {
+		rule.MinSoakHours = 12
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processPromotionRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.PromotionApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, outcome.Success)
+	assert.Equal(t, int64(sourceRelease.ID), outcome.SourceReleaseID.Int64)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessPromotionRulesNoApprovedRelease(t *testing.T) {
+	ctx, err := setupProcessPromotionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addSourceRelease(releasestate.Approved, time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC), "v1.1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(nil)) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processPromotionRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.PromotionApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, outcome.Success)
+	assert.False(t, outcome.SourceReleaseID.Valid)
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessPromotionRulesSupersededByRejectedRelease(t *testing.T) {
+	ctx, err := setupProcessPromotionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addSourceRelease(releasestate.Approved, time.Date(2021, time.March, 9, 12, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addSourceRelease(releasestate.Rejected, time.Date(2021, time.March, 9, 13, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule(nil)) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processPromotionRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessPromotionRulesSoakTimeNotPassed(t *testing.T) {
+	ctx, err := setupProcessPromotionRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ctx.addSourceRelease(releasestate.Approved, time.Date(2021, time.March, 10, 6, 0, 0, 0, time.UTC), "v1.2")
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.addRule(func(rule *dbmodels.PromotionApprovalRule) {
+		rule.MinSoakHours = 24
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processPromotionRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var count int64
+	err = ctx.db.Model(&dbmodels.PromotionApprovalRuleOutcome{}).Count(&count).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	nextEligibleAt, deferred := ctx.engine.NextEligibleTime()
+	assert.True(t, deferred)
+	assert.Equal(t, time.Date(2021, time.March, 11, 6, 0, 0, 0, time.UTC), nextEligibleAt.UTC())
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+}
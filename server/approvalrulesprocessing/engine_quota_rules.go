@@ -0,0 +1,142 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+)
+
+func (engine Engine) fetchQuotaRulePreviousOutcomes() (map[uint64]bool, error) {
+	outcomes, err := dbmodels.FindQuotaApprovalRuleOutcomes(engine.Db, engine.OrganizationID, engine.ReleaseBackgroundJob.ReleaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexQuotaRuleOutcomes(outcomes), nil
+}
+
+// processQuotaRules is to be called after all other rules have been processed. A quota only
+// matters at the moment the Release is approved, so as long as other rules are still pending,
+// the quota rules stay pending too.
+//
+// The caller must hold the Application's advisory lock (see `Engine.lockApplication()`) until
+// the Release is finalized, so that concurrent jobs can't approve more Releases than the quota allows.
+func (engine Engine) processQuotaRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
+	var nprocessed uint = 0
+
+	if nAlreadyProcessed+uint(len(rulesetContents.RulesOfType(dbmodels.QuotaApprovalRuleType))) < totalRules {
+		return releasestate.InProgress, nprocessed, nil
+	}
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.QuotaApprovalRuleType) {
+		rule := *r.(*dbmodels.QuotaApprovalRule)
+		success, outcomeAlreadyRecorded, approvedReleases, quotaFreesUpAt, err := engine.processQuotaRule(rule, previousOutcomes)
+		if err != nil {
+			return releasestate.Rejected, nprocessed,
+				maybeFormatRuleProcessingError(err, "Error processing quota rule org=%s, ID=%d: %w",
+					engine.OrganizationID, rule.ID, err)
+		}
+
+		nprocessed++
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		if success {
+			engine.Db.Logger.Info(context.Background(),
+				"Processed quota rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
+				engine.OrganizationID, rule.ID, success, ignoredError, resultState)
+		} else {
+			engine.Db.Logger.Info(context.Background(),
+				"Processed quota rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s: "+
+					"%d releases were approved in the past %d hours; quota frees up at %s",
+				engine.OrganizationID, rule.ID, success, ignoredError, resultState,
+				len(approvedReleases), rule.WindowHours, quotaFreesUpAt)
+		}
+		if !outcomeAlreadyRecorded {
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording release event: %w", err)
+			}
+			err = engine.createQuotaRuleOutcome(rule, event, success, approvedReleases, quotaFreesUpAt)
+			if err != nil {
+				return releasestate.Rejected, nprocessed,
+					fmt.Errorf("Error recording quota approval rule outcome: %w", err)
+			}
+		}
+		if resultState.IsFinal() {
+			return resultState, nprocessed, nil
+		}
+	}
+
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+		nprocessed, nil
+}
+
+// processQuotaRule counts the Application's Releases that were approved within the rule's window.
+// If that count has reached the rule's maximum, then the rule fails, and the time at which
+// enough of those Releases fall outside the window is returned.
+func (engine Engine) processQuotaRule(rule dbmodels.QuotaApprovalRule, previousOutcomes map[uint64]bool) (success bool, outcomeAlreadyRecorded bool, approvedReleases []dbmodels.Release, quotaFreesUpAt time.Time, err error) {
+	success, exists := previousOutcomes[rule.ID]
+	if exists {
+		return success, true, nil, time.Time{}, nil
+	}
+
+	window := time.Duration(rule.WindowHours) * time.Hour
+	approvedReleases, err = dbmodels.FindApprovedReleasesFinalizedSince(engine.Db, engine.OrganizationID,
+		engine.ReleaseBackgroundJob.ApplicationID, engine.now().Add(-window))
+	if err != nil {
+		return false, false, nil, time.Time{}, fmt.Errorf("Error loading approved releases: %w", err)
+	}
+
+	success, quotaFreesUpAt = computeQuotaOutcome(rule, approvedReleases)
+	return success, false, approvedReleases, quotaFreesUpAt, nil
+}
+
+// computeQuotaOutcome determines whether another Release may be approved, given the
+// Releases approved within the rule's window (ordered by finalization time). If not,
+// then it also returns the time at which the quota frees up.
+func computeQuotaOutcome(rule dbmodels.QuotaApprovalRule, approvedReleases []dbmodels.Release) (bool, time.Time) {
+	napproved := len(approvedReleases)
+	if napproved < int(rule.MaxReleases) {
+		return true, time.Time{}
+	}
+
+	// The quota frees up once the number of Releases within the window drops below the
+	// maximum, i.e. once the oldest `napproved - MaxReleases + 1` Releases have left the window.
+	window := time.Duration(rule.WindowHours) * time.Hour
+	return false, approvedReleases[napproved-int(rule.MaxReleases)].FinalizedAt.Time.Add(window)
+}
+
+func (engine Engine) createQuotaRuleOutcome(rule dbmodels.QuotaApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool, approvedReleases []dbmodels.Release, quotaFreesUpAt time.Time) error {
+	outcome := dbmodels.QuotaApprovalRuleOutcome{
+		ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+			BaseModel: dbmodels.BaseModel{
+				OrganizationID: engine.OrganizationID,
+			},
+			ReleaseRuleProcessedEventID: event.ReleaseEvent.ID,
+			Success:                     success,
+		},
+		QuotaApprovalRuleID: rule.ApprovalRule.ID,
+		ApprovedCount:       int32(len(approvedReleases)),
+	}
+	if !quotaFreesUpAt.IsZero() {
+		outcome.QuotaFreesUpAt.Time = quotaFreesUpAt
+		outcome.QuotaFreesUpAt.Valid = true
+	}
+	tx := engine.Db.Create(&outcome)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func indexQuotaRuleOutcomes(outcomes []dbmodels.QuotaApprovalRuleOutcome) map[uint64]bool {
+	result := make(map[uint64]bool)
+	for _, outcome := range outcomes {
+		result[outcome.QuotaApprovalRuleID] = outcome.Success
+	}
+	return result
+}
@@ -0,0 +1,225 @@
+package approvalrulesprocessing
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test processQuotaRules()
+
+type ProcessQuotaRulesTestContext struct {
+	db               *gorm.DB
+	org              dbmodels.Organization
+	app              dbmodels.Application
+	release          dbmodels.Release
+	enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+	job              dbmodels.ReleaseBackgroundJob
+	engine           Engine
+	rulesetContents  dbmodels.ApprovalRulesetContents
+}
+
+var quotaTestNow = time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func setupProcessQuotaRulesTest() (ProcessQuotaRulesTestContext, error) {
+	var ctx ProcessQuotaRulesTestContext
+	var err error
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return ProcessQuotaRulesTestContext{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, nil)
+		if err != nil {
+			return err
+		}
+
+		_, ctx.enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.org, ctx.app)
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return ProcessQuotaRulesTestContext{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+		Clock:                &mocking.FakeClock{Value: quotaTestNow},
+	}
+	return ctx, nil
+}
+
+func (ctx *ProcessQuotaRulesTestContext) addRule() error {
+	rule, err := dbmodels.CreateMockQuotaApprovalRule(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		func(rule *dbmodels.QuotaApprovalRule) {
+			rule.MaxReleases = 2
+			rule.WindowHours = 24
+		})
+	if err != nil {
+		return err
+	}
+	rule.BindingMode = approvalrulesetbindingmode.Enforcing
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
+	return nil
+}
+
+func (ctx *ProcessQuotaRulesTestContext) addApprovedRelease(finalizedAt time.Time) error {
+	_, err := dbmodels.CreateMockReleaseWithInProgressState(ctx.db, ctx.org, ctx.app, func(release *dbmodels.Release) {
+		release.State = releasestate.Approved
+		release.FinalizedAt = sql.NullTime{Time: finalizedAt, Valid: true}
+	})
+	return err
+}
+
+func TestProcessQuotaRulesSuccess(t *testing.T) {
+	ctx, err := setupProcessQuotaRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// Outside the window
+	if !assert.NoError(t, ctx.addApprovedRelease(quotaTestNow.Add(-30*time.Hour))) {
+		return
+	}
+	if !assert.NoError(t, ctx.addApprovedRelease(quotaTestNow.Add(-2*time.Hour))) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule()) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processQuotaRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.QuotaApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, outcome.Success)
+	assert.Equal(t, int32(1), outcome.ApprovedCount)
+	assert.False(t, outcome.QuotaFreesUpAt.Valid)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessQuotaRulesExceeded(t *testing.T) {
+	ctx, err := setupProcessQuotaRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addApprovedRelease(quotaTestNow.Add(-5*time.Hour))) {
+		return
+	}
+	if !assert.NoError(t, ctx.addApprovedRelease(quotaTestNow.Add(-2*time.Hour))) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule()) {
+		return
+	}
+
+	resultState, nprocessed, err := ctx.engine.processQuotaRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var outcome dbmodels.QuotaApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, outcome.Success)
+	assert.Equal(t, int32(2), outcome.ApprovedCount)
+	assert.True(t, outcome.QuotaFreesUpAt.Time.Equal(quotaTestNow.Add(19*time.Hour)))
+	assert.Equal(t, releasestate.Rejected, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+}
+
+func TestProcessQuotaRulesWaitsForOtherRules(t *testing.T) {
+	ctx, err := setupProcessQuotaRulesTest()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ctx.addRule()) {
+		return
+	}
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &dbmodels.ManualApprovalRule{})
+
+	resultState, nprocessed, err := ctx.engine.processQuotaRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var count int64
+	err = ctx.db.Model(&dbmodels.QuotaApprovalRuleOutcome{}).Count(&count).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+}
+
+// Test computeQuotaOutcome()
+
+func TestComputeQuotaOutcome(t *testing.T) {
+	rule := dbmodels.QuotaApprovalRule{MaxReleases: 2, WindowHours: 24}
+	makeReleases := func(finalizedAts ...time.Time) []dbmodels.Release {
+		var result []dbmodels.Release
+		for _, finalizedAt := range finalizedAts {
+			result = append(result, dbmodels.Release{FinalizedAt: sql.NullTime{Time: finalizedAt, Valid: true}})
+		}
+		return result
+	}
+	t1 := time.Date(2021, time.March, 10, 8, 0, 0, 0, time.UTC)
+	t2 := time.Date(2021, time.March, 10, 9, 0, 0, 0, time.UTC)
+	t3 := time.Date(2021, time.March, 10, 10, 0, 0, 0, time.UTC)
+
+	success, _ := computeQuotaOutcome(rule, makeReleases(t1))
+	assert.True(t, success)
+
+	success, quotaFreesUpAt := computeQuotaOutcome(rule, makeReleases(t1, t2))
+	assert.False(t, success)
+	assert.Equal(t, t1.Add(24*time.Hour), quotaFreesUpAt)
+
+	// More Releases than the maximum may have been approved, e.g. by Releases
+	// that aren't bound to this rule.
+	success, quotaFreesUpAt = computeQuotaOutcome(rule, makeReleases(t1, t2, t3))
+	assert.False(t, success)
+	assert.Equal(t, t2.Add(24*time.Hour), quotaFreesUpAt)
+}
@@ -2,14 +2,13 @@ package approvalrulesprocessing
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/schedule"
 )
 
 func (engine Engine) fetchScheduleRulePreviousOutcomes() (map[uint64]bool, error) {
@@ -21,25 +20,41 @@ func (engine Engine) fetchScheduleRulePreviousOutcomes() (map[uint64]bool, error
 	return indexScheduleRuleOutcomes(outcomes), nil
 }
 
-func (engine Engine) processScheduleRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint) (releasestate.State, uint, error) {
+func (engine *Engine) processScheduleRules(rulesetContents dbmodels.ApprovalRulesetContents, previousOutcomes map[uint64]bool, nAlreadyProcessed uint, totalRules uint) (releasestate.State, uint, error) {
 	var nprocessed uint = 0
-	var totalRules uint = rulesetContents.NumRules()
+	var organization dbmodels.Organization
+	var err error
 
-	for _, rule := range rulesetContents.ScheduleApprovalRules {
-		success, outcomeAlreadyRecorded, err := engine.processScheduleRule(rule, previousOutcomes)
+	if len(rulesetContents.RulesOfType(dbmodels.ScheduleApprovalRuleType)) > 0 {
+		organization, err = dbmodels.FindOrganizationByID(engine.Db, engine.OrganizationID)
+		if err != nil {
+			return releasestate.Rejected, nprocessed, fmt.Errorf("Error loading organization: %w", err)
+		}
+	}
+
+	for _, r := range rulesetContents.RulesOfType(dbmodels.ScheduleApprovalRuleType) {
+		rule := *r.(*dbmodels.ScheduleApprovalRule)
+		success, outcomeAlreadyRecorded, nextEligibleAt, err := engine.processScheduleRule(rule, organization, previousOutcomes)
 		if err != nil {
 			return releasestate.Rejected, nprocessed,
 				maybeFormatRuleProcessingError(err, "Error processing schedule rule org=%s, ID=%d: %w",
 					engine.OrganizationID, rule.ID, err)
 		}
+		if !nextEligibleAt.IsZero() {
+			engine.Db.Logger.Info(context.Background(),
+				"Schedule rule deferred until next time window: org=%s, ID=%d, nextEligibleAt=%s",
+				engine.OrganizationID, rule.ID, nextEligibleAt)
+			engine.deferUntil(nextEligibleAt)
+			continue
+		}
 
 		nprocessed++
-		resultState, ignoredError := determineReleaseStateFromOutcome(success, rule.BindingMode, isLastRule(nAlreadyProcessed, nprocessed, totalRules))
+		resultState, ignoredError := DetermineReleaseStateFromOutcome(success, rule.BindingMode, IsLastRule(nAlreadyProcessed, nprocessed, totalRules))
 		engine.Db.Logger.Info(context.Background(),
 			"Processed schedule rule: org=%s, ID=%d, success=%t, ignoredError=%t, resultState=%s",
 			engine.OrganizationID, rule.ID, success, ignoredError, resultState)
 		if !outcomeAlreadyRecorded {
-			event, err := engine.createRuleProcessedEvent(resultState, ignoredError)
+			event, err := engine.CreateRuleProcessedEvent(resultState, ignoredError)
 			if err != nil {
 				return releasestate.Rejected, nprocessed,
 					fmt.Errorf("Error recording release event: %w", err)
@@ -55,26 +70,54 @@ func (engine Engine) processScheduleRules(rulesetContents dbmodels.ApprovalRules
 		}
 	}
 
-	return determineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
+	return DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed, nprocessed, totalRules),
 		nprocessed, nil
 }
 
-func determineReleaseStateAfterProcessingRules(nAlreadyProcessed uint, nprocessed uint, totalRules uint) releasestate.State {
-	if isLastRule(nAlreadyProcessed, nprocessed, totalRules) {
+// DetermineReleaseStateAfterProcessingRules returns the Release state after a processor has
+// processed all its rules without any of them resulting in a final state.
+func DetermineReleaseStateAfterProcessingRules(nAlreadyProcessed uint, nprocessed uint, totalRules uint) releasestate.State {
+	if IsLastRule(nAlreadyProcessed, nprocessed, totalRules) {
 		return releasestate.Approved
 	}
 	return releasestate.InProgress
 }
 
-func (engine Engine) processScheduleRule(rule dbmodels.ScheduleApprovalRule, previousOutcomes map[uint64]bool) (bool, bool, error) {
+// processScheduleRule checks whether the Release's creation time falls within the rule's schedule.
+//
+// If not, and the rule is configured to defer until the next time window, then the current time is
+// checked instead. If that doesn't fall within the schedule either, then the rule stays pending:
+// no outcome is returned, but `nextEligibleAt` is set to the time at which the next window begins.
+// Rules bound in permissive mode are never deferred, because their failures are ignored anyway.
+func (engine Engine) processScheduleRule(rule dbmodels.ScheduleApprovalRule, organization dbmodels.Organization, previousOutcomes map[uint64]bool) (success bool, outcomeAlreadyRecorded bool, nextEligibleAt time.Time, err error) {
 	success, exists := previousOutcomes[rule.ID]
 	if exists {
-		return success, true, nil
+		return success, true, time.Time{}, nil
+	}
+
+	location, err := scheduleRuleLocation(rule, organization)
+	if err != nil {
+		return false, false, time.Time{}, err
 	}
 
 	// TODO: if there's an error, reject the release because the rules have errors
-	success, err := timeIsWithinSchedule(engine.ReleaseBackgroundJob.Release.CreatedAt, rule)
-	return success, false, err
+	success, err = timeIsWithinSchedule(engine.ReleaseBackgroundJob.Release.CreatedAt.In(location), rule)
+	if err != nil || success || !rule.DeferUntilWindow || rule.BindingMode == approvalrulesetbindingmode.Permissive {
+		return success, false, time.Time{}, err
+	}
+
+	now := engine.now().In(location)
+	success, err = timeIsWithinSchedule(now, rule)
+	if err != nil || success {
+		return success, false, time.Time{}, err
+	}
+
+	nextEligibleAt, found, err := nextScheduleWindowBegin(now, rule)
+	if err != nil || !found {
+		// A schedule that never opens again can't be waited for.
+		return false, false, time.Time{}, err
+	}
+	return false, false, nextEligibleAt, nil
 }
 
 func (engine Engine) createScheduleRuleOutcome(rule dbmodels.ScheduleApprovalRule, event dbmodels.ReleaseRuleProcessedEvent, success bool) error {
@@ -104,193 +147,192 @@ func indexScheduleRuleOutcomes(outcomes []dbmodels.ScheduleApprovalRuleOutcome)
 	return result
 }
 
-func timeIsWithinSchedule(releaseTime time.Time, rule dbmodels.ScheduleApprovalRule) (bool, error) {
-	if rule.BeginTime.Valid {
-		if !rule.EndTime.Valid {
-			panic(fmt.Sprintf("ScheduleApprovalRule %d: BeginTime non-null, but EndTime null", rule.ApprovalRule.ID))
-		}
+// scheduleRuleLocation returns the time zone in which a ScheduleApprovalRule's fields are to be
+// interpreted. That's the rule's own time zone, or else the organization's default time zone,
+// or else the server's local time zone.
+func scheduleRuleLocation(rule dbmodels.ScheduleApprovalRule, organization dbmodels.Organization) (*time.Location, error) {
+	var name string
+	if rule.TimeZone.Valid && len(rule.TimeZone.String) > 0 {
+		name = rule.TimeZone.String
+	} else if organization.DefaultTimeZone.Valid && len(organization.DefaultTimeZone.String) > 0 {
+		name = organization.DefaultTimeZone.String
+	} else {
+		return time.Local, nil
+	}
 
-		parsedBeginTime, err := parseScheduleTime(releaseTime, rule.BeginTime.String)
-		if err != nil {
-			return false, fmt.Errorf("Error parsing begin time '%s': %w", rule.BeginTime.String, err)
-		}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading time zone '%s': %w", name, err)
+	}
+	return location, nil
+}
 
-		parsedEndTime, err := parseScheduleTime(releaseTime, rule.EndTime.String)
-		if err != nil {
-			return false, fmt.Errorf("Error parsing end time '%s': %w", rule.EndTime.String, err)
-		}
+// timeIsWithinSchedule checks whether `releaseTime` falls within the rule's schedule. `releaseTime`
+// must already be in the time zone returned by `scheduleRuleLocation()`.
+//
+// The rule may specify multiple time ranges. A range whose end time lies before its begin time
+// crosses midnight, e.g. 22:00-02:00. The days of week, days of month and months of year are
+// matched against the day on which a range begins: with DaysOfWeek "fri", the range 22:00-02:00
+// also matches Saturday 01:00.
+func timeIsWithinSchedule(releaseTime time.Time, rule dbmodels.ScheduleApprovalRule) (bool, error) {
+	timeRanges, err := scheduleRuleTimeRanges(rule)
+	if err != nil {
+		return false, err
+	}
 
-		if releaseTime.Before(parsedBeginTime) || releaseTime.After(parsedEndTime) {
-			return false, nil
-		}
+	if len(timeRanges) == 0 {
+		return dateIsWithinSchedule(releaseTime, rule)
 	}
 
-	if rule.DaysOfWeek.Valid {
-		parsedWeekDays, err := parseScheduleWeekDays(rule.DaysOfWeek.String)
-		if err != nil {
-			return false, fmt.Errorf("Error parsing days of week '%s': %w", rule.DaysOfWeek.String, err)
-		}
+	for _, timeRange := range timeRanges {
+		// The range either began on the release's day, or (if it crosses midnight) on the day before.
+		for _, beginDate := range []time.Time{releaseTime, releaseTime.AddDate(0, 0, -1)} {
+			parsedBeginTime, parsedEndTime, err := resolveScheduleTimeRange(beginDate, timeRange)
+			if err != nil {
+				return false, err
+			}
+			if releaseTime.Before(parsedBeginTime) || releaseTime.After(parsedEndTime) {
+				continue
+			}
 
-		if !parsedWeekDays[releaseTime.Weekday()] {
-			return false, nil
+			matches, err := dateIsWithinSchedule(beginDate, rule)
+			if err != nil {
+				return false, err
+			}
+			if matches {
+				return true, nil
+			}
 		}
 	}
 
-	if rule.DaysOfMonth.Valid {
-		parsedMonthDays, err := parseScheduleMonthDays(rule.DaysOfMonth.String)
-		if err != nil {
-			return false, fmt.Errorf("Error parsing days of month '%s': %w", rule.DaysOfMonth.String, err)
-		}
+	return false, nil
+}
 
-		if !parsedMonthDays[releaseTime.Day()] {
-			return false, nil
-		}
+// nextScheduleWindowBegin returns the earliest time after `t` at which one of the rule's time windows
+// begins. `t` must already be in the time zone returned by `scheduleRuleLocation()`. The second
+// return value is false if the schedule doesn't open within the next four years, which can only
+// happen if the rule's days of month and months of year never coincide.
+func nextScheduleWindowBegin(t time.Time, rule dbmodels.ScheduleApprovalRule) (time.Time, bool, error) {
+	timeRanges, err := scheduleRuleTimeRanges(rule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(timeRanges) == 0 {
+		// The window spans whole days.
+		timeRanges = []schedule.TimeRange{{Begin: "00:00", End: "24:00"}}
 	}
 
-	if rule.MonthsOfYear.Valid {
-		parsedMonths, err := parseScheduleMonths(rule.MonthsOfYear.String)
+	// Four years cover every combination of days of month and months of year, including February 29.
+	for days := 0; days <= 4*366; days++ {
+		date := time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+		matches, err := dateIsWithinSchedule(date, rule)
 		if err != nil {
-			return false, fmt.Errorf("Error parsing months '%s': %w", rule.MonthsOfYear.String, err)
+			return time.Time{}, false, err
+		}
+		if !matches {
+			continue
 		}
 
-		if !parsedMonths[releaseTime.Month()] {
-			return false, nil
+		var result time.Time
+		for _, timeRange := range timeRanges {
+			beginTime, _, err := resolveScheduleTimeRange(date, timeRange)
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			if beginTime.After(t) && (result.IsZero() || beginTime.Before(result)) {
+				result = beginTime
+			}
+		}
+		if !result.IsZero() {
+			return result, true, nil
 		}
 	}
 
-	return true, nil
+	return time.Time{}, false, nil
 }
 
-// parseScheduleTime parses a ScheduleApprovalRule time string. It returns a `time.Time`
-// whose date is equal to `date`, but whose time equals that of the time string.
-//
-// `str` has the format of `HH:MM[:SS]`.
-//
-// Example:
-//
-//     parseScheduleTime(time.Date(2021, 2, 19, 0, 0, 0), "12:32") // => 2021-02-19 12:32
-func parseScheduleTime(date time.Time, str string) (time.Time, error) {
-	components := strings.SplitN(str, ":", 3)
-	if len(components) < 2 {
-		return time.Time{}, errors.New("Invalid time format (HH:MM[:SS] expected)")
+// scheduleRuleTimeRanges returns all time ranges specified by the rule: BeginTime-EndTime
+// followed by TimeRanges.
+func scheduleRuleTimeRanges(rule dbmodels.ScheduleApprovalRule) ([]schedule.TimeRange, error) {
+	var result []schedule.TimeRange
+
+	if rule.BeginTime.Valid {
+		if !rule.EndTime.Valid {
+			panic(fmt.Sprintf("ScheduleApprovalRule %d: BeginTime non-null, but EndTime null", rule.ApprovalRule.ID))
+		}
+		result = append(result, schedule.TimeRange{Begin: rule.BeginTime.String, End: rule.EndTime.String})
 	}
 
-	var hour, minute, second int64
-	var err error
+	if rule.TimeRanges.Valid {
+		parsedTimeRanges, err := schedule.ParseTimeRanges(rule.TimeRanges.String)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing time ranges '%s': %w", rule.TimeRanges.String, err)
+		}
+		result = append(result, parsedTimeRanges...)
+	}
+
+	return result, nil
+}
 
-	hour, err = strconv.ParseInt(components[0], 10, 8)
+// resolveScheduleTimeRange returns the begin and end time of the given range, for a range
+// that begins on `beginDate`. If the end time lies before the begin time, then the range
+// ends on the next day.
+func resolveScheduleTimeRange(beginDate time.Time, timeRange schedule.TimeRange) (time.Time, time.Time, error) {
+	parsedBeginTime, err := schedule.ParseTime(beginDate, timeRange.Begin)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("Error parsing hour component: %w", err)
-	}
-	if hour < 0 || hour > 24 {
-		return time.Time{}, fmt.Errorf("Error parsing hour component: %d is not a valid value", hour)
+		return time.Time{}, time.Time{}, fmt.Errorf("Error parsing begin time '%s': %w", timeRange.Begin, err)
 	}
 
-	minute, err = strconv.ParseInt(components[1], 10, 8)
+	parsedEndTime, err := schedule.ParseTime(beginDate, timeRange.End)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("Error parsing minute component: %w", err)
-	}
-	if minute < 0 || minute > 60 {
-		return time.Time{}, fmt.Errorf("Error parsing minute component: %d is not a valid value", minute)
+		return time.Time{}, time.Time{}, fmt.Errorf("Error parsing end time '%s': %w", timeRange.End, err)
 	}
 
-	if len(components) == 3 {
-		second, err = strconv.ParseInt(components[2], 10, 8)
+	if parsedEndTime.Before(parsedBeginTime) {
+		parsedEndTime, err = schedule.ParseTime(beginDate.AddDate(0, 0, 1), timeRange.End)
 		if err != nil {
-			return time.Time{}, fmt.Errorf("Error parsing second component: %w", err)
-		}
-		if second < 0 || second > 60 {
-			return time.Time{}, fmt.Errorf("Error parsing second component: %d is not a valid value", second)
+			return time.Time{}, time.Time{}, fmt.Errorf("Error parsing end time '%s': %w", timeRange.End, err)
 		}
-	} else {
-		second = 0
 	}
 
-	result := time.Date(date.Year(), date.Month(), date.Day(), int(hour), int(minute), int(second), 0, date.Location())
-	return result, nil
+	return parsedBeginTime, parsedEndTime, nil
 }
 
-func parseScheduleWeekDays(str string) (map[time.Weekday]bool, error) {
-	result := make(map[time.Weekday]bool)
-	for _, day := range strings.Split(str, " ") {
-		switch strings.ToLower(day) {
-		case "1", "mon", "monday":
-			result[time.Monday] = true
-		case "2", "tue", "tuesday":
-			result[time.Tuesday] = true
-		case "3", "wed", "wednesday":
-			result[time.Wednesday] = true
-		case "4", "thu", "thursday":
-			result[time.Thursday] = true
-		case "5", "fri", "friday":
-			result[time.Friday] = true
-		case "6", "sat", "saturday":
-			result[time.Saturday] = true
-		case "0", "7", "sun", "sunday":
-			result[time.Sunday] = true
-		case "":
-			continue
-		default:
-			return nil, fmt.Errorf("'%s' is not a recognized weekday", day)
+// dateIsWithinSchedule checks whether the date of `date` matches the rule's days of week,
+// days of month and months of year.
+func dateIsWithinSchedule(date time.Time, rule dbmodels.ScheduleApprovalRule) (bool, error) {
+	if rule.DaysOfWeek.Valid {
+		parsedWeekDays, err := schedule.ParseWeekDays(rule.DaysOfWeek.String)
+		if err != nil {
+			return false, fmt.Errorf("Error parsing days of week '%s': %w", rule.DaysOfWeek.String, err)
 		}
-	}
-	return result, nil
-}
 
-func parseScheduleMonthDays(str string) (map[int]bool, error) {
-	result := make(map[int]bool)
-	for _, day := range strings.Split(str, " ") {
-		if len(day) == 0 {
-			continue
+		if !parsedWeekDays[date.Weekday()] {
+			return false, nil
 		}
+	}
 
-		dayInt, err := strconv.Atoi(day)
+	if rule.DaysOfMonth.Valid {
+		parsedMonthDays, err := schedule.ParseMonthDays(rule.DaysOfMonth.String)
 		if err != nil {
-			return nil, fmt.Errorf("Error parsing month day '%s': %w", day, err)
+			return false, fmt.Errorf("Error parsing days of month '%s': %w", rule.DaysOfMonth.String, err)
 		}
 
-		if dayInt < 0 || dayInt > 31 {
-			return nil, fmt.Errorf("Month day '%s' is not a valid day", day)
+		if !parsedMonthDays[date.Day()] {
+			return false, nil
 		}
-
-		result[int(dayInt)] = true
 	}
-	return result, nil
-}
 
-func parseScheduleMonths(str string) (map[time.Month]bool, error) {
-	result := make(map[time.Month]bool)
-	for _, month := range strings.Split(str, " ") {
-		switch strings.ToLower(month) {
-		case "1", "jan", "january":
-			result[time.January] = true
-		case "2", "feb", "february":
-			result[time.February] = true
-		case "3", "mar", "march":
-			result[time.March] = true
-		case "4", "apr", "april":
-			result[time.April] = true
-		case "5", "may":
-			result[time.May] = true
-		case "6", "jun", "june":
-			result[time.June] = true
-		case "7", "jul", "july":
-			result[time.July] = true
-		case "8", "aug", "august":
-			result[time.August] = true
-		case "9", "sep", "september":
-			result[time.September] = true
-		case "10", "oct", "october":
-			result[time.October] = true
-		case "11", "nov", "november":
-			result[time.November] = true
-		case "12", "dec", "december":
-			result[time.December] = true
-		case "":
-			continue
-		default:
-			return nil, fmt.Errorf("'%s' is not a recognized month", month)
+	if rule.MonthsOfYear.Valid {
+		parsedMonths, err := schedule.ParseMonths(rule.MonthsOfYear.String)
+		if err != nil {
+			return false, fmt.Errorf("Error parsing months '%s': %w", rule.MonthsOfYear.String, err)
+		}
+
+		if !parsedMonths[date.Month()] {
+			return false, nil
 		}
 	}
-	return result, nil
+
+	return true, nil
 }
@@ -2,10 +2,10 @@ package approvalrulesprocessing
 
 import (
 	"database/sql"
-	"fmt"
 	"testing"
 	"time"
 
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
@@ -92,9 +92,9 @@ func TestProcessScheduleRulesSuccess(t *testing.T) {
 		return
 	}
 	rule.BindingMode = approvalrulesetbindingmode.Enforcing
-	ctx.rulesetContents.ScheduleApprovalRules = append(ctx.rulesetContents.ScheduleApprovalRules, rule)
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -146,9 +146,9 @@ func TestProcessScheduleRulesError(t *testing.T) {
 		return
 	}
 	rule.BindingMode = approvalrulesetbindingmode.Enforcing
-	ctx.rulesetContents.ScheduleApprovalRules = append(ctx.rulesetContents.ScheduleApprovalRules, rule)
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -200,9 +200,9 @@ func TestProcessScheduleRulesPermissiveMode(t *testing.T) {
 		return
 	}
 	rule.BindingMode = approvalrulesetbindingmode.Permissive
-	ctx.rulesetContents.ScheduleApprovalRules = append(ctx.rulesetContents.ScheduleApprovalRules, rule)
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -243,7 +243,7 @@ func TestProcessScheduleRulesEmptyRuleset(t *testing.T) {
 		return
 	}
 
-	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -266,9 +266,9 @@ func TestProcessScheduleRulesRerunSuccess(t *testing.T) {
 		return
 	}
 	rule.BindingMode = approvalrulesetbindingmode.Enforcing
-	ctx.rulesetContents.ScheduleApprovalRules = append(ctx.rulesetContents.ScheduleApprovalRules, rule)
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	_, _, err = ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0)
+	_, _, err = ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -280,7 +280,7 @@ func TestProcessScheduleRulesRerunSuccess(t *testing.T) {
 		return
 	}
 
-	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, indexScheduleRuleOutcomes(outcomes), 0)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, indexScheduleRuleOutcomes(outcomes), 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -332,9 +332,9 @@ func TestProcessScheduleRulesRerunFail(t *testing.T) {
 		return
 	}
 	rule.BindingMode = approvalrulesetbindingmode.Enforcing
-	ctx.rulesetContents.ScheduleApprovalRules = append(ctx.rulesetContents.ScheduleApprovalRules, rule)
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	_, _, err = ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0)
+	_, _, err = ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -346,7 +346,7 @@ func TestProcessScheduleRulesRerunFail(t *testing.T) {
 		return
 	}
 
-	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, indexScheduleRuleOutcomes(outcomes), 0)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, indexScheduleRuleOutcomes(outcomes), 0, ctx.rulesetContents.NumRules())
 	if !assert.NoError(t, err) {
 		return
 	}
@@ -381,476 +381,336 @@ func TestProcessScheduleRulesRerunFail(t *testing.T) {
 	assert.Equal(t, uint(1), nprocessed)
 }
 
-// Test parseScheduleTime()
-
-func TestParseScheduleTimeTooFewComponents(t *testing.T) {
-	inputs := []string{"", "1"}
-	for _, input := range inputs {
-		_, err := parseScheduleTime(time.Now(), input)
-		if assert.Error(t, err, "Input=%s", input) {
-			assert.Regexp(t, "Invalid time format", err.Error(), "Input=%s", input)
-		}
+func TestProcessScheduleRulesDeferred(t *testing.T) {
+	ctx, err := setupProcessScheduleRulesTest()
+	if !assert.NoError(t, err) {
+		return
 	}
-}
 
-func TestParseScheduleTimeInvalidValues(t *testing.T) {
-	type Input struct {
-		Value     string
-		Component string
+	rule, err := dbmodels.CreateMockScheduleApprovalRuleWholeDay(ctx.db, ctx.org,
+		ctx.enforcingBinding.ApprovalRuleset.Version.ID,
+		*ctx.enforcingBinding.ApprovalRuleset.Version.Adjustment,
+		func(r *dbmodels.ScheduleApprovalRule) {
+			r.BeginTime = sql.NullString{String: "9:00", Valid: true}
+			r.EndTime = sql.NullString{String: "16:30", Valid: true}
+			r.DaysOfWeek = sql.NullString{String: "mon tue wed thu fri", Valid: true}
+			r.TimeZone = sql.NullString{String: "UTC", Valid: true}
+			r.DeferUntilWindow = true
+		})
+	if !assert.NoError(t, err) {
+		return
 	}
+	rule.BindingMode = approvalrulesetbindingmode.Enforcing
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	inputs := []Input{
-		{Value: ":", Component: "hour"},
-		{Value: "a:", Component: "hour"},
-		{Value: "-1:", Component: "hour"},
-		{Value: "25:", Component: "hour"},
-
-		{Value: "1:", Component: "minute"},
-		{Value: "1:b", Component: "minute"},
-		{Value: "1:-1", Component: "minute"},
-		{Value: "1:61", Component: "minute"},
+	// Friday evening
+	ctx.engine.ReleaseBackgroundJob.Release.CreatedAt = time.Date(2021, time.March, 5, 16, 55, 0, 0, time.UTC)
+	ctx.engine.Clock = &mocking.FakeClock{Value: time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)}
 
-		{Value: "1:30:", Component: "second"},
-		{Value: "1:30:c", Component: "second"},
-		{Value: "1:30:-1", Component: "second"},
-		{Value: "1:30:61", Component: "second"},
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	for _, input := range inputs {
-		_, err := parseScheduleTime(time.Now(), input.Value)
-		if assert.Error(t, err, "Input=%#v", input) {
-			assert.Regexp(t, "Error parsing "+input.Component+" component",
-				err.Error(), "Input=%#v", input)
-		}
+	var numOutcomes int64
+	err = ctx.db.Model(&dbmodels.ScheduleApprovalRuleOutcome{}).Count(&numOutcomes).Error
+	if !assert.NoError(t, err) {
+		return
 	}
-}
 
-func TestParseScheduleTimeValidValues(t *testing.T) {
-	var err error
-	var parsed time.Time
-	now := time.Now()
+	nextEligibleAt, deferred := ctx.engine.NextEligibleTime()
+	assert.Equal(t, int64(0), numOutcomes)
+	assert.Equal(t, releasestate.InProgress, resultState)
+	assert.Equal(t, uint(0), nprocessed)
+	assert.True(t, deferred)
+	assert.Equal(t, time.Date(2021, time.March, 8, 9, 0, 0, 0, time.UTC), nextEligibleAt.UTC())
 
-	parsed, err = parseScheduleTime(now, "1:20")
-	if assert.NoError(t, err) {
-		assert.Equal(t, parsed.Hour(), 1)
-		assert.Equal(t, parsed.Minute(), 20)
-		assert.Equal(t, parsed.Second(), 0)
-	}
+	// Monday morning
+	ctx.engine.nextEligibleAt = time.Time{}
+	ctx.engine.Clock = &mocking.FakeClock{Value: nextEligibleAt}
 
-	parsed, err = parseScheduleTime(now, "01:20")
-	if assert.NoError(t, err) {
-		assert.Equal(t, parsed.Hour(), 1)
-		assert.Equal(t, parsed.Minute(), 20)
-		assert.Equal(t, parsed.Second(), 0)
+	resultState, nprocessed, err = ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	parsed, err = parseScheduleTime(now, "16:5")
-	if assert.NoError(t, err) {
-		assert.Equal(t, parsed.Hour(), 16)
-		assert.Equal(t, parsed.Minute(), 5)
-		assert.Equal(t, parsed.Second(), 0)
+	var outcome dbmodels.ScheduleApprovalRuleOutcome
+	err = ctx.db.Take(&outcome).Error
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	parsed, err = parseScheduleTime(now, "16:05")
-	if assert.NoError(t, err) {
-		assert.Equal(t, parsed.Hour(), 16)
-		assert.Equal(t, parsed.Minute(), 5)
-		assert.Equal(t, parsed.Second(), 0)
+	_, deferred = ctx.engine.NextEligibleTime()
+	assert.True(t, outcome.Success)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
+	assert.False(t, deferred)
+}
+
+func TestProcessScheduleRulesNotDeferredInPermissiveMode(t *testing.T) {
+	ctx, err := setupProcessScheduleRulesTest()
+	if !assert.NoError(t, err) {
+		return
 	}
 
-	parsed, err = parseScheduleTime(now, "8:47:1")
-	if assert.NoError(t, err) {
-		assert.Equal(t, parsed.Hour(), 8)
-		assert.Equal(t, parsed.Minute(), 47)
-		assert.Equal(t, parsed.Second(), 1)
+	rule, err := dbmodels.CreateMockScheduleApprovalRuleWholeDay(ctx.db, ctx.org,
+		ctx.permissiveBinding.ApprovalRuleset.Version.ID,
+		*ctx.permissiveBinding.ApprovalRuleset.Version.Adjustment,
+		func(r *dbmodels.ScheduleApprovalRule) {
+			r.BeginTime = sql.NullString{String: "0:00:00", Valid: true}
+			r.EndTime = sql.NullString{String: "0:00:01", Valid: true}
+			r.DeferUntilWindow = true
+		})
+	if !assert.NoError(t, err) {
+		return
 	}
+	rule.BindingMode = approvalrulesetbindingmode.Permissive
+	ctx.rulesetContents.Rules = append(ctx.rulesetContents.Rules, &rule)
 
-	parsed, err = parseScheduleTime(now, "8:47:01")
-	if assert.NoError(t, err) {
-		assert.Equal(t, parsed.Hour(), 8)
-		assert.Equal(t, parsed.Minute(), 47)
-		assert.Equal(t, parsed.Second(), 1)
+	resultState, nprocessed, err := ctx.engine.processScheduleRules(ctx.rulesetContents, map[uint64]bool{}, 0, ctx.rulesetContents.NumRules())
+	if !assert.NoError(t, err) {
+		return
 	}
+
+	_, deferred := ctx.engine.NextEligibleTime()
+	assert.False(t, deferred)
+	assert.Equal(t, releasestate.Approved, resultState)
+	assert.Equal(t, uint(1), nprocessed)
 }
 
-// Test parseScheduleWeekDays()
+// Test scheduleRuleLocation()
 
-func TestParseScheduleWeekDaysEmpty(t *testing.T) {
-	var parsed map[time.Weekday]bool
-	var err error
+func TestScheduleRuleLocationFromRule(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{TimeZone: sql.NullString{String: "America/New_York", Valid: true}}
+	org := dbmodels.Organization{DefaultTimeZone: sql.NullString{String: "Europe/Amsterdam", Valid: true}}
 
-	parsed, err = parseScheduleWeekDays("")
+	location, err := scheduleRuleLocation(rule, org)
 	if assert.NoError(t, err) {
-		assert.Equal(t, 0, len(parsed))
+		assert.Equal(t, "America/New_York", location.String())
 	}
 }
 
-func TestParseScheduleWeekDaysFull(t *testing.T) {
-	var parsed map[time.Weekday]bool
-	var err error
+func TestScheduleRuleLocationFromOrganization(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{}
+	org := dbmodels.Organization{DefaultTimeZone: sql.NullString{String: "Europe/Amsterdam", Valid: true}}
 
-	parsed, err = parseScheduleWeekDays("monday")
+	location, err := scheduleRuleLocation(rule, org)
 	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.Monday])
-	}
-
-	parsed, err = parseScheduleWeekDays("monday tuesday wednesday")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[time.Monday])
-		assert.True(t, parsed[time.Tuesday])
-		assert.True(t, parsed[time.Wednesday])
-	}
-
-	parsed, err = parseScheduleWeekDays("sunday thursday friday saturday")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 4, len(parsed))
-		assert.True(t, parsed[time.Thursday])
-		assert.True(t, parsed[time.Friday])
-		assert.True(t, parsed[time.Saturday])
-		assert.True(t, parsed[time.Sunday])
+		assert.Equal(t, "Europe/Amsterdam", location.String())
 	}
 }
 
-func TestParseScheduleWeekDaysAbbrev(t *testing.T) {
-	var parsed map[time.Weekday]bool
-	var err error
-
-	parsed, err = parseScheduleWeekDays("mon")
+func TestScheduleRuleLocationDefault(t *testing.T) {
+	location, err := scheduleRuleLocation(dbmodels.ScheduleApprovalRule{}, dbmodels.Organization{})
 	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.Monday])
+		assert.Equal(t, time.Local, location)
 	}
+}
 
-	parsed, err = parseScheduleWeekDays("mon tue wed")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[time.Monday])
-		assert.True(t, parsed[time.Tuesday])
-		assert.True(t, parsed[time.Wednesday])
-	}
+func TestScheduleRuleLocationInvalid(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{TimeZone: sql.NullString{String: "Mars/Olympus_Mons", Valid: true}}
 
-	parsed, err = parseScheduleWeekDays("sun thu fri sat")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 4, len(parsed))
-		assert.True(t, parsed[time.Thursday])
-		assert.True(t, parsed[time.Friday])
-		assert.True(t, parsed[time.Saturday])
-		assert.True(t, parsed[time.Sunday])
-	}
+	_, err := scheduleRuleLocation(rule, dbmodels.Organization{})
+	assert.Error(t, err)
 }
 
-func TestParseScheduleWeekDaysNumbers(t *testing.T) {
-	var parsed map[time.Weekday]bool
-	var err error
+// Test timeIsWithinSchedule()
 
-	parsed, err = parseScheduleWeekDays("1")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.Monday])
+func TestTimeIsWithinScheduleAcrossDSTTransition(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if !assert.NoError(t, err) {
+		return
 	}
-
-	parsed, err = parseScheduleWeekDays("1 2 3")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[time.Monday])
-		assert.True(t, parsed[time.Tuesday])
-		assert.True(t, parsed[time.Wednesday])
+	rule := dbmodels.ScheduleApprovalRule{
+		BeginTime: sql.NullString{String: "9:00", Valid: true},
+		EndTime:   sql.NullString{String: "17:00", Valid: true},
 	}
 
-	parsed, err = parseScheduleWeekDays("7 4 5 6")
+	// 07:30 UTC is 08:30 in Amsterdam during winter time (CET, UTC+1)...
+	winter := time.Date(2021, time.January, 11, 7, 30, 0, 0, time.UTC)
+	result, err := timeIsWithinSchedule(winter.In(amsterdam), rule)
 	if assert.NoError(t, err) {
-		assert.Equal(t, 4, len(parsed))
-		assert.True(t, parsed[time.Thursday])
-		assert.True(t, parsed[time.Friday])
-		assert.True(t, parsed[time.Saturday])
-		assert.True(t, parsed[time.Sunday])
+		assert.False(t, result)
 	}
 
-	parsed, err = parseScheduleWeekDays("0")
+	// ...but 09:30 during summer time (CEST, UTC+2).
+	summer := time.Date(2021, time.July, 12, 7, 30, 0, 0, time.UTC)
+	result, err = timeIsWithinSchedule(summer.In(amsterdam), rule)
 	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.Sunday])
+		assert.True(t, result)
 	}
 }
 
-func TestParseScheduleWeekDaysExcessiveSpaces(t *testing.T) {
-	var parsed map[time.Weekday]bool
-	var err error
+func TestTimeIsWithinScheduleUsesLocalDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, err) {
+		return
+	}
+	rule := dbmodels.ScheduleApprovalRule{
+		DaysOfWeek: sql.NullString{String: "mon", Valid: true},
+	}
 
-	parsed, err = parseScheduleWeekDays("  mon  wed    ")
+	// Tuesday 02:00 UTC is still Monday evening in New York.
+	releaseTime := time.Date(2021, time.March, 9, 2, 0, 0, 0, time.UTC)
+	result, err := timeIsWithinSchedule(releaseTime.In(newYork), rule)
 	if assert.NoError(t, err) {
-		assert.Equal(t, 2, len(parsed))
-		assert.True(t, parsed[time.Monday])
-		assert.True(t, parsed[time.Wednesday])
+		assert.True(t, result)
 	}
 }
 
-func TestParseScheduleWeekDaysCaseInsensitive(t *testing.T) {
-	var parsed map[time.Weekday]bool
-	var err error
-
-	inputs := []string{
-		"Mon Tue Wed Thu Fri Sat Sun",
-		"MON TUE WED THU FRI SAT SUN",
-		"monDay tuesDay wednesDay thursDay friDay saturDay sunDay",
-		"MONDAY TUESDAY WEDNESDAY THURSDAY FRIDAY SATURDAY SUNDAY",
-	}
-
-	for _, input := range inputs {
-		parsed, err = parseScheduleWeekDays(input)
-		if assert.NoError(t, err) {
-			assert.Equal(t, 7, len(parsed))
-			assert.True(t, parsed[time.Monday], "Input=%s", input)
-			assert.True(t, parsed[time.Tuesday], "Input=%s", input)
-			assert.True(t, parsed[time.Wednesday], "Input=%s", input)
-			assert.True(t, parsed[time.Thursday], "Input=%s", input)
-			assert.True(t, parsed[time.Friday], "Input=%s", input)
-			assert.True(t, parsed[time.Saturday], "Input=%s", input)
-			assert.True(t, parsed[time.Sunday], "Input=%s", input)
+func TestTimeIsWithinScheduleOvernightRange(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		BeginTime: sql.NullString{String: "22:00", Valid: true},
+		EndTime:   sql.NullString{String: "02:00", Valid: true},
+	}
+
+	inputs := map[time.Time]bool{
+		time.Date(2021, time.March, 5, 21, 59, 0, 0, time.UTC): false,
+		time.Date(2021, time.March, 5, 22, 0, 0, 0, time.UTC):  true,
+		time.Date(2021, time.March, 5, 23, 30, 0, 0, time.UTC): true,
+		time.Date(2021, time.March, 6, 1, 30, 0, 0, time.UTC):  true,
+		time.Date(2021, time.March, 6, 2, 0, 0, 0, time.UTC):   true,
+		time.Date(2021, time.March, 6, 2, 1, 0, 0, time.UTC):   false,
+		time.Date(2021, time.March, 6, 12, 0, 0, 0, time.UTC):  false,
+	}
+	for releaseTime, expected := range inputs {
+		result, err := timeIsWithinSchedule(releaseTime, rule)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
 		}
 	}
 }
 
-func TestParseScheduleWeekDaysUnknownInput(t *testing.T) {
-	var err error
-
-	_, err = parseScheduleWeekDays("today")
-	assert.Error(t, err)
-
-	_, err = parseScheduleWeekDays("mon:tue")
-	assert.Error(t, err)
-}
-
-// Test parseScheduleMonthDays()
-
-func TestParseScheduleMonthDaysEmpty(t *testing.T) {
-	var parsed map[int]bool
-	var err error
-
-	parsed, err = parseScheduleMonthDays("")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 0, len(parsed))
+func TestTimeIsWithinScheduleOvernightRangeMatchesStartDay(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		BeginTime:  sql.NullString{String: "22:00", Valid: true},
+		EndTime:    sql.NullString{String: "02:00", Valid: true},
+		DaysOfWeek: sql.NullString{String: "fri", Valid: true},
+	}
+
+	inputs := map[time.Time]bool{
+		// Thursday evening and Friday early morning belong to Thursday's window
+		time.Date(2021, time.March, 4, 23, 0, 0, 0, time.UTC): false,
+		time.Date(2021, time.March, 5, 1, 0, 0, 0, time.UTC):  false,
+		// Friday evening and Saturday early morning belong to Friday's window
+		time.Date(2021, time.March, 5, 23, 0, 0, 0, time.UTC): true,
+		time.Date(2021, time.March, 6, 1, 0, 0, 0, time.UTC):  true,
+		// Saturday evening belongs to Saturday's window
+		time.Date(2021, time.March, 6, 23, 0, 0, 0, time.UTC): false,
+	}
+	for releaseTime, expected := range inputs {
+		result, err := timeIsWithinSchedule(releaseTime, rule)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
+		}
 	}
 }
 
-func TestParseScheduleMonthDays(t *testing.T) {
-	var parsed map[int]bool
-	var err error
-
-	parsed, err = parseScheduleMonthDays("1")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[1])
+func TestTimeIsWithinScheduleMultipleRanges(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		TimeRanges:  sql.NullString{String: "22:00-02:00  09:00-12:00", Valid: true},
+		DaysOfMonth: sql.NullString{String: "31", Valid: true},
 	}
 
-	parsed, err = parseScheduleMonthDays("1 2 3")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[1])
-		assert.True(t, parsed[2])
-		assert.True(t, parsed[3])
+	inputs := map[time.Time]bool{
+		time.Date(2021, time.March, 31, 10, 0, 0, 0, time.UTC): true,
+		time.Date(2021, time.March, 31, 13, 0, 0, 0, time.UTC): false,
+		time.Date(2021, time.March, 31, 23, 0, 0, 0, time.UTC): true,
+		time.Date(2021, time.April, 1, 1, 0, 0, 0, time.UTC):   true,
+		time.Date(2021, time.April, 1, 10, 0, 0, 0, time.UTC):  false,
+		time.Date(2021, time.March, 31, 1, 0, 0, 0, time.UTC):  false,
 	}
-
-	var input = ""
-	for i := 1; i <= 31; i++ {
-		input += fmt.Sprintf("%d", i) + " "
-	}
-	parsed, err = parseScheduleMonthDays(input)
-	if assert.NoError(t, err) {
-		assert.Equal(t, 31, len(parsed))
-		for i := 1; i <= 31; i++ {
-			assert.True(t, parsed[i])
+	for releaseTime, expected := range inputs {
+		result, err := timeIsWithinSchedule(releaseTime, rule)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
 		}
 	}
 }
 
-func TestParseScheduleMonthDaysExcessiveSpaces(t *testing.T) {
-	var parsed map[int]bool
-	var err error
-
-	parsed, err = parseScheduleMonthDays("  1  15  30    ")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[1])
-		assert.True(t, parsed[15])
-		assert.True(t, parsed[30])
+func TestTimeIsWithinScheduleBeginEndTimeAndRanges(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		BeginTime:  sql.NullString{String: "09:00", Valid: true},
+		EndTime:    sql.NullString{String: "12:00", Valid: true},
+		TimeRanges: sql.NullString{String: "14:00-16:00", Valid: true},
 	}
-}
-
-func TestParseScheduleMonthDaysInvalidInput(t *testing.T) {
-	var err error
-
-	_, err = parseScheduleMonthDays("aa")
-	assert.Error(t, err)
-
-	_, err = parseScheduleMonthDays("1:2")
-	assert.Error(t, err)
 
-	_, err = parseScheduleMonthDays("32")
-	assert.Error(t, err)
-
-	_, err = parseScheduleMonthDays("-1")
-	assert.Error(t, err)
+	inputs := map[time.Time]bool{
+		time.Date(2021, time.March, 3, 10, 0, 0, 0, time.UTC): true,
+		time.Date(2021, time.March, 3, 13, 0, 0, 0, time.UTC): false,
+		time.Date(2021, time.March, 3, 15, 0, 0, 0, time.UTC): true,
+	}
+	for releaseTime, expected := range inputs {
+		result, err := timeIsWithinSchedule(releaseTime, rule)
+		if assert.NoError(t, err, "Time=%s", releaseTime) {
+			assert.Equal(t, expected, result, "Time=%s", releaseTime)
+		}
+	}
 }
 
-// Test parseScheduleMonths()
-
-func TestParseScheduleMonthsFull(t *testing.T) {
-	var parsed map[time.Month]bool
-	var err error
+// Test nextScheduleWindowBegin()
 
-	parsed, err = parseScheduleMonths("january")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.January])
+func TestNextScheduleWindowBeginSameDay(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		TimeRanges: sql.NullString{String: "09:00-12:00 14:00-17:00", Valid: true},
 	}
 
-	parsed, err = parseScheduleMonths("january february march")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[time.January])
-		assert.True(t, parsed[time.February])
-		assert.True(t, parsed[time.March])
-	}
-
-	parsed, err = parseScheduleMonths("december april may june july august september october november")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 9, len(parsed))
-		assert.True(t, parsed[time.April])
-		assert.True(t, parsed[time.May])
-		assert.True(t, parsed[time.June])
-		assert.True(t, parsed[time.July])
-		assert.True(t, parsed[time.August])
-		assert.True(t, parsed[time.September])
-		assert.True(t, parsed[time.October])
-		assert.True(t, parsed[time.November])
-		assert.True(t, parsed[time.December])
+	result, found, err := nextScheduleWindowBegin(time.Date(2021, time.March, 5, 12, 30, 0, 0, time.UTC), rule)
+	if assert.NoError(t, err) && assert.True(t, found) {
+		assert.Equal(t, time.Date(2021, time.March, 5, 14, 0, 0, 0, time.UTC), result)
 	}
 }
 
-func TestParseScheduleMonthsAbbrev(t *testing.T) {
-	var parsed map[time.Month]bool
-	var err error
+func TestNextScheduleWindowBeginSkipsExcludedDays(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		BeginTime:  sql.NullString{String: "09:00", Valid: true},
+		EndTime:    sql.NullString{String: "16:30", Valid: true},
+		DaysOfWeek: sql.NullString{String: "mon tue wed thu fri", Valid: true},
+	}
 
-	parsed, err = parseScheduleMonths("jan")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.January])
+	// Friday evening
+	result, found, err := nextScheduleWindowBegin(time.Date(2021, time.March, 5, 16, 55, 0, 0, time.UTC), rule)
+	if assert.NoError(t, err) && assert.True(t, found) {
+		assert.Equal(t, time.Date(2021, time.March, 8, 9, 0, 0, 0, time.UTC), result)
 	}
+}
 
-	parsed, err = parseScheduleMonths("jan feb mar")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[time.January])
-		assert.True(t, parsed[time.February])
-		assert.True(t, parsed[time.March])
+func TestNextScheduleWindowBeginWholeDays(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		DaysOfMonth: sql.NullString{String: "1", Valid: true},
 	}
 
-	parsed, err = parseScheduleMonths("dec apr may jun jul aug sep oct nov")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 9, len(parsed))
-		assert.True(t, parsed[time.April])
-		assert.True(t, parsed[time.May])
-		assert.True(t, parsed[time.June])
-		assert.True(t, parsed[time.July])
-		assert.True(t, parsed[time.August])
-		assert.True(t, parsed[time.September])
-		assert.True(t, parsed[time.October])
-		assert.True(t, parsed[time.November])
-		assert.True(t, parsed[time.December])
+	result, found, err := nextScheduleWindowBegin(time.Date(2021, time.March, 5, 12, 0, 0, 0, time.UTC), rule)
+	if assert.NoError(t, err) && assert.True(t, found) {
+		assert.Equal(t, time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC), result)
 	}
 }
 
-func TestParseScheduleMonthsNumbers(t *testing.T) {
-	var parsed map[time.Month]bool
-	var err error
-
-	parsed, err = parseScheduleMonths("1")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 1, len(parsed))
-		assert.True(t, parsed[time.January])
+func TestNextScheduleWindowBeginUsesLocalTime(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if !assert.NoError(t, err) {
+		return
 	}
-
-	parsed, err = parseScheduleMonths("1 2 3")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 3, len(parsed))
-		assert.True(t, parsed[time.January])
-		assert.True(t, parsed[time.February])
-		assert.True(t, parsed[time.March])
+	rule := dbmodels.ScheduleApprovalRule{
+		BeginTime: sql.NullString{String: "09:00", Valid: true},
+		EndTime:   sql.NullString{String: "17:00", Valid: true},
 	}
 
-	parsed, err = parseScheduleMonths("7 4 5 6 7 8 9 10 11 12")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 9, len(parsed))
-		assert.True(t, parsed[time.April])
-		assert.True(t, parsed[time.May])
-		assert.True(t, parsed[time.June])
-		assert.True(t, parsed[time.July])
-		assert.True(t, parsed[time.August])
-		assert.True(t, parsed[time.September])
-		assert.True(t, parsed[time.October])
-		assert.True(t, parsed[time.November])
-		assert.True(t, parsed[time.December])
+	// Across the DST transition on 2021-03-28
+	result, found, err := nextScheduleWindowBegin(time.Date(2021, time.March, 27, 18, 0, 0, 0, amsterdam), rule)
+	if assert.NoError(t, err) && assert.True(t, found) {
+		assert.Equal(t, time.Date(2021, time.March, 28, 7, 0, 0, 0, time.UTC), result.UTC())
 	}
 }
 
-func TestParseScheduleMonthsExcessiveSpaces(t *testing.T) {
-	var parsed map[time.Month]bool
-	var err error
-
-	parsed, err = parseScheduleMonths("  jan  feb    ")
-	if assert.NoError(t, err) {
-		assert.Equal(t, 2, len(parsed))
-		assert.True(t, parsed[time.January])
-		assert.True(t, parsed[time.February])
+func TestNextScheduleWindowBeginNeverOpens(t *testing.T) {
+	rule := dbmodels.ScheduleApprovalRule{
+		DaysOfMonth:  sql.NullString{String: "31", Valid: true},
+		MonthsOfYear: sql.NullString{String: "feb", Valid: true},
 	}
-}
 
-func TestParseScheduleMonthsCaseInsensitive(t *testing.T) {
-	var parsed map[time.Month]bool
-	var err error
-
-	inputs := []string{
-		"Jan Feb Mar Apr May Jun Jul Aug Sep Oct Nov Dec",
-		"JAN FEB MAR APR MAY JUN JUL AUG SEP OCT NOV DEC",
-		"januAry februAry marCh apRil maY juNe juLy auGust sepTember ocTober noVember deCember",
-		"JANUARY FEBRUARY MARCH APRIL MAY JUNE JULY AUGUST SEPTEMBER OCTOBER NOVEMBER DECEMBER",
-	}
-
-	for _, input := range inputs {
-		parsed, err = parseScheduleMonths(input)
-		if assert.NoError(t, err) {
-			assert.Equal(t, 12, len(parsed))
-			assert.True(t, parsed[time.January], "Input=%s", input)
-			assert.True(t, parsed[time.February], "Input=%s", input)
-			assert.True(t, parsed[time.March], "Input=%s", input)
-			assert.True(t, parsed[time.April], "Input=%s", input)
-			assert.True(t, parsed[time.May], "Input=%s", input)
-			assert.True(t, parsed[time.June], "Input=%s", input)
-			assert.True(t, parsed[time.July], "Input=%s", input)
-			assert.True(t, parsed[time.August], "Input=%s", input)
-			assert.True(t, parsed[time.September], "Input=%s", input)
-			assert.True(t, parsed[time.October], "Input=%s", input)
-			assert.True(t, parsed[time.November], "Input=%s", input)
-			assert.True(t, parsed[time.December], "Input=%s", input)
-		}
+	_, found, err := nextScheduleWindowBegin(time.Date(2021, time.March, 5, 12, 0, 0, 0, time.UTC), rule)
+	if assert.NoError(t, err) {
+		assert.False(t, found)
 	}
 }
-
-func TestParseScheduleMonthsUnknownInput(t *testing.T) {
-	var err error
-
-	_, err = parseScheduleMonths("today")
-	assert.Error(t, err)
-
-	_, err = parseScheduleMonths("jan:feb")
-	assert.Error(t, err)
-
-	_, err = parseScheduleMonths("-1")
-	assert.Error(t, err)
-
-	_, err = parseScheduleMonths("13")
-	assert.Error(t, err)
-}
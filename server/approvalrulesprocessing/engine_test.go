@@ -0,0 +1,224 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Test Engine.Run() with disabled rules
+
+type EngineDisabledRulesTestContext struct {
+	db      *gorm.DB
+	org     dbmodels.Organization
+	app     dbmodels.Application
+	release dbmodels.Release
+	job     dbmodels.ReleaseBackgroundJob
+	engine  Engine
+	server  *httptest.Server
+}
+
+// setupEngineDisabledRulesTest creates a Release that is bound to a single, enforcing HTTP API
+// rule. That rule fails if it's evaluated.
+func setupEngineDisabledRulesTest(ruleEnabled bool, rulesetEnabled bool) (EngineDisabledRulesTestContext, dbmodels.HTTPApiApprovalRule, error) {
+	var ctx EngineDisabledRulesTestContext
+	var rule dbmodels.HTTPApiApprovalRule
+	var err error
+
+	ctx.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	ctx.db, err = dbutils.SetupTestDatabase()
+	if err != nil {
+		return EngineDisabledRulesTestContext{}, dbmodels.HTTPApiApprovalRule{}, err
+	}
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		ctx.org, err = dbmodels.CreateMockOrganization(tx, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.org, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		ctx.release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.org, ctx.app, nil)
+		if err != nil {
+			return err
+		}
+
+		ruleset, err := dbmodels.CreateMockApprovalRulesetWith1Version(tx, ctx.org, "ruleset1",
+			func(adjustment *dbmodels.ApprovalRulesetAdjustment) {
+				adjustment.Enabled = lib.NewBoolPtr(rulesetEnabled)
+			})
+		if err != nil {
+			return err
+		}
+
+		_, err = dbmodels.CreateMockReleaseRulesetBindingWithEnforcingMode(tx, ctx.org, ctx.release,
+			ruleset, *ruleset.Version, *ruleset.Version.Adjustment, nil)
+		if err != nil {
+			return err
+		}
+
+		rule, err = dbmodels.CreateMockHTTPApiApprovalRule(tx, ctx.org, ruleset.Version.ID,
+			*ruleset.Version.Adjustment, ctx.server.URL,
+			func(rule *dbmodels.HTTPApiApprovalRule) {
+				rule.Enabled = lib.NewBoolPtr(ruleEnabled)
+			})
+		if err != nil {
+			return err
+		}
+
+		ctx.job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.org, ctx.app, ctx.release, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return EngineDisabledRulesTestContext{}, dbmodels.HTTPApiApprovalRule{}, err
+	}
+
+	ctx.engine = Engine{
+		Db:                   ctx.db,
+		OrganizationID:       ctx.org.ID,
+		ReleaseBackgroundJob: ctx.job,
+	}
+	return ctx, rule, nil
+}
+
+func TestEngineSkipsDisabledRules(t *testing.T) {
+	ctx, rule, err := setupEngineDisabledRulesTest(false, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ctx.server.Close()
+
+	err = ctx.engine.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var release dbmodels.Release
+	err = ctx.db.Take(&release).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	events, err := dbmodels.FindReleaseRuleSkippedEvents(ctx.db, ctx.org.ID, ctx.app.ID, ctx.release.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var numOutcomes int64
+	err = ctx.db.Model(&dbmodels.HTTPApiApprovalRuleOutcome{}).Count(&numOutcomes).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, releasestate.Approved, release.State)
+	assert.Equal(t, int64(0), numOutcomes)
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, string(dbmodels.HTTPApiApprovalRuleType), events[0].ApprovalRuleType.String)
+		assert.Equal(t, int64(rule.ID), events[0].ApprovalRuleID.Int64)
+		assert.Equal(t, "Rule is disabled", events[0].Reason)
+	}
+}
+
+func TestEngineSkipsRulesInDisabledRulesets(t *testing.T) {
+	ctx, _, err := setupEngineDisabledRulesTest(true, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ctx.server.Close()
+
+	err = ctx.engine.Run(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var release dbmodels.Release
+	err = ctx.db.Take(&release).Error
+	if !assert.NoError(t, err) {
+		return
+	}
+	events, err := dbmodels.FindReleaseRuleSkippedEvents(ctx.db, ctx.org.ID, ctx.app.ID, ctx.release.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, releasestate.Approved, release.State)
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, "Approval ruleset is disabled", events[0].Reason)
+	}
+}
+
+func TestEngineRecordsSkippedRulesOnce(t *testing.T) {
+	ctx, _, err := setupEngineDisabledRulesTest(false, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ctx.server.Close()
+
+	err = ctx.engine.recordSkippedRules([]dbmodels.SkippedApprovalRule{
+		{Type: dbmodels.HTTPApiApprovalRuleType, Rule: dbmodels.ApprovalRule{ID: 1}, Reason: "Rule is disabled"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ctx.engine.recordSkippedRules([]dbmodels.SkippedApprovalRule{
+		{Type: dbmodels.HTTPApiApprovalRuleType, Rule: dbmodels.ApprovalRule{ID: 1}, Reason: "Rule is disabled"},
+		{Type: dbmodels.ScheduleApprovalRuleType, Rule: dbmodels.ApprovalRule{ID: 1}, Reason: "Rule is disabled"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	events, err := dbmodels.FindReleaseRuleSkippedEvents(ctx.db, ctx.org.ID, ctx.app.ID, ctx.release.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, len(events))
+}
+
+// Test that Engine doesn't overwrite the state of a Release that was cancelled while it was processing.
+
+func TestEngineDoesNotOverwriteCancelledState(t *testing.T) {
+	ctx, _, err := setupEngineDisabledRulesTest(false, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ctx.server.Close()
+
+	err = ctx.db.Transaction(func(tx *gorm.DB) error {
+		release := ctx.release
+		_, _, err := dbmodels.CancelRelease(tx, &release, sql.NullString{}, time.Now())
+		return err
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = ctx.engine.finalizeJob(releasestate.Approved)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	release, err := dbmodels.FindRelease(ctx.db, ctx.org.ID, ctx.app.ID, ctx.release.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, releasestate.Cancelled, release.State)
+}
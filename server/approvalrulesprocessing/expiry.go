@@ -0,0 +1,71 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"gorm.io/gorm"
+)
+
+// expiredReleasesBatchSize is the maximum number of expired Releases that ExpireReleases()
+// loads into memory at once. It's a variable so that tests can lower it.
+var expiredReleasesBatchSize = 100
+
+// ExpireReleases cancels all Releases that remained in progress for longer than their
+// Application's ReleaseExpiryMinutes allows. It returns the number of Releases cancelled.
+//
+// Cancelling a Release acquires the same lock as Engine.Run(), so a Release that is being
+// processed is only cancelled after processing is done, and only if it's still in progress.
+func ExpireReleases(db *gorm.DB, clock mocking.IClock) (int, error) {
+	var nexpired int
+	var after *dbmodels.Release
+	now := clock.Now()
+
+	for {
+		releases, err := dbmodels.FindExpiredReleases(db, now, after, expiredReleasesBatchSize)
+		if err != nil {
+			return nexpired, fmt.Errorf("Error querying expired releases: %w", err)
+		}
+
+		for _, release := range releases {
+			expired, err := expireRelease(db, release, clock)
+			if err != nil {
+				return nexpired, err
+			}
+			if expired {
+				nexpired++
+			}
+		}
+
+		if len(releases) < expiredReleasesBatchSize {
+			return nexpired, nil
+		}
+		after = &releases[len(releases)-1]
+	}
+}
+
+func expireRelease(db *gorm.DB, release dbmodels.Release, clock mocking.IClock) (bool, error) {
+	job, err := dbmodels.FindReleaseBackgroundJob(db.Preload("Release"), release.OrganizationID,
+		release.ApplicationID, release.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The release has been finalized in the meantime.
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Error querying background job of release %s: %w", release.Description(), err)
+	}
+
+	engine := Engine{Db: db, OrganizationID: release.OrganizationID, ReleaseBackgroundJob: job, Clock: clock}
+	expired, err := engine.Expire()
+	if err != nil {
+		return false, err
+	}
+	if expired {
+		db.Logger.Info(context.Background(), "Release %s remained in progress for too long; cancelled it",
+			release.Description())
+	}
+	return expired, nil
+}
@@ -0,0 +1,146 @@
+package approvalrulesprocessing
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("ExpireReleases", func() {
+	var db *gorm.DB
+	var org dbmodels.Organization
+	var clock mocking.FakeClock
+
+	BeforeEach(func() {
+		var err error
+
+		db, err = dbutils.SetupTestDatabase()
+		Expect(err).ToNot(HaveOccurred())
+
+		org, err = dbmodels.CreateMockOrganization(db, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		clock = mocking.FakeClock{Value: time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)}
+	})
+
+	createMockJob := func(appID string, expiryMinutes sql.NullInt32, createdAt time.Time) dbmodels.ReleaseBackgroundJob {
+		var job dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			app, err := dbmodels.CreateMockApplicationWith1Version(tx, org,
+				func(app *dbmodels.Application) {
+					app.ID = appID
+				},
+				func(adjustment *dbmodels.ApplicationAdjustment) {
+					adjustment.ReleaseExpiryMinutes = expiryMinutes
+				})
+			Expect(err).ToNot(HaveOccurred())
+
+			release, err := dbmodels.CreateMockReleaseWithInProgressState(tx, org, app, func(release *dbmodels.Release) {
+				release.CreatedAt = createdAt
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, org, app, release, func(job *dbmodels.ReleaseBackgroundJob) {
+				job.LockSubID = uint32(release.ID)
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return job
+	}
+
+	It("cancels releases that remained in progress for longer than their application allows", func() {
+		job := createMockJob("app1", sql.NullInt32{Int32: 60, Valid: true}, clock.Now().Add(-61*time.Minute))
+
+		nexpired, err := ExpireReleases(db, &clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nexpired).To(Equal(1))
+
+		release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.Cancelled))
+		Expect(release.FinalizedAt.Valid).To(BeTrue())
+		Expect(release.FinalizedAt.Time).To(BeTemporally("==", clock.Now()))
+
+		_, err = dbmodels.FindReleaseBackgroundJob(db, org.ID, job.ApplicationID, job.ReleaseID)
+		Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+
+		var events []dbmodels.ReleaseCancelledEvent
+		err = db.Where("organization_id = ? AND application_id = ? AND release_id = ?",
+			org.ID, job.ApplicationID, job.ReleaseID).Find(&events).Error
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Reason.String).To(Equal(dbmodels.ReleaseCancelledEventExpiredReason))
+	})
+
+	It("leaves releases alone that haven't expired yet", func() {
+		job := createMockJob("app1", sql.NullInt32{Int32: 60, Valid: true}, clock.Now().Add(-59*time.Minute))
+
+		nexpired, err := ExpireReleases(db, &clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nexpired).To(Equal(0))
+
+		release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.InProgress))
+	})
+
+	It("leaves releases alone whose application has no expiry time", func() {
+		job := createMockJob("app1", sql.NullInt32{}, clock.Now().Add(-365*24*time.Hour))
+
+		nexpired, err := ExpireReleases(db, &clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nexpired).To(Equal(0))
+
+		release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.InProgress))
+	})
+
+	It("uses the expiry time of each release's own application", func() {
+		expiredJob := createMockJob("app1", sql.NullInt32{Int32: 60, Valid: true}, clock.Now().Add(-2*time.Hour))
+		otherJob := createMockJob("app2", sql.NullInt32{Int32: 180, Valid: true}, clock.Now().Add(-2*time.Hour))
+
+		nexpired, err := ExpireReleases(db, &clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nexpired).To(Equal(1))
+
+		release, err := dbmodels.FindRelease(db, org.ID, expiredJob.ApplicationID, expiredJob.ReleaseID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.Cancelled))
+
+		release, err = dbmodels.FindRelease(db, org.ID, otherJob.ApplicationID, otherJob.ReleaseID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.InProgress))
+	})
+
+	It("processes expired releases in batches", func() {
+		defer func(orig int) { expiredReleasesBatchSize = orig }(expiredReleasesBatchSize)
+		expiredReleasesBatchSize = 2
+
+		var jobs []dbmodels.ReleaseBackgroundJob
+		for i := 1; i <= 5; i++ {
+			jobs = append(jobs, createMockJob(fmt.Sprintf("app%d", i), sql.NullInt32{Int32: 60, Valid: true},
+				clock.Now().Add(-2*time.Hour)))
+		}
+
+		nexpired, err := ExpireReleases(db, &clock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nexpired).To(Equal(5))
+
+		for _, job := range jobs {
+			release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Cancelled))
+		}
+	})
+})
@@ -0,0 +1,188 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultWorkerConcurrency   = 10
+	DefaultWorkerPollInterval  = 5 * time.Second
+	DefaultWorkerSweepInterval = 5 * time.Minute
+
+	workerListenRetryDelay = 5 * time.Second
+)
+
+// Worker processes ReleaseBackgroundJobs whose scheduled run is due. Multiple Workers may run
+// concurrently, in multiple processes: jobs are claimed with `SELECT ... FOR UPDATE SKIP LOCKED`
+// (see dbmodels.ClaimDueReleaseBackgroundJobs), so each due job is processed by only one Worker.
+//
+// Besides polling, a Worker listens for notifications on
+// dbmodels.ReleaseBackgroundJobsNotificationChannel, so that newly scheduled jobs are claimed
+// by an idle Worker right away. As a safety net against jobs that were lost (e.g. because the
+// process that claimed them died), a Worker periodically schedules all jobs whose claim has
+// expired (see dbmodels.ScheduleAbandonedReleaseBackgroundJobs). During the same
+// sweep, it cancels Releases that remained in progress for too long (see ExpireReleases()).
+//
+// Unlike ProcessInBackground(), a Worker doesn't sleep until a deferred Release becomes eligible.
+// Instead, it schedules the job to run again at that time, so that it doesn't occupy a slot
+// in the meantime.
+type Worker struct {
+	Db *gorm.DB
+
+	// Concurrency is the maximum number of jobs that are processed concurrently.
+	Concurrency int
+
+	// PollInterval is how often the database is checked for due jobs while there are free slots.
+	PollInterval time.Duration
+
+	// SweepInterval is how often abandoned jobs are scheduled, and expired Releases are cancelled.
+	// The first sweep happens after one interval. If negative, then no sweeps are performed.
+	SweepInterval time.Duration
+
+	// Clock is used to determine the current time. If nil, then the real clock is used.
+	Clock mocking.IClock
+}
+
+// Run processes due jobs until `ctx` is done. In-flight jobs are then interrupted (see
+// Engine.Run()), and Run waits until they have stopped.
+func (worker Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, worker.concurrency())
+	wakeup := make(chan struct{}, 1)
+
+	pollInterval := worker.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultWorkerPollInterval
+	}
+	sweepInterval := worker.SweepInterval
+	if sweepInterval == 0 {
+		sweepInterval = DefaultWorkerSweepInterval
+	}
+
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		worker.listen(ctx, wakeup)
+	}()
+
+	var sweepTicker <-chan time.Time
+	if sweepInterval > 0 {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		sweepTicker = ticker.C
+	}
+
+	for {
+		worker.claimAndProcessJobs(ctx, slots, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		case <-wakeup:
+		case <-sweepTicker:
+			worker.sweep()
+		}
+	}
+}
+
+// listen sends to `wakeup` whenever a job is scheduled, until `ctx` is done.
+func (worker Worker) listen(ctx context.Context, wakeup chan<- struct{}) {
+	for {
+		err := dbutils.Listen(ctx, worker.Db, dbmodels.ReleaseBackgroundJobsNotificationChannel, func(payload string) {
+			select {
+			case wakeup <- struct{}{}:
+			default:
+				// A wakeup is already pending.
+			}
+		})
+		if err == nil {
+			return
+		}
+
+		worker.Db.Logger.Warn(context.Background(),
+			"Error listening for release background job notifications; will retry in %.0f seconds: %s",
+			workerListenRetryDelay.Seconds(), err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerListenRetryDelay):
+		}
+	}
+}
+
+func (worker Worker) sweep() {
+	n, err := dbmodels.ScheduleAbandonedReleaseBackgroundJobs(worker.Db, worker.clock().Now())
+	if err != nil {
+		worker.Db.Logger.Error(context.Background(), "Error scheduling abandoned release background jobs: %s", err.Error())
+	} else if n > 0 {
+		worker.Db.Logger.Info(context.Background(), "Scheduled %d abandoned release background jobs", n)
+	}
+
+	_, err = ExpireReleases(worker.Db, worker.clock())
+	if err != nil {
+		worker.Db.Logger.Error(context.Background(), "Error expiring releases: %s", err.Error())
+	}
+}
+
+func (worker Worker) claimAndProcessJobs(ctx context.Context, slots chan struct{}, wg *sync.WaitGroup) {
+	nfree := cap(slots) - len(slots)
+	if nfree <= 0 {
+		return
+	}
+
+	jobs, err := dbmodels.ClaimDueReleaseBackgroundJobs(worker.Db, worker.clock().Now(), nfree)
+	if err != nil {
+		worker.Db.Logger.Error(context.Background(), "Error claiming release background jobs: %s", err.Error())
+		return
+	}
+
+	for _, job := range jobs {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(job dbmodels.ReleaseBackgroundJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			worker.process(ctx, job)
+		}(job)
+	}
+}
+
+func (worker Worker) process(ctx context.Context, job dbmodels.ReleaseBackgroundJob) {
+	nextEligibleAt, deferred, err := processReleaseBackgroundJobOnce(ctx, worker.Db, job.OrganizationID, &job, worker.clock(), false)
+	if err != nil || !deferred {
+		// Failures and interruptions have already been logged and recorded.
+		return
+	}
+
+	worker.Db.Logger.Info(context.Background(), "Release %s is deferred until %s; scheduling it to be processed then",
+		job.Release.Description(), nextEligibleAt)
+	err = job.ScheduleRun(worker.Db, nextEligibleAt)
+	if err != nil {
+		worker.Db.Logger.Error(context.Background(), "Error scheduling release %s to be processed at %s: %s",
+			job.Release.Description(), nextEligibleAt, err.Error())
+	}
+}
+
+func (worker Worker) concurrency() int {
+	if worker.Concurrency <= 0 {
+		return DefaultWorkerConcurrency
+	}
+	return worker.Concurrency
+}
+
+func (worker Worker) clock() mocking.IClock {
+	if worker.Clock == nil {
+		return mocking.RealClock{}
+	}
+	return worker.Clock
+}
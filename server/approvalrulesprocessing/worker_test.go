@@ -0,0 +1,242 @@
+package approvalrulesprocessing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Worker", func() {
+	var db *gorm.DB
+	var org dbmodels.Organization
+	var clock mocking.FakeClock
+	var cancel context.CancelFunc
+	var done chan struct{}
+
+	BeforeEach(func() {
+		var err error
+
+		db, err = dbutils.SetupTestDatabase()
+		Expect(err).ToNot(HaveOccurred())
+
+		org, err = dbmodels.CreateMockOrganization(db, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Friday evening
+		clock = mocking.FakeClock{Value: time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)}
+	})
+
+	AfterEach(func() {
+		if cancel != nil {
+			cancel()
+			Eventually(done).Should(BeClosed())
+			cancel = nil
+		}
+	})
+
+	startWorkerWith := func(pollInterval time.Duration, sweepInterval time.Duration) {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		worker := Worker{Db: db, Concurrency: 2, PollInterval: pollInterval, SweepInterval: sweepInterval, Clock: &clock}
+		go func() {
+			defer close(done)
+			worker.Run(ctx)
+		}()
+	}
+
+	startWorker := func() {
+		startWorkerWith(10*time.Millisecond, -1)
+	}
+
+	var numMockJobs int
+
+	createMockJob := func(tx *gorm.DB, nextRunAt sql.NullTime) dbmodels.ReleaseBackgroundJob {
+		numMockJobs++
+		app, err := dbmodels.CreateMockApplicationWith1Version(tx, org, func(app *dbmodels.Application) {
+			app.ID = fmt.Sprintf("app%d", numMockJobs)
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		release, err := dbmodels.CreateMockReleaseWithInProgressState(tx, org, app, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		job, err := dbmodels.CreateMockReleaseBackgroundJob(tx, org, app, release, func(job *dbmodels.ReleaseBackgroundJob) {
+			job.LockSubID = uint32(release.ID)
+			job.NextRunAt = nextRunAt
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		return job
+	}
+
+	It("processes jobs whose scheduled run is due", func() {
+		var dueJob, unscheduledJob dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			dueJob = createMockJob(tx, sql.NullTime{Time: clock.Now(), Valid: true})
+			unscheduledJob = createMockJob(tx, sql.NullTime{})
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		startWorker()
+
+		Eventually(func() releasestate.State {
+			release, err := dbmodels.FindRelease(db, org.ID, dueJob.ApplicationID, dueJob.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			return release.State
+		}).Should(Equal(releasestate.Approved))
+
+		release, err := dbmodels.FindRelease(db, org.ID, unscheduledJob.ApplicationID, unscheduledJob.ReleaseID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.InProgress))
+	})
+
+	It("schedules deferred releases instead of waiting for them", func() {
+		var job dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			job = createMockJob(tx, sql.NullTime{Time: clock.Now(), Valid: true})
+
+			ruleset, err := dbmodels.CreateMockApprovalRulesetWith1Version(tx, org, "ruleset1", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = dbmodels.CreateMockReleaseRulesetBindingWithEnforcingMode(tx, org, job.Release,
+				ruleset, *ruleset.Version, *ruleset.Version.Adjustment, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = dbmodels.CreateMockScheduleApprovalRuleWholeDay(tx, org, ruleset.Version.ID,
+				*ruleset.Version.Adjustment, func(rule *dbmodels.ScheduleApprovalRule) {
+					rule.BeginTime = sql.NullString{String: "9:00", Valid: true}
+					rule.EndTime = sql.NullString{String: "16:30", Valid: true}
+					rule.DaysOfWeek = sql.NullString{String: "mon tue wed thu fri", Valid: true}
+					rule.TimeZone = sql.NullString{String: "UTC", Valid: true}
+					rule.DeferUntilWindow = true
+				})
+			Expect(err).ToNot(HaveOccurred())
+
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		startWorker()
+
+		Eventually(func() time.Time {
+			job, err := dbmodels.FindReleaseBackgroundJob(db, org.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			return job.NextRunAt.Time
+		}).Should(BeTemporally("==", time.Date(2021, time.March, 8, 9, 0, 0, 0, time.UTC)))
+		Expect(clock.Now()).To(Equal(time.Date(2021, time.March, 5, 17, 30, 0, 0, time.UTC)))
+	})
+
+	It("doesn't process jobs whose scheduled run isn't due yet", func() {
+		var job dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			job = createMockJob(tx, sql.NullTime{Time: clock.Now().Add(time.Minute), Valid: true})
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		startWorker()
+
+		Consistently(func() releasestate.State {
+			release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			return release.State
+		}, "100ms").Should(Equal(releasestate.InProgress))
+	})
+
+	It("doesn't process dead jobs", func() {
+		var job dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			job = createMockJob(tx, sql.NullTime{Time: clock.Now(), Valid: true})
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		err = job.RecordFailure(db, errors.New("fake error"), clock.Now(), nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		startWorker()
+
+		Consistently(func() releasestate.State {
+			release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			return release.State
+		}, "100ms").Should(Equal(releasestate.InProgress))
+	})
+
+	It("claims jobs as soon as they're created, without waiting for the next poll", func() {
+		startWorkerWith(time.Hour, -1)
+		// Give the worker some time to start listening for notifications.
+		time.Sleep(100 * time.Millisecond)
+
+		var job dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			app, err := dbmodels.CreateMockApplicationWith1Version(tx, org, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			release, err := dbmodels.CreateMockReleaseWithInProgressState(tx, org, app, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			job, err = dbmodels.CreateReleaseBackgroundJob(tx, org.ID, app.ID, release)
+			Expect(err).ToNot(HaveOccurred())
+
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() releasestate.State {
+			release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			return release.State
+		}).Should(Equal(releasestate.Approved))
+	})
+
+	It("periodically schedules jobs whose claim has expired", func() {
+		var job dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			job = createMockJob(tx, sql.NullTime{})
+			return job.ExtendClaim(tx, clock.Now().Add(-time.Minute))
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		startWorkerWith(10*time.Millisecond, 50*time.Millisecond)
+
+		Eventually(func() releasestate.State {
+			release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+			Expect(err).ToNot(HaveOccurred())
+			return release.State
+		}).Should(Equal(releasestate.Approved))
+	})
+
+	It("doesn't schedule jobs that are claimed, or whose release awaits further input", func() {
+		var claimedJob, awaitingJob dbmodels.ReleaseBackgroundJob
+		err := db.Transaction(func(tx *gorm.DB) error {
+			claimedJob = createMockJob(tx, sql.NullTime{})
+			awaitingJob = createMockJob(tx, sql.NullTime{})
+			return claimedJob.ExtendClaim(tx, clock.Now().Add(time.Minute))
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		startWorkerWith(10*time.Millisecond, 20*time.Millisecond)
+
+		Consistently(func() []releasestate.State {
+			var result []releasestate.State
+			for _, job := range []dbmodels.ReleaseBackgroundJob{claimedJob, awaitingJob} {
+				release, err := dbmodels.FindRelease(db, org.ID, job.ApplicationID, job.ReleaseID)
+				Expect(err).ToNot(HaveOccurred())
+				result = append(result, release.State)
+			}
+			return result
+		}, "100ms").Should(Equal([]releasestate.State{releasestate.InProgress, releasestate.InProgress}))
+	})
+})
@@ -0,0 +1,47 @@
+package authz
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
+
+const (
+	ActionCreateCalendar CollectionAction = "calendars/create"
+	ActionListCalendars  CollectionAction = "calendars/list"
+
+	ActionReadCalendar          SingularAction = "calendar/read"
+	ActionUpdateCalendar        SingularAction = "calendar/update"
+	ActionDeleteCalendar        SingularAction = "calendar/delete"
+	ActionImportCalendarEntries SingularAction = "calendar/import_entries"
+)
+
+type CalendarAuthorizer struct{}
+
+// CollectionAuthorizations returns which collection actions an OrganizationMember is
+// allowed to perform.
+func (CalendarAuthorizer) CollectionAuthorizations(orgMember dbmodels.IOrganizationMember) map[CollectionAction]struct{} {
+	result := make(map[CollectionAction]struct{})
+
+	result[ActionCreateCalendar] = struct{}{}
+	result[ActionListCalendars] = struct{}{}
+
+	return result
+}
+
+// SingularAuthorizations returns which actions an OrganizationMember is
+// allowed to perform, on a target Calendar.
+func (CalendarAuthorizer) SingularAuthorizations(orgMember dbmodels.IOrganizationMember,
+	target interface{}) map[SingularAction]struct{} {
+
+	result := make(map[SingularAction]struct{})
+
+	if orgMember.GetOrganizationID() != target.(dbmodels.Calendar).OrganizationID {
+		return result
+	}
+
+	result[ActionReadCalendar] = struct{}{}
+	result[ActionUpdateCalendar] = struct{}{}
+	result[ActionDeleteCalendar] = struct{}{}
+	result[ActionImportCalendarEntries] = struct{}{}
+
+	return result
+}
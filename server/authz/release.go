@@ -2,16 +2,32 @@ package authz
 
 import (
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/organizationmemberrole"
 )
 
 const (
 	ActionListReleases CollectionAction = "releases/list"
 
-	ActionReadRelease   SingularAction = "release/read"
-	ActionUpdateRelease SingularAction = "release/update"
-	ActionDeleteRelease SingularAction = "release/delete"
+	ActionReadRelease             SingularAction = "release/read"
+	ActionUpdateRelease           SingularAction = "release/update"
+	ActionDeleteRelease           SingularAction = "release/delete"
+	ActionManuallyApproveRelease  SingularAction = "release/manually_approve"
+	ActionRetryRelease            SingularAction = "release/retry"
+	ActionCancelRelease           SingularAction = "release/cancel"
+	ActionReportReleaseDeployment SingularAction = "release/report_deployment"
+	ActionOverrideRelease         SingularAction = "release/override"
+	ActionConfirmReleaseOverride  SingularAction = "release/confirm_override"
 )
 
+// ReleaseManualApproverRoles are the roles that are allowed to approve or reject a
+// Release through a manual approval rule. They're also allowed to override a Release's
+// approval rules (see dbmodels.OverrideRelease), and to confirm each other's overrides.
+var ReleaseManualApproverRoles = []organizationmemberrole.Role{
+	organizationmemberrole.OrgAdmin,
+	organizationmemberrole.Admin,
+	organizationmemberrole.ChangeManager,
+}
+
 type ReleaseAuthorizer struct{}
 
 // CollectionAuthorizations returns which collection actions an OrganizationMember is
@@ -38,6 +54,24 @@ func (ReleaseAuthorizer) SingularAuthorizations(orgMember dbmodels.IOrganization
 	result[ActionReadRelease] = struct{}{}
 	result[ActionUpdateRelease] = struct{}{}
 	result[ActionDeleteRelease] = struct{}{}
+	result[ActionRetryRelease] = struct{}{}
+	result[ActionCancelRelease] = struct{}{}
+	result[ActionReportReleaseDeployment] = struct{}{}
+	if IsReleaseManualApproverRole(orgMember.GetRole()) {
+		result[ActionManuallyApproveRelease] = struct{}{}
+		result[ActionOverrideRelease] = struct{}{}
+		result[ActionConfirmReleaseOverride] = struct{}{}
+	}
 
 	return result
 }
+
+// IsReleaseManualApproverRole returns whether the given role is one of ReleaseManualApproverRoles.
+func IsReleaseManualApproverRole(role organizationmemberrole.Role) bool {
+	for _, approverRole := range ReleaseManualApproverRoles {
+		if role == approverRole {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000010)
+}
+
+// HTTPApiApprovalRuleOutcome.ResponseCode used to be an uint8, which cannot
+// hold HTTP status codes. It is now an uint16 stored as an int.
+var migration20210310000010 = gormigrate.Migration{
+	ID: "20210310000010 HTTP API approval rule outcome response code",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE http_api_approval_rule_outcomes ALTER COLUMN response_code TYPE int").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE http_api_approval_rule_outcomes ALTER COLUMN response_code TYPE smallint").Error
+	},
+}
@@ -0,0 +1,30 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000020)
+}
+
+var migration20210310000020 = gormigrate.Migration{
+	ID: "20210310000020 Schedule approval rule time zone",
+	Migrate: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE organizations ADD COLUMN default_time_zone text").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("ALTER TABLE schedule_approval_rules ADD COLUMN time_zone text").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE schedule_approval_rules DROP COLUMN time_zone").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("ALTER TABLE organizations DROP COLUMN default_time_zone").Error
+	},
+}
@@ -0,0 +1,20 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000030)
+}
+
+var migration20210310000030 = gormigrate.Migration{
+	ID: "20210310000030 Schedule approval rule time ranges",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE schedule_approval_rules ADD COLUMN time_ranges text").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE schedule_approval_rules DROP COLUMN time_ranges").Error
+	},
+}
@@ -0,0 +1,98 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000040)
+}
+
+var migration20210310000040 = gormigrate.Migration{
+	ID: "20210310000040 Calendar",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type ReleaseRuleProcessedEvent struct {
+			BaseModel
+			ID uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ApprovalRulesetAdjustment struct {
+			BaseModel
+			ApprovalRulesetVersionID uint64 `gorm:"primaryKey; not null"`
+			AdjustmentNumber         uint32 `gorm:"type:int; primaryKey; not null; check:(adjustment_number > 0)"`
+		}
+
+		type Calendar struct {
+			BaseModel
+			ID          string `gorm:"type:citext; primaryKey; not null"`
+			DisplayName string `gorm:"not null"`
+			TimeZone    sql.NullString
+			CreatedAt   time.Time `gorm:"not null"`
+			UpdatedAt   time.Time `gorm:"not null"`
+		}
+
+		type CalendarEntry struct {
+			BaseModel
+			ID             uint64   `gorm:"primaryKey; autoIncrement; not null"`
+			CalendarID     string   `gorm:"type:citext; not null; index:calendar_entry_calendar_idx"`
+			Calendar       Calendar `gorm:"foreignKey:OrganizationID,CalendarID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+			UID            sql.NullString
+			Summary        string    `gorm:"not null"`
+			AllDay         bool      `gorm:"not null"`
+			BeginAt        time.Time `gorm:"not null"`
+			EndAt          time.Time `gorm:"not null; check:(end_at >= begin_at)"`
+			RecurrenceRule sql.NullString
+			CreatedAt      time.Time `gorm:"not null"`
+		}
+
+		type CalendarApprovalRule struct {
+			BaseModel
+			ID                              uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ApprovalRulesetVersionID        uint64                    `gorm:"not null"`
+			ApprovalRulesetAdjustmentNumber uint32                    `gorm:"type:int; not null; check:(approval_ruleset_adjustment_number >= 0)"`
+			ApprovalRulesetAdjustment       ApprovalRulesetAdjustment `gorm:"foreignKey:OrganizationID,ApprovalRulesetVersionID,ApprovalRulesetAdjustmentNumber; references:OrganizationID,ApprovalRulesetVersionID,AdjustmentNumber; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Enabled                         *bool                     `gorm:"not null; default:true"`
+			CreatedAt                       time.Time                 `gorm:"not null"`
+			CalendarID                      string                    `gorm:"type:citext; not null"`
+			Calendar                        Calendar                  `gorm:"foreignKey:OrganizationID,CalendarID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+		}
+
+		type CalendarApprovalRuleOutcome struct {
+			BaseModel
+			ID                          uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ReleaseRuleProcessedEventID uint64                    `gorm:"not null"`
+			ReleaseRuleProcessedEvent   ReleaseRuleProcessedEvent `gorm:"foreignKey:OrganizationID,ReleaseRuleProcessedEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Success                     bool                      `gorm:"not null"`
+			CreatedAt                   time.Time                 `gorm:"not null"`
+			CalendarApprovalRuleID      uint64                    `gorm:"not null"`
+			CalendarApprovalRule        CalendarApprovalRule      `gorm:"foreignKey:OrganizationID,CalendarApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			BlockingEntrySummary        sql.NullString
+		}
+
+		err := tx.AutoMigrate(&Calendar{}, &CalendarEntry{}, &CalendarApprovalRule{},
+			&CalendarApprovalRuleOutcome{})
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("CREATE INDEX calendar_approval_rules_version_idx ON calendar_approval_rules " +
+			"(organization_id, approval_ruleset_version_id, approval_ruleset_adjustment_number)").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("calendar_approval_rule_outcomes", "calendar_approval_rules",
+			"calendar_entries", "calendars")
+	},
+}
@@ -0,0 +1,28 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000050)
+}
+
+var migration20210310000050 = gormigrate.Migration{
+	ID: "20210310000050 Deferred schedule approval",
+	Migrate: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE schedule_approval_rules ADD COLUMN defer_until_window boolean NOT NULL DEFAULT false").Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE releases ADD COLUMN next_eligible_at timestamptz").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE releases DROP COLUMN next_eligible_at").Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE schedule_approval_rules DROP COLUMN defer_until_window").Error
+	},
+}
@@ -0,0 +1,84 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000070)
+}
+
+var migration20210310000070 = gormigrate.Migration{
+	ID: "20210310000070 Quota approval rules",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type ReleaseRuleProcessedEvent struct {
+			BaseModel
+			ID uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ApprovalRulesetAdjustment struct {
+			BaseModel
+			ApprovalRulesetVersionID uint64 `gorm:"primaryKey; not null"`
+			AdjustmentNumber         uint32 `gorm:"type:int; primaryKey; not null; check:(adjustment_number > 0)"`
+		}
+
+		type QuotaApprovalRule struct {
+			BaseModel
+			ID                              uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ApprovalRulesetVersionID        uint64                    `gorm:"not null"`
+			ApprovalRulesetAdjustmentNumber uint32                    `gorm:"type:int; not null; check:(approval_ruleset_adjustment_number >= 0)"`
+			ApprovalRulesetAdjustment       ApprovalRulesetAdjustment `gorm:"foreignKey:OrganizationID,ApprovalRulesetVersionID,ApprovalRulesetAdjustmentNumber; references:OrganizationID,ApprovalRulesetVersionID,AdjustmentNumber; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Enabled                         *bool                     `gorm:"not null; default:true"`
+			CreatedAt                       time.Time                 `gorm:"not null"`
+			MaxReleases                     int32                     `gorm:"type:int; not null; check:(max_releases > 0)"`
+			WindowHours                     int32                     `gorm:"type:int; not null; check:(window_hours > 0)"`
+		}
+
+		type QuotaApprovalRuleOutcome struct {
+			BaseModel
+			ID                          uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ReleaseRuleProcessedEventID uint64                    `gorm:"not null"`
+			ReleaseRuleProcessedEvent   ReleaseRuleProcessedEvent `gorm:"foreignKey:OrganizationID,ReleaseRuleProcessedEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Success                     bool                      `gorm:"not null"`
+			CreatedAt                   time.Time                 `gorm:"not null"`
+			QuotaApprovalRuleID         uint64                    `gorm:"not null"`
+			QuotaApprovalRule           QuotaApprovalRule         `gorm:"foreignKey:OrganizationID,QuotaApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			ApprovedCount               int32                     `gorm:"type:int; not null"`
+			QuotaFreesUpAt              sql.NullTime
+		}
+
+		err := tx.AutoMigrate(&QuotaApprovalRule{}, &QuotaApprovalRuleOutcome{})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("CREATE INDEX quota_approval_rules_version_idx ON quota_approval_rules " +
+			"(organization_id, approval_ruleset_version_id, approval_ruleset_adjustment_number)").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("CREATE INDEX releases_finalized_at_idx ON releases " +
+			"(organization_id, application_id, finalized_at) WHERE state = 'approved'").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		err := tx.Exec("DROP INDEX releases_finalized_at_idx").Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable("quota_approval_rule_outcomes", "quota_approval_rules")
+	},
+}
@@ -0,0 +1,72 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000080)
+}
+
+var migration20210310000080 = gormigrate.Migration{
+	ID: "20210310000080 Expression approval rules",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type ReleaseRuleProcessedEvent struct {
+			BaseModel
+			ID uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ApprovalRulesetAdjustment struct {
+			BaseModel
+			ApprovalRulesetVersionID uint64 `gorm:"primaryKey; not null"`
+			AdjustmentNumber         uint32 `gorm:"type:int; primaryKey; not null; check:(adjustment_number > 0)"`
+		}
+
+		type ExpressionApprovalRule struct {
+			BaseModel
+			ID                              uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ApprovalRulesetVersionID        uint64                    `gorm:"not null"`
+			ApprovalRulesetAdjustmentNumber uint32                    `gorm:"type:int; not null; check:(approval_ruleset_adjustment_number >= 0)"`
+			ApprovalRulesetAdjustment       ApprovalRulesetAdjustment `gorm:"foreignKey:OrganizationID,ApprovalRulesetVersionID,ApprovalRulesetAdjustmentNumber; references:OrganizationID,ApprovalRulesetVersionID,AdjustmentNumber; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Enabled                         *bool                     `gorm:"not null; default:true"`
+			CreatedAt                       time.Time                 `gorm:"not null"`
+			Expression                      string                    `gorm:"not null"`
+		}
+
+		type ExpressionApprovalRuleOutcome struct {
+			BaseModel
+			ID                          uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ReleaseRuleProcessedEventID uint64                    `gorm:"not null"`
+			ReleaseRuleProcessedEvent   ReleaseRuleProcessedEvent `gorm:"foreignKey:OrganizationID,ReleaseRuleProcessedEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Success                     bool                      `gorm:"not null"`
+			CreatedAt                   time.Time                 `gorm:"not null"`
+			ExpressionApprovalRuleID    uint64                    `gorm:"not null"`
+			ExpressionApprovalRule      ExpressionApprovalRule    `gorm:"foreignKey:OrganizationID,ExpressionApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			FailureMessage              sql.NullString
+		}
+
+		err := tx.AutoMigrate(&ExpressionApprovalRule{}, &ExpressionApprovalRuleOutcome{})
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("CREATE INDEX expression_approval_rules_version_idx ON expression_approval_rules " +
+			"(organization_id, approval_ruleset_version_id, approval_ruleset_adjustment_number)").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("expression_approval_rule_outcomes", "expression_approval_rules")
+	},
+}
@@ -0,0 +1,104 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000100)
+}
+
+var migration20210310000100 = gormigrate.Migration{
+	ID: "20210310000100 Callback approval rules",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type Release struct {
+			BaseModel
+			ApplicationID string `gorm:"type:citext; primaryKey; not null"`
+			ID            uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ReleaseRuleProcessedEvent struct {
+			BaseModel
+			ID uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ApprovalRulesetAdjustment struct {
+			BaseModel
+			ApprovalRulesetVersionID uint64 `gorm:"primaryKey; not null"`
+			AdjustmentNumber         uint32 `gorm:"type:int; primaryKey; not null; check:(adjustment_number > 0)"`
+		}
+
+		type CallbackApprovalRule struct {
+			BaseModel
+			ID                              uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ApprovalRulesetVersionID        uint64                    `gorm:"not null"`
+			ApprovalRulesetAdjustmentNumber uint32                    `gorm:"type:int; not null; check:(approval_ruleset_adjustment_number >= 0)"`
+			ApprovalRulesetAdjustment       ApprovalRulesetAdjustment `gorm:"foreignKey:OrganizationID,ApprovalRulesetVersionID,ApprovalRulesetAdjustmentNumber; references:OrganizationID,ApprovalRulesetVersionID,AdjustmentNumber; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Enabled                         *bool                     `gorm:"not null; default:true"`
+			CreatedAt                       time.Time                 `gorm:"not null"`
+			URL                             string                    `gorm:"not null"`
+			Username                        sql.NullString
+			Password                        sql.NullString
+			TLSCaCertificate                sql.NullString
+			TimeoutMinutes                  int32 `gorm:"type:int; not null; default:60; check:(timeout_minutes > 0)"`
+		}
+
+		type CallbackApprovalRuleOutcome struct {
+			BaseModel
+			ID                          uint64                    `gorm:"primaryKey; autoIncrement; not null"`
+			ReleaseRuleProcessedEventID uint64                    `gorm:"not null"`
+			ReleaseRuleProcessedEvent   ReleaseRuleProcessedEvent `gorm:"foreignKey:OrganizationID,ReleaseRuleProcessedEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Success                     bool                      `gorm:"not null"`
+			CreatedAt                   time.Time                 `gorm:"not null"`
+			CallbackApprovalRuleID      uint64                    `gorm:"not null"`
+			CallbackApprovalRule        CallbackApprovalRule      `gorm:"foreignKey:OrganizationID,CallbackApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			Message                     sql.NullString
+		}
+
+		type CallbackApprovalRuleRequest struct {
+			BaseModel
+			ID                     uint64               `gorm:"primaryKey; autoIncrement; not null"`
+			ApplicationID          string               `gorm:"type:citext; not null"`
+			ReleaseID              uint64               `gorm:"not null"`
+			Release                Release              `gorm:"foreignKey:OrganizationID,ApplicationID,ReleaseID; references:OrganizationID,ApplicationID,ID; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+			CallbackApprovalRuleID uint64               `gorm:"not null"`
+			CallbackApprovalRule   CallbackApprovalRule `gorm:"foreignKey:OrganizationID,CallbackApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			TokenHash              string               `gorm:"not null; unique"`
+			CreatedAt              time.Time            `gorm:"not null"`
+			ExpiresAt              time.Time            `gorm:"not null"`
+			CompletedAt            sql.NullTime
+			Success                sql.NullBool `gorm:"check:((completed_at IS NULL) = (success IS NULL))"`
+			Message                sql.NullString
+		}
+
+		err := tx.AutoMigrate(&CallbackApprovalRule{}, &CallbackApprovalRuleOutcome{}, &CallbackApprovalRuleRequest{})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("CREATE INDEX callback_approval_rules_version_idx ON callback_approval_rules " +
+			"(organization_id, approval_ruleset_version_id, approval_ruleset_adjustment_number)").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("CREATE UNIQUE INDEX callback_approval_rule_requests_release_rule_idx ON callback_approval_rule_requests " +
+			"(organization_id, release_id, callback_approval_rule_id)").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("callback_approval_rule_requests", "callback_approval_rule_outcomes", "callback_approval_rules")
+	},
+}
@@ -0,0 +1,44 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000110)
+}
+
+var approvalRuleTablesWithEvaluationOrder = []string{
+	"http_api_approval_rules",
+	"schedule_approval_rules",
+	"manual_approval_rules",
+	"calendar_approval_rules",
+	"dependency_approval_rules",
+	"quota_approval_rules",
+	"callback_approval_rules",
+	"promotion_approval_rules",
+	"expression_approval_rules",
+}
+
+var migration20210310000110 = gormigrate.Migration{
+	ID: "20210310000110 Approval rule evaluation order",
+	Migrate: func(tx *gorm.DB) error {
+		for _, table := range approvalRuleTablesWithEvaluationOrder {
+			err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN evaluation_order int NOT NULL DEFAULT 0").Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		for _, table := range approvalRuleTablesWithEvaluationOrder {
+			err := tx.Exec("ALTER TABLE " + table + " DROP COLUMN evaluation_order").Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,55 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000120)
+}
+
+var migration20210310000120 = gormigrate.Migration{
+	ID: "20210310000120 Release rule skipped event",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type Release struct {
+			BaseModel
+			ApplicationID string `gorm:"type:citext; primaryKey; not null"`
+			ID            uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ReleaseEvent struct {
+			BaseModel
+			ID            uint64    `gorm:"primaryKey; not null"`
+			ReleaseID     uint64    `gorm:"not null"`
+			ApplicationID string    `gorm:"type:citext; not null"`
+			Release       Release   `gorm:"foreignKey:OrganizationID,ApplicationID,ReleaseID; references:OrganizationID,ApplicationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			CreatedAt     time.Time `gorm:"not null"`
+		}
+
+		type ReleaseRuleSkippedEvent struct {
+			ReleaseEvent
+			ApprovalRulesetID sql.NullString `gorm:"type:citext"`
+			ApprovalRuleType  sql.NullString
+			ApprovalRuleID    sql.NullInt64
+			Reason            string `gorm:"not null"`
+		}
+
+		return tx.AutoMigrate(&ReleaseRuleSkippedEvent{})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable("release_rule_skipped_events")
+	},
+}
@@ -0,0 +1,28 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000130)
+}
+
+var migration20210310000130 = gormigrate.Migration{
+	ID: "20210310000130 Release background job retry state",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE release_background_jobs" +
+			" ADD COLUMN attempts int NOT NULL DEFAULT 0," +
+			" ADD COLUMN last_error text," +
+			" ADD COLUMN next_run_at timestamptz," +
+			" ADD COLUMN dead_at timestamptz").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE release_background_jobs" +
+			" DROP COLUMN attempts," +
+			" DROP COLUMN last_error," +
+			" DROP COLUMN next_run_at," +
+			" DROP COLUMN dead_at").Error
+	},
+}
@@ -0,0 +1,32 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000140)
+}
+
+var migration20210310000140 = gormigrate.Migration{
+	ID: "20210310000140 Release expiry",
+	Migrate: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE application_adjustments" +
+			" ADD COLUMN release_expiry_minutes int" +
+			" CONSTRAINT chk_application_adjustments_release_expiry_minutes CHECK (release_expiry_minutes > 0)").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("ALTER TABLE release_cancelled_events ADD COLUMN reason text").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE release_cancelled_events DROP COLUMN reason").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("ALTER TABLE application_adjustments DROP COLUMN release_expiry_minutes").Error
+	},
+}
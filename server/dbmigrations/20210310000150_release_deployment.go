@@ -0,0 +1,133 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000150)
+}
+
+var migration20210310000150 = gormigrate.Migration{
+	ID: "20210310000150 Release deployment",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type Release struct {
+			BaseModel
+			ApplicationID string `gorm:"type:citext; primaryKey; not null"`
+			ID            uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ReleaseEvent struct {
+			BaseModel
+			ID            uint64    `gorm:"primaryKey; not null"`
+			ReleaseID     uint64    `gorm:"not null"`
+			ApplicationID string    `gorm:"type:citext; not null"`
+			Release       Release   `gorm:"foreignKey:OrganizationID,ApplicationID,ReleaseID; references:OrganizationID,ApplicationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			CreatedAt     time.Time `gorm:"not null"`
+		}
+
+		type ReleaseDeploymentEvent struct {
+			ReleaseEvent
+			State    string `gorm:"type:deployment_state; not null"`
+			Comments sql.NullString
+		}
+
+		err := tx.Exec("CREATE TYPE deployment_state AS ENUM " +
+			"('deploying', 'deployed', 'deploy_failed', 'rolled_back')").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("ALTER TABLE releases ADD COLUMN deployment_state deployment_state").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.AutoMigrate(&ReleaseDeploymentEvent{})
+		if err != nil {
+			return err
+		}
+
+		// Allow ReleaseDeploymentEvents to be the subject of a CreationAuditRecord.
+		err = tx.Exec("ALTER TABLE creation_audit_records" +
+			" ADD COLUMN release_deployment_event_id bigint," +
+			" ADD CONSTRAINT fk_creation_audit_records_release_deployment_event" +
+			" FOREIGN KEY (organization_id, release_deployment_event_id)" +
+			" REFERENCES release_deployment_events (organization_id, id)" +
+			" ON UPDATE CASCADE ON DELETE RESTRICT").Error
+		if err != nil {
+			return err
+		}
+		return replaceCreationAuditRecordSubjectCheck(tx, []string{
+			"application_adjustment_number",
+			"approval_ruleset_adjustment_number",
+			"application_approval_ruleset_binding_adjustment_number",
+			"manual_approval_rule_outcome_id",
+			"release_created_event_id",
+			"release_cancelled_event_id",
+			"release_deployment_event_id",
+		})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		err := tx.Exec("DELETE FROM creation_audit_records WHERE release_deployment_event_id IS NOT NULL").Error
+		if err != nil {
+			return err
+		}
+		err = replaceCreationAuditRecordSubjectCheck(tx, []string{
+			"application_adjustment_number",
+			"approval_ruleset_adjustment_number",
+			"application_approval_ruleset_binding_adjustment_number",
+			"manual_approval_rule_outcome_id",
+			"release_created_event_id",
+			"release_cancelled_event_id",
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("ALTER TABLE creation_audit_records DROP COLUMN release_deployment_event_id").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Migrator().DropTable("release_deployment_events")
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("ALTER TABLE releases DROP COLUMN deployment_state").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("DROP TYPE deployment_state").Error
+	},
+}
+
+// replaceCreationAuditRecordSubjectCheck replaces the check constraint which ensures that a
+// CreationAuditRecord has exactly one subject, given the columns that identify a subject.
+func replaceCreationAuditRecordSubjectCheck(tx *gorm.DB, subjectColumns []string) error {
+	var sum string
+	for i, column := range subjectColumns {
+		if i > 0 {
+			sum += " + "
+		}
+		sum += "(CASE WHEN " + column + " IS NULL THEN 0 ELSE 1 END)"
+	}
+
+	return tx.Exec("ALTER TABLE creation_audit_records" +
+		" DROP CONSTRAINT chk_creation_audit_records_application_version_id," +
+		" ADD CONSTRAINT chk_creation_audit_records_application_version_id CHECK ((" + sum + ") = 1)").Error
+}
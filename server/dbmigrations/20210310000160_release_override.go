@@ -0,0 +1,135 @@
+package dbmigrations
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000160)
+}
+
+var migration20210310000160 = gormigrate.Migration{
+	ID: "20210310000160 Release override",
+	Migrate: func(tx *gorm.DB) error {
+		type Organization struct {
+			ID string `gorm:"type:citext; primaryKey; not null"`
+		}
+
+		type BaseModel struct {
+			OrganizationID string       `gorm:"type:citext; primaryKey; not null"`
+			Organization   Organization `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+		}
+
+		type Release struct {
+			BaseModel
+			ApplicationID string `gorm:"type:citext; primaryKey; not null"`
+			ID            uint64 `gorm:"primaryKey; not null"`
+		}
+
+		type ReleaseEvent struct {
+			BaseModel
+			ID            uint64    `gorm:"primaryKey; not null"`
+			ReleaseID     uint64    `gorm:"not null"`
+			ApplicationID string    `gorm:"type:citext; not null"`
+			Release       Release   `gorm:"foreignKey:OrganizationID,ApplicationID,ReleaseID; references:OrganizationID,ApplicationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+			CreatedAt     time.Time `gorm:"not null"`
+		}
+
+		type ReleaseOverriddenEvent struct {
+			ReleaseEvent
+			Justification        string `gorm:"not null"`
+			ConfirmationDeadline sql.NullTime
+		}
+
+		type ReleaseOverrideConfirmedEvent struct {
+			ReleaseEvent
+			ReleaseOverriddenEventID uint64                 `gorm:"not null"`
+			ReleaseOverriddenEvent   ReleaseOverriddenEvent `gorm:"foreignKey:OrganizationID,ReleaseOverriddenEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+		}
+
+		err := tx.Exec("ALTER TABLE releases ADD COLUMN overridden boolean NOT NULL DEFAULT false").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("ALTER TABLE application_adjustments" +
+			" ADD COLUMN override_confirmation_minutes int" +
+			" CONSTRAINT chk_application_adjustments_override_confirmation_minutes CHECK (override_confirmation_minutes > 0)").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.AutoMigrate(&ReleaseOverriddenEvent{}, &ReleaseOverrideConfirmedEvent{})
+		if err != nil {
+			return err
+		}
+
+		// Allow both event types to be the subject of a CreationAuditRecord.
+		err = tx.Exec("ALTER TABLE creation_audit_records" +
+			" ADD COLUMN release_overridden_event_id bigint," +
+			" ADD COLUMN release_override_confirmed_event_id bigint," +
+			" ADD CONSTRAINT fk_creation_audit_records_release_overridden_event" +
+			" FOREIGN KEY (organization_id, release_overridden_event_id)" +
+			" REFERENCES release_overridden_events (organization_id, id)" +
+			" ON UPDATE CASCADE ON DELETE RESTRICT," +
+			" ADD CONSTRAINT fk_creation_audit_records_release_override_confirmed_event" +
+			" FOREIGN KEY (organization_id, release_override_confirmed_event_id)" +
+			" REFERENCES release_override_confirmed_events (organization_id, id)" +
+			" ON UPDATE CASCADE ON DELETE RESTRICT").Error
+		if err != nil {
+			return err
+		}
+		return replaceCreationAuditRecordSubjectCheck(tx, []string{
+			"application_adjustment_number",
+			"approval_ruleset_adjustment_number",
+			"application_approval_ruleset_binding_adjustment_number",
+			"manual_approval_rule_outcome_id",
+			"release_created_event_id",
+			"release_cancelled_event_id",
+			"release_deployment_event_id",
+			"release_overridden_event_id",
+			"release_override_confirmed_event_id",
+		})
+	},
+	Rollback: func(tx *gorm.DB) error {
+		err := tx.Exec("DELETE FROM creation_audit_records" +
+			" WHERE release_overridden_event_id IS NOT NULL OR release_override_confirmed_event_id IS NOT NULL").Error
+		if err != nil {
+			return err
+		}
+		err = replaceCreationAuditRecordSubjectCheck(tx, []string{
+			"application_adjustment_number",
+			"approval_ruleset_adjustment_number",
+			"application_approval_ruleset_binding_adjustment_number",
+			"manual_approval_rule_outcome_id",
+			"release_created_event_id",
+			"release_cancelled_event_id",
+			"release_deployment_event_id",
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("ALTER TABLE creation_audit_records" +
+			" DROP COLUMN release_override_confirmed_event_id," +
+			" DROP COLUMN release_overridden_event_id").Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Migrator().DropTable("release_override_confirmed_events", "release_overridden_events")
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("ALTER TABLE application_adjustments DROP COLUMN override_confirmation_minutes").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("ALTER TABLE releases DROP COLUMN overridden").Error
+	},
+}
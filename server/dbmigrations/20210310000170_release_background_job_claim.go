@@ -0,0 +1,28 @@
+package dbmigrations
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbutils/gormigrate"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDbMigration(&migration20210310000170)
+}
+
+var migration20210310000170 = gormigrate.Migration{
+	ID: "20210310000170 Release background job claim",
+	Migrate: func(tx *gorm.DB) error {
+		err := tx.Exec("ALTER TABLE release_background_jobs ADD COLUMN claimed_until timestamptz").Error
+		if err != nil {
+			return err
+		}
+
+		// We can't tell which of the existing unscheduled jobs are still being processed, and which
+		// were abandoned. So treat them all as abandoned: they're rescheduled during the next sweep.
+		return tx.Exec("UPDATE release_background_jobs SET claimed_until = NOW()" +
+			" WHERE dead_at IS NULL AND next_run_at IS NULL").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE release_background_jobs DROP COLUMN claimed_until").Error
+	},
+}
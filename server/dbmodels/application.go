@@ -1,6 +1,7 @@
 package dbmodels
 
 import (
+	"database/sql"
 	"reflect"
 
 	"github.com/fullstaq-labs/sqedule/lib"
@@ -38,6 +39,14 @@ type ApplicationAdjustment struct {
 
 	DisplayName string `gorm:"not null"`
 
+	// ReleaseExpiryMinutes, if not null, is the maximum number of minutes that a Release
+	// may remain in progress. After that, the Release is cancelled automatically.
+	ReleaseExpiryMinutes sql.NullInt32 `gorm:"check:(release_expiry_minutes > 0)"`
+
+	// OverrideConfirmationMinutes, if not null, means that overriding a Release's approval rules
+	// must be confirmed by a second OrganizationMember within this many minutes.
+	OverrideConfirmationMinutes sql.NullInt32 `gorm:"check:(override_confirmation_minutes > 0)"`
+
 	ApplicationVersion ApplicationVersion `gorm:"foreignKey:OrganizationID,ApplicationVersionID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 }
 
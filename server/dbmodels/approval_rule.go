@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/fullstaq-labs/sqedule/lib"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalpolicy"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/retrypolicy"
@@ -17,15 +18,22 @@ import (
 type ApprovalRuleType string
 
 const (
-	HTTPApiApprovalRuleType  ApprovalRuleType = "http_api"
-	ScheduleApprovalRuleType ApprovalRuleType = "schedule"
-	ManualApprovalRuleType   ApprovalRuleType = "manual"
-
-	NumApprovalRuleTypes uint = 3
+	HTTPApiApprovalRuleType    ApprovalRuleType = "http_api"
+	ScheduleApprovalRuleType   ApprovalRuleType = "schedule"
+	ManualApprovalRuleType     ApprovalRuleType = "manual"
+	CalendarApprovalRuleType   ApprovalRuleType = "calendar"
+	DependencyApprovalRuleType ApprovalRuleType = "dependency"
+	QuotaApprovalRuleType      ApprovalRuleType = "quota"
+	CallbackApprovalRuleType   ApprovalRuleType = "callback"
+	PromotionApprovalRuleType  ApprovalRuleType = "promotion"
+	ExpressionApprovalRuleType ApprovalRuleType = "expression"
 )
 
 type IApprovalRule interface {
 	Type() ApprovalRuleType
+	BaseApprovalRule() ApprovalRule
+	ApprovalRulesetVersionAndAdjustmentKey() ApprovalRulesetVersionAndAdjustmentKey
+	DisabledReason() string
 	ClearPrimaryKey()
 	AssociateWithApprovalRulesetAdjustment(adjustment ApprovalRulesetAdjustment)
 }
@@ -39,10 +47,20 @@ type ApprovalRule struct {
 	Enabled                         *bool                     `gorm:"not null; default:true"`
 	CreatedAt                       time.Time                 `gorm:"not null"`
 
+	// EvaluationOrder determines when this rule is evaluated, relative to the other rules that
+	// a Release is subject to. Rules with a lower EvaluationOrder are evaluated first. Rules with
+	// the same EvaluationOrder are independent of each other, and may be evaluated concurrently.
+	EvaluationOrder int32 `gorm:"type:int; not null; default:0"`
+
 	// BindingMode is the mode with which the containing Ruleset is bound to some entity.
 	// This is only set by `FindApprovalRulesBoundToRelease()`. It's not a real table
 	// column, so don't add to database migrations.
 	BindingMode approvalrulesetbindingmode.Mode `gorm:"<-:false"`
+
+	// RulesetEnabled is whether the ApprovalRulesetAdjustment that contains this rule is enabled.
+	// Like BindingMode, this is only set by `FindApprovalRulesBoundToRelease()` and is not a
+	// real table column.
+	RulesetEnabled *bool `gorm:"<-:false"`
 }
 
 type HTTPApiApprovalRule struct {
@@ -62,6 +80,19 @@ type ScheduleApprovalRule struct {
 	DaysOfWeek   sql.NullString
 	DaysOfMonth  sql.NullString
 	MonthsOfYear sql.NullString
+
+	// TimeRanges is a space-separated list of `HH:MM[:SS]-HH:MM[:SS]` ranges, in addition
+	// to BeginTime-EndTime. A range whose end lies before its begin crosses midnight.
+	TimeRanges sql.NullString
+
+	// TimeZone is the IANA time zone name in which the above fields are interpreted.
+	// If null, then Organization.DefaultTimeZone is used.
+	TimeZone sql.NullString
+
+	// DeferUntilWindow specifies what happens with a Release that's created outside the
+	// schedule. If false, then the rule fails. If true, then the rule stays pending until
+	// the next time window begins, at which point the rule succeeds.
+	DeferUntilWindow bool `gorm:"not null; default:false"`
 }
 
 type ManualApprovalRule struct {
@@ -70,10 +101,82 @@ type ManualApprovalRule struct {
 	Minimum        sql.NullInt32         `gorm:"check:((approval_policy = 'minimum') = (minimum IS NOT NULL))"`
 }
 
+// CalendarApprovalRule rejects a Release whose time falls inside one of the entries
+// of the referenced Calendar.
+type CalendarApprovalRule struct {
+	ApprovalRule
+	CalendarID string   `gorm:"type:citext; not null"`
+	Calendar   Calendar `gorm:"foreignKey:OrganizationID,CalendarID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+}
+
+// DependencyApprovalRule requires that another Application has an approved Release
+// which meets the rule's conditions.
+type DependencyApprovalRule struct {
+	ApprovalRule
+	DependencyApplicationID string      `gorm:"type:citext; not null"`
+	DependencyApplication   Application `gorm:"foreignKey:OrganizationID,DependencyApplicationID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// MaxAgeHours, if not null, requires that the dependency's Release was created at
+	// most this many hours before the Release being evaluated.
+	MaxAgeHours sql.NullInt32 `gorm:"check:(max_age_hours > 0)"`
+
+	// SameSourceIdentity requires that the dependency's Release has the same
+	// SourceIdentity as the Release being evaluated.
+	SameSourceIdentity bool `gorm:"not null; default:false"`
+}
+
+// QuotaApprovalRule limits how many Releases of an Application may be approved
+// within a rolling time window.
+type QuotaApprovalRule struct {
+	ApprovalRule
+	MaxReleases int32 `gorm:"type:int; not null; check:(max_releases > 0)"`
+	WindowHours int32 `gorm:"type:int; not null; check:(window_hours > 0)"`
+}
+
+// CallbackApprovalRule sends a job to an external system, which reports the result
+// asynchronously by calling back with a one-time token. This is meant for long-running
+// checks, such as security scans. See CallbackApprovalRuleRequest.
+type CallbackApprovalRule struct {
+	ApprovalRule
+	URL              string `gorm:"not null"`
+	Username         sql.NullString
+	Password         sql.NullString
+	TLSCaCertificate sql.NullString
+
+	// TimeoutMinutes is how long to wait for the callback. If the external
+	// system doesn't call back in time, then the rule fails.
+	TimeoutMinutes int32 `gorm:"type:int; not null; default:60; check:(timeout_minutes > 0)"`
+}
+
+// ExpressionApprovalRule evaluates a boolean expression over the Release's metadata,
+// source identity and comments. See the `expression` package for the expression language.
+type ExpressionApprovalRule struct {
+	ApprovalRule
+	Expression string `gorm:"not null"`
+}
+
+// PromotionApprovalRule requires that the Release's SourceIdentity was approved for another
+// Application (the source Application, e.g. a staging environment) some time earlier, and
+// that it wasn't rejected there afterwards.
+type PromotionApprovalRule struct {
+	ApprovalRule
+	SourceApplicationID string      `gorm:"type:citext; not null"`
+	SourceApplication   Application `gorm:"foreignKey:OrganizationID,SourceApplicationID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// MinSoakHours is the minimum number of hours that must have passed since
+	// the source Application's Release was approved.
+	MinSoakHours int32 `gorm:"type:int; not null; default:0; check:(min_soak_hours >= 0)"`
+}
+
 //
 // ******** ApprovalRule methods ********
 //
 
+// BaseApprovalRule returns the fields that all approval rule types have in common.
+func (r ApprovalRule) BaseApprovalRule() ApprovalRule {
+	return r
+}
+
 func (r ApprovalRule) ApprovalRulesetVersionAndAdjustmentKey() ApprovalRulesetVersionAndAdjustmentKey {
 	return ApprovalRulesetVersionAndAdjustmentKey{
 		VersionID:        r.ApprovalRulesetVersionID,
@@ -104,17 +207,59 @@ func (r ManualApprovalRule) Type() ApprovalRuleType {
 	return ManualApprovalRuleType
 }
 
+func (r CalendarApprovalRule) Type() ApprovalRuleType {
+	return CalendarApprovalRuleType
+}
+
+func (r DependencyApprovalRule) Type() ApprovalRuleType {
+	return DependencyApprovalRuleType
+}
+
+func (r QuotaApprovalRule) Type() ApprovalRuleType {
+	return QuotaApprovalRuleType
+}
+
+func (r CallbackApprovalRule) Type() ApprovalRuleType {
+	return CallbackApprovalRuleType
+}
+
+func (r PromotionApprovalRule) Type() ApprovalRuleType {
+	return PromotionApprovalRuleType
+}
+
+func (r ExpressionApprovalRule) Type() ApprovalRuleType {
+	return ExpressionApprovalRuleType
+}
+
+// IsEnabled returns whether this rule itself is enabled. It doesn't look at whether the
+// containing ruleset is enabled: see DisabledReason() for that.
+func (r ApprovalRule) IsEnabled() bool {
+	return lib.DerefBoolPtrWithDefault(r.Enabled, true)
+}
+
+// DisabledReason returns why this rule must not be evaluated, or the empty string if it
+// must be evaluated. The ruleset is only taken into account if this rule was found by
+// `FindApprovalRulesBoundToRelease()`.
+func (r ApprovalRule) DisabledReason() string {
+	if !r.IsEnabled() {
+		return "Rule is disabled"
+	}
+	if !lib.DerefBoolPtrWithDefault(r.RulesetEnabled, true) {
+		return "Approval ruleset is disabled"
+	}
+	return ""
+}
+
 //
 // ******** Find/load functions ********
 //
 
 // FindApprovalRulesBoundToRelease finds all ApprovalRules that are bound to a specific Release.
 // It populates the `BindingMode` field so that you know which ApprovalRules are bound
-// to the Release through which mode.
+// to the Release through which mode, and the `RulesetEnabled` field so that you know
+// whether the containing ruleset is enabled.
 func FindApprovalRulesBoundToRelease(db *gorm.DB, organizationID string, applicationID string, releaseID uint64) (ApprovalRulesetContents, error) {
 	var result ApprovalRulesetContents
-	var tx *gorm.DB
-	var ruleTypesProcessed uint = 0
 	var bindingsCondition = db.Where("approval_rules.organization_id = ? "+
 		"AND release_approval_ruleset_bindings.application_id = ? "+
 		"AND release_approval_ruleset_bindings.release_id = ?",
@@ -123,40 +268,25 @@ func FindApprovalRulesBoundToRelease(db *gorm.DB, organizationID string, applica
 		"ON approval_rules.organization_id = release_approval_ruleset_bindings.organization_id " +
 		"AND approval_rules.approval_ruleset_version_id = release_approval_ruleset_bindings.approval_ruleset_version_id " +
 		"AND approval_rules.approval_ruleset_adjustment_number = release_approval_ruleset_bindings.approval_ruleset_adjustment_number"
-	const selector = "approval_rules.*, release_approval_ruleset_bindings.mode AS binding_mode"
-
-	ruleTypesProcessed++
-	tx = db.Where(bindingsCondition).
-		Joins(joinConditionString).
-		Table("http_api_approval_rules approval_rules").
-		Select(selector).
-		Find(&result.HTTPApiApprovalRules)
-	if tx.Error != nil {
-		return ApprovalRulesetContents{}, tx.Error
-	}
-
-	ruleTypesProcessed++
-	tx = db.Where(bindingsCondition).
-		Joins(joinConditionString).
-		Table("schedule_approval_rules approval_rules").
-		Select(selector).
-		Find(&result.ScheduleApprovalRules)
-	if tx.Error != nil {
-		return ApprovalRulesetContents{}, tx.Error
-	}
-
-	ruleTypesProcessed++
-	tx = db.Where(bindingsCondition).
-		Joins(joinConditionString).
-		Table("manual_approval_rules approval_rules").
-		Select(selector).
-		Find(&result.ManualApprovalRules)
-	if tx.Error != nil {
-		return ApprovalRulesetContents{}, tx.Error
-	}
-
-	if ruleTypesProcessed != NumApprovalRuleTypes {
-		panic("Bug: code does not cover all approval rule types")
+	const rulesetJoinConditionString = "LEFT JOIN approval_ruleset_adjustments " +
+		"ON approval_rules.organization_id = approval_ruleset_adjustments.organization_id " +
+		"AND approval_rules.approval_ruleset_version_id = approval_ruleset_adjustments.approval_ruleset_version_id " +
+		"AND approval_rules.approval_ruleset_adjustment_number = approval_ruleset_adjustments.adjustment_number"
+	const selector = "approval_rules.*, release_approval_ruleset_bindings.mode AS binding_mode, " +
+		"approval_ruleset_adjustments.enabled AS ruleset_enabled"
+
+	for _, definition := range ApprovalRuleTypeDefinitions() {
+		rules, err := definition.FindRules(db.Where(bindingsCondition).
+			Joins(joinConditionString).
+			Joins(rulesetJoinConditionString).
+			Table(definition.TableName() + " approval_rules").
+			Select(selector))
+		if err != nil {
+			return ApprovalRulesetContents{}, err
+		}
+		for _, rule := range rules {
+			result.Rules = append(result.Rules, rule)
+		}
 	}
 
 	return result, nil
@@ -167,30 +297,13 @@ func FindApprovalRulesBoundToRelease(db *gorm.DB, organizationID string, applica
 //
 
 func DeleteApprovalRulesForApprovalRulesetProposal(db *gorm.DB, organizationID string, proposalID uint64) error {
-	var ruleTypesProcessed uint = 0
 	var conditions = db.Where("organization_id = ? AND approval_ruleset_version_id = ?", organizationID, proposalID)
-	var err error
-
-	ruleTypesProcessed++
-	err = db.Where(conditions).Delete(HTTPApiApprovalRule{}).Error
-	if err != nil {
-		return err
-	}
-
-	ruleTypesProcessed++
-	err = db.Where(conditions).Delete(ScheduleApprovalRule{}).Error
-	if err != nil {
-		return err
-	}
-
-	ruleTypesProcessed++
-	err = db.Where(conditions).Delete(ManualApprovalRule{}).Error
-	if err != nil {
-		return err
-	}
 
-	if ruleTypesProcessed != NumApprovalRuleTypes {
-		panic("Bug: code does not cover all approval rule types")
+	for _, definition := range ApprovalRuleTypeDefinitions() {
+		err := db.Where(conditions).Delete(definition.NewRule()).Error
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
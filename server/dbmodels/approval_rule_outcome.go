@@ -14,9 +14,15 @@ import (
 type ApprovalRuleOutcomeType string
 
 const (
-	HTTPApiApprovalRuleOutcomeType  ApprovalRuleOutcomeType = "http_api"
-	ScheduleApprovalRuleOutcomeType ApprovalRuleOutcomeType = "schedule"
-	ManualApprovalRuleOutcomeType   ApprovalRuleOutcomeType = "manual"
+	HTTPApiApprovalRuleOutcomeType    ApprovalRuleOutcomeType = "http_api"
+	ScheduleApprovalRuleOutcomeType   ApprovalRuleOutcomeType = "schedule"
+	ManualApprovalRuleOutcomeType     ApprovalRuleOutcomeType = "manual"
+	CalendarApprovalRuleOutcomeType   ApprovalRuleOutcomeType = "calendar"
+	DependencyApprovalRuleOutcomeType ApprovalRuleOutcomeType = "dependency"
+	QuotaApprovalRuleOutcomeType      ApprovalRuleOutcomeType = "quota"
+	CallbackApprovalRuleOutcomeType   ApprovalRuleOutcomeType = "callback"
+	PromotionApprovalRuleOutcomeType  ApprovalRuleOutcomeType = "promotion"
+	ExpressionApprovalRuleOutcomeType ApprovalRuleOutcomeType = "expression"
 )
 
 type ApprovalRuleOutcome struct {
@@ -32,7 +38,7 @@ type HTTPApiApprovalRuleOutcome struct {
 	ApprovalRuleOutcome
 	HTTPApiApprovalRuleID uint64              `gorm:"not null"`
 	HTTPApiApprovalRule   HTTPApiApprovalRule `gorm:"foreignKey:OrganizationID,HTTPApiApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
-	ResponseCode          uint8               `gorm:"not null"`
+	ResponseCode          uint16              `gorm:"type:int; not null"`
 	ResponseContentType   string              `gorm:"not null"`
 	ResponseBody          []byte              `gorm:"not null"`
 }
@@ -50,10 +56,87 @@ type ManualApprovalRuleOutcome struct {
 	Comments             sql.NullString
 }
 
+type CalendarApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+	CalendarApprovalRuleID uint64               `gorm:"not null"`
+	CalendarApprovalRule   CalendarApprovalRule `gorm:"foreignKey:OrganizationID,CalendarApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// BlockingEntrySummary is the summary of the CalendarEntry that caused the rule to fail.
+	// It's a copy instead of a reference, because entries are replaced when a Calendar is re-imported.
+	BlockingEntrySummary sql.NullString
+}
+
+type DependencyApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+	DependencyApprovalRuleID uint64                 `gorm:"not null"`
+	DependencyApprovalRule   DependencyApprovalRule `gorm:"foreignKey:OrganizationID,DependencyApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// DependencyReleaseID is the ID of the dependency Application's Release that satisfied
+	// the rule. It's null if the rule failed.
+	DependencyReleaseID sql.NullInt64
+}
+
+type QuotaApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+	QuotaApprovalRuleID uint64            `gorm:"not null"`
+	QuotaApprovalRule   QuotaApprovalRule `gorm:"foreignKey:OrganizationID,QuotaApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// ApprovedCount is the number of the Application's Releases that were approved
+	// within the rule's window, at the time the rule was processed.
+	ApprovedCount int32 `gorm:"type:int; not null"`
+
+	// QuotaFreesUpAt is the time at which a new Release may be approved again.
+	// It's null if the rule succeeded.
+	QuotaFreesUpAt sql.NullTime
+}
+
+type CallbackApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+	CallbackApprovalRuleID uint64               `gorm:"not null"`
+	CallbackApprovalRule   CallbackApprovalRule `gorm:"foreignKey:OrganizationID,CallbackApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// Message is the message that the external system passed in its callback, or an
+	// explanation of why no callback was received. It's null if there's no message.
+	Message sql.NullString
+}
+
+type ExpressionApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+	ExpressionApprovalRuleID uint64                 `gorm:"not null"`
+	ExpressionApprovalRule   ExpressionApprovalRule `gorm:"foreignKey:OrganizationID,ExpressionApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// FailureMessage explains why the rule failed: either because the expression evaluated
+	// to false, or because evaluating it resulted in an error. It's null if the rule succeeded.
+	FailureMessage sql.NullString
+}
+
+type PromotionApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+	PromotionApprovalRuleID uint64                `gorm:"not null"`
+	PromotionApprovalRule   PromotionApprovalRule `gorm:"foreignKey:OrganizationID,PromotionApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// SourceReleaseID is the ID of the source Application's Release that satisfied
+	// the rule. It's null if the rule failed.
+	SourceReleaseID sql.NullInt64
+}
+
 //
 // ******** Find/load functions ********
 //
 
+func FindHTTPApiApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]HTTPApiApprovalRuleOutcome, error) {
+	var result []HTTPApiApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = http_api_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = http_api_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("http_api_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
 func FindScheduleApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]ScheduleApprovalRuleOutcome, error) {
 	var result []ScheduleApprovalRuleOutcome
 
@@ -66,3 +149,122 @@ func FindScheduleApprovalRuleOutcomes(db *gorm.DB, organizationID string, releas
 	tx = tx.Find(&result)
 	return result, tx.Error
 }
+
+func FindCalendarApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]CalendarApprovalRuleOutcome, error) {
+	var result []CalendarApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = calendar_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = calendar_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("calendar_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+func FindDependencyApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]DependencyApprovalRuleOutcome, error) {
+	var result []DependencyApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = dependency_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = dependency_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("dependency_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+func FindQuotaApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]QuotaApprovalRuleOutcome, error) {
+	var result []QuotaApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = quota_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = quota_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("quota_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+func FindCallbackApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]CallbackApprovalRuleOutcome, error) {
+	var result []CallbackApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = callback_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = callback_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("callback_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+func FindPromotionApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]PromotionApprovalRuleOutcome, error) {
+	var result []PromotionApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = promotion_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = promotion_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("promotion_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+func FindExpressionApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]ExpressionApprovalRuleOutcome, error) {
+	var result []ExpressionApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = expression_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = expression_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("expression_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID)
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+// FindManualApprovalRuleOutcomes returns all ManualApprovalRuleOutcomes for the given Release,
+// ordered from oldest to newest.
+func FindManualApprovalRuleOutcomes(db *gorm.DB, organizationID string, releaseID uint64) ([]ManualApprovalRuleOutcome, error) {
+	var result []ManualApprovalRuleOutcome
+
+	tx := db.
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = manual_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = manual_approval_rule_outcomes.release_rule_processed_event_id").
+		Where("manual_approval_rule_outcomes.organization_id = ? AND release_rule_processed_events.release_id = ?",
+			organizationID, releaseID).
+		Order("manual_approval_rule_outcomes.created_at, manual_approval_rule_outcomes.id")
+	tx = tx.Find(&result)
+	return result, tx.Error
+}
+
+// ManualApprovalRuleOutcomeExistsForOrganizationMember checks whether the given OrganizationMember
+// has already recorded a ManualApprovalRuleOutcome for the given Release and ManualApprovalRule.
+func ManualApprovalRuleOutcomeExistsForOrganizationMember(db *gorm.DB, organizationID string, releaseID uint64,
+	ruleID uint64, orgMember IOrganizationMember) (bool, error) {
+
+	var count int64
+	memberColumn := creationAuditRecordOrganizationMemberColumn(orgMember)
+
+	tx := db.
+		Model(&ManualApprovalRuleOutcome{}).
+		Joins("LEFT JOIN release_rule_processed_events "+
+			"ON release_rule_processed_events.organization_id = manual_approval_rule_outcomes.organization_id "+
+			"AND release_rule_processed_events.id = manual_approval_rule_outcomes.release_rule_processed_event_id").
+		Joins("LEFT JOIN creation_audit_records "+
+			"ON creation_audit_records.organization_id = manual_approval_rule_outcomes.organization_id "+
+			"AND creation_audit_records.manual_approval_rule_outcome_id = manual_approval_rule_outcomes.id").
+		Where("manual_approval_rule_outcomes.organization_id = ? "+
+			"AND release_rule_processed_events.release_id = ? "+
+			"AND manual_approval_rule_outcomes.manual_approval_rule_id = ? "+
+			"AND "+memberColumn+" = ?",
+			organizationID, releaseID, ruleID, orgMember.ID()).
+		Count(&count)
+	return count > 0, tx.Error
+}
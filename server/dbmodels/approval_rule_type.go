@@ -0,0 +1,153 @@
+package dbmodels
+
+import (
+	"reflect"
+
+	"github.com/fullstaq-labs/sqedule/lib"
+	"gorm.io/gorm"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// ApprovalRuleTypeDefinition tells the database layer how to load and store the rules (and rule
+// outcomes) of a specific ApprovalRuleType. Code that must deal with all rule types iterates over
+// ApprovalRuleTypeDefinitions() instead of handling each type explicitly, so that adding a rule
+// type doesn't require changes all over the codebase.
+//
+// This is only the database part of a rule type. Rule types that aren't built in are registered
+// with approvalrulesprocessing.RegisterApprovalRuleType(), which requires their definition to
+// also implement the JSON and evaluation parts.
+type ApprovalRuleTypeDefinition interface {
+	Type() ApprovalRuleType
+
+	// TableName returns the name of the table in which rules of this type are stored.
+	TableName() string
+
+	// NewRule returns a pointer to a new, zero-valued rule of this type.
+	NewRule() IApprovalRule
+
+	// FindRules runs `query` against this type's table, and returns pointers to the rules found.
+	FindRules(query *gorm.DB) ([]IApprovalRule, error)
+
+	// LoadOutcomes runs `query` against the table in which outcomes of this rule type are
+	// stored, and associates pointers to the outcomes found with the corresponding events.
+	LoadOutcomes(query *gorm.DB, eventsIndexByID map[uint64]*ReleaseRuleProcessedEvent) error
+}
+
+// BasicApprovalRuleTypeDefinition implements ApprovalRuleTypeDefinition for a rule model and an
+// outcome model, using reflection. The outcome model must have a field named after the rule
+// model, which holds the rule that the outcome belongs to.
+//
+// The built-in rule types are defined with it, and registered rule types may embed it.
+type BasicApprovalRuleTypeDefinition struct {
+	RuleType ApprovalRuleType
+	Table    string
+
+	// Rule is a pointer to a zero-valued rule of this type, e.g. `&ScheduleApprovalRule{}`.
+	Rule IApprovalRule
+
+	// Outcome is a pointer to a zero-valued outcome of this type, e.g. `&ScheduleApprovalRuleOutcome{}`.
+	Outcome interface{}
+}
+
+var approvalRuleTypeDefinitions = []ApprovalRuleTypeDefinition{
+	BasicApprovalRuleTypeDefinition{HTTPApiApprovalRuleType, "http_api_approval_rules", &HTTPApiApprovalRule{}, &HTTPApiApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{ScheduleApprovalRuleType, "schedule_approval_rules", &ScheduleApprovalRule{}, &ScheduleApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{ManualApprovalRuleType, "manual_approval_rules", &ManualApprovalRule{}, &ManualApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{CalendarApprovalRuleType, "calendar_approval_rules", &CalendarApprovalRule{}, &CalendarApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{DependencyApprovalRuleType, "dependency_approval_rules", &DependencyApprovalRule{}, &DependencyApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{QuotaApprovalRuleType, "quota_approval_rules", &QuotaApprovalRule{}, &QuotaApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{CallbackApprovalRuleType, "callback_approval_rules", &CallbackApprovalRule{}, &CallbackApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{PromotionApprovalRuleType, "promotion_approval_rules", &PromotionApprovalRule{}, &PromotionApprovalRuleOutcome{}},
+	BasicApprovalRuleTypeDefinition{ExpressionApprovalRuleType, "expression_approval_rules", &ExpressionApprovalRule{}, &ExpressionApprovalRuleOutcome{}},
+}
+
+//
+// ******** Registry functions ********
+//
+
+// AddApprovalRuleTypeDefinition adds support for a rule type that isn't built in, and panics if a
+// rule type with the same name is already supported. Don't call it directly: call
+// approvalrulesprocessing.RegisterApprovalRuleType() instead, which registers all parts of a
+// rule type at once.
+func AddApprovalRuleTypeDefinition(definition ApprovalRuleTypeDefinition) {
+	if FindApprovalRuleTypeDefinition(definition.Type()) != nil {
+		panic("Approval rule type " + string(definition.Type()) + " is already registered")
+	}
+	approvalRuleTypeDefinitions = append(approvalRuleTypeDefinitions, definition)
+}
+
+// ApprovalRuleTypeDefinitions returns the definitions of all supported rule types: first the
+// built-in ones, then the registered ones in order of registration.
+func ApprovalRuleTypeDefinitions() []ApprovalRuleTypeDefinition {
+	return approvalRuleTypeDefinitions
+}
+
+// FindApprovalRuleTypeDefinition returns the definition of the given rule type, or nil if
+// that rule type isn't supported.
+func FindApprovalRuleTypeDefinition(ruleType ApprovalRuleType) ApprovalRuleTypeDefinition {
+	for _, definition := range approvalRuleTypeDefinitions {
+		if definition.Type() == ruleType {
+			return definition
+		}
+	}
+	return nil
+}
+
+//
+// ******** BasicApprovalRuleTypeDefinition methods ********
+//
+
+func (d BasicApprovalRuleTypeDefinition) Type() ApprovalRuleType {
+	return d.RuleType
+}
+
+func (d BasicApprovalRuleTypeDefinition) TableName() string {
+	return d.Table
+}
+
+func (d BasicApprovalRuleTypeDefinition) NewRule() IApprovalRule {
+	return reflect.New(d.ruleModelType()).Interface().(IApprovalRule)
+}
+
+func (d BasicApprovalRuleTypeDefinition) FindRules(query *gorm.DB) ([]IApprovalRule, error) {
+	rules := lib.ReflectMakeValPtr(reflect.MakeSlice(reflect.SliceOf(d.ruleModelType()), 0, 0))
+	err := query.Find(rules.Interface()).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rules = rules.Elem()
+	result := make([]IApprovalRule, 0, rules.Len())
+	for i := 0; i < rules.Len(); i++ {
+		result = append(result, rules.Index(i).Addr().Interface().(IApprovalRule))
+	}
+	return result, nil
+}
+
+func (d BasicApprovalRuleTypeDefinition) LoadOutcomes(query *gorm.DB, eventsIndexByID map[uint64]*ReleaseRuleProcessedEvent) error {
+	outcomeType := reflect.TypeOf(d.Outcome).Elem()
+	outcomes := lib.ReflectMakeValPtr(reflect.MakeSlice(reflect.SliceOf(outcomeType), 0, 0))
+	err := query.Preload(d.ruleModelType().Name()).Find(outcomes.Interface()).Error
+	if err != nil {
+		return err
+	}
+
+	outcomes = outcomes.Elem()
+	for i := 0; i < outcomes.Len(); i++ {
+		outcome := outcomes.Index(i).Addr()
+		eventID := outcome.Elem().FieldByName("ApprovalRuleOutcome").Interface().(ApprovalRuleOutcome).ReleaseRuleProcessedEventID
+		event, ok := eventsIndexByID[eventID]
+		if ok {
+			event.ApprovalRuleType = d.RuleType
+			event.ApprovalRuleOutcome = outcome.Interface()
+		}
+	}
+	return nil
+}
+
+func (d BasicApprovalRuleTypeDefinition) ruleModelType() reflect.Type {
+	return reflect.TypeOf(d.Rule).Elem()
+}
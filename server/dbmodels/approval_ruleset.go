@@ -2,6 +2,7 @@ package dbmodels
 
 import (
 	"reflect"
+	"sort"
 
 	"github.com/fullstaq-labs/sqedule/lib"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/proposalstate"
@@ -68,16 +69,17 @@ type ApprovalRulesetWithStats struct {
 // database table. ApprovalRuleset is not capable of actually physically containing all the
 // associated ApprovalRules. In contrast, ApprovalRulesetContents *is* a container which
 // physically contains ApprovalRules.
-//
-// ApprovalRulesetContents can contain all supported ApprovalRule types in a way that doesn't
-// use pointers, and that doesn't require typecasting. It's a more efficient alternative to
-// `[]*ApprovalRule`. This latter requires pointers and is thus not as memory-efficient.
-// Furthermore, this latter requires the use of casting to find out which specific subtype an
-// element is.
 type ApprovalRulesetContents struct {
-	HTTPApiApprovalRules  []HTTPApiApprovalRule
-	ScheduleApprovalRules []ScheduleApprovalRule
-	ManualApprovalRules   []ManualApprovalRule
+	// Rules contains pointers to rules of any supported type, e.g. `*ScheduleApprovalRule`.
+	// Use RulesOfType() to find the rules of a specific type.
+	Rules []IApprovalRule
+}
+
+// SkippedApprovalRule describes an ApprovalRule that must not be evaluated, and why.
+type SkippedApprovalRule struct {
+	Type   ApprovalRuleType
+	Rule   ApprovalRule
+	Reason string
 }
 
 //
@@ -86,54 +88,85 @@ type ApprovalRulesetContents struct {
 
 // NumRules returns the total number of rules in this ApprovalRulesetContents.
 func (c ApprovalRulesetContents) NumRules() uint {
-	return uint(len(c.HTTPApiApprovalRules)) +
-		uint(len(c.ScheduleApprovalRules)) +
-		uint(len(c.ManualApprovalRules))
+	return uint(len(c.Rules))
 }
 
+// RulesOfType returns the rules of the given type, in the order in which they appear in Rules.
+// The caller can cast each element to a pointer of the corresponding rule model.
+func (c ApprovalRulesetContents) RulesOfType(ruleType ApprovalRuleType) []IApprovalRule {
+	var result []IApprovalRule
+	for _, rule := range c.Rules {
+		if rule.Type() == ruleType {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// CopyAsUnsaved returns a deep copy of these contents, in which the rules have no primary key
+// and aren't associated with an ApprovalRulesetAdjustment.
 func (c ApprovalRulesetContents) CopyAsUnsaved() ApprovalRulesetContents {
-	//nolint:errcheck
-	c.ForEach(func(rule IApprovalRule) error {
-		rule.ClearPrimaryKey()
-		rule.AssociateWithApprovalRulesetAdjustment(ApprovalRulesetAdjustment{})
-		return nil
-	})
-	return c
+	result := ApprovalRulesetContents{Rules: make([]IApprovalRule, 0, len(c.Rules))}
+	for _, rule := range c.Rules {
+		unsaved := lib.ReflectMakeValPtr(reflect.ValueOf(rule).Elem()).Interface().(IApprovalRule)
+		unsaved.ClearPrimaryKey()
+		unsaved.AssociateWithApprovalRulesetAdjustment(ApprovalRulesetAdjustment{})
+		result.Rules = append(result.Rules, unsaved)
+	}
+	return result
 }
 
 func (c *ApprovalRulesetContents) ForEach(callback func(rule IApprovalRule) error) error {
-	var ruleTypesProcessed uint = 0
-	var err error
-
-	ruleTypesProcessed++
-	for i := range c.HTTPApiApprovalRules {
-		err = callback(&c.HTTPApiApprovalRules[i])
+	for _, rule := range c.Rules {
+		err := callback(rule)
 		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	ruleTypesProcessed++
-	for i := range c.ScheduleApprovalRules {
-		err = callback(&c.ScheduleApprovalRules[i])
-		if err != nil {
-			return err
+// EvaluationStages splits the rules into groups of rules with the same EvaluationOrder. The
+// groups are sorted by EvaluationOrder, from low to high.
+func (c ApprovalRulesetContents) EvaluationStages() []ApprovalRulesetContents {
+	stages := make(map[int32]*ApprovalRulesetContents)
+	for _, rule := range c.Rules {
+		evaluationOrder := rule.BaseApprovalRule().EvaluationOrder
+		stage, exists := stages[evaluationOrder]
+		if !exists {
+			stage = &ApprovalRulesetContents{}
+			stages[evaluationOrder] = stage
 		}
+		stage.Rules = append(stage.Rules, rule)
 	}
 
-	ruleTypesProcessed++
-	for i := range c.ManualApprovalRules {
-		err = callback(&c.ManualApprovalRules[i])
-		if err != nil {
-			return err
-		}
+	evaluationOrders := make([]int32, 0, len(stages))
+	for evaluationOrder := range stages {
+		evaluationOrders = append(evaluationOrders, evaluationOrder)
 	}
+	sort.Slice(evaluationOrders, func(i, j int) bool {
+		return evaluationOrders[i] < evaluationOrders[j]
+	})
 
-	if ruleTypesProcessed != NumApprovalRuleTypes {
-		panic("Bug: code does not cover all approval rule types")
+	result := make([]ApprovalRulesetContents, 0, len(evaluationOrders))
+	for _, evaluationOrder := range evaluationOrders {
+		result = append(result, *stages[evaluationOrder])
 	}
+	return result
+}
 
-	return err
+// WithoutDisabledRules returns a copy of these contents without the rules that must not be
+// evaluated, plus a description of each rule that was left out. See
+// `ApprovalRule.DisabledReason()`.
+func (c ApprovalRulesetContents) WithoutDisabledRules() (enabled ApprovalRulesetContents, skipped []SkippedApprovalRule) {
+	for _, rule := range c.Rules {
+		if reason := rule.DisabledReason(); len(reason) > 0 {
+			skipped = append(skipped, SkippedApprovalRule{Type: rule.Type(), Rule: rule.BaseApprovalRule(), Reason: reason})
+		} else {
+			enabled.Rules = append(enabled.Rules, rule)
+		}
+	}
+	return enabled, skipped
 }
 
 //
@@ -370,58 +403,23 @@ func LoadApprovalRulesetVersionsLatestAdjustments(db *gorm.DB, organizationID st
 
 func LoadApprovalRulesetAdjustmentsApprovalRules(db *gorm.DB, organizationID string, adjustments []*ApprovalRulesetAdjustment) error {
 	var adjustmentIndex map[ApprovalRulesetVersionAndAdjustmentKey][]*ApprovalRulesetAdjustment = indexAdjustmentsByKey(adjustments)
-	var query, tx *gorm.DB
-	var ruleTypesProcessed uint = 0
-	var httpAPIApprovalRules []HTTPApiApprovalRule
-	var scheduleApprovalRules []ScheduleApprovalRule
-	var manualApprovalRules []ManualApprovalRule
-
-	query = db.Where("organization_id = ? AND (approval_ruleset_version_id, approval_ruleset_adjustment_number) IN ?",
+	var query = db.Where("organization_id = ? AND (approval_ruleset_version_id, approval_ruleset_adjustment_number) IN ?",
 		organizationID, collectApprovalRulesetAdjustmentsQueryValues(adjustments))
 
-	ruleTypesProcessed++
-	tx = db.Where(query).Find(&httpAPIApprovalRules)
-	if tx.Error != nil {
-		return tx.Error
-	}
-	for _, rule := range httpAPIApprovalRules {
-		key := rule.ApprovalRulesetVersionAndAdjustmentKey()
-		matchingAdjustments := adjustmentIndex[key]
-		for _, adjustment := range matchingAdjustments {
-			adjustment.Rules.HTTPApiApprovalRules = append(adjustment.Rules.HTTPApiApprovalRules, rule)
-		}
-	}
-
-	ruleTypesProcessed++
-	tx = db.Where(query).Find(&scheduleApprovalRules)
-	if tx.Error != nil {
-		return tx.Error
-	}
-	for _, rule := range scheduleApprovalRules {
-		key := rule.ApprovalRulesetVersionAndAdjustmentKey()
-		matchingAdjustments := adjustmentIndex[key]
-		for _, adjustment := range matchingAdjustments {
-			adjustment.Rules.ScheduleApprovalRules = append(adjustment.Rules.ScheduleApprovalRules, rule)
+	for _, definition := range ApprovalRuleTypeDefinitions() {
+		rules, err := definition.FindRules(db.Where(query))
+		if err != nil {
+			return err
 		}
-	}
-
-	ruleTypesProcessed++
-	tx = db.Where(query).Find(&manualApprovalRules)
-	if tx.Error != nil {
-		return tx.Error
-	}
-	for _, rule := range manualApprovalRules {
-		key := rule.ApprovalRulesetVersionAndAdjustmentKey()
-		matchingAdjustments := adjustmentIndex[key]
-		for _, adjustment := range matchingAdjustments {
-			adjustment.Rules.ManualApprovalRules = append(adjustment.Rules.ManualApprovalRules, rule)
+		for _, rule := range rules {
+			key := rule.ApprovalRulesetVersionAndAdjustmentKey()
+			matchingAdjustments := adjustmentIndex[key]
+			for _, adjustment := range matchingAdjustments {
+				adjustment.Rules.Rules = append(adjustment.Rules.Rules, rule)
+			}
 		}
 	}
 
-	if ruleTypesProcessed != NumApprovalRuleTypes {
-		panic("Bug: code does not cover all approval rule types")
-	}
-
 	return nil
 }
 
@@ -1,20 +1,151 @@
 package dbmodels
 
 import (
+	"github.com/fullstaq-labs/sqedule/lib"
 	"github.com/fullstaq-labs/sqedule/server/dbutils"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+var _ = Describe("ApprovalRuleTypeDefinition", func() {
+	It("is defined for all built-in rule types", func() {
+		for _, definition := range ApprovalRuleTypeDefinitions() {
+			rule := definition.NewRule()
+			Expect(rule.Type()).To(Equal(definition.Type()))
+		}
+		Expect(ApprovalRuleTypeDefinitions()).To(HaveLen(9))
+	})
+
+	Describe("registered rule types", func() {
+		var originalDefinitions []ApprovalRuleTypeDefinition
+
+		BeforeEach(func() {
+			originalDefinitions = approvalRuleTypeDefinitions
+			AddApprovalRuleTypeDefinition(testApprovalRuleTypeDefinition)
+		})
+
+		AfterEach(func() {
+			approvalRuleTypeDefinitions = originalDefinitions
+		})
+
+		It("refuses to register a rule type twice", func() {
+			Expect(func() {
+				AddApprovalRuleTypeDefinition(testApprovalRuleTypeDefinition)
+			}).To(Panic())
+		})
+
+		It("finds registered rule types", func() {
+			definition := FindApprovalRuleTypeDefinition(testApprovalRuleType)
+			Expect(definition).ToNot(BeNil())
+			Expect(definition.NewRule()).To(Equal(&testApprovalRule{}))
+		})
+	})
+})
+
 var _ = Describe("ApprovalRulesetContents", func() {
-	It("supports all ruleset types", func() {
+	It("contains rules of all types, including registered ones", func() {
 		contents := ApprovalRulesetContents{
-			HTTPApiApprovalRules:  []HTTPApiApprovalRule{{}},
-			ScheduleApprovalRules: []ScheduleApprovalRule{{}},
-			ManualApprovalRules:   []ManualApprovalRule{{}},
+			Rules: []IApprovalRule{
+				&ManualApprovalRule{ApprovalRule: ApprovalRule{ID: 1, EvaluationOrder: 1}},
+				&testApprovalRule{ApprovalRule: ApprovalRule{ID: 2, EvaluationOrder: 0}},
+				&testApprovalRule{ApprovalRule: ApprovalRule{ID: 3, Enabled: lib.NewBoolPtr(false)}},
+			},
 		}
-		Expect(contents.NumRules()).To(BeNumerically("==", NumApprovalRuleTypes))
+		Expect(contents.NumRules()).To(BeNumerically("==", 3))
+		Expect(contents.RulesOfType(testApprovalRuleType)).To(HaveLen(2))
+
+		var types []ApprovalRuleType
+		Expect(contents.ForEach(func(rule IApprovalRule) error {
+			types = append(types, rule.Type())
+			return nil
+		})).To(Succeed())
+		Expect(types).To(Equal([]ApprovalRuleType{ManualApprovalRuleType, testApprovalRuleType, testApprovalRuleType}))
+
+		enabled, skipped := contents.WithoutDisabledRules()
+		Expect(skipped).To(HaveLen(1))
+		Expect(skipped[0].Type).To(Equal(testApprovalRuleType))
+		Expect(skipped[0].Rule.ID).To(BeNumerically("==", 3))
+
+		stages := enabled.EvaluationStages()
+		Expect(stages).To(HaveLen(2))
+		Expect(stages[0].Rules).To(HaveLen(1))
+		Expect(stages[0].Rules[0].BaseApprovalRule().ID).To(BeNumerically("==", 2))
+		Expect(stages[1].RulesOfType(ManualApprovalRuleType)).To(HaveLen(1))
+	})
+
+	Describe("CopyAsUnsaved", func() {
+		It("copies the rules without their primary keys", func() {
+			rule := &ScheduleApprovalRule{ApprovalRule: ApprovalRule{ID: 1, ApprovalRulesetVersionID: 2}}
+			contents := ApprovalRulesetContents{Rules: []IApprovalRule{rule}}
+
+			unsaved := contents.CopyAsUnsaved()
+			Expect(unsaved.Rules).To(HaveLen(1))
+			Expect(unsaved.Rules[0].BaseApprovalRule().ID).To(BeNumerically("==", 0))
+			Expect(unsaved.Rules[0].BaseApprovalRule().ApprovalRulesetVersionID).To(BeNumerically("==", 0))
+			Expect(rule.ID).To(BeNumerically("==", 1))
+		})
+	})
+
+	Describe("EvaluationStages", func() {
+		It("groups rules by evaluation order, from low to high", func() {
+			contents := ApprovalRulesetContents{
+				Rules: []IApprovalRule{
+					&HTTPApiApprovalRule{ApprovalRule: ApprovalRule{ID: 1, EvaluationOrder: 10}},
+					&HTTPApiApprovalRule{ApprovalRule: ApprovalRule{ID: 2, EvaluationOrder: 0}},
+					&HTTPApiApprovalRule{ApprovalRule: ApprovalRule{ID: 3, EvaluationOrder: 10}},
+					&ManualApprovalRule{ApprovalRule: ApprovalRule{ID: 4, EvaluationOrder: -5}},
+					&ScheduleApprovalRule{ApprovalRule: ApprovalRule{ID: 5, EvaluationOrder: 10}},
+				},
+			}
+
+			stages := contents.EvaluationStages()
+			Expect(stages).To(HaveLen(3))
+
+			Expect(stages[0].NumRules()).To(BeNumerically("==", 1))
+			Expect(stages[0].RulesOfType(ManualApprovalRuleType)).To(HaveLen(1))
+			Expect(stages[0].Rules[0].BaseApprovalRule().ID).To(BeNumerically("==", 4))
+
+			Expect(stages[1].NumRules()).To(BeNumerically("==", 1))
+			Expect(stages[1].RulesOfType(HTTPApiApprovalRuleType)).To(HaveLen(1))
+			Expect(stages[1].Rules[0].BaseApprovalRule().ID).To(BeNumerically("==", 2))
+
+			Expect(stages[2].NumRules()).To(BeNumerically("==", 3))
+			Expect(stages[2].RulesOfType(HTTPApiApprovalRuleType)).To(HaveLen(2))
+			Expect(stages[2].Rules[0].BaseApprovalRule().ID).To(BeNumerically("==", 1))
+			Expect(stages[2].Rules[1].BaseApprovalRule().ID).To(BeNumerically("==", 3))
+			Expect(stages[2].RulesOfType(ScheduleApprovalRuleType)).To(HaveLen(1))
+		})
+
+		It("returns no stages if there are no rules", func() {
+			Expect(ApprovalRulesetContents{}.EvaluationStages()).To(BeEmpty())
+		})
+	})
+
+	Describe("WithoutDisabledRules", func() {
+		It("leaves out disabled rules and rules in disabled rulesets", func() {
+			contents := ApprovalRulesetContents{
+				Rules: []IApprovalRule{
+					&HTTPApiApprovalRule{ApprovalRule: ApprovalRule{ID: 1}},
+					&HTTPApiApprovalRule{ApprovalRule: ApprovalRule{ID: 2, Enabled: lib.NewBoolPtr(false)}},
+					&ScheduleApprovalRule{ApprovalRule: ApprovalRule{ID: 3, Enabled: lib.NewBoolPtr(true), RulesetEnabled: lib.NewBoolPtr(false)}},
+					&ScheduleApprovalRule{ApprovalRule: ApprovalRule{ID: 4, Enabled: lib.NewBoolPtr(true), RulesetEnabled: lib.NewBoolPtr(true)}},
+				},
+			}
+
+			enabled, skipped := contents.WithoutDisabledRules()
+			Expect(enabled.NumRules()).To(BeNumerically("==", 2))
+			Expect(enabled.Rules[0].BaseApprovalRule().ID).To(BeNumerically("==", 1))
+			Expect(enabled.Rules[1].BaseApprovalRule().ID).To(BeNumerically("==", 4))
+
+			Expect(skipped).To(HaveLen(2))
+			Expect(skipped[0].Type).To(Equal(HTTPApiApprovalRuleType))
+			Expect(skipped[0].Rule.ID).To(BeNumerically("==", 2))
+			Expect(skipped[0].Reason).To(Equal("Rule is disabled"))
+			Expect(skipped[1].Type).To(Equal(ScheduleApprovalRuleType))
+			Expect(skipped[1].Rule.ID).To(BeNumerically("==", 3))
+			Expect(skipped[1].Reason).To(Equal("Approval ruleset is disabled"))
+		})
 	})
 })
 
@@ -42,3 +173,24 @@ var _ = Describe("ApprovalRuleset finders", func() {
 		})
 	})
 })
+
+const testApprovalRuleType ApprovalRuleType = "test"
+
+type testApprovalRule struct {
+	ApprovalRule
+}
+
+func (r testApprovalRule) Type() ApprovalRuleType {
+	return testApprovalRuleType
+}
+
+type testApprovalRuleOutcome struct {
+	ApprovalRuleOutcome
+}
+
+var testApprovalRuleTypeDefinition = BasicApprovalRuleTypeDefinition{
+	RuleType: testApprovalRuleType,
+	Table:    "test_approval_rules",
+	Rule:     &testApprovalRule{},
+	Outcome:  &testApprovalRuleOutcome{},
+}
@@ -0,0 +1,154 @@
+package dbmodels
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"gorm.io/gorm"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// Calendar is a named collection of blocked dates and times, such as public
+// holidays or change freezes. It's referenced by CalendarApprovalRules.
+type Calendar struct {
+	BaseModel
+	ID          string `gorm:"type:citext; primaryKey; not null"`
+	DisplayName string `gorm:"not null"`
+
+	// TimeZone is the IANA time zone name in which all-day entries are interpreted.
+	// If null, then Organization.DefaultTimeZone is used.
+	TimeZone sql.NullString
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// CalendarEntry is a period during which a Calendar blocks releases. If AllDay is true,
+// then BeginAt and EndAt are dates (midnight UTC), which are matched against a release's
+// date in the Calendar's time zone. EndAt is exclusive.
+type CalendarEntry struct {
+	BaseModel
+	ID         uint64   `gorm:"primaryKey; autoIncrement; not null"`
+	CalendarID string   `gorm:"type:citext; not null; index:calendar_entry_calendar_idx"`
+	Calendar   Calendar `gorm:"foreignKey:OrganizationID,CalendarID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UID        sql.NullString
+	Summary    string    `gorm:"not null"`
+	AllDay     bool      `gorm:"not null"`
+	BeginAt    time.Time `gorm:"not null"`
+	EndAt      time.Time `gorm:"not null; check:(end_at >= begin_at)"`
+
+	// RecurrenceRule is an iCalendar RRULE, as supported by `icalendar.ParseRecurrenceRule()`.
+	RecurrenceRule sql.NullString
+
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+//
+// ******** Calendar methods ********
+//
+
+// Location returns the time zone in which this Calendar's all-day entries are to be
+// interpreted. That's the Calendar's own time zone, or else the organization's default
+// time zone, or else the server's local time zone.
+func (c Calendar) Location(organization Organization) (*time.Location, error) {
+	var name string
+	if c.TimeZone.Valid && len(c.TimeZone.String) > 0 {
+		name = c.TimeZone.String
+	} else if organization.DefaultTimeZone.Valid && len(organization.DefaultTimeZone.String) > 0 {
+		name = organization.DefaultTimeZone.String
+	} else {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading time zone '%s': %w", name, err)
+	}
+	return location, nil
+}
+
+//
+// ******** Find/load functions ********
+//
+
+// FindCalendars finds all Calendars in the given organization.
+func FindCalendars(db *gorm.DB, organizationID string) ([]Calendar, error) {
+	var result []Calendar
+	tx := db.Where("organization_id = ?", organizationID)
+	tx.Find(&result)
+	return result, tx.Error
+}
+
+// FindCalendar looks up a Calendar by its ID.
+// When not found, returns a `gorm.ErrRecordNotFound` error.
+func FindCalendar(db *gorm.DB, organizationID string, id string) (Calendar, error) {
+	var result Calendar
+
+	tx := db.Where("organization_id = ? AND id = ?", organizationID, id)
+	tx.Take(&result)
+	return result, dbutils.CreateFindOperationError(tx)
+}
+
+// FindCalendarEntries finds all entries of the given Calendar, ordered by begin time.
+func FindCalendarEntries(db *gorm.DB, organizationID string, calendarID string) ([]CalendarEntry, error) {
+	var result []CalendarEntry
+	tx := db.
+		Where("organization_id = ? AND calendar_id = ?", organizationID, calendarID).
+		Order("begin_at, id").
+		Find(&result)
+	return result, tx.Error
+}
+
+// FindCalendarEntriesPossiblyContainingTime finds the entries of the given Calendar that
+// might contain `t`: all recurring entries, plus all non-recurring entries that overlap with
+// `t` with a margin of one day. The margin is necessary because all-day entries are matched
+// in the Calendar's time zone. Callers must check each returned entry for an exact match.
+func FindCalendarEntriesPossiblyContainingTime(db *gorm.DB, organizationID string, calendarID string, t time.Time) ([]CalendarEntry, error) {
+	var result []CalendarEntry
+	tx := db.
+		Where("organization_id = ? AND calendar_id = ?", organizationID, calendarID).
+		Where("recurrence_rule IS NOT NULL OR (begin_at <= ? AND end_at >= ?)",
+			t.AddDate(0, 0, 1), t.AddDate(0, 0, -1)).
+		Order("begin_at, id").
+		Find(&result)
+	return result, tx.Error
+}
+
+// CountCalendarApprovalRulesReferencingCalendar counts how many CalendarApprovalRules
+// (in any ApprovalRuleset version or proposal) reference the given Calendar.
+func CountCalendarApprovalRulesReferencingCalendar(db *gorm.DB, organizationID string, calendarID string) (int64, error) {
+	var result int64
+	tx := db.
+		Model(&CalendarApprovalRule{}).
+		Where("organization_id = ? AND calendar_id = ?", organizationID, calendarID).
+		Count(&result)
+	return result, tx.Error
+}
+
+//
+// ******** Other functions ********
+//
+
+// ReplaceCalendarEntries deletes all entries of the given Calendar, and creates the given
+// entries instead. It should be called inside a transaction.
+func ReplaceCalendarEntries(db *gorm.DB, organizationID string, calendarID string, entries []CalendarEntry) error {
+	err := db.Where("organization_id = ? AND calendar_id = ?", organizationID, calendarID).
+		Delete(&CalendarEntry{}).Error
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+	for i := range entries {
+		entries[i].OrganizationID = organizationID
+		entries[i].CalendarID = calendarID
+	}
+	return db.Omit("Calendar").Create(&entries).Error
+}
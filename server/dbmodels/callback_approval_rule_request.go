@@ -0,0 +1,101 @@
+package dbmodels
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"gorm.io/gorm"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// CallbackApprovalRuleRequest records that a CallbackApprovalRule's job was sent to an external
+// system on behalf of a Release, along with the one-time token with which that system calls back.
+// Once the external system has called back, the request is completed, and the engine records a
+// CallbackApprovalRuleOutcome the next time it processes the Release.
+type CallbackApprovalRuleRequest struct {
+	BaseModel
+	ID                     uint64               `gorm:"primaryKey; autoIncrement; not null"`
+	ApplicationID          string               `gorm:"type:citext; not null"`
+	ReleaseID              uint64               `gorm:"not null"`
+	Release                Release              `gorm:"foreignKey:OrganizationID,ApplicationID,ReleaseID; references:OrganizationID,ApplicationID,ID; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CallbackApprovalRuleID uint64               `gorm:"not null"`
+	CallbackApprovalRule   CallbackApprovalRule `gorm:"foreignKey:OrganizationID,CallbackApprovalRuleID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// TokenHash is the SHA-256 hash of the callback token (see HashCallbackToken).
+	// The token itself is not stored.
+	TokenHash string    `gorm:"not null; unique"`
+	CreatedAt time.Time `gorm:"not null"`
+
+	// ExpiresAt is the time after which callbacks are no longer accepted,
+	// and after which the rule fails.
+	ExpiresAt time.Time `gorm:"not null"`
+
+	// CompletedAt is the time at which the external system called back, or at which
+	// sending the job failed. It's null while the request is still pending.
+	CompletedAt sql.NullTime
+	Success     sql.NullBool `gorm:"check:((completed_at IS NULL) = (success IS NULL))"`
+	Message     sql.NullString
+}
+
+//
+// ******** Find/load functions ********
+//
+
+// FindCallbackApprovalRuleRequests returns all CallbackApprovalRuleRequests for the given Release.
+func FindCallbackApprovalRuleRequests(db *gorm.DB, organizationID string, releaseID uint64) ([]CallbackApprovalRuleRequest, error) {
+	var result []CallbackApprovalRuleRequest
+	tx := db.Where("organization_id = ? AND release_id = ?", organizationID, releaseID).Find(&result)
+	return result, tx.Error
+}
+
+// FindCallbackApprovalRuleRequestByToken looks up a CallbackApprovalRuleRequest by its callback token.
+// Tokens are globally unique, so this doesn't require an organization ID.
+func FindCallbackApprovalRuleRequestByToken(db *gorm.DB, token string) (CallbackApprovalRuleRequest, error) {
+	var result CallbackApprovalRuleRequest
+
+	tx := db.Where("token_hash = ?", HashCallbackToken(token))
+	tx.Take(&result)
+	return result, dbutils.CreateFindOperationError(tx)
+}
+
+//
+// ******** Other functions ********
+//
+
+// HashCallbackToken returns the value that's stored in `CallbackApprovalRuleRequest.TokenHash`
+// for the given token.
+func HashCallbackToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompleteCallbackApprovalRuleRequest marks the given request as completed with the given result.
+// It only does so if the request wasn't already completed, so that tokens can only be used once.
+// Returns whether the request was updated.
+func CompleteCallbackApprovalRuleRequest(db *gorm.DB, request *CallbackApprovalRuleRequest, success bool, message sql.NullString, completedAt time.Time) (bool, error) {
+	tx := db.
+		Model(&CallbackApprovalRuleRequest{}).
+		Where("organization_id = ? AND id = ? AND completed_at IS NULL", request.OrganizationID, request.ID).
+		Updates(map[string]interface{}{
+			"completed_at": completedAt,
+			"success":      success,
+			"message":      message,
+		})
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return false, nil
+	}
+
+	request.CompletedAt = sql.NullTime{Time: completedAt, Valid: true}
+	request.Success = sql.NullBool{Bool: success, Valid: true}
+	request.Message = message
+	return true, nil
+}
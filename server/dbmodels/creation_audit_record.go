@@ -27,7 +27,7 @@ type CreationAuditRecord struct {
 
 	// Subject association
 
-	ApplicationVersionID        *uint64               `gorm:"check:((CASE WHEN application_adjustment_number IS NULL THEN 0 ELSE 1 END) + (CASE WHEN approval_ruleset_adjustment_number IS NULL THEN 0 ELSE 1 END) + (CASE WHEN application_approval_ruleset_binding_adjustment_number IS NULL THEN 0 ELSE 1 END) + (CASE WHEN manual_approval_rule_outcome_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_created_event_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_cancelled_event_id IS NULL THEN 0 ELSE 1 END) = 1)"`
+	ApplicationVersionID        *uint64               `gorm:"check:((CASE WHEN application_adjustment_number IS NULL THEN 0 ELSE 1 END) + (CASE WHEN approval_ruleset_adjustment_number IS NULL THEN 0 ELSE 1 END) + (CASE WHEN application_approval_ruleset_binding_adjustment_number IS NULL THEN 0 ELSE 1 END) + (CASE WHEN manual_approval_rule_outcome_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_created_event_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_cancelled_event_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_deployment_event_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_overridden_event_id IS NULL THEN 0 ELSE 1 END) + (CASE WHEN release_override_confirmed_event_id IS NULL THEN 0 ELSE 1 END) = 1)"`
 	ApplicationAdjustmentNumber *uint32               `gorm:"type:int; check:((application_version_id IS NULL) = (application_adjustment_number IS NULL))"`
 	ApplicationAdjustment       ApplicationAdjustment `gorm:"foreignKey:OrganizationID,ApplicationVersionID,ApplicationAdjustmentNumber; references:OrganizationID,ApplicationVersionID,AdjustmentNumber; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 
@@ -47,6 +47,15 @@ type CreationAuditRecord struct {
 
 	ReleaseCancelledEventID *uint64
 	ReleaseCancelledEvent   ReleaseCancelledEvent `gorm:"foreignKey:OrganizationID,ReleaseCancelledEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	ReleaseDeploymentEventID *uint64
+	ReleaseDeploymentEvent   ReleaseDeploymentEvent `gorm:"foreignKey:OrganizationID,ReleaseDeploymentEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	ReleaseOverriddenEventID *uint64
+	ReleaseOverriddenEvent   ReleaseOverriddenEvent `gorm:"foreignKey:OrganizationID,ReleaseOverriddenEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	ReleaseOverrideConfirmedEventID *uint64
+	ReleaseOverrideConfirmedEvent   ReleaseOverrideConfirmedEvent `gorm:"foreignKey:OrganizationID,ReleaseOverrideConfirmedEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 }
 
 //
@@ -73,6 +82,19 @@ func NewCreationAuditRecord(organizationID string, creator IOrganizationMember,
 	return result
 }
 
+// creationAuditRecordOrganizationMemberColumn returns the (table-qualified) CreationAuditRecord
+// column that identifies the given OrganizationMember.
+func creationAuditRecordOrganizationMemberColumn(orgMember IOrganizationMember) string {
+	switch orgMember.Type() {
+	case UserType:
+		return "creation_audit_records.user_email"
+	case ServiceAccountType:
+		return "creation_audit_records.service_account_name"
+	default:
+		panic("Unsupported organization member type " + string(orgMember.Type()))
+	}
+}
+
 //
 // ******** Deletion functions ********
 //
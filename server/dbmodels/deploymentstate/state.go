@@ -0,0 +1,58 @@
+package deploymentstate
+
+import "database/sql/driver"
+
+// State is the deployment state of an approved Release, as reported by the deployment pipeline.
+type State string
+
+const (
+	// Deploying means that the Release is being deployed.
+	Deploying State = "deploying"
+	// Deployed means that the Release has been deployed successfully.
+	Deployed State = "deployed"
+	// DeployFailed means that deploying the Release failed.
+	DeployFailed State = "deploy_failed"
+	// RolledBack means that a deployed Release has been rolled back.
+	RolledBack State = "rolled_back"
+)
+
+// All lists all states.
+var All = []State{Deploying, Deployed, DeployFailed, RolledBack}
+
+// Scan ...
+func (t *State) Scan(value interface{}) error {
+	*t = State(value.(string))
+	return nil
+}
+
+// Value ...
+func (t State) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// IsValid returns whether this is one of the known states.
+func (t State) IsValid() bool {
+	for _, state := range All {
+		if t == state {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionFrom returns whether a Release may go from state `from` to this state.
+// An empty `from` means that no deployment state has been reported yet.
+func (t State) CanTransitionFrom(from State) bool {
+	switch t {
+	case Deploying:
+		// A failed or rolled back deployment may be retried.
+		return from == "" || from == DeployFailed || from == RolledBack
+	case Deployed, DeployFailed:
+		// Pipelines that don't report the start of a deployment may report the result directly.
+		return from == "" || from == Deploying
+	case RolledBack:
+		return from == Deployed
+	default:
+		return false
+	}
+}
@@ -1,6 +1,8 @@
 package dbmodels
 
 import (
+	"database/sql"
+
 	"github.com/fullstaq-labs/sqedule/server/dbutils"
 	"gorm.io/gorm"
 )
@@ -12,6 +14,10 @@ import (
 type Organization struct {
 	ID          string `gorm:"type:citext; primaryKey; not null"`
 	DisplayName string `gorm:"not null"`
+
+	// DefaultTimeZone is the IANA time zone name that ScheduleApprovalRules use
+	// when they don't specify a time zone themselves.
+	DefaultTimeZone sql.NullString
 }
 
 //
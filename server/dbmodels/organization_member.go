@@ -81,3 +81,21 @@ func FindOrganizationMember(db *gorm.DB, organizationID string, orgMemberType Or
 		panic(fmt.Errorf("Bug: unsupported organization member type %s", orgMemberType))
 	}
 }
+
+// CountOrganizationMembersWithRoles returns the number of Users and ServiceAccounts in the given
+// organization that have one of the given roles.
+func CountOrganizationMembersWithRoles(db *gorm.DB, organizationID string, roles []organizationmemberrole.Role) (int64, error) {
+	var numUsers, numServiceAccounts int64
+
+	tx := db.Model(&User{}).
+		Where("organization_id = ? AND role IN ?", organizationID, roles).
+		Count(&numUsers)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	tx = db.Model(&ServiceAccount{}).
+		Where("organization_id = ? AND role IN ?", organizationID, roles).
+		Count(&numServiceAccounts)
+	return numUsers + numServiceAccounts, tx.Error
+}
@@ -0,0 +1,59 @@
+package dbmodels
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/organizationmemberrole"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OrganizationMember", func() {
+	var db *gorm.DB
+	var err error
+
+	Describe("CountOrganizationMembersWithRoles", func() {
+		var org Organization
+
+		BeforeEach(func() {
+			db, err = dbutils.SetupTestDatabase()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = db.Transaction(func(tx *gorm.DB) error {
+				org, err = CreateMockOrganization(tx, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				user := User{
+					OrganizationMember: OrganizationMember{
+						BaseModel:    BaseModel{OrganizationID: org.ID},
+						Role:         organizationmemberrole.ChangeManager,
+						PasswordHash: "unauthenticatable",
+					},
+					Email: "user1@example.com",
+				}
+				Expect(tx.Omit(clause.Associations).Create(&user).Error).ToNot(HaveOccurred())
+
+				_, err = CreateMockServiceAccountWithAdminRole(tx, org, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = CreateMockServiceAccountWithAdminRole(tx, org, func(sa *ServiceAccount) {
+					sa.Name = "technician"
+					sa.Role = organizationmemberrole.Technician
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("counts both users and service accounts with the given roles", func() {
+			count, err := CountOrganizationMembersWithRoles(db, org.ID,
+				[]organizationmemberrole.Role{organizationmemberrole.Admin, organizationmemberrole.ChangeManager})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically("==", 2))
+		})
+	})
+})
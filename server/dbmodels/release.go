@@ -2,19 +2,28 @@ package dbmodels
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/deploymentstate"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
 	"github.com/fullstaq-labs/sqedule/server/dbutils"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 //
 // ******** Types, constants & variables ********
 //
 
+// ApplicationReleasesPostgresLockClassID is the first key of the two-key PostgreSQL advisory lock
+// which serializes the approval of an Application's Releases. The second key is derived from the
+// organization ID and the application ID. PostgreSQL keeps two-key advisory locks separate from
+// single-key ones, so this doesn't conflict with ReleaseBackgroundJob locks.
+const ApplicationReleasesPostgresLockClassID int32 = 1
+
 type Release struct {
 	BaseModel
 	ApplicationID  string             `gorm:"type:citext; primaryKey; not null"`
@@ -27,6 +36,63 @@ type Release struct {
 	CreatedAt      time.Time `gorm:"not null"`
 	UpdatedAt      time.Time `gorm:"not null"`
 	FinalizedAt    sql.NullTime
+
+	// NextEligibleAt is the time at which a deferred ScheduleApprovalRule will be re-evaluated.
+	// It's null if the Release isn't waiting for a schedule window.
+	NextEligibleAt sql.NullTime
+
+	// DeploymentState is the last deployment state that was reported for this Release
+	// (see ReportReleaseDeployment). It's null if no deployment has been reported yet.
+	DeploymentState sql.NullString `gorm:"type:deployment_state"`
+
+	// Overridden is whether this Release was approved through an override (see OverrideRelease),
+	// instead of through its approval rules.
+	Overridden bool `gorm:"not null; default:false"`
+}
+
+// ReleaseFilter narrows down which Releases are returned by a query. Zero-valued fields don't
+// filter anything. Time ranges include their lower bound and exclude their upper bound.
+type ReleaseFilter struct {
+	States []releasestate.State
+
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	FinalizedAfter  time.Time
+	FinalizedBefore time.Time
+
+	SourceIdentityPrefix string
+
+	// Metadata matches Releases whose metadata contains this JSON object (JSONB containment).
+	Metadata map[string]interface{}
+
+	Overridden *bool
+}
+
+// ReleaseSortOrder specifies the order in which a query returns Releases.
+type ReleaseSortOrder string
+
+const (
+	ReleaseSortCreatedAtAsc    ReleaseSortOrder = "created_at"
+	ReleaseSortCreatedAtDesc   ReleaseSortOrder = "-created_at"
+	ReleaseSortFinalizedAtAsc  ReleaseSortOrder = "finalized_at"
+	ReleaseSortFinalizedAtDesc ReleaseSortOrder = "-finalized_at"
+
+	DefaultReleaseSortOrder = ReleaseSortCreatedAtDesc
+)
+
+// OverridableReleaseStates lists the states in which a Release may be overridden (see
+// Release.IsOverridable).
+var OverridableReleaseStates = []releasestate.State{
+	releasestate.InProgress,
+	releasestate.Rejected,
+}
+
+// AllReleaseSortOrders lists all valid ReleaseSortOrders.
+var AllReleaseSortOrders = []ReleaseSortOrder{
+	ReleaseSortCreatedAtAsc,
+	ReleaseSortCreatedAtDesc,
+	ReleaseSortFinalizedAtAsc,
+	ReleaseSortFinalizedAtDesc,
 }
 
 //
@@ -37,6 +103,307 @@ func (r Release) Description() string {
 	return fmt.Sprintf("(org=%s, app=%s, releaseID=%d)", r.OrganizationID, r.ApplicationID, r.ID)
 }
 
+// IsOverridable returns whether this Release may be overridden (see OverrideRelease). Besides
+// in-progress Releases, rejected ones may be overridden too: enforcing rules such as schedule
+// rules reject a Release right after its creation, which is when a hotfix needs an override.
+func (r Release) IsOverridable() bool {
+	for _, state := range OverridableReleaseStates {
+		if r.State == state {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalizeRelease sets the state of an in-progress Release to the given final state, and deletes
+// its ReleaseBackgroundJob. The Release is only modified if it's still in progress in the database,
+// so that a Release which was finalized concurrently (e.g. cancelled while its rules were being
+// processed) keeps its state. Returns whether the Release was finalized.
+func FinalizeRelease(tx *gorm.DB, release *Release, state releasestate.State, now time.Time) (bool, error) {
+	return finalizeReleaseFromStates(tx, release, []releasestate.State{releasestate.InProgress}, state, now)
+}
+
+// finalizeReleaseFromStates is like FinalizeRelease, but modifies the Release if it's in any of
+// `fromStates` in the database.
+func finalizeReleaseFromStates(tx *gorm.DB, release *Release, fromStates []releasestate.State, state releasestate.State,
+	now time.Time) (bool, error) {
+
+	savetx := tx.Model(&Release{}).
+		Where("organization_id = ? AND application_id = ? AND id = ? AND state IN ?",
+			release.OrganizationID, release.ApplicationID, release.ID, fromStates).
+		Updates(map[string]interface{}{
+			"state":            state,
+			"finalized_at":     now,
+			"next_eligible_at": nil,
+		})
+	if savetx.Error != nil {
+		return false, savetx.Error
+	}
+	if savetx.RowsAffected == 0 {
+		return false, nil
+	}
+
+	release.State = state
+	release.FinalizedAt = sql.NullTime{Time: now, Valid: true}
+	release.NextEligibleAt = sql.NullTime{}
+
+	deletetx := tx.Where("organization_id = ? AND application_id = ? AND release_id = ?",
+		release.OrganizationID, release.ApplicationID, release.ID).
+		Delete(&ReleaseBackgroundJob{})
+	if deletetx.Error != nil {
+		return true, deletetx.Error
+	}
+
+	return true, NotifyRelease(tx, ReleaseNotification{
+		OrganizationID: release.OrganizationID,
+		ApplicationID:  release.ApplicationID,
+		ReleaseID:      release.ID,
+		State:          state,
+	})
+}
+
+// CancelRelease finalizes an in-progress Release with the `cancelled` state (see FinalizeRelease())
+// and records a ReleaseCancelledEvent with the given reason. Returns false if the Release was
+// already finalized, in which case nothing is changed.
+func CancelRelease(tx *gorm.DB, release *Release, reason sql.NullString, now time.Time) (ReleaseCancelledEvent, bool, error) {
+	finalized, err := FinalizeRelease(tx, release, releasestate.Cancelled, now)
+	if err != nil || !finalized {
+		return ReleaseCancelledEvent{}, false, err
+	}
+
+	event := ReleaseCancelledEvent{
+		ReleaseEvent: ReleaseEvent{
+			BaseModel:     BaseModel{OrganizationID: release.OrganizationID},
+			ReleaseID:     release.ID,
+			ApplicationID: release.ApplicationID,
+			CreatedAt:     now,
+		},
+		Reason: reason,
+	}
+	err = tx.Omit(clause.Associations).Create(&event).Error
+	if err != nil {
+		return ReleaseCancelledEvent{}, false, err
+	}
+
+	return event, true, NotifyReleaseEventCreated(tx, event.ReleaseEvent, ReleaseCancelledEventType)
+}
+
+// OverrideRelease approves an in-progress or rejected Release regardless of its approval rules
+// (see FinalizeRelease), marks it as overridden, and records a ReleaseOverriddenEvent with the given
+// justification. Returns false if the Release is no longer overridable (see Release.IsOverridable),
+// in which case nothing is changed.
+func OverrideRelease(tx *gorm.DB, release *Release, justification string, now time.Time) (ReleaseOverriddenEvent, bool, error) {
+	finalized, err := finalizeOverriddenRelease(tx, release, now)
+	if err != nil || !finalized {
+		return ReleaseOverriddenEvent{}, false, err
+	}
+
+	event, err := createReleaseOverriddenEvent(tx, release, justification, sql.NullTime{}, now)
+	if err != nil {
+		return ReleaseOverriddenEvent{}, false, err
+	}
+	return event, true, nil
+}
+
+// RequestReleaseOverride records a ReleaseOverriddenEvent that must be confirmed (see
+// ConfirmReleaseOverride) before `confirmationDeadline`. The Release itself isn't modified:
+// its approval rules are still evaluated in the meantime, and may reject it before the override
+// is confirmed.
+//
+// The caller is responsible for checking whether the Release is overridable.
+func RequestReleaseOverride(tx *gorm.DB, release Release, justification string, confirmationDeadline time.Time,
+	now time.Time) (ReleaseOverriddenEvent, error) {
+
+	return createReleaseOverriddenEvent(tx, &release, justification,
+		sql.NullTime{Time: confirmationDeadline, Valid: true}, now)
+}
+
+// ConfirmReleaseOverride approves an in-progress or rejected Release regardless of its approval
+// rules (see FinalizeRelease), marks it as overridden, and records a ReleaseOverrideConfirmedEvent
+// for the given pending ReleaseOverriddenEvent. Returns false if the Release is no longer
+// overridable (see Release.IsOverridable), in which case nothing is changed.
+//
+// The caller is responsible for checking whether the override may still be confirmed, and by whom.
+func ConfirmReleaseOverride(tx *gorm.DB, release *Release, overriddenEvent ReleaseOverriddenEvent,
+	now time.Time) (ReleaseOverrideConfirmedEvent, bool, error) {
+
+	finalized, err := finalizeOverriddenRelease(tx, release, now)
+	if err != nil || !finalized {
+		return ReleaseOverrideConfirmedEvent{}, false, err
+	}
+
+	event := ReleaseOverrideConfirmedEvent{
+		ReleaseEvent: ReleaseEvent{
+			BaseModel:     BaseModel{OrganizationID: release.OrganizationID},
+			ReleaseID:     release.ID,
+			ApplicationID: release.ApplicationID,
+			CreatedAt:     now,
+		},
+		ReleaseOverriddenEventID: overriddenEvent.ID,
+	}
+	err = tx.Omit(clause.Associations).Create(&event).Error
+	if err != nil {
+		return ReleaseOverrideConfirmedEvent{}, false, err
+	}
+
+	return event, true, NotifyReleaseEventCreated(tx, event.ReleaseEvent, ReleaseOverrideConfirmedEventType)
+}
+
+func finalizeOverriddenRelease(tx *gorm.DB, release *Release, now time.Time) (bool, error) {
+	finalized, err := finalizeReleaseFromStates(tx, release, OverridableReleaseStates, releasestate.Approved, now)
+	if err != nil || !finalized {
+		return false, err
+	}
+
+	err = tx.Model(&Release{}).
+		Where("organization_id = ? AND application_id = ? AND id = ?",
+			release.OrganizationID, release.ApplicationID, release.ID).
+		Update("overridden", true).
+		Error
+	if err != nil {
+		return false, err
+	}
+	release.Overridden = true
+	return true, nil
+}
+
+func createReleaseOverriddenEvent(tx *gorm.DB, release *Release, justification string,
+	confirmationDeadline sql.NullTime, now time.Time) (ReleaseOverriddenEvent, error) {
+
+	event := ReleaseOverriddenEvent{
+		ReleaseEvent: ReleaseEvent{
+			BaseModel:     BaseModel{OrganizationID: release.OrganizationID},
+			ReleaseID:     release.ID,
+			ApplicationID: release.ApplicationID,
+			CreatedAt:     now,
+		},
+		Justification:        justification,
+		ConfirmationDeadline: confirmationDeadline,
+	}
+	err := tx.Omit(clause.Associations).Create(&event).Error
+	if err != nil {
+		return ReleaseOverriddenEvent{}, err
+	}
+
+	return event, NotifyReleaseEventCreated(tx, event.ReleaseEvent, ReleaseOverriddenEventType)
+}
+
+// ReportReleaseDeployment sets the deployment state of a Release, and records a
+// ReleaseDeploymentEvent. The Release is only modified if its deployment state in the database
+// is still the same as in `release`, so that concurrent reports don't overwrite each other.
+// Returns whether the deployment state was modified.
+//
+// The caller is responsible for checking whether the Release is approved, and whether its
+// deployment state may transition to the given state.
+func ReportReleaseDeployment(tx *gorm.DB, release *Release, state deploymentstate.State, comments sql.NullString,
+	now time.Time) (ReleaseDeploymentEvent, bool, error) {
+
+	updatetx := tx.Model(&Release{}).
+		Where("organization_id = ? AND application_id = ? AND id = ?",
+			release.OrganizationID, release.ApplicationID, release.ID)
+	if release.DeploymentState.Valid {
+		updatetx = updatetx.Where("deployment_state = ?", release.DeploymentState.String)
+	} else {
+		updatetx = updatetx.Where("deployment_state IS NULL")
+	}
+	updatetx = updatetx.Updates(map[string]interface{}{
+		"deployment_state": state,
+		"updated_at":       now,
+	})
+	if updatetx.Error != nil {
+		return ReleaseDeploymentEvent{}, false, updatetx.Error
+	}
+	if updatetx.RowsAffected == 0 {
+		return ReleaseDeploymentEvent{}, false, nil
+	}
+
+	release.DeploymentState = sql.NullString{String: string(state), Valid: true}
+	release.UpdatedAt = now
+
+	event := ReleaseDeploymentEvent{
+		ReleaseEvent: ReleaseEvent{
+			BaseModel:     BaseModel{OrganizationID: release.OrganizationID},
+			ReleaseID:     release.ID,
+			ApplicationID: release.ApplicationID,
+			CreatedAt:     now,
+		},
+		State:    state,
+		Comments: comments,
+	}
+	err := tx.Omit(clause.Associations).Create(&event).Error
+	if err != nil {
+		return ReleaseDeploymentEvent{}, false, err
+	}
+
+	return event, true, NotifyReleaseEventCreated(tx, event.ReleaseEvent, ReleaseDeploymentEventType)
+}
+
+//
+// ******** ReleaseFilter methods ********
+//
+
+// Apply adds the filter's conditions to a Release query.
+func (filter ReleaseFilter) Apply(tx *gorm.DB) (*gorm.DB, error) {
+	if len(filter.States) > 0 {
+		tx = tx.Where("state IN ?", filter.States)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		tx = tx.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		tx = tx.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if !filter.FinalizedAfter.IsZero() {
+		tx = tx.Where("finalized_at >= ?", filter.FinalizedAfter)
+	}
+	if !filter.FinalizedBefore.IsZero() {
+		tx = tx.Where("finalized_at < ?", filter.FinalizedBefore)
+	}
+	if len(filter.SourceIdentityPrefix) > 0 {
+		tx = tx.Where("source_identity LIKE ?", dbutils.EscapeLikePattern(filter.SourceIdentityPrefix)+"%")
+	}
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("metadata @> ?::jsonb", string(metadata))
+	}
+	if filter.Overridden != nil {
+		tx = tx.Where("overridden = ?", *filter.Overridden)
+	}
+	return tx, nil
+}
+
+//
+// ******** ReleaseSortOrder methods ********
+//
+
+// IsValid returns whether this is one of AllReleaseSortOrders.
+func (order ReleaseSortOrder) IsValid() bool {
+	for _, o := range AllReleaseSortOrders {
+		if order == o {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply adds this sort order to a Release query. Releases with equal sort keys are ordered by ID,
+// so that pagination is stable.
+func (order ReleaseSortOrder) Apply(tx *gorm.DB) *gorm.DB {
+	switch order {
+	case ReleaseSortCreatedAtAsc:
+		return tx.Order("created_at, id")
+	case ReleaseSortFinalizedAtAsc:
+		return tx.Order("finalized_at NULLS LAST, id")
+	case ReleaseSortFinalizedAtDesc:
+		return tx.Order("finalized_at DESC NULLS LAST, id DESC")
+	default:
+		return tx.Order("created_at DESC, id DESC")
+	}
+}
+
 //
 // ******** Find/load functions ********
 //
@@ -51,6 +418,48 @@ func FindReleases(db *gorm.DB, organizationID string, applicationID string) ([]R
 	return result, tx.Error
 }
 
+// FindApprovedReleasesFinalizedSince returns the Application's approved Releases that were
+// finalized at or after `since`, ordered by finalization time.
+func FindApprovedReleasesFinalizedSince(db *gorm.DB, organizationID string, applicationID string, since time.Time) ([]Release, error) {
+	tx := db.Where("state = ? AND finalized_at >= ?", releasestate.Approved, since).Order("finalized_at")
+	return FindReleases(tx, organizationID, applicationID)
+}
+
+// latestApplicationAdjustmentExpiryQuery is a lateral subquery that selects the ReleaseExpiryMinutes
+// of the latest adjustment of the latest version of a Release's Application.
+const latestApplicationAdjustmentExpiryQuery = "SELECT application_adjustments.release_expiry_minutes" +
+	" FROM application_versions" +
+	" JOIN application_adjustments ON application_adjustments.organization_id = application_versions.organization_id" +
+	" AND application_adjustments.application_version_id = application_versions.id" +
+	" WHERE application_versions.organization_id = releases.organization_id" +
+	" AND application_versions.application_id = releases.application_id" +
+	" AND application_versions.version_number IS NOT NULL" +
+	" ORDER BY application_versions.version_number DESC, application_adjustments.adjustment_number DESC" +
+	" LIMIT 1"
+
+// FindExpiredReleases returns, across all organizations, the in-progress Releases that were
+// created longer ago than their Application's ReleaseExpiryMinutes allows. At most `limit`
+// Releases are returned, ordered by creation time. To fetch the next batch, pass the last
+// Release of the previous batch as `after`.
+func FindExpiredReleases(db *gorm.DB, now time.Time, after *Release, limit int) ([]Release, error) {
+	var result []Release
+	tx := db.
+		Select("releases.*").
+		Joins("JOIN LATERAL ("+latestApplicationAdjustmentExpiryQuery+") latest_application_adjustment ON true").
+		Where("releases.state = ?", releasestate.InProgress).
+		Where("latest_application_adjustment.release_expiry_minutes IS NOT NULL").
+		Where("releases.created_at + latest_application_adjustment.release_expiry_minutes * interval '1 minute' <= ?", now)
+	if after != nil {
+		tx = tx.Where("(releases.created_at, releases.organization_id, releases.application_id, releases.id) > (?, ?, ?, ?)",
+			after.CreatedAt, after.OrganizationID, after.ApplicationID, after.ID)
+	}
+	tx = tx.
+		Order("releases.created_at, releases.organization_id, releases.application_id, releases.id").
+		Limit(limit).
+		Find(&result)
+	return result, tx.Error
+}
+
 // FindRelease looks up a Release by its ID and its application ID.
 // When not found, returns a `gorm.ErrRecordNotFound` error.
 func FindRelease(db *gorm.DB, organizationID string, applicationID string, releaseID uint64) (Release, error) {
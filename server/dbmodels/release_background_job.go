@@ -1,6 +1,7 @@
 package dbmodels
 
 import (
+	"database/sql"
 	"fmt"
 	"math"
 	"math/rand"
@@ -23,6 +24,16 @@ const ReleaseBackgroundJobPostgresLockNamespace uint64 = 1 * 0xffffffff
 // ReleaseBackgroundJobMaxLockID is the maximum value that `ReleaseBackgroundJob.LockID` may have.
 var ReleaseBackgroundJobMaxLockSubID uint32 = uint32(math.Pow(2, 31)) - 1
 
+// ReleaseBackgroundJobsNotificationChannel is the PostgreSQL notification channel on which a
+// notification is sent whenever a ReleaseBackgroundJob is scheduled to run, so that idle
+// processes can claim it without waiting for their next poll.
+const ReleaseBackgroundJobsNotificationChannel = "sqedule_release_background_jobs"
+
+// ReleaseBackgroundJobClaimDuration is how long a claim on a ReleaseBackgroundJob lasts (see
+// ReleaseBackgroundJob.ClaimedUntil). It must be comfortably longer than processing a Release's
+// approval rules takes, or else the job may be processed by another process in the meantime.
+const ReleaseBackgroundJobClaimDuration = 15 * time.Minute
+
 // ReleaseBackgroundJob ...
 type ReleaseBackgroundJob struct {
 	BaseModel
@@ -31,6 +42,152 @@ type ReleaseBackgroundJob struct {
 	Release       Release   `gorm:"foreignKey:OrganizationID,ApplicationID,ReleaseID; references:OrganizationID,ApplicationID,ID; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	LockSubID     uint32    `gorm:"type:int; autoIncrement; unique; not null; check:(lock_sub_id > 0)"`
 	CreatedAt     time.Time `gorm:"not null"`
+
+	// Attempts is the number of times that processing this job has failed.
+	Attempts uint32 `gorm:"type:int; not null; default:0"`
+	// LastError is the error that occurred during the last failed attempt.
+	LastError sql.NullString
+	// NextRunAt is the time at which the job should be processed by whichever process claims
+	// it first (see ClaimDueReleaseBackgroundJobs). This is set when a failed job should be
+	// retried, or when the job is to be processed by a worker. It's null if no run is scheduled.
+	NextRunAt sql.NullTime
+	// DeadAt is the time at which we gave up retrying this job. It's null if the
+	// job isn't dead.
+	DeadAt sql.NullTime
+	// ClaimedUntil is the time until which the process that claimed the job (see Claim) may
+	// process it. If that process dies, then the claim expires, and the job is picked up by
+	// ScheduleAbandonedReleaseBackgroundJobs. It's null if nobody is processing the job: either
+	// because a run is scheduled, or because the Release awaits further input (e.g. a manual
+	// approval), whose arrival schedules a run.
+	ClaimedUntil sql.NullTime
+}
+
+// ReleaseBackgroundJobState describes where a ReleaseBackgroundJob is in its retry lifecycle.
+type ReleaseBackgroundJobState string
+
+const (
+	// ReleaseBackgroundJobPending means that the job hasn't failed, or has been retried manually.
+	ReleaseBackgroundJobPending ReleaseBackgroundJobState = "pending"
+	// ReleaseBackgroundJobRetrying means that the job has failed and that a retry is scheduled.
+	ReleaseBackgroundJobRetrying ReleaseBackgroundJobState = "retrying"
+	// ReleaseBackgroundJobDead means that the job failed too many times and won't be retried
+	// until someone retries it manually.
+	ReleaseBackgroundJobDead ReleaseBackgroundJobState = "dead"
+)
+
+//
+// ******** ReleaseBackgroundJob methods ********
+//
+
+// State returns the job's retry lifecycle state, as derived from DeadAt, NextRunAt and Attempts.
+func (job ReleaseBackgroundJob) State() ReleaseBackgroundJobState {
+	if job.DeadAt.Valid {
+		return ReleaseBackgroundJobDead
+	}
+	if job.NextRunAt.Valid && job.Attempts > 0 {
+		return ReleaseBackgroundJobRetrying
+	}
+	return ReleaseBackgroundJobPending
+}
+
+// ScheduleRun schedules the job to be processed at the given time, by whichever process
+// claims it first through Claim() or ClaimDueReleaseBackgroundJobs().
+func (job *ReleaseBackgroundJob) ScheduleRun(db *gorm.DB, at time.Time) error {
+	job.NextRunAt = sql.NullTime{Time: at, Valid: true}
+	job.ClaimedUntil = sql.NullTime{}
+	err := db.Model(job).Omit(clause.Associations).Updates(map[string]interface{}{
+		"next_run_at":   job.NextRunAt,
+		"claimed_until": job.ClaimedUntil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return dbutils.Notify(db, ReleaseBackgroundJobsNotificationChannel, "")
+}
+
+// Claim unschedules the job's scheduled run and claims the job for ReleaseBackgroundJobClaimDuration,
+// so that no other process claims it. It returns false if the job has no scheduled run (e.g. because
+// another process claimed it first), or if the job is dead.
+func (job *ReleaseBackgroundJob) Claim(db *gorm.DB, now time.Time) (bool, error) {
+	claimedUntil := sql.NullTime{Time: now.Add(ReleaseBackgroundJobClaimDuration), Valid: true}
+	tx := db.Model(job).Omit(clause.Associations).
+		Where("next_run_at IS NOT NULL AND dead_at IS NULL").
+		Updates(map[string]interface{}{
+			"next_run_at":   nil,
+			"claimed_until": claimedUntil,
+		})
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	job.NextRunAt = sql.NullTime{}
+	if tx.RowsAffected == 0 {
+		return false, nil
+	}
+	job.ClaimedUntil = claimedUntil
+	return true, nil
+}
+
+// ExtendClaim extends the job's claim until the given time. This is used by processes
+// which wait until a deferred Release becomes eligible, instead of scheduling a run.
+func (job *ReleaseBackgroundJob) ExtendClaim(db *gorm.DB, until time.Time) error {
+	job.ClaimedUntil = sql.NullTime{Time: until, Valid: true}
+	return db.Model(job).Omit(clause.Associations).Update("claimed_until", job.ClaimedUntil).Error
+}
+
+// Unclaim releases the job's claim after processing, when the Release awaits further input. The
+// claim is left alone if it has changed in the meantime, i.e. if another process claimed the job.
+func (job *ReleaseBackgroundJob) Unclaim(db *gorm.DB) error {
+	if !job.ClaimedUntil.Valid {
+		return nil
+	}
+	err := db.Model(job).Omit(clause.Associations).
+		Where("claimed_until = ?", job.ClaimedUntil.Time).
+		Update("claimed_until", nil).
+		Error
+	if err != nil {
+		return err
+	}
+	job.ClaimedUntil = sql.NullTime{}
+	return nil
+}
+
+// RecordFailure increments the job's attempt counter and records the error. If `nextRunAt` is
+// non-nil then a retry is scheduled at that time; otherwise the job is marked as dead at `now`.
+// Either way, the job's claim is released.
+func (job *ReleaseBackgroundJob) RecordFailure(db *gorm.DB, failure error, now time.Time, nextRunAt *time.Time) error {
+	job.Attempts++
+	job.LastError = sql.NullString{String: failure.Error(), Valid: true}
+	job.ClaimedUntil = sql.NullTime{}
+	if nextRunAt != nil {
+		job.NextRunAt = sql.NullTime{Time: *nextRunAt, Valid: true}
+		job.DeadAt = sql.NullTime{}
+	} else {
+		job.NextRunAt = sql.NullTime{}
+		job.DeadAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	columns := job.retryStateColumns()
+	columns["claimed_until"] = job.ClaimedUntil
+	return db.Model(job).Omit(clause.Associations).Updates(columns).Error
+}
+
+// ResetRetryState clears the job's attempt counter, last error and dead state, so that it
+// becomes pending again.
+func (job *ReleaseBackgroundJob) ResetRetryState(db *gorm.DB) error {
+	job.Attempts = 0
+	job.LastError = sql.NullString{}
+	job.NextRunAt = sql.NullTime{}
+	job.DeadAt = sql.NullTime{}
+	return db.Model(job).Omit(clause.Associations).Updates(job.retryStateColumns()).Error
+}
+
+func (job ReleaseBackgroundJob) retryStateColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"attempts":    job.Attempts,
+		"last_error":  job.LastError,
+		"next_run_at": job.NextRunAt,
+		"dead_at":     job.DeadAt,
+	}
 }
 
 //
@@ -62,6 +219,7 @@ func createReleaseBackgroundJobWithDebug(db *gorm.DB, organizationID string, app
 				ApplicationID: applicationID,
 				ReleaseID:     release.ID,
 				Release:       release,
+				NextRunAt:     sql.NullTime{Time: time.Now(), Valid: true},
 			}
 			if numTry > 0 {
 				// We were unable to obtain a free lock sub-ID through auto-incrementation.
@@ -87,6 +245,12 @@ func createReleaseBackgroundJobWithDebug(db *gorm.DB, organizationID string, app
 	}
 
 	if created {
+		// Let idle processes know that they can claim this job, in case the caller
+		// doesn't process it itself (or dies before it can).
+		err = dbutils.Notify(db, ReleaseBackgroundJobsNotificationChannel, "")
+		if err != nil {
+			return ReleaseBackgroundJob{}, numTry, err
+		}
 		return job, numTry, nil
 	}
 	if err != nil {
@@ -109,10 +273,91 @@ func FindReleaseBackgroundJob(db *gorm.DB, organizationID string, applicationID
 	return result, dbutils.CreateFindOperationError(tx)
 }
 
-// FindUnlockedReleaseBackgroundJobs returns all ReleaseBackgroundJobs, in the entire database
-// (across organizations), that aren't currently being processed by approvalrulesprocessing.Engine.
-func FindUnlockedReleaseBackgroundJobs(db *gorm.DB) ([]ReleaseBackgroundJob, error) {
+// ClaimDueReleaseBackgroundJobs returns ReleaseBackgroundJobs, in the entire database (across
+// organizations), whose scheduled run is due at `now`, earliest first. If `limit` is greater than
+// zero then at most that many jobs are returned. The returned jobs' runs are unscheduled (NextRunAt
+// is cleared, and the jobs are claimed as with ReleaseBackgroundJob.Claim()) in the same transaction,
+// and jobs that are being claimed by other transactions are skipped, so that each due job is claimed
+// only once, even when multiple processes claim concurrently.
+func ClaimDueReleaseBackgroundJobs(db *gorm.DB, now time.Time, limit int) ([]ReleaseBackgroundJob, error) {
+	return claimReleaseBackgroundJobs(db, now, limit, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("dead_at IS NULL AND next_run_at IS NOT NULL AND next_run_at <= ?", now).
+			Order("next_run_at")
+	})
+}
+
+// ClaimAbandonedReleaseBackgroundJobs returns all ReleaseBackgroundJobs, in the entire database
+// (across organizations), whose claim has expired without the job being finalized, rescheduled or
+// released, e.g. because the process that claimed them died. The returned jobs are claimed
+// like ClaimDueReleaseBackgroundJobs() does. Dead jobs are not returned.
+func ClaimAbandonedReleaseBackgroundJobs(db *gorm.DB, now time.Time) ([]ReleaseBackgroundJob, error) {
+	return claimReleaseBackgroundJobs(db, now, 0, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("dead_at IS NULL AND next_run_at IS NULL AND claimed_until <= ?", now)
+	})
+}
+
+func claimReleaseBackgroundJobs(db *gorm.DB, now time.Time, limit int, scope func(tx *gorm.DB) *gorm.DB) ([]ReleaseBackgroundJob, error) {
 	var result []ReleaseBackgroundJob
-	tx := db.Clauses(clause.Locking{Strength: "UPDATE SKIP LOCKED"}).Preload("Release").Find(&result)
-	return result, tx.Error
+	claimedUntil := sql.NullTime{Time: now.Add(ReleaseBackgroundJobClaimDuration), Valid: true}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		findtx := scope(tx.Clauses(clause.Locking{Strength: "UPDATE SKIP LOCKED"}).Preload("Release"))
+		if limit > 0 {
+			findtx = findtx.Limit(limit)
+		}
+		findtx = findtx.Find(&result)
+		if findtx.Error != nil {
+			return findtx.Error
+		}
+
+		for i := range result {
+			job := &result[i]
+			job.NextRunAt = sql.NullTime{}
+			job.ClaimedUntil = claimedUntil
+			err := tx.Model(job).Omit(clause.Associations).Updates(map[string]interface{}{
+				"next_run_at":   nil,
+				"claimed_until": claimedUntil,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//
+// ******** Other functions ********
+//
+
+// ScheduleAbandonedReleaseBackgroundJobs schedules all ReleaseBackgroundJobs, in the entire database
+// (across organizations), whose claim has expired (see ClaimAbandonedReleaseBackgroundJobs), to run
+// at `now`. Jobs that are being processed, or whose Release awaits further input, are left alone,
+// and so are jobs whose Release is deferred until after `now` (see Release.NextEligibleAt).
+// This is the ClaimDueReleaseBackgroundJobs() counterpart of ClaimAbandonedReleaseBackgroundJobs().
+func ScheduleAbandonedReleaseBackgroundJobs(db *gorm.DB, now time.Time) (int64, error) {
+	tx := db.Model(&ReleaseBackgroundJob{}).
+		Where("dead_at IS NULL AND next_run_at IS NULL AND claimed_until <= ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM releases"+
+			" WHERE releases.organization_id = release_background_jobs.organization_id"+
+			" AND releases.application_id = release_background_jobs.application_id"+
+			" AND releases.id = release_background_jobs.release_id"+
+			" AND releases.next_eligible_at > ?)", now).
+		Updates(map[string]interface{}{
+			"next_run_at":   now,
+			"claimed_until": nil,
+		})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	if tx.RowsAffected > 0 {
+		err := dbutils.Notify(db, ReleaseBackgroundJobsNotificationChannel, "")
+		if err != nil {
+			return tx.RowsAffected, err
+		}
+	}
+	return tx.RowsAffected, nil
 }
@@ -1,9 +1,12 @@
 package dbmodels
 
 import (
+	"database/sql"
 	"time"
 
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/deploymentstate"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
 	"gorm.io/gorm"
 )
 
@@ -14,11 +17,19 @@ import (
 type ReleaseEventType string
 
 const (
-	ReleaseCreatedEventType       ReleaseEventType = "created"
-	ReleaseCancelledEventType     ReleaseEventType = "cancelled"
-	ReleaseRuleProcessedEventType ReleaseEventType = "rule_processed"
-
-	NumReleaseEventTypes uint = 3
+	ReleaseCreatedEventType           ReleaseEventType = "created"
+	ReleaseCancelledEventType         ReleaseEventType = "cancelled"
+	ReleaseRuleProcessedEventType     ReleaseEventType = "rule_processed"
+	ReleaseRuleSkippedEventType       ReleaseEventType = "rule_skipped"
+	ReleaseDeploymentEventType        ReleaseEventType = "deployment"
+	ReleaseOverriddenEventType        ReleaseEventType = "overridden"
+	ReleaseOverrideConfirmedEventType ReleaseEventType = "override_confirmed"
+
+	NumReleaseEventTypes uint = 7
+
+	// ReleaseCancelledEventExpiredReason is the reason of a ReleaseCancelledEvent that was
+	// created because the Release remained in progress for longer than its Application allows.
+	ReleaseCancelledEventExpiredReason = "expired"
 )
 
 type ReleaseEvent struct {
@@ -36,6 +47,8 @@ type ReleaseCreatedEvent struct {
 
 type ReleaseCancelledEvent struct {
 	ReleaseEvent
+	// Reason is set if the Release wasn't cancelled by a user, e.g. ReleaseCancelledEventExpiredReason.
+	Reason sql.NullString
 }
 
 type ReleaseRuleProcessedEvent struct {
@@ -43,16 +56,57 @@ type ReleaseRuleProcessedEvent struct {
 	ResultState  releasestate.State `gorm:"type:release_state; not null"`
 	IgnoredError bool               `gorm:"not null"`
 
-	// These are set by LoadReleaseRuleProcessedEventsApprovalRuleOutcomes()
-	HTTPApiApprovalRuleOutcome  *HTTPApiApprovalRuleOutcome  `gorm:"-"`
-	ScheduleApprovalRuleOutcome *ScheduleApprovalRuleOutcome `gorm:"-"`
-	ManualApprovalRuleOutcome   *ManualApprovalRuleOutcome   `gorm:"-"`
+	// These are set by LoadReleaseRuleProcessedEventsApprovalRuleOutcomes(). ApprovalRuleOutcome
+	// is a pointer to an outcome of the given type, e.g. `*ScheduleApprovalRuleOutcome`.
+	ApprovalRuleType    ApprovalRuleType `gorm:"-"`
+	ApprovalRuleOutcome interface{}      `gorm:"-"`
+}
+
+// ReleaseRuleSkippedEvent records that an ApprovalRule, or an entire ApprovalRuleset, was not
+// evaluated for a Release because it's disabled.
+type ReleaseRuleSkippedEvent struct {
+	ReleaseEvent
+	// ApprovalRulesetID is set if an entire ruleset was skipped because its binding is disabled.
+	ApprovalRulesetID sql.NullString `gorm:"type:citext"`
+	// ApprovalRuleType and ApprovalRuleID are set if a single rule was skipped.
+	ApprovalRuleType sql.NullString
+	ApprovalRuleID   sql.NullInt64
+	Reason           string `gorm:"not null"`
+}
+
+// ReleaseDeploymentEvent records that the deployment state of an approved Release was reported.
+type ReleaseDeploymentEvent struct {
+	ReleaseEvent
+	State    deploymentstate.State `gorm:"type:deployment_state; not null"`
+	Comments sql.NullString
+}
+
+// ReleaseOverriddenEvent records that an OrganizationMember forced a Release to be approved,
+// regardless of its approval rules. If ConfirmationDeadline is set, then the override only takes
+// effect once a different OrganizationMember confirms it (see ReleaseOverrideConfirmedEvent)
+// before that deadline.
+type ReleaseOverriddenEvent struct {
+	ReleaseEvent
+	Justification        string `gorm:"not null"`
+	ConfirmationDeadline sql.NullTime
+}
+
+// ReleaseOverrideConfirmedEvent records that a second OrganizationMember confirmed a
+// ReleaseOverriddenEvent.
+type ReleaseOverrideConfirmedEvent struct {
+	ReleaseEvent
+	ReleaseOverriddenEventID uint64                 `gorm:"not null"`
+	ReleaseOverriddenEvent   ReleaseOverriddenEvent `gorm:"foreignKey:OrganizationID,ReleaseOverriddenEventID; references:OrganizationID,ID; constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 }
 
 type ReleaseEventCollection struct {
-	ReleaseCreatedEvents       []ReleaseCreatedEvent
-	ReleaseCancelledEvents     []ReleaseCancelledEvent
-	ReleaseRuleProcessedEvents []ReleaseRuleProcessedEvent
+	ReleaseCreatedEvents           []ReleaseCreatedEvent
+	ReleaseCancelledEvents         []ReleaseCancelledEvent
+	ReleaseRuleProcessedEvents     []ReleaseRuleProcessedEvent
+	ReleaseRuleSkippedEvents       []ReleaseRuleSkippedEvent
+	ReleaseDeploymentEvents        []ReleaseDeploymentEvent
+	ReleaseOverriddenEvents        []ReleaseOverriddenEvent
+	ReleaseOverrideConfirmedEvents []ReleaseOverrideConfirmedEvent
 }
 
 //
@@ -63,7 +117,11 @@ type ReleaseEventCollection struct {
 func (c ReleaseEventCollection) NumEvents() uint {
 	return uint(len(c.ReleaseCreatedEvents)) +
 		uint(len(c.ReleaseCancelledEvents)) +
-		uint(len(c.ReleaseRuleProcessedEvents))
+		uint(len(c.ReleaseRuleProcessedEvents)) +
+		uint(len(c.ReleaseRuleSkippedEvents)) +
+		uint(len(c.ReleaseDeploymentEvents)) +
+		uint(len(c.ReleaseOverriddenEvents)) +
+		uint(len(c.ReleaseOverrideConfirmedEvents))
 }
 
 //
@@ -103,67 +161,102 @@ func FindReleaseEvents(db *gorm.DB, organizationID string, applicationID string,
 		return ReleaseEventCollection{}, tx.Error
 	}
 
-	if typesProcessed != NumReleaseEventTypes {
-		panic("Bug: code does not cover all release event types")
+	typesProcessed++
+	tx = db.
+		Where(conditions).
+		Order("created_at").
+		Find(&result.ReleaseRuleSkippedEvents)
+	if tx.Error != nil {
+		return ReleaseEventCollection{}, tx.Error
 	}
 
-	return result, nil
-}
-
-func LoadReleaseRuleProcessedEventsApprovalRuleOutcomes(db *gorm.DB, organizationID string, events []*ReleaseRuleProcessedEvent) error {
-	var tx *gorm.DB
-	var typesProcessed uint
-
-	eventIDs := CollectReleaseRuleProcessedEventIDs(events)
-	eventsIndexByID := indexReleaseRuleProcessedEventsByID(events)
-	conditions := db.Where("organization_id = ? AND release_rule_processed_event_id IN ?", organizationID, eventIDs)
-
 	typesProcessed++
-	var httpAPIApprovalRuleOutcomes []HTTPApiApprovalRuleOutcome
-	tx = db.Where(conditions).Preload("HTTPApiApprovalRule").Find(&httpAPIApprovalRuleOutcomes)
+	tx = db.
+		Where(conditions).
+		Order("created_at").
+		Find(&result.ReleaseDeploymentEvents)
 	if tx.Error != nil {
-		return tx.Error
-	}
-	for i := range httpAPIApprovalRuleOutcomes {
-		outcome := &httpAPIApprovalRuleOutcomes[i]
-		event, ok := eventsIndexByID[outcome.ReleaseRuleProcessedEventID]
-		if ok {
-			event.HTTPApiApprovalRuleOutcome = outcome
-		}
+		return ReleaseEventCollection{}, tx.Error
 	}
 
 	typesProcessed++
-	var scheduleApprovalRuleOutcomes []ScheduleApprovalRuleOutcome
-	tx = db.Where(conditions).Preload("ScheduleApprovalRule").Find(&scheduleApprovalRuleOutcomes)
+	tx = db.
+		Where(conditions).
+		Order("created_at").
+		Find(&result.ReleaseOverriddenEvents)
 	if tx.Error != nil {
-		return tx.Error
-	}
-	for i := range scheduleApprovalRuleOutcomes {
-		outcome := &scheduleApprovalRuleOutcomes[i]
-		event, ok := eventsIndexByID[outcome.ReleaseRuleProcessedEventID]
-		if ok {
-			event.ScheduleApprovalRuleOutcome = outcome
-		}
+		return ReleaseEventCollection{}, tx.Error
 	}
 
 	typesProcessed++
-	var manualApprovalRuleOutcomes []ManualApprovalRuleOutcome
-	tx = db.Where(conditions).Preload("ManualApprovalRule").Find(&manualApprovalRuleOutcomes)
+	tx = db.
+		Where(conditions).
+		Order("created_at").
+		Find(&result.ReleaseOverrideConfirmedEvents)
 	if tx.Error != nil {
-		return tx.Error
-	}
-	for i := range manualApprovalRuleOutcomes {
-		outcome := &manualApprovalRuleOutcomes[i]
-		event, ok := eventsIndexByID[outcome.ReleaseRuleProcessedEventID]
-		if ok {
-			event.ManualApprovalRuleOutcome = outcome
-		}
+		return ReleaseEventCollection{}, tx.Error
 	}
 
 	if typesProcessed != NumReleaseEventTypes {
 		panic("Bug: code does not cover all release event types")
 	}
 
+	return result, nil
+}
+
+func FindReleaseRuleSkippedEvents(db *gorm.DB, organizationID string, applicationID string, releaseID uint64) ([]ReleaseRuleSkippedEvent, error) {
+	var result []ReleaseRuleSkippedEvent
+	tx := db.Where("organization_id = ? AND application_id = ? AND release_id = ?", organizationID, applicationID, releaseID)
+	tx = tx.Order("created_at").Find(&result)
+	return result, tx.Error
+}
+
+// FindPendingReleaseOverriddenEvent finds the most recent ReleaseOverriddenEvent of the given
+// Release that still awaits confirmation, i.e. whose ConfirmationDeadline hasn't passed yet.
+// Returns gorm.ErrRecordNotFound if there is none.
+func FindPendingReleaseOverriddenEvent(db *gorm.DB, organizationID string, applicationID string, releaseID uint64,
+	now time.Time) (ReleaseOverriddenEvent, error) {
+
+	var result ReleaseOverriddenEvent
+	tx := db.
+		Where("organization_id = ? AND application_id = ? AND release_id = ? AND confirmation_deadline > ?",
+			organizationID, applicationID, releaseID, now).
+		Order("created_at DESC, id DESC").
+		Take(&result)
+	return result, dbutils.CreateFindOperationError(tx)
+}
+
+// ReleaseOverriddenEventCreatedByOrganizationMember checks whether the given ReleaseOverriddenEvent
+// was created by the given OrganizationMember.
+func ReleaseOverriddenEventCreatedByOrganizationMember(db *gorm.DB, organizationID string, eventID uint64,
+	orgMember IOrganizationMember) (bool, error) {
+
+	var count int64
+	memberColumn := creationAuditRecordOrganizationMemberColumn(orgMember)
+
+	tx := db.
+		Model(&CreationAuditRecord{}).
+		Where("creation_audit_records.organization_id = ? "+
+			"AND creation_audit_records.release_overridden_event_id = ? "+
+			"AND "+memberColumn+" = ?",
+			organizationID, eventID, orgMember.ID()).
+		Count(&count)
+	return count > 0, tx.Error
+}
+
+func LoadReleaseRuleProcessedEventsApprovalRuleOutcomes(db *gorm.DB, organizationID string, events []*ReleaseRuleProcessedEvent) error {
+	eventIDs := CollectReleaseRuleProcessedEventIDs(events)
+	eventsIndexByID := indexReleaseRuleProcessedEventsByID(events)
+	conditions := db.Where("organization_id = ? AND release_rule_processed_event_id IN ?", organizationID, eventIDs)
+
+	// There's one outcome type per approval rule type.
+	for _, definition := range ApprovalRuleTypeDefinitions() {
+		err := definition.LoadOutcomes(db.Where(conditions), eventsIndexByID)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
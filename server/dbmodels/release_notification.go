@@ -0,0 +1,64 @@
+package dbmodels
+
+import (
+	"encoding/json"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"gorm.io/gorm"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// ReleaseNotificationsChannel is the PostgreSQL notification channel on which ReleaseNotifications
+// are sent.
+const ReleaseNotificationsChannel = "sqedule_releases"
+
+// ReleaseNotification announces that a ReleaseEvent was created, or that a Release's state changed.
+// It's sent with NotifyRelease(), and is used for streaming release events to HTTP clients.
+type ReleaseNotification struct {
+	OrganizationID string `json:"organization_id"`
+	ApplicationID  string `json:"application_id"`
+	ReleaseID      uint64 `json:"release_id"`
+
+	// EventType and EventID are set if a ReleaseEvent was created.
+	EventType ReleaseEventType `json:"event_type,omitempty"`
+	EventID   uint64           `json:"event_id,omitempty"`
+
+	// State is set if the Release's state changed.
+	State releasestate.State `json:"state,omitempty"`
+}
+
+//
+// ******** Other functions ********
+//
+
+// NotifyRelease sends a ReleaseNotification on ReleaseNotificationsChannel. If `db` is a
+// transaction, then the notification is only delivered when the transaction commits.
+func NotifyRelease(db *gorm.DB, notification ReleaseNotification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return dbutils.Notify(db, ReleaseNotificationsChannel, string(payload))
+}
+
+// NotifyReleaseEventCreated sends a ReleaseNotification about a newly created ReleaseEvent.
+func NotifyReleaseEventCreated(db *gorm.DB, event ReleaseEvent, eventType ReleaseEventType) error {
+	return NotifyRelease(db, ReleaseNotification{
+		OrganizationID: event.OrganizationID,
+		ApplicationID:  event.ApplicationID,
+		ReleaseID:      event.ReleaseID,
+		EventType:      eventType,
+		EventID:        event.ID,
+	})
+}
+
+// ParseReleaseNotification parses the payload of a notification on ReleaseNotificationsChannel.
+func ParseReleaseNotification(payload string) (ReleaseNotification, error) {
+	var result ReleaseNotification
+	err := json.Unmarshal([]byte(payload), &result)
+	return result, err
+}
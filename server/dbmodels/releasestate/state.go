@@ -16,6 +16,9 @@ const (
 	Rejected State = "rejected"
 )
 
+// All lists all states.
+var All = []State{InProgress, Cancelled, Approved, Rejected}
+
 // Scan ...
 func (t *State) Scan(value interface{}) error {
 	*t = State(value.(string))
@@ -27,6 +30,16 @@ func (t State) Value() (driver.Value, error) {
 	return string(t), nil
 }
 
+// IsValid returns whether this is one of the known states.
+func (t State) IsValid() bool {
+	for _, state := range All {
+		if t == state {
+			return true
+		}
+	}
+	return false
+}
+
 // IsFinal returns whether this is a final state.
 func (t State) IsFinal() bool {
 	return t != InProgress
@@ -6,10 +6,12 @@ import (
 	"time"
 
 	"github.com/fullstaq-labs/sqedule/lib"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalpolicy"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalrulesetbindingmode"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/organizationmemberrole"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/proposalstate"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/retrypolicy"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -473,33 +475,114 @@ func CreateMockReleaseBackgroundJob(db *gorm.DB, organization Organization, app
 	return result, nil
 }
 
-func CreateMockScheduleApprovalRuleWholeDay(db *gorm.DB, organization Organization, rulesetVersionID uint64,
-	rulesetAdjustment ApprovalRulesetAdjustment, customizeFunc func(rule *ScheduleApprovalRule)) (ScheduleApprovalRule, error) {
+// newMockApprovalRule returns the ApprovalRule base that all CreateMock*ApprovalRule functions share.
+func newMockApprovalRule(organization Organization, rulesetVersionID uint64, rulesetAdjustment ApprovalRulesetAdjustment) ApprovalRule {
+	return ApprovalRule{
+		BaseModel: BaseModel{
+			OrganizationID: organization.ID,
+			Organization:   organization,
+		},
+		ApprovalRulesetVersionID:        rulesetVersionID,
+		ApprovalRulesetAdjustmentNumber: rulesetAdjustment.AdjustmentNumber,
+		ApprovalRulesetAdjustment:       rulesetAdjustment,
+		Enabled:                         lib.NewBoolPtr(true),
+	}
+}
 
-	result := ScheduleApprovalRule{
-		ApprovalRule: ApprovalRule{
-			BaseModel: BaseModel{
-				OrganizationID: organization.ID,
-				Organization:   organization,
-			},
-			ApprovalRulesetVersionID:        rulesetVersionID,
-			ApprovalRulesetAdjustmentNumber: rulesetAdjustment.AdjustmentNumber,
-			ApprovalRulesetAdjustment:       rulesetAdjustment,
-			Enabled:                         lib.NewBoolPtr(true),
+// createMockApprovalRule saves a concrete approval rule, as created by a CreateMock*ApprovalRule function.
+func createMockApprovalRule(db *gorm.DB, rule interface{}) error {
+	return db.Omit(clause.Associations).Create(rule).Error
+}
+
+func CreateMockHTTPApiApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, url string, customizeFunc func(rule *HTTPApiApprovalRule)) (HTTPApiApprovalRule, error) {
+
+	result := HTTPApiApprovalRule{
+		ApprovalRule: newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		URL:          url,
+		RetryPolicy:  retrypolicy.Never,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockHTTPApiApprovalRuleOutcome(db *gorm.DB, event ReleaseRuleProcessedEvent, rule HTTPApiApprovalRule, success bool, customizeFunc func(outcome *HTTPApiApprovalRuleOutcome)) (HTTPApiApprovalRuleOutcome, error) {
+	result := HTTPApiApprovalRuleOutcome{
+		ApprovalRuleOutcome: ApprovalRuleOutcome{
+			BaseModel:                   event.BaseModel,
+			ReleaseRuleProcessedEventID: event.ID,
+			ReleaseRuleProcessedEvent:   event,
+			Success:                     success,
 		},
-		BeginTime: sql.NullString{String: "0:00:00", Valid: true},
-		EndTime:   sql.NullString{String: "23:59:59", Valid: true},
+		HTTPApiApprovalRuleID: rule.ID,
+		HTTPApiApprovalRule:   rule,
+		ResponseCode:          200,
+		ResponseContentType:   "application/json",
+		ResponseBody:          []byte("{}"),
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	tx := db.Omit(clause.Associations).Create(&result)
+	if tx.Error != nil {
+		return HTTPApiApprovalRuleOutcome{}, tx.Error
+	}
+	return result, nil
+}
+
+func CreateMockCallbackApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, url string, customizeFunc func(rule *CallbackApprovalRule)) (CallbackApprovalRule, error) {
+
+	result := CallbackApprovalRule{
+		ApprovalRule:   newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		URL:            url,
+		TimeoutMinutes: 60,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockCallbackApprovalRuleRequest(db *gorm.DB, release Release, rule CallbackApprovalRule, token string,
+	customizeFunc func(request *CallbackApprovalRuleRequest)) (CallbackApprovalRuleRequest, error) {
+
+	result := CallbackApprovalRuleRequest{
+		BaseModel:              release.BaseModel,
+		ApplicationID:          release.ApplicationID,
+		ReleaseID:              release.ID,
+		Release:                release,
+		CallbackApprovalRuleID: rule.ID,
+		CallbackApprovalRule:   rule,
+		TokenHash:              HashCallbackToken(token),
+		ExpiresAt:              time.Now().Add(time.Hour),
 	}
 	if customizeFunc != nil {
 		customizeFunc(&result)
 	}
 	tx := db.Omit(clause.Associations).Create(&result)
 	if tx.Error != nil {
-		return ScheduleApprovalRule{}, tx.Error
+		return CallbackApprovalRuleRequest{}, tx.Error
 	}
 	return result, nil
 }
 
+func CreateMockScheduleApprovalRuleWholeDay(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, customizeFunc func(rule *ScheduleApprovalRule)) (ScheduleApprovalRule, error) {
+
+	result := ScheduleApprovalRule{
+		ApprovalRule: newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		BeginTime:    sql.NullString{String: "0:00:00", Valid: true},
+		EndTime:      sql.NullString{String: "23:59:59", Valid: true},
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
 func CreateMockScheduleApprovalRuleOutcome(db *gorm.DB, event ReleaseRuleProcessedEvent, rule ScheduleApprovalRule, success bool, customizeFunc func(outcome *ScheduleApprovalRuleOutcome)) (ScheduleApprovalRuleOutcome, error) {
 	result := ScheduleApprovalRuleOutcome{
 		ApprovalRuleOutcome: ApprovalRuleOutcome{
@@ -521,6 +604,127 @@ func CreateMockScheduleApprovalRuleOutcome(db *gorm.DB, event ReleaseRuleProcess
 	return result, nil
 }
 
+func CreateMockManualApprovalRuleWithAnyPolicy(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, customizeFunc func(rule *ManualApprovalRule)) (ManualApprovalRule, error) {
+
+	result := ManualApprovalRule{
+		ApprovalRule:   newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		ApprovalPolicy: approvalpolicy.Any,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockCalendar(db *gorm.DB, organization Organization, id string, customizeFunc func(calendar *Calendar)) (Calendar, error) {
+	result := Calendar{
+		BaseModel: BaseModel{
+			OrganizationID: organization.ID,
+			Organization:   organization,
+		},
+		ID:          id,
+		DisplayName: id,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	tx := db.Omit(clause.Associations).Create(&result)
+	if tx.Error != nil {
+		return Calendar{}, tx.Error
+	}
+	return result, nil
+}
+
+func CreateMockCalendarEntryAllDay(db *gorm.DB, calendar Calendar, date time.Time, customizeFunc func(entry *CalendarEntry)) (CalendarEntry, error) {
+	result := CalendarEntry{
+		BaseModel:  calendar.BaseModel,
+		CalendarID: calendar.ID,
+		Calendar:   calendar,
+		Summary:    "Mock entry",
+		AllDay:     true,
+		BeginAt:    time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		EndAt:      time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	tx := db.Omit(clause.Associations).Create(&result)
+	if tx.Error != nil {
+		return CalendarEntry{}, tx.Error
+	}
+	return result, nil
+}
+
+func CreateMockCalendarApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, calendar Calendar, customizeFunc func(rule *CalendarApprovalRule)) (CalendarApprovalRule, error) {
+
+	result := CalendarApprovalRule{
+		ApprovalRule: newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		CalendarID:   calendar.ID,
+		Calendar:     calendar,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockDependencyApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, dependencyApp Application, customizeFunc func(rule *DependencyApprovalRule)) (DependencyApprovalRule, error) {
+
+	result := DependencyApprovalRule{
+		ApprovalRule:            newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		DependencyApplicationID: dependencyApp.ID,
+		DependencyApplication:   dependencyApp,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockQuotaApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, customizeFunc func(rule *QuotaApprovalRule)) (QuotaApprovalRule, error) {
+
+	result := QuotaApprovalRule{
+		ApprovalRule: newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		MaxReleases:  3,
+		WindowHours:  24,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockExpressionApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, expression string, customizeFunc func(rule *ExpressionApprovalRule)) (ExpressionApprovalRule, error) {
+
+	result := ExpressionApprovalRule{
+		ApprovalRule: newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		Expression:   expression,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
+func CreateMockPromotionApprovalRule(db *gorm.DB, organization Organization, rulesetVersionID uint64,
+	rulesetAdjustment ApprovalRulesetAdjustment, sourceApp Application, customizeFunc func(rule *PromotionApprovalRule)) (PromotionApprovalRule, error) {
+
+	result := PromotionApprovalRule{
+		ApprovalRule:        newMockApprovalRule(organization, rulesetVersionID, rulesetAdjustment),
+		SourceApplicationID: sourceApp.ID,
+		SourceApplication:   sourceApp,
+	}
+	if customizeFunc != nil {
+		customizeFunc(&result)
+	}
+	return result, createMockApprovalRule(db, &result)
+}
+
 func CreateMockCreationAuditRecord(db *gorm.DB, organization Organization, customizeFunc func(record *CreationAuditRecord)) (CreationAuditRecord, error) {
 	result := CreationAuditRecord{
 		BaseModel: BaseModel{
@@ -0,0 +1,68 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"gorm.io/gorm"
+)
+
+// Notify sends a PostgreSQL notification on the given channel. If `db` is a transaction, then
+// the notification is only delivered once the transaction commits.
+func Notify(db *gorm.DB, channel string, payload string) error {
+	return db.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+// Listen listens for PostgreSQL notifications on the given channel, on a dedicated connection
+// from db's connection pool. It calls `handler` with each notification's payload, until `ctx`
+// is done (in which case it returns nil) or until an error occurs. The connection stops listening
+// before it's returned to the pool.
+func Listen(ctx context.Context, db *gorm.DB, channel string, handler func(payload string)) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("Error obtaining a database connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("Listening for notifications is only supported on PostgreSQL")
+		}
+		pgxConn := stdlibConn.Conn()
+
+		_, err := pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+		if err != nil {
+			return fmt.Errorf("Error listening on notification channel %s: %w", channel, err)
+		}
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				// Stop listening before the connection is returned to the pool, so that its next
+				// user doesn't receive our notifications. If that fails, the pool discards the
+				// connection instead.
+				_, unlistenErr := pgxConn.Exec(context.Background(), "UNLISTEN *")
+				if unlistenErr != nil {
+					return fmt.Errorf("%s (stopping listening also failed: %s): %w",
+						err.Error(), unlistenErr.Error(), driver.ErrBadConn)
+				}
+				return err
+			}
+			handler(notification.Payload)
+		}
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -50,6 +50,14 @@ func CreateFindOperationError(tx *gorm.DB) error {
 	return nil
 }
 
+// EscapeLikePattern escapes the characters that have a special meaning in the pattern of an SQL
+// LIKE expression, so that `str` is matched literally.
+func EscapeLikePattern(str string) string {
+	return likePatternEscaper.Replace(str)
+}
+
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 // IsUniqueConstraintError checks whether the given gorm error represents a unique key constraint error,
 // on the given constraint name.
 func IsUniqueConstraintError(err error, constraintName string) bool {
@@ -31,3 +31,8 @@ func TestIsUniqueConstraintError(t *testing.T) {
 	assert.True(t, !IsUniqueConstraintError(err, "foo_pkey"), "Error=%s", err.Error())
 	assert.True(t, IsUniqueConstraintError(err, "foo_name_key"), "Error=%s", err.Error())
 }
+
+func TestEscapeLikePattern(t *testing.T) {
+	assert.Equal(t, "abc", EscapeLikePattern("abc"))
+	assert.Equal(t, `100\% a\_b c\\d`, EscapeLikePattern(`100% a_b c\d`))
+}
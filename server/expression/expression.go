@@ -0,0 +1,101 @@
+// Package expression compiles and evaluates the boolean expressions used by
+// ExpressionApprovalRules. Expressions are written in the Common Expression
+// Language (CEL), which is side-effect free and can't access anything other than
+// the variables that we pass in. Evaluation is further bounded by a cost limit
+// and a timeout, so that a pathological expression can't stall rule processing.
+package expression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+)
+
+// Variables are the values that an expression may refer to.
+type Variables struct {
+	ApplicationID  string
+	Metadata       map[string]interface{}
+	SourceIdentity string
+	Comments       string
+}
+
+// Program is a compiled expression.
+type Program struct {
+	program cel.Program
+}
+
+const (
+	costLimit    uint64 = 1000000
+	evalTimeout         = time.Second
+	interruptInt uint   = 100
+)
+
+var env *cel.Env
+
+func init() {
+	var err error
+	env, err = cel.NewEnv(
+		cel.Variable("application_id", cel.StringType),
+		cel.Variable("metadata", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("source_identity", cel.StringType),
+		cel.Variable("comments", cel.StringType),
+	)
+	if err != nil {
+		panic("Error initializing expression environment: " + err.Error())
+	}
+}
+
+// Compile parses and type-checks an expression. It returns an error if the expression
+// is invalid, or if it can't evaluate to a boolean.
+func Compile(text string) (Program, error) {
+	ast, issues := env.Compile(text)
+	if issues != nil && issues.Err() != nil {
+		return Program{}, issues.Err()
+	}
+
+	outputType := ast.OutputType()
+	if !outputType.IsAssignableType(cel.BoolType) {
+		return Program{}, fmt.Errorf("expression must evaluate to a boolean, not %s", outputType)
+	}
+
+	program, err := env.Program(ast,
+		cel.CostLimit(costLimit),
+		cel.InterruptCheckFrequency(interruptInt))
+	if err != nil {
+		return Program{}, err
+	}
+	return Program{program: program}, nil
+}
+
+// Evaluate runs the Program against the given Variables. An error is returned if
+// evaluation fails, for example because the expression refers to a metadata key
+// that doesn't exist.
+func (p Program) Evaluate(vars Variables) (bool, error) {
+	metadata := vars.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), evalTimeout)
+	defer cancel()
+
+	result, _, err := p.program.ContextEval(ctx, map[string]interface{}{
+		"application_id":  vars.ApplicationID,
+		"metadata":        metadata,
+		"source_identity": vars.SourceIdentity,
+		"comments":        vars.Comments,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := result.(types.Bool)
+	if !ok {
+		return false, errors.New("expression did not evaluate to a boolean")
+	}
+	return bool(value), nil
+}
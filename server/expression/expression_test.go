@@ -0,0 +1,92 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileRejectsSyntaxErrors(t *testing.T) {
+	_, err := Compile(`metadata.environment ==`)
+	assert.Error(t, err)
+}
+
+func TestCompileRejectsUnknownVariables(t *testing.T) {
+	_, err := Compile(`release.state == "approved"`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "undeclared reference to 'release'")
+	}
+}
+
+func TestCompileRejectsNonBooleanExpressions(t *testing.T) {
+	_, err := Compile(`source_identity + "x"`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "must evaluate to a boolean")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	program, err := Compile(`metadata.environment == "prod" && metadata.tests_passed == true && source_identity.matches("^v[0-9]+")`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	vars := Variables{
+		Metadata: map[string]interface{}{
+			"environment":  "prod",
+			"tests_passed": true,
+		},
+		SourceIdentity: "v1.2.3",
+	}
+	result, err := program.Evaluate(vars)
+	if assert.NoError(t, err) {
+		assert.True(t, result)
+	}
+
+	vars.SourceIdentity = "main"
+	result, err = program.Evaluate(vars)
+	if assert.NoError(t, err) {
+		assert.False(t, result)
+	}
+}
+
+func TestEvaluateMissingMetadataKey(t *testing.T) {
+	program, err := Compile(`metadata.environment == "prod"`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = program.Evaluate(Variables{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no such key: environment")
+	}
+}
+
+func TestEvaluateNonBooleanDynamicResult(t *testing.T) {
+	program, err := Compile(`metadata.environment`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = program.Evaluate(Variables{Metadata: map[string]interface{}{"environment": "prod"}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "did not evaluate to a boolean")
+	}
+}
+
+func TestEvaluateHasMacro(t *testing.T) {
+	program, err := Compile(`!has(metadata.region) || metadata.region == "eu"`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result, err := program.Evaluate(Variables{})
+	if assert.NoError(t, err) {
+		assert.True(t, result)
+	}
+
+	result, err = program.Evaluate(Variables{Metadata: map[string]interface{}{"region": "us"}})
+	if assert.NoError(t, err) {
+		assert.False(t, result)
+	}
+}
@@ -1,8 +1,10 @@
 package httpapi
 
 import (
+	"context"
 	"sync"
 
+	"github.com/fullstaq-labs/sqedule/server/httpapi/releasestream"
 	"gorm.io/gorm"
 )
 
@@ -12,4 +14,16 @@ type Context struct {
 	UseTestAuthentication bool
 	DevelopmentMode       bool
 	CorsOrigin            string
+
+	// AutoProcessReleaseInBackground specifies whether Releases are processed in this process.
+	// If false, then processing is left to workers (see approvalrulesprocessing.Worker).
+	AutoProcessReleaseInBackground bool
+
+	// BackgroundProcessingContext, when done, interrupts the processing of Releases in the
+	// background, including waiting for deferred rules. If nil, then context.Background() is used.
+	BackgroundProcessingContext context.Context
+
+	// ReleaseStreamBroker is used for streaming release events. Its Run() method must be called
+	// separately. If nil, then streaming release events is unavailable.
+	ReleaseStreamBroker *releasestream.Broker
 }
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -53,6 +54,12 @@ func (ctx Context) CreateApprovalRuleset(ginctx *gin.Context) {
 		return
 	}
 
+	// Query database
+
+	if !ctx.checkApprovalRulesetVersionInputReferences(ginctx, orgID, *input.Version) {
+		return
+	}
+
 	// Modify database
 
 	ruleset := dbmodels.ApprovalRuleset{BaseModel: dbmodels.BaseModel{OrganizationID: orgID}}
@@ -262,6 +269,10 @@ func (ctx Context) UpdateApprovalRuleset(ginctx *gin.Context) {
 
 	// Query database
 
+	if input.Version != nil && !ctx.checkApprovalRulesetVersionInputReferences(ginctx, orgID, *input.Version) {
+		return
+	}
+
 	err = dbmodels.LoadApprovalRulesetsLatestVersionsAndAdjustments(ctx.Db, orgID, []*dbmodels.ApprovalRuleset{&ruleset})
 	if err != nil {
 		respondWithDbQueryError("approval ruleset latest versions", err, ginctx)
@@ -624,6 +635,10 @@ func (ctx Context) UpdateApprovalRulesetProposal(ginctx *gin.Context) {
 
 	// Query database
 
+	if !ctx.checkApprovalRulesetVersionInputReferences(ginctx, orgID, input) {
+		return
+	}
+
 	var latestApprovedVersionNumber uint32 = 0
 	err = dbmodels.LoadApprovalRulesetsLatestVersions(ctx.Db, orgID, []*dbmodels.ApprovalRuleset{&ruleset})
 	if err != nil {
@@ -1003,3 +1018,55 @@ func (ctx Context) DeleteApprovalRulesetProposal(ginctx *gin.Context) {
 
 	ginctx.JSON(http.StatusOK, gin.H{})
 }
+
+//
+// ******** Helper functions ********
+//
+
+// checkApprovalRulesetVersionInputReferences checks whether all objects referenced by the
+// input's approval rules (such as Calendars) exist. If not, then it responds with an error
+// and returns false.
+func (ctx Context) checkApprovalRulesetVersionInputReferences(ginctx *gin.Context, orgID string, input json.ApprovalRulesetVersionInput) bool {
+	contents := input.ToDbmodelsApprovalRulesetContents(orgID)
+
+	for _, r := range contents.RulesOfType(dbmodels.CalendarApprovalRuleType) {
+		rule := r.(*dbmodels.CalendarApprovalRule)
+		_, err := dbmodels.FindCalendar(ctx.Db, orgID, rule.CalendarID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ginctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "Invalid input: calendar '" + rule.CalendarID + "' not found"})
+			return false
+		} else if err != nil {
+			respondWithDbQueryError("calendar", err, ginctx)
+			return false
+		}
+	}
+
+	for _, r := range contents.RulesOfType(dbmodels.DependencyApprovalRuleType) {
+		rule := r.(*dbmodels.DependencyApprovalRule)
+		_, err := dbmodels.FindApplication(ctx.Db, orgID, rule.DependencyApplicationID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ginctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "Invalid input: application '" + rule.DependencyApplicationID + "' not found"})
+			return false
+		} else if err != nil {
+			respondWithDbQueryError("application", err, ginctx)
+			return false
+		}
+	}
+
+	for _, r := range contents.RulesOfType(dbmodels.PromotionApprovalRuleType) {
+		rule := r.(*dbmodels.PromotionApprovalRule)
+		_, err := dbmodels.FindApplication(ctx.Db, orgID, rule.SourceApplicationID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ginctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "Invalid input: application '" + rule.SourceApplicationID + "' not found"})
+			return false
+		} else if err != nil {
+			respondWithDbQueryError("application", err, ginctx)
+			return false
+		}
+	}
+
+	return true
+}
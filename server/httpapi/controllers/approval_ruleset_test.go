@@ -67,6 +67,74 @@ var _ = Describe("approval-ruleset API", func() {
 			Expect(rule.BeginTime.String).To(Equal("1:00"))
 			Expect(rule.EndTime.String).To(Equal("2:00"))
 		})
+
+		It("rejects dependency rules referencing a nonexistent application", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/approval-rulesets", gin.H{
+				"id": "ruleset1",
+				"version": gin.H{
+					"display_name":   "Ruleset 1",
+					"proposal_state": "final",
+					"approval_rules": []gin.H{
+						{"type": "dependency", "dependency_application_id": "nonexistent"},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("application 'nonexistent' not found"))
+		})
+
+		It("rejects promotion rules referencing a nonexistent source application", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/approval-rulesets", gin.H{
+				"id": "ruleset1",
+				"version": gin.H{
+					"display_name":   "Ruleset 1",
+					"proposal_state": "final",
+					"approval_rules": []gin.H{
+						{"type": "promotion", "source_application_id": "nonexistent", "min_soak_hours": 24},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("application 'nonexistent' not found"))
+		})
+
+		It("rejects schedule rules with malformed time ranges", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/approval-rulesets", gin.H{
+				"id": "ruleset1",
+				"version": gin.H{
+					"display_name":   "Ruleset 1",
+					"proposal_state": "final",
+					"approval_rules": []gin.H{
+						{"type": "schedule", "time_ranges": "22:00-25:00"},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("invalid 'time_ranges'"))
+		})
+
+		It("rejects expression rules with invalid expressions", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/approval-rulesets", gin.H{
+				"id": "ruleset1",
+				"version": gin.H{
+					"display_name":   "Ruleset 1",
+					"proposal_state": "final",
+					"approval_rules": []gin.H{
+						{"type": "expression", "expression": "metadata.environment =="},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("invalid expression"))
+		})
 	})
 
 	Describe("GET /approval-rulesets", func() {
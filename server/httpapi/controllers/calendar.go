@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+
+	"github.com/fullstaq-labs/sqedule/server/authz"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/auth"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/fullstaq-labs/sqedule/server/icalendar"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (ctx Context) CreateCalendar(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+
+	var input json.CalendarInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if input.ID == nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: 'id' field must be set"})
+		return
+	}
+	if input.DisplayName == nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: 'display_name' field must be set"})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.CalendarAuthorizer{}
+	if !authz.AuthorizeCollectionAction(authorizer, orgMember, authz.ActionCreateCalendar) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Modify database
+
+	calendar := dbmodels.Calendar{
+		BaseModel: dbmodels.BaseModel{OrganizationID: orgID},
+		ID:        *input.ID,
+	}
+	json.PatchCalendar(&calendar, input)
+	if err := ctx.Db.Omit(clause.Associations).Create(&calendar).Error; err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateCalendarWithEntries(calendar, []dbmodels.CalendarEntry{})
+	ginctx.JSON(http.StatusCreated, output)
+}
+
+func (ctx Context) ListCalendars(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+
+	// Check authorization
+
+	authorizer := authz.CalendarAuthorizer{}
+	if !authz.AuthorizeCollectionAction(authorizer, orgMember, authz.ActionListCalendars) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	calendars, err := dbmodels.FindCalendars(ctx.Db, orgID)
+	if err != nil {
+		respondWithDbQueryError("calendars", err, ginctx)
+		return
+	}
+
+	// Generate response
+
+	outputList := make([]json.Calendar, 0, len(calendars))
+	for _, calendar := range calendars {
+		outputList = append(outputList, json.CreateCalendar(calendar))
+	}
+	ginctx.JSON(http.StatusOK, gin.H{"items": outputList})
+}
+
+func (ctx Context) GetCalendar(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	id := ginctx.Param("id")
+
+	calendar, err := dbmodels.FindCalendar(ctx.Db, orgID, id)
+	if err != nil {
+		respondWithDbQueryError("calendar", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.CalendarAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionReadCalendar, calendar) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	entries, err := dbmodels.FindCalendarEntries(ctx.Db, orgID, calendar.ID)
+	if err != nil {
+		respondWithDbQueryError("calendar entries", err, ginctx)
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateCalendarWithEntries(calendar, entries)
+	ginctx.JSON(http.StatusOK, output)
+}
+
+func (ctx Context) UpdateCalendar(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	id := ginctx.Param("id")
+
+	calendar, err := dbmodels.FindCalendar(ctx.Db, orgID, id)
+	if err != nil {
+		respondWithDbQueryError("calendar", err, ginctx)
+		return
+	}
+
+	var input json.CalendarInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.CalendarAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionUpdateCalendar, calendar) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	entries, err := dbmodels.FindCalendarEntries(ctx.Db, orgID, calendar.ID)
+	if err != nil {
+		respondWithDbQueryError("calendar entries", err, ginctx)
+		return
+	}
+
+	// Modify database
+
+	json.PatchCalendar(&calendar, input)
+	if err = ctx.Db.Omit(clause.Associations).Save(&calendar).Error; err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateCalendarWithEntries(calendar, entries)
+	ginctx.JSON(http.StatusOK, output)
+}
+
+func (ctx Context) DeleteCalendar(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	id := ginctx.Param("id")
+
+	calendar, err := dbmodels.FindCalendar(ctx.Db, orgID, id)
+	if err != nil {
+		respondWithDbQueryError("calendar", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.CalendarAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionDeleteCalendar, calendar) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	nrules, err := dbmodels.CountCalendarApprovalRulesReferencingCalendar(ctx.Db, orgID, calendar.ID)
+	if err != nil {
+		respondWithDbQueryError("calendar approval rules", err, ginctx)
+		return
+	}
+	if nrules > 0 {
+		ginctx.JSON(http.StatusUnprocessableEntity,
+			gin.H{"error": "This calendar is still referenced by one or more approval rules"})
+		return
+	}
+
+	// Modify database
+
+	if err = ctx.Db.Delete(&calendar).Error; err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	ginctx.JSON(http.StatusOK, gin.H{})
+}
+
+// ImportCalendarEntries replaces all entries of a Calendar with the events
+// from the iCalendar (.ics) file in the request body.
+func (ctx Context) ImportCalendarEntries(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	id := ginctx.Param("id")
+
+	calendar, err := dbmodels.FindCalendar(ctx.Db, orgID, id)
+	if err != nil {
+		respondWithDbQueryError("calendar", err, ginctx)
+		return
+	}
+
+	organization, err := dbmodels.FindOrganizationByID(ctx.Db, orgID)
+	if err != nil {
+		respondWithDbQueryError("organization", err, ginctx)
+		return
+	}
+
+	location, err := calendar.Location(organization)
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := ginctx.GetRawData()
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Error reading request body: " + err.Error()})
+		return
+	}
+
+	events, err := icalendar.Parse(bytes.NewReader(body), location)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: error parsing iCalendar data: " + err.Error()})
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.CalendarAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionImportCalendarEntries, calendar) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Modify database
+
+	entries := make([]dbmodels.CalendarEntry, 0, len(events))
+	for _, event := range events {
+		entry := dbmodels.CalendarEntry{
+			UID:     sql.NullString{String: event.UID, Valid: len(event.UID) > 0},
+			Summary: event.Summary,
+			AllDay:  event.AllDay,
+			BeginAt: event.Begin,
+			EndAt:   event.End,
+		}
+		if event.Recurrence != nil {
+			entry.RecurrenceRule = sql.NullString{String: event.Recurrence.String(), Valid: true}
+		}
+		entries = append(entries, entry)
+	}
+
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		return dbmodels.ReplaceCalendarEntries(tx, orgID, calendar.ID, entries)
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateCalendarWithEntries(calendar, entries)
+	ginctx.JSON(http.StatusOK, output)
+}
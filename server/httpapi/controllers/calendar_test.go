@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("calendar API", func() {
+	var ctx HTTPTestContext
+	var err error
+
+	Describe("POST /calendars", func() {
+		BeforeEach(func() {
+			ctx, err = SetupHTTPTestContext(nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("creates a calendar", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/calendars", gin.H{
+				"id":           "holidays",
+				"display_name": "Holidays",
+				"time_zone":    "Europe/Amsterdam",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(201))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["id"]).To(Equal("holidays"))
+			Expect(body["time_zone"]).To(Equal("Europe/Amsterdam"))
+			Expect(body["entries"]).To(BeEmpty())
+
+			calendar, err := dbmodels.FindCalendar(ctx.Db, ctx.Org.ID, "holidays")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calendar.DisplayName).To(Equal("Holidays"))
+		})
+
+		It("rejects invalid time zones", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/calendars", gin.H{
+				"id":           "holidays",
+				"display_name": "Holidays",
+				"time_zone":    "Mars/Olympus_Mons",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+		})
+	})
+
+	Describe("PUT /calendars/:id/entries", func() {
+		BeforeEach(func() {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				calendar, err := dbmodels.CreateMockCalendar(tx, ctx.Org, "holidays", nil)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = dbmodels.CreateMockCalendarEntryAllDay(tx, calendar,
+					time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), nil)
+				Expect(err).ToNot(HaveOccurred())
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Submit := func(body string) {
+			req, err := http.NewRequest("PUT", "/v1/calendars/holidays/entries", strings.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Content-Type", "text/calendar")
+			SetupHTTPTestAuthentication(req, ctx.Org, ctx.ServiceAccount)
+			ctx.ServeHTTP(req)
+		}
+
+		It("replaces the calendar's entries with the imported events", func() {
+			Submit("BEGIN:VCALENDAR\r\n" +
+				"VERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"UID:christmas\r\n" +
+				"SUMMARY:Christmas\r\n" +
+				"DTSTART;VALUE=DATE:20201225\r\n" +
+				"DTEND;VALUE=DATE:20201227\r\n" +
+				"RRULE:FREQ=YEARLY\r\n" +
+				"END:VEVENT\r\n" +
+				"END:VCALENDAR\r\n")
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			entries, err := dbmodels.FindCalendarEntries(ctx.Db, ctx.Org.ID, "holidays")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Summary).To(Equal("Christmas"))
+			Expect(entries[0].AllDay).To(BeTrue())
+			Expect(entries[0].RecurrenceRule.String).To(Equal("FREQ=YEARLY;INTERVAL=1"))
+		})
+
+		It("rejects invalid iCalendar data", func() {
+			Submit("hello world")
+			Expect(ctx.Recorder.Code).To(Equal(400))
+
+			entries, err := dbmodels.FindCalendarEntries(ctx.Db, ctx.Org.ID, "holidays")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+	})
+
+	Describe("DELETE /calendars/:id", func() {
+		var calendar dbmodels.Calendar
+
+		Setup := func(referenced bool) {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				calendar, err = dbmodels.CreateMockCalendar(tx, ctx.Org, "holidays", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				if referenced {
+					ruleset, err := dbmodels.CreateMockApprovalRulesetWith1Version(tx, ctx.Org, "ruleset1", nil)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = dbmodels.CreateMockCalendarApprovalRule(tx, ctx.Org, ruleset.Version.ID,
+						*ruleset.Version.Adjustment, calendar, nil)
+					Expect(err).ToNot(HaveOccurred())
+				}
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("deletes the calendar", func() {
+			Setup(false)
+			req, err := ctx.NewRequestWithAuth("DELETE", "/v1/calendars/holidays", nil)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			_, err = dbmodels.FindCalendar(ctx.Db, ctx.Org.ID, "holidays")
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+		})
+
+		It("refuses to delete a calendar that is referenced by approval rules", func() {
+			Setup(true)
+			req, err := ctx.NewRequestWithAuth("DELETE", "/v1/calendars/holidays", nil)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			ctx.Recorder = httptest.NewRecorder()
+			req, err = ctx.NewRequestWithAuth("GET", "/v1/calendars/holidays", nil)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+		})
+	})
+
+	Describe("POST /approval-rulesets", func() {
+		BeforeEach(func() {
+			ctx, err = SetupHTTPTestContext(nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects calendar rules referencing a nonexistent calendar", func() {
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/approval-rulesets", gin.H{
+				"id": "ruleset1",
+				"version": gin.H{
+					"display_name":   "Ruleset 1",
+					"proposal_state": "final",
+					"approval_rules": []gin.H{
+						{"type": "calendar", "calendar_id": "nonexistent"},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("calendar 'nonexistent' not found"))
+		})
+	})
+})
@@ -1,8 +1,13 @@
 package controllers
 
 import (
+	"context"
 	"sync"
+	"time"
 
+	"github.com/fullstaq-labs/sqedule/server/approvalrulesprocessing"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/releasestream"
 	"gorm.io/gorm"
 )
 
@@ -10,6 +15,12 @@ type Context struct {
 	Db                             *gorm.DB
 	AutoProcessReleaseInBackground bool
 	WaitGroup                      *sync.WaitGroup
+
+	// BackgroundProcessingContext is passed to approvalrulesprocessing.ProcessInBackground().
+	BackgroundProcessingContext context.Context
+
+	// ReleaseStreamBroker is used for streaming release events. If nil, then streaming is unavailable.
+	ReleaseStreamBroker *releasestream.Broker
 }
 
 func NewContext(db *gorm.DB, wg *sync.WaitGroup) Context {
@@ -17,5 +28,23 @@ func NewContext(db *gorm.DB, wg *sync.WaitGroup) Context {
 		Db:                             db,
 		AutoProcessReleaseInBackground: true,
 		WaitGroup:                      wg,
+		BackgroundProcessingContext:    context.Background(),
+	}
+}
+
+// processReleaseBackgroundJob schedules the given job to be processed right away. The job can then be
+// claimed by any idle worker (see approvalrulesprocessing.Worker). If AutoProcessReleaseInBackground is
+// enabled, then this process tries to claim the job itself, and processes it in the background.
+func (ctx Context) processReleaseBackgroundJob(organizationID string, job dbmodels.ReleaseBackgroundJob) error {
+	err := job.ScheduleRun(ctx.Db, time.Now())
+	if err != nil || !ctx.AutoProcessReleaseInBackground {
+		return err
+	}
+
+	claimed, err := job.Claim(ctx.Db, time.Now())
+	if err != nil || !claimed {
+		// If not claimed, then another process has claimed it and is processing it.
+		return err
 	}
+	return approvalrulesprocessing.ProcessInBackground(ctx.BackgroundProcessingContext, ctx.Db, organizationID, job, ctx.WaitGroup)
 }
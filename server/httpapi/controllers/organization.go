@@ -10,6 +10,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// organizationUpdatableFields are the Organization fields that the update endpoints write.
+// They're selected explicitly so that zero values, such as a cleared DefaultTimeZone, are
+// written too.
+var organizationUpdatableFields = []string{"ID", "DisplayName", "DefaultTimeZone"}
+
 func (ctx Context) GetCurrentOrganization(ginctx *gin.Context) {
 	// Fetch authentication, parse input, fetch related objects
 
@@ -75,6 +80,10 @@ func (ctx Context) UpdateCurrentOrganization(ginctx *gin.Context) {
 		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
+	if err := input.Validate(); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
 
 	// Check authorization
 
@@ -94,15 +103,16 @@ func (ctx Context) UpdateCurrentOrganization(ginctx *gin.Context) {
 
 	// Modify database
 
-	json.PatchDbOrganization(&organization, input)
-	if err = ctx.Db.Save(&organization).Error; err != nil {
+	var organization2 dbmodels.Organization = organization
+	json.PatchDbOrganization(&organization2, input)
+	if err = ctx.Db.Model(&organization).Select(organizationUpdatableFields).Updates(organization2).Error; err != nil {
 		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Generate response
 
-	output := json.CreateFromDbOrganization(organization)
+	output := json.CreateFromDbOrganization(organization2)
 	ginctx.JSON(http.StatusOK, output)
 }
 
@@ -117,6 +127,10 @@ func (ctx Context) UpdateOrganization(ginctx *gin.Context) {
 		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
+	if err := input.Validate(); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
 
 	// Check authorization
 
@@ -138,13 +152,13 @@ func (ctx Context) UpdateOrganization(ginctx *gin.Context) {
 
 	var organization2 dbmodels.Organization = organization
 	json.PatchDbOrganization(&organization2, input)
-	if err = ctx.Db.Model(&organization).Updates(organization2).Error; err != nil {
+	if err = ctx.Db.Model(&organization).Select(organizationUpdatableFields).Updates(organization2).Error; err != nil {
 		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Generate response
 
-	output := json.CreateFromDbOrganization(organization)
+	output := json.CreateFromDbOrganization(organization2)
 	ginctx.JSON(http.StatusOK, output)
 }
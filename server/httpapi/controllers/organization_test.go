@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"database/sql"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("organization API", func() {
+	var ctx HTTPTestContext
+	var err error
+
+	Setup := func() {
+		ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+			ctx.Org.DefaultTimeZone = sql.NullString{String: "Europe/Amsterdam", Valid: true}
+			return tx.Save(&ctx.Org).Error
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Describe("PATCH /organization", func() {
+		BeforeEach(Setup)
+
+		It("sets the default time zone", func() {
+			req, err := ctx.NewRequestWithAuth("PATCH", "/v1/organization", gin.H{
+				"default_time_zone": "Asia/Tokyo",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["default_time_zone"]).To(Equal("Asia/Tokyo"))
+
+			org, err := dbmodels.FindOrganizationByID(ctx.Db, ctx.Org.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(org.DefaultTimeZone).To(Equal(sql.NullString{String: "Asia/Tokyo", Valid: true}))
+		})
+
+		It("clears the default time zone when given an empty string", func() {
+			req, err := ctx.NewRequestWithAuth("PATCH", "/v1/organization", gin.H{
+				"default_time_zone": "",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["default_time_zone"]).To(BeNil())
+
+			org, err := dbmodels.FindOrganizationByID(ctx.Db, ctx.Org.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(org.DefaultTimeZone.Valid).To(BeFalse())
+		})
+
+		It("leaves the default time zone alone when not given", func() {
+			req, err := ctx.NewRequestWithAuth("PATCH", "/v1/organization", gin.H{
+				"display_name": "Renamed",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			org, err := dbmodels.FindOrganizationByID(ctx.Db, ctx.Org.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(org.DisplayName).To(Equal("Renamed"))
+			Expect(org.DefaultTimeZone).To(Equal(sql.NullString{String: "Europe/Amsterdam", Valid: true}))
+		})
+
+		It("rejects invalid time zones", func() {
+			req, err := ctx.NewRequestWithAuth("PATCH", "/v1/organization", gin.H{
+				"default_time_zone": "Mars/Olympus_Mons",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+		})
+	})
+
+	Describe("PATCH /organizations/:id", func() {
+		BeforeEach(Setup)
+
+		It("clears the default time zone when given an empty string", func() {
+			req, err := ctx.NewRequestWithAuth("PATCH", "/v1/organizations/org1", gin.H{
+				"default_time_zone": "",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["default_time_zone"]).To(BeNil())
+
+			org, err := dbmodels.FindOrganizationByID(ctx.Db, ctx.Org.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(org.DefaultTimeZone.Valid).To(BeFalse())
+		})
+	})
+})
@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/fullstaq-labs/sqedule/server/approvalrulesprocessing"
 	"github.com/fullstaq-labs/sqedule/server/authz"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/deploymentstate"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
 	"github.com/fullstaq-labs/sqedule/server/dbutils"
 	"github.com/fullstaq-labs/sqedule/server/httpapi/auth"
@@ -48,12 +53,14 @@ func (ctx *Context) CreateRelease(ginctx *gin.Context) {
 
 	// Query database
 
-	if includeAppJSON {
-		err = dbmodels.LoadApplicationsLatestVersionsAndAdjustments(ctx.Db, orgID, []*dbmodels.Application{&application})
-		if err != nil {
-			respondWithDbQueryError("application versions", err, ginctx)
-			return
-		}
+	err = dbmodels.LoadApplicationsLatestVersionsAndAdjustments(ctx.Db, orgID, []*dbmodels.Application{&application})
+	if err != nil {
+		respondWithDbQueryError("application versions", err, ginctx)
+		return
+	}
+	if applicationIsDisabled(application) {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cannot create a release for a disabled application"})
+		return
 	}
 
 	// Modify database
@@ -62,80 +69,242 @@ func (ctx *Context) CreateRelease(ginctx *gin.Context) {
 	var releaseRulesetBindings []dbmodels.ReleaseApprovalRulesetBinding
 	var job dbmodels.ReleaseBackgroundJob
 	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
-		release = dbmodels.Release{
-			BaseModel:     dbmodels.BaseModel{OrganizationID: orgID},
-			ApplicationID: applicationID,
-			State:         releasestate.InProgress,
-			Metadata:      datatypes.JSONMap{},
-		}
-		json.PatchDbRelease(&release, input)
-		if err := tx.Create(&release).Error; err != nil {
-			return err
-		}
+		var createdEvent dbmodels.ReleaseCreatedEvent
+		var err error
 
-		appRulesetBindings, err := dbmodels.FindApplicationApprovalRulesetBindings(
-			tx.Preload("ApprovalRuleset"), orgID, applicationID)
+		release, releaseRulesetBindings, createdEvent, err = createReleaseWithRulesetBindings(tx, orgID, applicationID, input)
 		if err != nil {
 			return err
 		}
-		err = dbmodels.LoadApplicationApprovalRulesetBindingsLatestVersionsAndAdjustments(tx, orgID,
-			dbmodels.MakeApplicationApprovalRulesetBindingsPointerArray(appRulesetBindings))
+
+		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+		creationRecord.ReleaseCreatedEventID = &createdEvent.ID
+		err = tx.Omit(clause.Associations).Create(&creationRecord).Error
 		if err != nil {
 			return err
 		}
-		err = dbmodels.LoadApprovalRulesetsLatestVersionsAndAdjustments(tx, orgID,
-			dbmodels.CollectApprovalRulesetsWithApplicationApprovalRulesetBindings(appRulesetBindings))
+
+		job, err = dbmodels.CreateReleaseBackgroundJob(tx, orgID, applicationID, release)
 		if err != nil {
-			return err
+			return fmt.Errorf("Error creating background job for processing this Release: %w", err)
 		}
 
-		releaseRulesetBindings, err = dbmodels.CreateReleaseApprovalRulesetBindings(tx, release.ID, appRulesetBindings)
+		return nil
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = ctx.processReleaseBackgroundJob(orgID, job)
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateFromDbReleaseWithAssociations(release, includeAppJSON, &releaseRulesetBindings)
+	ginctx.JSON(http.StatusCreated, output)
+}
+
+// errDryRunRollback is returned from a dry run's transaction function, so that the transaction is rolled back.
+var errDryRunRollback = errors.New("rolling back dry run")
+
+func (ctx Context) DryRunRelease(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	var input json.ReleasePatchablePart
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	application, err := dbmodels.FindApplication(ctx.Db, orgID, applicationID)
+	if err != nil {
+		respondWithDbQueryError("application", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.ApplicationAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionCreateRelease, application) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	err = dbmodels.LoadApplicationsLatestVersionsAndAdjustments(ctx.Db, orgID, []*dbmodels.Application{&application})
+	if err != nil {
+		respondWithDbQueryError("application versions", err, ginctx)
+		return
+	}
+	if applicationIsDisabled(application) {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cannot create a release for a disabled application"})
+		return
+	}
+
+	// Evaluate rules inside a transaction that is always rolled back, so that
+	// nothing is persisted
+
+	var output json.ReleaseDryRunResult
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		release, releaseRulesetBindings, _, err := createReleaseWithRulesetBindings(tx, orgID, applicationID, input)
 		if err != nil {
 			return err
 		}
 
-		createdEvent := dbmodels.ReleaseCreatedEvent{
-			ReleaseEvent: dbmodels.ReleaseEvent{
-				BaseModel:     dbmodels.BaseModel{OrganizationID: orgID},
-				ReleaseID:     release.ID,
+		engine := approvalrulesprocessing.Engine{
+			Db:             tx,
+			OrganizationID: orgID,
+			ReleaseBackgroundJob: dbmodels.ReleaseBackgroundJob{
+				BaseModel:     release.BaseModel,
 				ApplicationID: applicationID,
+				ReleaseID:     release.ID,
+				Release:       release,
 			},
 		}
-		err = tx.Create(&createdEvent).Error
+		resultState, err := engine.DryRun(ginctx.Request.Context())
 		if err != nil {
 			return err
 		}
 
-		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
-		creationRecord.ReleaseCreatedEventID = &createdEvent.ID
-		err = tx.Omit(clause.Associations).Create(&creationRecord).Error
+		events, err := dbmodels.FindReleaseEvents(tx, orgID, applicationID, release.ID)
 		if err != nil {
 			return err
 		}
-
-		job, err = dbmodels.CreateReleaseBackgroundJob(tx, orgID, applicationID, release)
+		err = dbmodels.LoadReleaseRuleProcessedEventsApprovalRuleOutcomes(tx, orgID,
+			dbmodels.MakeReleaseRuleProcessedEventsPointerArray(events.ReleaseRuleProcessedEvents))
 		if err != nil {
-			return fmt.Errorf("Error creating background job for processing this Release: %w", err)
+			return err
+		}
+		// The creation event is an artifact of the dry run; only rule events are interesting.
+		events.ReleaseCreatedEvents = nil
+
+		output = json.ReleaseDryRunResult{
+			State:                   string(resultState),
+			ApprovalRulesetBindings: make([]json.ReleaseApprovalRulesetBindingWithRulesetAssociation, 0, len(releaseRulesetBindings)),
+			Events:                  createReleaseEventsJSON(events),
+			NotEvaluatedRules:       make([]json.ApprovalRuleEnum, 0, len(engine.NotEvaluatedRules())),
+		}
+		if nextEligibleAt, deferred := engine.NextEligibleTime(); deferred {
+			output.NextEligibleAt = &nextEligibleAt
+		}
+		for _, binding := range releaseRulesetBindings {
+			output.ApprovalRulesetBindings = append(output.ApprovalRulesetBindings,
+				json.CreateFromDbReleaseApprovalRulesetBindingWithRulesetAssociation(binding))
+		}
+		for _, rule := range engine.NotEvaluatedRules() {
+			output.NotEvaluatedRules = append(output.NotEvaluatedRules, json.CreateApprovalRuleEnum(rule))
 		}
 
-		return nil
+		return errDryRunRollback
 	})
-	if err != nil {
+	if err != nil && !errors.Is(err, errDryRunRollback) {
 		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if ctx.AutoProcessReleaseInBackground {
-		err = approvalrulesprocessing.ProcessInBackground(ctx.Db, orgID, job, ctx.WaitGroup)
+	// Generate response
+
+	ginctx.JSON(http.StatusOK, output)
+}
+
+// createReleaseWithRulesetBindings creates a Release, binds it to the Application's approval rulesets, and
+// records the Release's creation. Disabled ruleset bindings are not bound; instead, a ReleaseRuleSkippedEvent
+// is recorded for each of them.
+func createReleaseWithRulesetBindings(tx *gorm.DB, orgID string, applicationID string, input json.ReleasePatchablePart) (dbmodels.Release, []dbmodels.ReleaseApprovalRulesetBinding, dbmodels.ReleaseCreatedEvent, error) {
+	release := dbmodels.Release{
+		BaseModel:     dbmodels.BaseModel{OrganizationID: orgID},
+		ApplicationID: applicationID,
+		State:         releasestate.InProgress,
+		Metadata:      datatypes.JSONMap{},
+	}
+	json.PatchDbRelease(&release, input)
+	if err := tx.Create(&release).Error; err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+
+	appRulesetBindings, err := dbmodels.FindApplicationApprovalRulesetBindings(
+		tx.Preload("ApprovalRuleset"), orgID, applicationID)
+	if err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+	err = dbmodels.LoadApplicationApprovalRulesetBindingsLatestVersionsAndAdjustments(tx, orgID,
+		dbmodels.MakeApplicationApprovalRulesetBindingsPointerArray(appRulesetBindings))
+	if err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+	err = dbmodels.LoadApprovalRulesetsLatestVersionsAndAdjustments(tx, orgID,
+		dbmodels.CollectApprovalRulesetsWithApplicationApprovalRulesetBindings(appRulesetBindings))
+	if err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+
+	enabledAppRulesetBindings, disabledAppRulesetBindings := partitionApplicationApprovalRulesetBindingsByEnabled(appRulesetBindings)
+	releaseRulesetBindings, err := dbmodels.CreateReleaseApprovalRulesetBindings(tx, release.ID, enabledAppRulesetBindings)
+	if err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+
+	createdEvent := dbmodels.ReleaseCreatedEvent{
+		ReleaseEvent: dbmodels.ReleaseEvent{
+			BaseModel:     dbmodels.BaseModel{OrganizationID: orgID},
+			ReleaseID:     release.ID,
+			ApplicationID: applicationID,
+		},
+	}
+	err = tx.Create(&createdEvent).Error
+	if err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+	err = dbmodels.NotifyReleaseEventCreated(tx, createdEvent.ReleaseEvent, dbmodels.ReleaseCreatedEventType)
+	if err != nil {
+		return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+	}
+
+	for _, binding := range disabledAppRulesetBindings {
+		skippedEvent := dbmodels.ReleaseRuleSkippedEvent{
+			ReleaseEvent: dbmodels.ReleaseEvent{
+				BaseModel:     dbmodels.BaseModel{OrganizationID: orgID},
+				ReleaseID:     release.ID,
+				ApplicationID: applicationID,
+			},
+			ApprovalRulesetID: sql.NullString{String: binding.ApprovalRulesetID, Valid: true},
+			Reason:            "Approval ruleset binding is disabled",
+		}
+		err = tx.Omit(clause.Associations).Create(&skippedEvent).Error
+		if err != nil {
+			return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
+		}
+		err = dbmodels.NotifyReleaseEventCreated(tx, skippedEvent.ReleaseEvent, dbmodels.ReleaseRuleSkippedEventType)
 		if err != nil {
-			ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return dbmodels.Release{}, nil, dbmodels.ReleaseCreatedEvent{}, err
 		}
 	}
 
-	// Generate response
+	return release, releaseRulesetBindings, createdEvent, nil
+}
 
-	output := json.CreateFromDbReleaseWithAssociations(release, includeAppJSON, &releaseRulesetBindings)
-	ginctx.JSON(http.StatusCreated, output)
+func applicationIsDisabled(application dbmodels.Application) bool {
+	return application.Version != nil && application.Version.Adjustment != nil && !application.Version.Adjustment.IsEnabled()
+}
+
+func partitionApplicationApprovalRulesetBindingsByEnabled(bindings []dbmodels.ApplicationApprovalRulesetBinding) (enabled []dbmodels.ApplicationApprovalRulesetBinding, disabled []dbmodels.ApplicationApprovalRulesetBinding) {
+	for _, binding := range bindings {
+		if binding.Version.Adjustment.IsEnabled() {
+			enabled = append(enabled, binding)
+		} else {
+			disabled = append(disabled, binding)
+		}
+	}
+	return enabled, disabled
 }
 
 func (ctx Context) ListReleases(ginctx *gin.Context) {
@@ -146,6 +315,12 @@ func (ctx Context) ListReleases(ginctx *gin.Context) {
 	applicationID := ginctx.Param("application_id")
 	includeAppJSON := len(applicationID) == 0
 
+	filter, sortOrder, err := parseReleaseListFilter(ginctx)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Check authorization
 
 	if len(applicationID) > 0 {
@@ -175,9 +350,12 @@ func (ctx Context) ListReleases(ginctx *gin.Context) {
 	if includeAppJSON {
 		tx = tx.Preload("Application")
 	}
-	releases, err := dbmodels.FindReleases(
-		tx.Order("created_at DESC"),
-		orgID, applicationID)
+	tx, err = filter.Apply(tx)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	releases, err := dbmodels.FindReleases(sortOrder.Apply(tx), orgID, applicationID)
 	if err != nil {
 		respondWithDbQueryError("releases", err, ginctx)
 		return
@@ -254,13 +432,23 @@ func (ctx Context) GetRelease(ginctx *gin.Context) {
 		return
 	}
 
+	job, err := dbmodels.FindReleaseBackgroundJob(ctx.Db, orgID, applicationID, release.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		respondWithDbQueryError("release background job", err, ginctx)
+		return
+	}
+
 	// Generate response
 
 	output := json.CreateFromDbReleaseWithAssociations(release, includeAppJSON, &bindings)
+	if err == nil {
+		jobJSON := json.CreateFromDbReleaseBackgroundJob(job)
+		output.BackgroundJob = &jobJSON
+	}
 	ginctx.JSON(http.StatusOK, output)
 }
 
-func (ctx Context) GetReleaseEvents(ginctx *gin.Context) {
+func (ctx Context) RetryRelease(ginctx *gin.Context) {
 	// Fetch authentication, parse input, fetch related objects
 
 	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
@@ -283,54 +471,250 @@ func (ctx Context) GetReleaseEvents(ginctx *gin.Context) {
 	// Check authorization
 
 	authorizer := authz.ReleaseAuthorizer{}
-	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionReadRelease, release) {
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionRetryRelease, release) {
 		respondWithUnauthorizedError(ginctx)
 		return
 	}
 
 	// Query database
 
-	events, err := dbmodels.FindReleaseEvents(ctx.Db, orgID, applicationID, release.ID)
+	job, err := dbmodels.FindReleaseBackgroundJob(ctx.Db.Preload("Release"), orgID, applicationID, release.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This release is not being processed"})
+		return
+	}
 	if err != nil {
-		respondWithDbQueryError("release events", err, ginctx)
+		respondWithDbQueryError("release background job", err, ginctx)
+		return
+	}
+	if job.State() != dbmodels.ReleaseBackgroundJobDead {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Processing of this release has not failed permanently"})
 		return
 	}
 
-	err = dbmodels.LoadReleaseRuleProcessedEventsApprovalRuleOutcomes(ctx.Db, orgID,
-		dbmodels.MakeReleaseRuleProcessedEventsPointerArray(events.ReleaseRuleProcessedEvents))
+	// Modify database
+
+	err = job.ResetRetryState(ctx.Db)
 	if err != nil {
-		respondWithDbQueryError("approval rule outcomes", err, ginctx)
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = ctx.processReleaseBackgroundJob(orgID, job)
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Generate response
 
-	var typesProcessed uint = 0
-	outputList := make([]json.ReleaseEventEnum, 0, events.NumEvents())
+	output := json.CreateFromDbReleaseWithAssociations(release, false, nil)
+	jobJSON := json.CreateFromDbReleaseBackgroundJob(job)
+	output.BackgroundJob = &jobJSON
+	ginctx.JSON(http.StatusOK, output)
+}
 
-	typesProcessed++
-	for _, event := range events.ReleaseCreatedEvents {
-		eventJSON := json.CreateReleaseCreatedEvent(event)
-		outputList = append(outputList, json.ReleaseEventEnum{ReleaseCreatedEvent: &eventJSON})
+func (ctx Context) CancelRelease(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+		return
 	}
 
-	typesProcessed++
-	for _, event := range events.ReleaseRuleProcessedEvents {
-		eventJSON := json.CreateReleaseRuleProcessedEvent(event)
-		outputList = append(outputList, json.ReleaseEventEnum{ReleaseRuleProcessedEvent: &eventJSON})
+	// The input body is optional.
+	var input json.ReleaseCancelInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
 	}
 
-	typesProcessed++
-	for _, event := range events.ReleaseCancelledEvents {
-		eventJSON := json.CreateReleaseCancelledEvent(event)
-		outputList = append(outputList, json.ReleaseEventEnum{ReleaseCancelledEvent: &eventJSON})
+	release, err := dbmodels.FindRelease(ctx.Db, orgID, applicationID, releaseID)
+	if err != nil {
+		respondWithDbQueryError("release", err, ginctx)
+		return
 	}
 
-	if typesProcessed != dbmodels.NumReleaseEventTypes {
-		panic("Bug: code does not cover all release event types")
+	// Check authorization
+
+	authorizer := authz.ReleaseAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionCancelRelease, release) {
+		respondWithUnauthorizedError(ginctx)
+		return
 	}
 
-	ginctx.JSON(http.StatusOK, gin.H{"items": outputList})
+	// Modify database
+
+	var cancelled bool
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		var event dbmodels.ReleaseCancelledEvent
+		var err error
+
+		reason := sql.NullString{}
+		if input.Reason != nil && len(*input.Reason) > 0 {
+			reason = sql.NullString{String: *input.Reason, Valid: true}
+		}
+		event, cancelled, err = dbmodels.CancelRelease(tx, &release, reason, time.Now())
+		if err != nil || !cancelled {
+			return err
+		}
+
+		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+		creationRecord.ReleaseCancelledEventID = &event.ID
+		return tx.Omit(clause.Associations).Create(&creationRecord).Error
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !cancelled {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This release has already been finalized"})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateFromDbReleaseWithAssociations(release, false, nil)
+	ginctx.JSON(http.StatusOK, output)
+}
+
+func (ctx Context) ReportReleaseDeployment(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+		return
+	}
+
+	var input json.ReleaseDeploymentInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	state := deploymentstate.State(*input.State)
+
+	release, err := dbmodels.FindRelease(ctx.Db, orgID, applicationID, releaseID)
+	if err != nil {
+		respondWithDbQueryError("release", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.ReleaseAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionReportReleaseDeployment, release) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Modify database
+
+	if release.State != releasestate.Approved {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Only approved releases can be deployed"})
+		return
+	}
+	if !state.CanTransitionFrom(deploymentstate.State(release.DeploymentState.String)) {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf(
+			"The release's deployment state cannot change from '%s' to '%s'",
+			release.DeploymentState.String, state)})
+		return
+	}
+
+	var reported bool
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		var event dbmodels.ReleaseDeploymentEvent
+		var err error
+
+		comments := sql.NullString{}
+		if input.Comments != nil && len(*input.Comments) > 0 {
+			comments = sql.NullString{String: *input.Comments, Valid: true}
+		}
+		event, reported, err = dbmodels.ReportReleaseDeployment(tx, &release, state, comments, time.Now())
+		if err != nil || !reported {
+			return err
+		}
+
+		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+		creationRecord.ReleaseDeploymentEventID = &event.ID
+		return tx.Omit(clause.Associations).Create(&creationRecord).Error
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !reported {
+		ginctx.JSON(http.StatusConflict, gin.H{"error": "The release's deployment state was changed concurrently; please try again"})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateFromDbReleaseWithAssociations(release, false, nil)
+	ginctx.JSON(http.StatusOK, output)
+}
+
+func (ctx Context) GetReleaseEvents(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+		return
+	}
+
+	release, err := dbmodels.FindRelease(ctx.Db, orgID, applicationID, releaseID)
+	if err != nil {
+		respondWithDbQueryError("release", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.ReleaseAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionReadRelease, release) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	events, err := dbmodels.FindReleaseEvents(ctx.Db, orgID, applicationID, release.ID)
+	if err != nil {
+		respondWithDbQueryError("release events", err, ginctx)
+		return
+	}
+
+	err = dbmodels.LoadReleaseRuleProcessedEventsApprovalRuleOutcomes(ctx.Db, orgID,
+		dbmodels.MakeReleaseRuleProcessedEventsPointerArray(events.ReleaseRuleProcessedEvents))
+	if err != nil {
+		respondWithDbQueryError("approval rule outcomes", err, ginctx)
+		return
+	}
+
+	// Generate response
+
+	ginctx.JSON(http.StatusOK, gin.H{"items": createReleaseEventsJSON(events)})
 }
 
 func (ctx Context) UpdateRelease(ginctx *gin.Context) {
@@ -401,3 +785,56 @@ func (ctx Context) UpdateRelease(ginctx *gin.Context) {
 	output := json.CreateFromDbReleaseWithAssociations(release, includeAppJSON, &bindings)
 	ginctx.JSON(http.StatusOK, output)
 }
+
+func createReleaseEventsJSON(events dbmodels.ReleaseEventCollection) []json.ReleaseEventEnum {
+	var typesProcessed uint = 0
+	outputList := make([]json.ReleaseEventEnum, 0, events.NumEvents())
+
+	typesProcessed++
+	for _, event := range events.ReleaseCreatedEvents {
+		eventJSON := json.CreateReleaseCreatedEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseCreatedEvent: &eventJSON})
+	}
+
+	typesProcessed++
+	for _, event := range events.ReleaseRuleProcessedEvents {
+		eventJSON := json.CreateReleaseRuleProcessedEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseRuleProcessedEvent: &eventJSON})
+	}
+
+	typesProcessed++
+	for _, event := range events.ReleaseRuleSkippedEvents {
+		eventJSON := json.CreateReleaseRuleSkippedEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseRuleSkippedEvent: &eventJSON})
+	}
+
+	typesProcessed++
+	for _, event := range events.ReleaseCancelledEvents {
+		eventJSON := json.CreateReleaseCancelledEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseCancelledEvent: &eventJSON})
+	}
+
+	typesProcessed++
+	for _, event := range events.ReleaseDeploymentEvents {
+		eventJSON := json.CreateReleaseDeploymentEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseDeploymentEvent: &eventJSON})
+	}
+
+	typesProcessed++
+	for _, event := range events.ReleaseOverriddenEvents {
+		eventJSON := json.CreateReleaseOverriddenEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseOverriddenEvent: &eventJSON})
+	}
+
+	typesProcessed++
+	for _, event := range events.ReleaseOverrideConfirmedEvents {
+		eventJSON := json.CreateReleaseOverrideConfirmedEvent(event)
+		outputList = append(outputList, json.ReleaseEventEnum{ReleaseOverrideConfirmedEvent: &eventJSON})
+	}
+
+	if typesProcessed != dbmodels.NumReleaseEventTypes {
+		panic("Bug: code does not cover all release event types")
+	}
+
+	return outputList
+}
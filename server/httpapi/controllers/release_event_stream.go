@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/authz"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/auth"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/releasestream"
+	"github.com/gin-gonic/gin"
+)
+
+// releaseEventStreamKeepAliveInterval is how often a comment is sent on an idle event stream,
+// so that proxies don't close the connection.
+const releaseEventStreamKeepAliveInterval = 15 * time.Second
+
+// StreamReleaseEvents streams ReleaseNotifications as Server-Sent Events, for a single Release,
+// for all Releases in an Application, or for all Releases in the organization.
+func (ctx Context) StreamReleaseEvents(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	filter := releasestream.Filter{
+		OrganizationID: orgID,
+		ApplicationID:  ginctx.Param("application_id"),
+	}
+
+	if len(ginctx.Param("id")) > 0 {
+		releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+		if err != nil {
+			ginctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+			return
+		}
+		filter.ReleaseID = releaseID
+	}
+
+	// Check authorization
+
+	if filter.ReleaseID != 0 {
+		release, err := dbmodels.FindRelease(ctx.Db, orgID, filter.ApplicationID, filter.ReleaseID)
+		if err != nil {
+			respondWithDbQueryError("release", err, ginctx)
+			return
+		}
+
+		authorizer := authz.ReleaseAuthorizer{}
+		if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionReadRelease, release) {
+			respondWithUnauthorizedError(ginctx)
+			return
+		}
+	} else if len(filter.ApplicationID) > 0 {
+		application, err := dbmodels.FindApplication(ctx.Db, orgID, filter.ApplicationID)
+		if err != nil {
+			respondWithDbQueryError("application", err, ginctx)
+			return
+		}
+
+		authorizer := authz.ApplicationAuthorizer{}
+		if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionReadApplication, application) {
+			respondWithUnauthorizedError(ginctx)
+			return
+		}
+	} else if !authz.AuthorizeCollectionAction(authz.ReleaseAuthorizer{}, orgMember, authz.ActionListReleases) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	if ctx.ReleaseStreamBroker == nil {
+		ginctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "Release event streaming is not available"})
+		return
+	}
+
+	// Generate response
+
+	subscription := ctx.ReleaseStreamBroker.Subscribe(filter)
+	defer subscription.Close()
+
+	ginctx.Header("Content-Type", "text/event-stream")
+	ginctx.Header("Cache-Control", "no-cache")
+	// Disables response buffering in Nginx.
+	ginctx.Header("X-Accel-Buffering", "no")
+	ginctx.Status(http.StatusOK)
+	ginctx.Writer.Flush()
+
+	keepAliveTimer := time.NewTicker(releaseEventStreamKeepAliveInterval)
+	defer keepAliveTimer.Stop()
+
+	ginctx.Stream(func(w io.Writer) bool {
+		select {
+		case notification, ok := <-subscription.C:
+			if !ok {
+				// The server is shutting down.
+				return false
+			}
+			ginctx.SSEvent("release", json.CreateFromDbReleaseNotification(notification))
+			return true
+		case <-keepAliveTimer.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/gin-gonic/gin"
+)
+
+// parseReleaseListFilter parses the query string parameters with which ListReleases
+// filters and sorts Releases.
+func parseReleaseListFilter(ginctx *gin.Context) (dbmodels.ReleaseFilter, dbmodels.ReleaseSortOrder, error) {
+	var filter dbmodels.ReleaseFilter
+	var err error
+
+	// Multiple states may be passed as multiple parameters, or as a comma-separated list.
+	for _, param := range ginctx.QueryArray("state") {
+		for _, str := range strings.Split(param, ",") {
+			state := releasestate.State(strings.TrimSpace(str))
+			if len(state) == 0 {
+				continue
+			}
+			if !state.IsValid() {
+				return dbmodels.ReleaseFilter{}, "",
+					fmt.Errorf("Error in 'state' parameter: '%s' is not one of %v", state, releasestate.All)
+			}
+			filter.States = append(filter.States, state)
+		}
+	}
+
+	if filter.CreatedAfter, err = parseReleaseListTimeParam(ginctx, "created_after"); err != nil {
+		return dbmodels.ReleaseFilter{}, "", err
+	}
+	if filter.CreatedBefore, err = parseReleaseListTimeParam(ginctx, "created_before"); err != nil {
+		return dbmodels.ReleaseFilter{}, "", err
+	}
+	if filter.FinalizedAfter, err = parseReleaseListTimeParam(ginctx, "finalized_after"); err != nil {
+		return dbmodels.ReleaseFilter{}, "", err
+	}
+	if filter.FinalizedBefore, err = parseReleaseListTimeParam(ginctx, "finalized_before"); err != nil {
+		return dbmodels.ReleaseFilter{}, "", err
+	}
+
+	filter.SourceIdentityPrefix = ginctx.Query("source_identity_prefix")
+
+	if metadata := ginctx.Query("metadata"); len(metadata) > 0 {
+		if err = encjson.Unmarshal([]byte(metadata), &filter.Metadata); err != nil {
+			return dbmodels.ReleaseFilter{}, "",
+				fmt.Errorf("Error parsing 'metadata' parameter as a JSON object: %w", err)
+		}
+	}
+
+	if overridden := ginctx.Query("overridden"); len(overridden) > 0 {
+		value, err := strconv.ParseBool(overridden)
+		if err != nil {
+			return dbmodels.ReleaseFilter{}, "",
+				fmt.Errorf("Error parsing 'overridden' parameter as a boolean: %w", err)
+		}
+		filter.Overridden = &value
+	}
+
+	sortOrder := dbmodels.ReleaseSortOrder(ginctx.DefaultQuery("sort", string(dbmodels.DefaultReleaseSortOrder)))
+	if !sortOrder.IsValid() {
+		return dbmodels.ReleaseFilter{}, "",
+			fmt.Errorf("Error in 'sort' parameter: must be one of %v", dbmodels.AllReleaseSortOrders)
+	}
+
+	return filter, sortOrder, nil
+}
+
+// parseReleaseListTimeParam parses a query string parameter as either an RFC 3339 timestamp,
+// or as a date (which means midnight UTC). Returns the zero time if the parameter isn't given.
+func parseReleaseListTimeParam(ginctx *gin.Context, name string) (time.Time, error) {
+	str := ginctx.Query(name)
+	if len(str) == 0 {
+		return time.Time{}, nil
+	}
+
+	if result, err := time.Parse(time.RFC3339, str); err == nil {
+		return result, nil
+	}
+	result, err := time.Parse("2006-01-02", str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Error parsing '%s' parameter: must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseReleaseListFilter", func() {
+	Parse := func(query string) (dbmodels.ReleaseFilter, dbmodels.ReleaseSortOrder, error) {
+		ginctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginctx.Request = httptest.NewRequest("GET", "/v1/releases?"+query, nil)
+		return parseReleaseListFilter(ginctx)
+	}
+
+	It("returns an empty filter and the default sort order if no parameters are given", func() {
+		filter, sortOrder, err := Parse("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter.States).To(BeEmpty())
+		Expect(filter.CreatedAfter.IsZero()).To(BeTrue())
+		Expect(filter.Metadata).To(BeNil())
+		Expect(sortOrder).To(Equal(dbmodels.DefaultReleaseSortOrder))
+	})
+
+	It("parses multiple states", func() {
+		filter, _, err := Parse("state=rejected,cancelled&state=approved")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter.States).To(Equal([]releasestate.State{releasestate.Rejected, releasestate.Cancelled, releasestate.Approved}))
+	})
+
+	It("rejects unknown states", func() {
+		_, _, err := Parse("state=foo")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("'foo' is not one of"))
+	})
+
+	It("parses timestamps and dates", func() {
+		filter, _, err := Parse("created_after=2021-03-01&created_before=2021-03-08T12:00:00%2B01:00")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter.CreatedAfter).To(Equal(time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(filter.CreatedBefore).To(BeTemporally("==", time.Date(2021, time.March, 8, 11, 0, 0, 0, time.UTC)))
+	})
+
+	It("rejects invalid timestamps", func() {
+		_, _, err := Parse("finalized_before=yesterday")
+		Expect(err).To(MatchError("Error parsing 'finalized_before' parameter: must be an RFC 3339 timestamp or a YYYY-MM-DD date"))
+	})
+
+	It("parses the source identity prefix and metadata", func() {
+		filter, _, err := Parse(`source_identity_prefix=v1.&metadata={"env":"prod"}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter.SourceIdentityPrefix).To(Equal("v1."))
+		Expect(filter.Metadata).To(Equal(map[string]interface{}{"env": "prod"}))
+	})
+
+	It("rejects metadata that isn't a JSON object", func() {
+		_, _, err := Parse(`metadata=[1]`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Error parsing 'metadata' parameter as a JSON object"))
+	})
+
+	It("parses the overridden flag", func() {
+		filter, _, err := Parse("overridden=true")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter.Overridden).ToNot(BeNil())
+		Expect(*filter.Overridden).To(BeTrue())
+
+		_, _, err = Parse("overridden=maybe")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("parses the sort order", func() {
+		_, sortOrder, err := Parse("sort=-finalized_at")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sortOrder).To(Equal(dbmodels.ReleaseSortFinalizedAtDesc))
+
+		_, _, err = Parse("sort=foo")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fullstaq-labs/sqedule/server/authz"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/auth"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (ctx Context) CreateReleaseManualApproval(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+		return
+	}
+
+	var input json.ReleaseManualApprovalInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	release, err := dbmodels.FindRelease(ctx.Db, orgID, applicationID, releaseID)
+	if err != nil {
+		respondWithDbQueryError("release", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.ReleaseAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionManuallyApproveRelease, release) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	if release.State.IsFinal() {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This release is already finalized"})
+		return
+	}
+
+	rules, err := dbmodels.FindApprovalRulesBoundToRelease(ctx.Db, orgID, applicationID, release.ID)
+	if err != nil {
+		respondWithDbQueryError("approval rules", err, ginctx)
+		return
+	}
+
+	rule, err := pickManualApprovalRule(rules.RulesOfType(dbmodels.ManualApprovalRuleType), input.RuleID)
+	if err != nil {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Modify database
+
+	var outcome dbmodels.ManualApprovalRuleOutcome
+	var alreadyFinalized, alreadyDecided bool
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		// Lock the release so that concurrent submissions by the same organization member
+		// can't both pass the checks below, and so that the release can't be finalized
+		// (e.g. cancelled or overridden) in the meantime.
+		lockedRelease, err := dbmodels.FindRelease(tx.Clauses(clause.Locking{Strength: "UPDATE"}), orgID, applicationID, release.ID)
+		if err != nil {
+			return err
+		}
+		if lockedRelease.State.IsFinal() {
+			alreadyFinalized = true
+			return nil
+		}
+
+		alreadyDecided, err = dbmodels.ManualApprovalRuleOutcomeExistsForOrganizationMember(tx, orgID, release.ID, rule.ID, orgMember)
+		if err != nil || alreadyDecided {
+			return err
+		}
+
+		// The result state is determined by approvalrulesprocessing.Engine,
+		// which updates this event afterwards.
+		event := dbmodels.ReleaseRuleProcessedEvent{
+			ReleaseEvent: dbmodels.ReleaseEvent{
+				BaseModel:     dbmodels.BaseModel{OrganizationID: orgID},
+				ReleaseID:     release.ID,
+				ApplicationID: applicationID,
+			},
+			ResultState: releasestate.InProgress,
+		}
+		err = tx.Omit(clause.Associations).Create(&event).Error
+		if err != nil {
+			return err
+		}
+
+		outcome = dbmodels.ManualApprovalRuleOutcome{
+			ApprovalRuleOutcome: dbmodels.ApprovalRuleOutcome{
+				BaseModel:                   dbmodels.BaseModel{OrganizationID: orgID},
+				ReleaseRuleProcessedEventID: event.ID,
+			},
+			ManualApprovalRuleID: rule.ID,
+		}
+		json.PatchDbManualApprovalRuleOutcome(&outcome, input)
+		err = tx.Omit(clause.Associations).Create(&outcome).Error
+		if err != nil {
+			return err
+		}
+
+		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+		creationRecord.ManualApprovalRuleOutcomeID = &outcome.ID
+		return tx.Omit(clause.Associations).Create(&creationRecord).Error
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if alreadyFinalized {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This release is already finalized"})
+		return
+	}
+	if alreadyDecided {
+		ginctx.JSON(http.StatusUnprocessableEntity,
+			gin.H{"error": "You have already approved or rejected this release for this rule"})
+		return
+	}
+
+	job, err := dbmodels.FindReleaseBackgroundJob(ctx.Db.Preload("Release"), orgID, applicationID, release.ID)
+	if err == nil {
+		err = ctx.processReleaseBackgroundJob(orgID, job)
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The release is already being finalized.
+		err = nil
+	}
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	outcome.ManualApprovalRule = rule
+	output := json.CreateManualApprovalRuleOutcome(outcome)
+	ginctx.JSON(http.StatusCreated, output)
+}
+
+func pickManualApprovalRule(rules []dbmodels.IApprovalRule, ruleID *uint64) (dbmodels.ManualApprovalRule, error) {
+	if ruleID == nil {
+		switch len(rules) {
+		case 0:
+			return dbmodels.ManualApprovalRule{}, errors.New("This release is not bound to any manual approval rules")
+		case 1:
+			return *rules[0].(*dbmodels.ManualApprovalRule), nil
+		default:
+			return dbmodels.ManualApprovalRule{},
+				errors.New("This release is bound to multiple manual approval rules, so 'rule_id' must be specified")
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.BaseApprovalRule().ID == *ruleID {
+			return *rule.(*dbmodels.ManualApprovalRule), nil
+		}
+	}
+	return dbmodels.ManualApprovalRule{},
+		fmt.Errorf("This release is not bound to a manual approval rule with ID %d", *ruleID)
+}
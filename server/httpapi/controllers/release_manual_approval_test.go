@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http/httptest"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("release manual approval API", func() {
+	var ctx HTTPTestContext
+	var err error
+
+	Describe("POST /applications/:app_id/releases/:id/manual-approvals", func() {
+		var app dbmodels.Application
+		var release dbmodels.Release
+		var ruleset dbmodels.ApprovalRuleset
+		var rule dbmodels.ManualApprovalRule
+
+		Setup := func(autoProcessReleaseInBackground bool, releaseCustomizeFunc func(release *dbmodels.Release)) {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.Org, app, releaseCustomizeFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				ruleset, err = dbmodels.CreateMockApprovalRulesetWith1Version(tx, ctx.Org, "ruleset1", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockReleaseRulesetBindingWithEnforcingMode(tx, ctx.Org, release,
+					ruleset, *ruleset.Version, *ruleset.Version.Adjustment, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				rule, err = dbmodels.CreateMockManualApprovalRuleWithAnyPolicy(tx, ctx.Org, ruleset.Version.ID,
+					*ruleset.Version.Adjustment, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.Org, app, release, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				ctx.ControllerCtx.AutoProcessReleaseInBackground = autoProcessReleaseInBackground
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Submit := func(body gin.H) {
+			req, err := ctx.NewRequestWithAuth("POST",
+				fmt.Sprintf("/v1/applications/%s/releases/%d/manual-approvals", app.ID, release.ID), body)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+		}
+
+		AfterEach(func() {
+			ctx.ControllerCtx.WaitGroup.Wait()
+		})
+
+		It("creates a ManualApprovalRuleOutcome and CreationAuditRecord", func() {
+			Setup(false, nil)
+			Submit(gin.H{"approved": true, "comments": "looks good"})
+			Expect(ctx.Recorder.Code).To(Equal(201))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["success"]).To(BeTrue())
+			Expect(body["comments"]).To(Equal("looks good"))
+
+			var outcome dbmodels.ManualApprovalRuleOutcome
+			tx := ctx.Db.Take(&outcome)
+			Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+			Expect(outcome.ManualApprovalRuleID).To(Equal(rule.ID))
+			Expect(outcome.Success).To(BeTrue())
+			Expect(outcome.Comments.String).To(Equal("looks good"))
+
+			var creationRecord dbmodels.CreationAuditRecord
+			tx = ctx.Db.Take(&creationRecord)
+			Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+			Expect(creationRecord.ServiceAccountName.String).To(Equal(ctx.ServiceAccount.Name))
+			Expect(creationRecord.ManualApprovalRuleOutcomeID).ToNot(BeNil())
+			Expect(*creationRecord.ManualApprovalRuleOutcomeID).To(Equal(outcome.ID))
+		})
+
+		It("approves the release eventually", func() {
+			Setup(true, nil)
+			Submit(gin.H{"approved": true})
+			Expect(ctx.Recorder.Code).To(Equal(201))
+
+			Eventually(func() releasestate.State {
+				var release dbmodels.Release
+				tx := ctx.Db.Take(&release)
+				Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+				return release.State
+			}).Should(Equal(releasestate.Approved))
+		})
+
+		It("rejects the release eventually", func() {
+			Setup(true, nil)
+			Submit(gin.H{"approved": false})
+			Expect(ctx.Recorder.Code).To(Equal(201))
+
+			Eventually(func() releasestate.State {
+				var release dbmodels.Release
+				tx := ctx.Db.Take(&release)
+				Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+				return release.State
+			}).Should(Equal(releasestate.Rejected))
+		})
+
+		It("refuses a second decision from the same organization member", func() {
+			Setup(false, nil)
+			Submit(gin.H{"approved": true})
+			Expect(ctx.Recorder.Code).To(Equal(201))
+
+			ctx.Recorder = httptest.NewRecorder()
+			Submit(gin.H{"approved": true})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+		})
+
+		It("refuses to approve a finalized release", func() {
+			Setup(false, func(release *dbmodels.Release) {
+				release.State = releasestate.Rejected
+			})
+			Submit(gin.H{"approved": true})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+		})
+
+		It("requires a rule ID if the release is bound to multiple manual approval rules", func() {
+			Setup(false, nil)
+			rule2, err := dbmodels.CreateMockManualApprovalRuleWithAnyPolicy(ctx.Db, ctx.Org, ruleset.Version.ID,
+				*ruleset.Version.Adjustment, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Submit(gin.H{"approved": true})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			ctx.Recorder = httptest.NewRecorder()
+			Submit(gin.H{"approved": true, "rule_id": rule2.ID})
+			Expect(ctx.Recorder.Code).To(Equal(201))
+		})
+	})
+})
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/authz"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/auth"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const releaseNotOverridableMessage = "Only releases that are in progress or rejected can be overridden"
+
+func (ctx Context) OverrideRelease(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+		return
+	}
+
+	var input json.ReleaseOverrideInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	justification := strings.TrimSpace(*input.Justification)
+
+	release, err := dbmodels.FindRelease(ctx.Db, orgID, applicationID, releaseID)
+	if err != nil {
+		respondWithDbQueryError("release", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.ReleaseAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionOverrideRelease, release) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	if !release.IsOverridable() {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": releaseNotOverridableMessage})
+		return
+	}
+
+	application, err := dbmodels.FindApplication(ctx.Db, orgID, applicationID)
+	if err != nil {
+		respondWithDbQueryError("application", err, ginctx)
+		return
+	}
+	err = dbmodels.LoadApplicationsLatestVersionsAndAdjustments(ctx.Db, orgID, []*dbmodels.Application{&application})
+	if err != nil {
+		respondWithDbQueryError("application versions", err, ginctx)
+		return
+	}
+
+	// Modify database
+
+	now := time.Now()
+	if confirmationMinutes := applicationOverrideConfirmationMinutes(application); confirmationMinutes > 0 {
+		err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+			event, err := dbmodels.RequestReleaseOverride(tx, release, justification,
+				now.Add(time.Duration(confirmationMinutes)*time.Minute), now)
+			if err != nil {
+				return err
+			}
+
+			creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+			creationRecord.ReleaseOverriddenEventID = &event.ID
+			return tx.Omit(clause.Associations).Create(&creationRecord).Error
+		})
+		if err != nil {
+			ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// The override only takes effect once it's confirmed, so the release keeps its current state.
+		output := json.CreateFromDbReleaseWithAssociations(release, false, nil)
+		ginctx.JSON(http.StatusAccepted, output)
+		return
+	}
+
+	var overridden bool
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		var event dbmodels.ReleaseOverriddenEvent
+		var err error
+
+		event, overridden, err = dbmodels.OverrideRelease(tx, &release, justification, now)
+		if err != nil || !overridden {
+			return err
+		}
+
+		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+		creationRecord.ReleaseOverriddenEventID = &event.ID
+		return tx.Omit(clause.Associations).Create(&creationRecord).Error
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !overridden {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": releaseNotOverridableMessage})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateFromDbReleaseWithAssociations(release, false, nil)
+	ginctx.JSON(http.StatusOK, output)
+}
+
+func (ctx Context) ConfirmReleaseOverride(ginctx *gin.Context) {
+	// Fetch authentication, parse input, fetch related objects
+
+	orgMember := auth.GetAuthenticatedOrgMemberNoFail(ginctx)
+	orgID := orgMember.GetOrganizationID()
+	applicationID := ginctx.Param("application_id")
+
+	releaseID, err := strconv.ParseUint(ginctx.Param("id"), 10, 64)
+	if err != nil {
+		ginctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "Error parsing 'id' parameter as an integer: " + err.Error()})
+		return
+	}
+
+	release, err := dbmodels.FindRelease(ctx.Db, orgID, applicationID, releaseID)
+	if err != nil {
+		respondWithDbQueryError("release", err, ginctx)
+		return
+	}
+
+	// Check authorization
+
+	authorizer := authz.ReleaseAuthorizer{}
+	if !authz.AuthorizeSingularAction(authorizer, orgMember, authz.ActionConfirmReleaseOverride, release) {
+		respondWithUnauthorizedError(ginctx)
+		return
+	}
+
+	// Query database
+
+	if !release.IsOverridable() {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": releaseNotOverridableMessage})
+		return
+	}
+
+	now := time.Now()
+	overriddenEvent, err := dbmodels.FindPendingReleaseOverriddenEvent(ctx.Db, orgID, applicationID, release.ID, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ginctx.JSON(http.StatusUnprocessableEntity,
+			gin.H{"error": "This release has no override awaiting confirmation, or its confirmation deadline has passed"})
+		return
+	}
+	if err != nil {
+		respondWithDbQueryError("release overridden event", err, ginctx)
+		return
+	}
+
+	createdBySelf, err := dbmodels.ReleaseOverriddenEventCreatedByOrganizationMember(ctx.Db, orgID, overriddenEvent.ID, orgMember)
+	if err != nil {
+		respondWithDbQueryError("creation audit records", err, ginctx)
+		return
+	}
+	if createdBySelf {
+		ginctx.JSON(http.StatusUnprocessableEntity,
+			gin.H{"error": "An override must be confirmed by someone other than the one who requested it"})
+		return
+	}
+
+	// Modify database
+
+	var confirmed bool
+	err = ctx.Db.Transaction(func(tx *gorm.DB) error {
+		var event dbmodels.ReleaseOverrideConfirmedEvent
+		var err error
+
+		event, confirmed, err = dbmodels.ConfirmReleaseOverride(tx, &release, overriddenEvent, now)
+		if err != nil || !confirmed {
+			return err
+		}
+
+		creationRecord := dbmodels.NewCreationAuditRecord(orgID, orgMember, ginctx.ClientIP())
+		creationRecord.ReleaseOverrideConfirmedEventID = &event.ID
+		return tx.Omit(clause.Associations).Create(&creationRecord).Error
+	})
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !confirmed {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": releaseNotOverridableMessage})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateFromDbReleaseWithAssociations(release, false, nil)
+	ginctx.JSON(http.StatusOK, output)
+}
+
+// applicationOverrideConfirmationMinutes returns the application's OverrideConfirmationMinutes,
+// or 0 if overrides don't need to be confirmed.
+func applicationOverrideConfirmationMinutes(application dbmodels.Application) int32 {
+	if application.Version == nil || application.Version.Adjustment == nil {
+		return 0
+	}
+	return application.Version.Adjustment.OverrideConfirmationMinutes.Int32
+}
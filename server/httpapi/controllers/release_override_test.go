@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/lib/mocking"
+	"github.com/fullstaq-labs/sqedule/server/approvalrulesprocessing"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/organizationmemberrole"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("release override API", func() {
+	var ctx HTTPTestContext
+	var err error
+	var app dbmodels.Application
+	var release dbmodels.Release
+	var job dbmodels.ReleaseBackgroundJob
+	var confirmer dbmodels.ServiceAccount
+	var technician dbmodels.ServiceAccount
+
+	Setup := func(state releasestate.State, confirmationMinutes int32) {
+		ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+			app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, func(adjustment *dbmodels.ApplicationAdjustment) {
+				if confirmationMinutes > 0 {
+					adjustment.OverrideConfirmationMinutes = sql.NullInt32{Int32: confirmationMinutes, Valid: true}
+				}
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.Org, app, func(release *dbmodels.Release) {
+				release.State = state
+				release.CreatedAt = time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			if state == releasestate.InProgress {
+				job, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.Org, app, release, nil)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			// An enforcing schedule rule whose window doesn't include the release's creation time,
+			// so that processing the release's rules rejects it.
+			ruleset, err := dbmodels.CreateMockApprovalRulesetWith1Version(tx, ctx.Org, "ruleset1", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = dbmodels.CreateMockReleaseRulesetBindingWithEnforcingMode(tx, ctx.Org, release,
+				ruleset, *ruleset.Version, *ruleset.Version.Adjustment, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = dbmodels.CreateMockScheduleApprovalRuleWholeDay(tx, ctx.Org, ruleset.Version.ID,
+				*ruleset.Version.Adjustment, func(rule *dbmodels.ScheduleApprovalRule) {
+					rule.BeginTime = sql.NullString{String: "1:00", Valid: true}
+					rule.EndTime = sql.NullString{String: "1:01", Valid: true}
+				})
+			Expect(err).ToNot(HaveOccurred())
+
+			confirmer, err = dbmodels.CreateMockServiceAccountWithAdminRole(tx, ctx.Org, func(sa *dbmodels.ServiceAccount) {
+				sa.Name = "confirmer"
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			technician, err = dbmodels.CreateMockServiceAccountWithAdminRole(tx, ctx.Org, func(sa *dbmodels.ServiceAccount) {
+				sa.Name = "technician"
+				sa.Role = organizationmemberrole.Technician
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	ProcessRules := func() {
+		engine := approvalrulesprocessing.Engine{
+			Db:                   ctx.Db,
+			OrganizationID:       ctx.Org.ID,
+			ReleaseBackgroundJob: job,
+			Clock:                &mocking.FakeClock{Value: release.CreatedAt},
+		}
+		Expect(engine.Run(context.Background())).To(Succeed())
+
+		release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.State).To(Equal(releasestate.Rejected))
+	}
+
+	NewRequest := func(path string, body interface{}, orgMember dbmodels.IOrganizationMember) *http.Request {
+		req, err := ctx.NewRequestWithAuth("POST",
+			fmt.Sprintf("/v1/applications/%s/releases/%d/%s", app.ID, release.ID, path), body)
+		Expect(err).ToNot(HaveOccurred())
+		if orgMember != nil {
+			SetupHTTPTestAuthentication(req, ctx.Org, orgMember)
+		}
+		return req
+	}
+
+	Override := func(body interface{}, orgMember dbmodels.IOrganizationMember) {
+		ctx.Recorder = httptest.NewRecorder()
+		ctx.ServeHTTP(NewRequest("override", body, orgMember))
+	}
+
+	Confirm := func(orgMember dbmodels.IOrganizationMember) {
+		ctx.Recorder = httptest.NewRecorder()
+		ctx.ServeHTTP(NewRequest("override/confirm", nil, orgMember))
+	}
+
+	Describe("POST /applications/:app_id/releases/:id/override", func() {
+		It("approves the release and records an override event", func() {
+			Setup(releasestate.InProgress, 0)
+
+			Override(gin.H{"justification": "hotfix for incident 42"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "approved"))
+			Expect(body).To(HaveKeyWithValue("overridden", true))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Approved))
+			Expect(release.Overridden).To(BeTrue())
+
+			_, err = dbmodels.FindReleaseBackgroundJob(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+
+			var event dbmodels.ReleaseOverriddenEvent
+			Expect(ctx.Db.Take(&event).Error).ToNot(HaveOccurred())
+			Expect(event.Justification).To(Equal("hotfix for incident 42"))
+			Expect(event.ConfirmationDeadline.Valid).To(BeFalse())
+
+			var creationRecord dbmodels.CreationAuditRecord
+			Expect(ctx.Db.Where("release_overridden_event_id = ?", event.ID).Take(&creationRecord).Error).ToNot(HaveOccurred())
+			Expect(creationRecord.ServiceAccountName.String).To(Equal(ctx.ServiceAccount.Name))
+		})
+
+		It("requires a justification", func() {
+			Setup(releasestate.InProgress, 0)
+
+			Override(gin.H{"justification": " "}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+		})
+
+		It("refuses organization members without an overrider role", func() {
+			Setup(releasestate.InProgress, 0)
+
+			Override(gin.H{"justification": "hotfix"}, technician)
+			Expect(ctx.Recorder.Code).To(Equal(401))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.InProgress))
+		})
+
+		It("approves a release that an enforcing schedule rule has already rejected", func() {
+			Setup(releasestate.InProgress, 0)
+			ProcessRules()
+
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "approved"))
+			Expect(body).To(HaveKeyWithValue("overridden", true))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Approved))
+			Expect(release.Overridden).To(BeTrue())
+		})
+
+		It("refuses to override a release that has already been approved", func() {
+			Setup(releasestate.Approved, 0)
+
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+		})
+
+		It("refuses to override a release that has been cancelled", func() {
+			Setup(releasestate.Cancelled, 0)
+
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+		})
+
+		It("shows the override in the release's events", func() {
+			Setup(releasestate.InProgress, 0)
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			ctx.Recorder = httptest.NewRecorder()
+			req, err := ctx.NewRequestWithAuth("GET", fmt.Sprintf("/v1/applications/%s/releases/%d/events", app.ID, release.ID), nil)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			items := body["items"].([]interface{})
+			Expect(items).To(HaveLen(1))
+			Expect(items[0]).To(HaveKeyWithValue("type", "overridden"))
+			Expect(items[0]).To(HaveKeyWithValue("justification", "hotfix"))
+		})
+	})
+
+	Describe("when the application requires override confirmation", func() {
+		It("leaves the release in progress until the override is confirmed", func() {
+			Setup(releasestate.InProgress, 15)
+
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(202))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "in_progress"))
+			Expect(body).To(HaveKeyWithValue("overridden", false))
+
+			var event dbmodels.ReleaseOverriddenEvent
+			Expect(ctx.Db.Take(&event).Error).ToNot(HaveOccurred())
+			Expect(event.ConfirmationDeadline.Valid).To(BeTrue())
+			Expect(event.ConfirmationDeadline.Time).To(BeTemporally("~", time.Now().Add(15*time.Minute), time.Minute))
+
+			Confirm(confirmer)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err = ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "approved"))
+			Expect(body).To(HaveKeyWithValue("overridden", true))
+
+			var confirmedEvent dbmodels.ReleaseOverrideConfirmedEvent
+			Expect(ctx.Db.Take(&confirmedEvent).Error).ToNot(HaveOccurred())
+			Expect(confirmedEvent.ReleaseOverriddenEventID).To(Equal(event.ID))
+
+			var creationRecord dbmodels.CreationAuditRecord
+			Expect(ctx.Db.Where("release_override_confirmed_event_id = ?", confirmedEvent.ID).Take(&creationRecord).Error).ToNot(HaveOccurred())
+			Expect(creationRecord.ServiceAccountName.String).To(Equal(confirmer.Name))
+		})
+
+		It("approves the release if its rules reject it before the override is confirmed", func() {
+			Setup(releasestate.InProgress, 15)
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(202))
+
+			ProcessRules()
+
+			Confirm(confirmer)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "approved"))
+			Expect(body).To(HaveKeyWithValue("overridden", true))
+		})
+
+		It("refuses confirmation by the organization member who requested the override", func() {
+			Setup(releasestate.InProgress, 15)
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(202))
+
+			Confirm(nil)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.InProgress))
+		})
+
+		It("refuses confirmation by organization members without an overrider role", func() {
+			Setup(releasestate.InProgress, 15)
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(202))
+
+			Confirm(technician)
+			Expect(ctx.Recorder.Code).To(Equal(401))
+		})
+
+		It("refuses confirmation after the deadline", func() {
+			Setup(releasestate.InProgress, 15)
+			Override(gin.H{"justification": "hotfix"}, nil)
+			Expect(ctx.Recorder.Code).To(Equal(202))
+
+			err = ctx.Db.Model(&dbmodels.ReleaseOverriddenEvent{}).Where("true").
+				Update("confirmation_deadline", time.Now().Add(-time.Minute)).Error
+			Expect(err).ToNot(HaveOccurred())
+
+			Confirm(confirmer)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.InProgress))
+			Expect(release.Overridden).To(BeFalse())
+		})
+
+		It("refuses confirmation if no override was requested", func() {
+			Setup(releasestate.InProgress, 15)
+
+			Confirm(confirmer)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+		})
+	})
+})
@@ -1,14 +1,21 @@
 package controllers
 
 import (
+	"database/sql"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"time"
 
+	"github.com/fullstaq-labs/sqedule/lib"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/deploymentstate"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
 	"github.com/fullstaq-labs/sqedule/server/dbutils"
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/gomega"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -179,6 +186,189 @@ var _ = Describe("release API", func() {
 				return release.State
 			}).ShouldNot(Equal(releasestate.InProgress))
 		})
+
+		It("does not bind disabled approval ruleset bindings, but records that they were skipped", func() {
+			var enforcingBinding dbmodels.ApplicationApprovalRulesetBinding
+
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, enforcingBinding, err = dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.Org, app)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = tx.Model(&dbmodels.ApplicationApprovalRulesetBindingAdjustment{}).
+					Where("organization_id = ? AND application_approval_ruleset_binding_version_id = ?",
+						ctx.Org.ID, enforcingBinding.Version.ID).
+					Update("enabled", false).
+					Error
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases", app.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(201))
+
+			var release dbmodels.Release
+			tx := ctx.Db.Take(&release)
+			Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+
+			bindings, err := dbmodels.FindAllReleaseApprovalRulesetBindings(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bindings).To(HaveLen(1))
+			Expect(bindings[0].ApprovalRulesetID).ToNot(Equal(enforcingBinding.ApprovalRulesetID))
+
+			events, err := dbmodels.FindReleaseRuleSkippedEvents(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].ApprovalRulesetID.String).To(Equal(enforcingBinding.ApprovalRulesetID))
+			Expect(events[0].Reason).To(Equal("Approval ruleset binding is disabled"))
+		})
+
+		It("refuses to create a release for a disabled application", func() {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil,
+					func(adjustment *dbmodels.ApplicationAdjustment) {
+						adjustment.Enabled = lib.NewBoolPtr(false)
+					})
+				Expect(err).ToNot(HaveOccurred())
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases", app.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			var count int64
+			err = ctx.Db.Model(&dbmodels.Release{}).Count(&count).Error
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically("==", 0))
+		})
+	})
+
+	Describe("POST /applications/:app_id/releases/dry-run", func() {
+		var app dbmodels.Application
+		var body gin.H
+
+		BeforeEach(func() {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, enforcingBinding, err := dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.Org, app)
+				Expect(err).ToNot(HaveOccurred())
+
+				ruleset := enforcingBinding.ApprovalRuleset
+				_, err = dbmodels.CreateMockScheduleApprovalRuleWholeDay(tx, ctx.Org, ruleset.Version.ID, *ruleset.Version.Adjustment, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/dry-run", app.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err = ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("outputs the resulting state and the rule verdicts", func() {
+			Expect(body).To(HaveKeyWithValue("state", "approved"))
+			Expect(body).To(HaveKeyWithValue("approval_ruleset_bindings", HaveLen(2)))
+			Expect(body).To(HaveKeyWithValue("events", HaveLen(1)))
+			Expect(body).To(HaveKeyWithValue("not_evaluated_rules", BeEmpty()))
+
+			event := body["events"].([]interface{})[0].(map[string]interface{})
+			Expect(event).To(HaveKeyWithValue("type", "rule_processed"))
+			Expect(event).To(HaveKeyWithValue("result_state", "approved"))
+			outcome := event["approval_rule_outcome"].(map[string]interface{})
+			Expect(outcome).To(HaveKeyWithValue("type", "schedule"))
+			Expect(outcome).To(HaveKeyWithValue("success", BeTrue()))
+		})
+
+		It("does not persist anything", func() {
+			var count int64
+
+			err = ctx.Db.Model(&dbmodels.Release{}).Count(&count).Error
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically("==", 0))
+
+			err = ctx.Db.Model(&dbmodels.ReleaseRuleProcessedEvent{}).Count(&count).Error
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically("==", 0))
+
+			err = ctx.Db.Model(&dbmodels.ScheduleApprovalRuleOutcome{}).Count(&count).Error
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically("==", 0))
+
+			err = ctx.Db.Model(&dbmodels.ReleaseBackgroundJob{}).Count(&count).Error
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically("==", 0))
+		})
+	})
+
+	Describe("POST /applications/:app_id/releases/dry-run with an HTTP API rule", func() {
+		var server *httptest.Server
+		var nrequests int
+		var body gin.H
+
+		BeforeEach(func() {
+			nrequests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nrequests++
+			}))
+
+			var app dbmodels.Application
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, enforcingBinding, err := dbmodels.CreateMockApplicationApprovalRulesetsAndBindingsWith2Modes1Version(tx, ctx.Org, app)
+				Expect(err).ToNot(HaveOccurred())
+
+				ruleset := enforcingBinding.ApprovalRuleset
+				_, err = dbmodels.CreateMockHTTPApiApprovalRule(tx, ctx.Org, ruleset.Version.ID, *ruleset.Version.Adjustment, server.URL, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/dry-run", app.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err = ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("does not call the rule's URL", func() {
+			Expect(nrequests).To(Equal(0))
+		})
+
+		It("reports the rule as not evaluated", func() {
+			Expect(body).To(HaveKeyWithValue("state", "in_progress"))
+			Expect(body).To(HaveKeyWithValue("events", BeEmpty()))
+			Expect(body).To(HaveKeyWithValue("not_evaluated_rules", HaveLen(1)))
+
+			rule := body["not_evaluated_rules"].([]interface{})[0].(map[string]interface{})
+			Expect(rule).To(HaveKeyWithValue("type", "http_api"))
+			Expect(rule).To(HaveKeyWithValue("url", server.URL))
+		})
 	})
 
 	Describe("GET /releases", func() {
@@ -242,6 +432,85 @@ var _ = Describe("release API", func() {
 		})
 	})
 
+	Describe("GET /releases with filters", func() {
+		var mctx MultipleAppsAndReleasesTestContext
+
+		BeforeEach(func() {
+			mctx = SetupMultipleAppsAndReleasesTestContext()
+
+			err = ctx.Db.Model(&mctx.release2).Updates(map[string]interface{}{
+				"state":           releasestate.Rejected,
+				"finalized_at":    time.Now(),
+				"source_identity": "v2.0_rc1",
+				"metadata":        datatypes.JSONMap{"env": "prod", "team": "checkout"},
+			}).Error
+			Expect(err).ToNot(HaveOccurred())
+			err = ctx.Db.Model(&mctx.release3).Updates(map[string]interface{}{
+				"source_identity": "v2.1",
+				"metadata":        datatypes.JSONMap{"env": "staging"},
+			}).Error
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		List := func(query string) []uint64 {
+			ctx.Recorder = httptest.NewRecorder()
+			req, err := ctx.NewRequestWithAuth("GET", "/v1/releases?"+query, nil)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			var result []uint64
+			for _, item := range body["items"].([]interface{}) {
+				result = append(result, uint64(item.(map[string]interface{})["id"].(float64)))
+			}
+			return result
+		}
+
+		It("filters by state", func() {
+			Expect(List("state=rejected,cancelled")).To(Equal([]uint64{mctx.release2.ID}))
+		})
+
+		It("filters by creation time", func() {
+			query := url.Values{}
+			query.Set("created_after", time.Now().Add(-2500*time.Millisecond).Format(time.RFC3339Nano))
+			query.Set("created_before", time.Now().Add(-1500*time.Millisecond).Format(time.RFC3339Nano))
+			Expect(List(query.Encode())).To(Equal([]uint64{mctx.release2.ID}))
+		})
+
+		It("filters by finalization time", func() {
+			query := url.Values{}
+			query.Set("finalized_after", time.Now().Add(-time.Hour).Format(time.RFC3339))
+			Expect(List(query.Encode())).To(Equal([]uint64{mctx.release2.ID}))
+		})
+
+		It("filters by source identity prefix", func() {
+			Expect(List("source_identity_prefix=v2.")).To(Equal([]uint64{mctx.release2.ID, mctx.release3.ID}))
+			Expect(List("source_identity_prefix=v2.0_")).To(Equal([]uint64{mctx.release2.ID}))
+			// Underscores are matched literally.
+			Expect(List("source_identity_prefix=v2_")).To(BeEmpty())
+		})
+
+		It("filters by metadata", func() {
+			query := url.Values{}
+			query.Set("metadata", `{"env":"prod"}`)
+			Expect(List(query.Encode())).To(Equal([]uint64{mctx.release2.ID}))
+		})
+
+		It("sorts", func() {
+			Expect(List("sort=created_at")).To(Equal([]uint64{mctx.release3.ID, mctx.release2.ID, mctx.release1.ID}))
+			Expect(List("sort=-created_at")).To(Equal([]uint64{mctx.release1.ID, mctx.release2.ID, mctx.release3.ID}))
+		})
+
+		It("rejects invalid parameters", func() {
+			req, err := ctx.NewRequestWithAuth("GET", "/v1/releases?state=foo", nil)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+			Expect(ctx.Recorder.Code).To(Equal(400))
+		})
+	})
+
 	Describe("GET /applications/:app_id/releases", func() {
 		var mctx MultipleAppsAndReleasesTestContext
 		var body gin.H
@@ -344,6 +613,220 @@ var _ = Describe("release API", func() {
 		})
 	})
 
+	Describe("POST /applications/:app_id/releases/:id/retry", func() {
+		var app dbmodels.Application
+		var release dbmodels.Release
+
+		Setup := func(dead bool) {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.Org, app, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.Org, app, release, func(job *dbmodels.ReleaseBackgroundJob) {
+					job.Attempts = 11
+					job.LastError = sql.NullString{String: "fake error", Valid: true}
+					if dead {
+						job.DeadAt = sql.NullTime{Time: time.Now(), Valid: true}
+					}
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		AfterEach(func() {
+			ctx.ControllerCtx.WaitGroup.Wait()
+		})
+
+		It("resets a dead job and processes the release", func() {
+			Setup(true)
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/%d/retry", app.ID, release.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["background_job"]).To(HaveKeyWithValue("state", "pending"))
+			Expect(body["background_job"]).To(HaveKeyWithValue("attempts", BeNumerically("==", 0)))
+
+			ctx.ControllerCtx.WaitGroup.Wait()
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Approved))
+		})
+
+		It("refuses to retry a job that isn't dead", func() {
+			Setup(false)
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/%d/retry", app.ID, release.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+
+			Expect(ctx.Recorder.Code).To(Equal(422))
+		})
+	})
+
+	Describe("POST /applications/:app_id/releases/:id/cancel", func() {
+		var app dbmodels.Application
+		var release dbmodels.Release
+
+		Setup := func(state releasestate.State) {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.Org, app, func(release *dbmodels.Release) {
+					release.State = state
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				if state == releasestate.InProgress {
+					_, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.Org, app, release, nil)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("cancels the release and removes its background job", func() {
+			Setup(releasestate.InProgress)
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/%d/cancel", app.ID, release.ID),
+				gin.H{"reason": "pipeline aborted"})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "cancelled"))
+			Expect(body["finalized_at"]).ToNot(BeNil())
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Cancelled))
+			Expect(release.FinalizedAt.Valid).To(BeTrue())
+
+			_, err = dbmodels.FindReleaseBackgroundJob(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+
+			var event dbmodels.ReleaseCancelledEvent
+			Expect(ctx.Db.Take(&event).Error).ToNot(HaveOccurred())
+			Expect(event.Reason.String).To(Equal("pipeline aborted"))
+
+			var creationRecord dbmodels.CreationAuditRecord
+			Expect(ctx.Db.Where("release_cancelled_event_id = ?", event.ID).Take(&creationRecord).Error).ToNot(HaveOccurred())
+		})
+
+		It("refuses to cancel a release that has already been finalized", func() {
+			Setup(releasestate.Approved)
+
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/%d/cancel", app.ID, release.ID), gin.H{})
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+
+			Expect(ctx.Recorder.Code).To(Equal(422))
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.State).To(Equal(releasestate.Approved))
+		})
+	})
+
+	Describe("POST /applications/:app_id/releases/:id/deployment", func() {
+		var app dbmodels.Application
+		var release dbmodels.Release
+
+		Setup := func(state releasestate.State) {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err = dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				release, err = dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.Org, app, func(release *dbmodels.Release) {
+					release.State = state
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Report := func(input gin.H) {
+			req, err := ctx.NewRequestWithAuth("POST", fmt.Sprintf("/v1/applications/%s/releases/%d/deployment", app.ID, release.ID),
+				input)
+			Expect(err).ToNot(HaveOccurred())
+			ctx.ServeHTTP(req)
+		}
+
+		It("updates the deployment state and records events", func() {
+			Setup(releasestate.Approved)
+
+			Report(gin.H{"state": "deploying"})
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			ctx.Recorder = httptest.NewRecorder()
+			Report(gin.H{"state": "deployed", "comments": "pipeline job 123"})
+			Expect(ctx.Recorder.Code).To(Equal(200))
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(HaveKeyWithValue("state", "approved"))
+			Expect(body).To(HaveKeyWithValue("deployment_state", "deployed"))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.DeploymentState.String).To(Equal("deployed"))
+
+			var events []dbmodels.ReleaseDeploymentEvent
+			Expect(ctx.Db.Order("created_at, id").Find(&events).Error).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].State).To(Equal(deploymentstate.Deploying))
+			Expect(events[0].Comments.Valid).To(BeFalse())
+			Expect(events[1].State).To(Equal(deploymentstate.Deployed))
+			Expect(events[1].Comments.String).To(Equal("pipeline job 123"))
+
+			var creationRecord dbmodels.CreationAuditRecord
+			Expect(ctx.Db.Where("release_deployment_event_id = ?", events[1].ID).Take(&creationRecord).Error).ToNot(HaveOccurred())
+		})
+
+		It("refuses to report the deployment of a release that isn't approved", func() {
+			Setup(releasestate.InProgress)
+
+			Report(gin.H{"state": "deployed"})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.DeploymentState.Valid).To(BeFalse())
+		})
+
+		It("refuses invalid deployment state transitions", func() {
+			Setup(releasestate.Approved)
+
+			Report(gin.H{"state": "rolled_back"})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+
+			release, err = dbmodels.FindRelease(ctx.Db, ctx.Org.ID, app.ID, release.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.DeploymentState.Valid).To(BeFalse())
+		})
+
+		It("refuses unknown deployment states", func() {
+			Setup(releasestate.Approved)
+
+			Report(gin.H{"state": "foo"})
+			Expect(ctx.Recorder.Code).To(Equal(400))
+		})
+	})
+
 	Describe("GET /applications/:app_id/releases/:id/events", func() {
 		var app dbmodels.Application
 		var release dbmodels.Release
@@ -24,11 +24,21 @@ func (ctx Context) InstallAuthenticatedRoutes(rg *gin.RouterGroup) {
 
 	// Releases
 	rg.GET("releases", ctx.ListReleases)
+	rg.GET("releases/events/stream", ctx.StreamReleaseEvents)
 	rg.GET("applications/:application_id/releases", ctx.ListReleases)
+	rg.GET("applications/:application_id/releases/events/stream", ctx.StreamReleaseEvents)
 	rg.POST("applications/:application_id/releases", ctx.CreateRelease)
+	rg.POST("applications/:application_id/releases/dry-run", ctx.DryRunRelease)
 	rg.GET("applications/:application_id/releases/:id", ctx.GetRelease)
 	rg.GET("applications/:application_id/releases/:id/events", ctx.GetReleaseEvents)
+	rg.GET("applications/:application_id/releases/:id/events/stream", ctx.StreamReleaseEvents)
 	rg.PATCH("applications/:application_id/releases/:id", ctx.UpdateRelease)
+	rg.POST("applications/:application_id/releases/:id/manual-approvals", ctx.CreateReleaseManualApproval)
+	rg.POST("applications/:application_id/releases/:id/retry", ctx.RetryRelease)
+	rg.POST("applications/:application_id/releases/:id/cancel", ctx.CancelRelease)
+	rg.POST("applications/:application_id/releases/:id/deployment", ctx.ReportReleaseDeployment)
+	rg.POST("applications/:application_id/releases/:id/override", ctx.OverrideRelease)
+	rg.POST("applications/:application_id/releases/:id/override/confirm", ctx.ConfirmReleaseOverride)
 
 	// Approval ruleset bindings
 	rg.GET("application-approval-ruleset-bindings", ctx.ListApplicationApprovalRulesetBindings)
@@ -56,8 +66,19 @@ func (ctx Context) InstallAuthenticatedRoutes(rg *gin.RouterGroup) {
 	rg.PATCH("approval-rulesets/:id/proposals/:version_id", ctx.UpdateApprovalRulesetProposal)
 	rg.PUT("approval-rulesets/:id/proposals/:version_id/state", ctx.UpdateApprovalRulesetProposalState)
 	rg.DELETE("approval-rulesets/:id/proposals/:version_id", ctx.DeleteApprovalRulesetProposal)
+
+	// Calendars
+	rg.POST("calendars", ctx.CreateCalendar)
+	rg.GET("calendars", ctx.ListCalendars)
+	rg.GET("calendars/:id", ctx.GetCalendar)
+	rg.PATCH("calendars/:id", ctx.UpdateCalendar)
+	rg.DELETE("calendars/:id", ctx.DeleteCalendar)
+	rg.PUT("calendars/:id/entries", ctx.ImportCalendarEntries)
 }
 
 func (ctx Context) InstallUnauthenticatedRoutes(rg *gin.RouterGroup) {
 	rg.GET("about", ctx.About)
+
+	// Rule callbacks. These are authenticated by the one-time token in the URL.
+	rg.POST("rule-callbacks/:token", ctx.CreateRuleCallback)
 }
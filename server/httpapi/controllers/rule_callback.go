@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/httpapi/json"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateRuleCallback is called by an external system to report the result of a
+// CallbackApprovalRule's job. The one-time token in the URL identifies the
+// CallbackApprovalRuleRequest, and serves as authentication.
+func (ctx Context) CreateRuleCallback(ginctx *gin.Context) {
+	// Parse input, fetch related objects
+
+	var input json.RuleCallbackInput
+	if err := ginctx.ShouldBindJSON(&input); err != nil {
+		ginctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	request, err := dbmodels.FindCallbackApprovalRuleRequestByToken(ctx.Db, ginctx.Param("token"))
+	if err != nil {
+		respondWithDbQueryError("callback request", err, ginctx)
+		return
+	}
+
+	// Query database
+
+	if request.CompletedAt.Valid {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This callback token has already been used"})
+		return
+	}
+	now := time.Now()
+	if !now.Before(request.ExpiresAt) {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This callback token has expired"})
+		return
+	}
+
+	// Modify database
+
+	var message sql.NullString
+	if input.Message != nil && len(*input.Message) > 0 {
+		message = sql.NullString{String: *input.Message, Valid: true}
+	}
+	updated, err := dbmodels.CompleteCallbackApprovalRuleRequest(ctx.Db, &request, *input.Success, message, now)
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		ginctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This callback token has already been used"})
+		return
+	}
+
+	job, err := dbmodels.FindReleaseBackgroundJob(ctx.Db.Preload("Release"), request.OrganizationID,
+		request.ApplicationID, request.ReleaseID)
+	if err == nil {
+		err = ctx.processReleaseBackgroundJob(request.OrganizationID, job)
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The release is already being finalized.
+		err = nil
+	}
+	if err != nil {
+		ginctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate response
+
+	output := json.CreateCallbackApprovalRuleRequest(request)
+	ginctx.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/releasestate"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("rule callback API", func() {
+	var ctx HTTPTestContext
+	var err error
+
+	Describe("POST /rule-callbacks/:token", func() {
+		var request dbmodels.CallbackApprovalRuleRequest
+
+		Setup := func(autoProcessReleaseInBackground bool, requestCustomizeFunc func(request *dbmodels.CallbackApprovalRuleRequest)) {
+			ctx, err = SetupHTTPTestContext(func(ctx *HTTPTestContext, tx *gorm.DB) error {
+				app, err := dbmodels.CreateMockApplicationWith1Version(tx, ctx.Org, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				release, err := dbmodels.CreateMockReleaseWithInProgressState(tx, ctx.Org, app, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				ruleset, err := dbmodels.CreateMockApprovalRulesetWith1Version(tx, ctx.Org, "ruleset1", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockReleaseRulesetBindingWithEnforcingMode(tx, ctx.Org, release,
+					ruleset, *ruleset.Version, *ruleset.Version.Adjustment, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				rule, err := dbmodels.CreateMockCallbackApprovalRule(tx, ctx.Org, ruleset.Version.ID,
+					*ruleset.Version.Adjustment, "http://localhost", nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				request, err = dbmodels.CreateMockCallbackApprovalRuleRequest(tx, release, rule, "token123", requestCustomizeFunc)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = dbmodels.CreateMockReleaseBackgroundJob(tx, ctx.Org, app, release, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				ctx.ControllerCtx.AutoProcessReleaseInBackground = autoProcessReleaseInBackground
+
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Submit := func(token string, body gin.H) {
+			// No authentication: the token is the authentication.
+			req, err := ctx.NewRequestWithAuth("POST", "/v1/rule-callbacks/"+token, body)
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Del("TestOrgID")
+			req.Header.Del("TestOrgMemberType")
+			req.Header.Del("TestOrgMemberID")
+			ctx.ServeHTTP(req)
+		}
+
+		AfterEach(func() {
+			ctx.ControllerCtx.WaitGroup.Wait()
+		})
+
+		It("completes the callback request", func() {
+			Setup(false, nil)
+			Submit("token123", gin.H{"success": true, "message": "No vulnerabilities found"})
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			body, err := ctx.BodyJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body["success"]).To(BeTrue())
+			Expect(body["message"]).To(Equal("No vulnerabilities found"))
+
+			var updatedRequest dbmodels.CallbackApprovalRuleRequest
+			tx := ctx.Db.Where("id = ?", request.ID).Take(&updatedRequest)
+			Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+			Expect(updatedRequest.CompletedAt.Valid).To(BeTrue())
+			Expect(updatedRequest.Success.Bool).To(BeTrue())
+			Expect(updatedRequest.Message.String).To(Equal("No vulnerabilities found"))
+		})
+
+		It("approves the release eventually", func() {
+			Setup(true, nil)
+			Submit("token123", gin.H{"success": true})
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			Eventually(func() releasestate.State {
+				var release dbmodels.Release
+				tx := ctx.Db.Take(&release)
+				Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+				return release.State
+			}).Should(Equal(releasestate.Approved))
+
+			var outcome dbmodels.CallbackApprovalRuleOutcome
+			tx := ctx.Db.Take(&outcome)
+			Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+			Expect(outcome.Success).To(BeTrue())
+		})
+
+		It("rejects the release eventually", func() {
+			Setup(true, nil)
+			Submit("token123", gin.H{"success": false, "message": "Critical vulnerability found"})
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			Eventually(func() releasestate.State {
+				var release dbmodels.Release
+				tx := ctx.Db.Take(&release)
+				Expect(dbutils.CreateFindOperationError(tx)).ToNot(HaveOccurred())
+				return release.State
+			}).Should(Equal(releasestate.Rejected))
+		})
+
+		It("responds with 404 for unknown tokens", func() {
+			Setup(false, nil)
+			Submit("unknown", gin.H{"success": true})
+			Expect(ctx.Recorder.Code).To(Equal(404))
+		})
+
+		It("refuses tokens that have already been used", func() {
+			Setup(false, nil)
+			Submit("token123", gin.H{"success": true})
+			Expect(ctx.Recorder.Code).To(Equal(200))
+
+			ctx.Recorder = httptest.NewRecorder()
+			Submit("token123", gin.H{"success": false})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("already been used"))
+		})
+
+		It("refuses expired tokens", func() {
+			Setup(false, func(request *dbmodels.CallbackApprovalRuleRequest) {
+				request.ExpiresAt = time.Now().Add(-time.Minute)
+			})
+			Submit("token123", gin.H{"success": true})
+			Expect(ctx.Recorder.Code).To(Equal(422))
+			Expect(ctx.Recorder.Body.String()).To(ContainSubstring("expired"))
+		})
+
+		It("requires 'success' to be set", func() {
+			Setup(false, nil)
+			Submit("token123", gin.H{"message": "hello"})
+			Expect(ctx.Recorder.Code).To(Equal(400))
+		})
+	})
+})
@@ -76,11 +76,12 @@ func SetupHTTPTestContext(initializer func(ctx *HTTPTestContext, tx *gorm.DB) er
 	gin.SetMode(gin.TestMode)
 	hctx.Engine = gin.Default()
 
+	hctx.ControllerCtx = NewContext(hctx.Db, hctx.WaitGroup)
+	hctx.ControllerCtx.InstallUnauthenticatedRoutes(hctx.Engine.Group("/v1"))
+
 	orgMemberLookupMiddleware := auth.NewOrgMemberLookupMiddleware(hctx.Db, true)
 	routingGroup := hctx.Engine.Group("/v1")
 	routingGroup.Use(orgMemberLookupMiddleware)
-
-	hctx.ControllerCtx = NewContext(hctx.Db, hctx.WaitGroup)
 	hctx.ControllerCtx.InstallAuthenticatedRoutes(routingGroup)
 
 	hctx.Recorder = httptest.NewRecorder()
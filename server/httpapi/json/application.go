@@ -27,8 +27,10 @@ type ApplicationWithLatestApprovedVersion struct {
 
 type ApplicationVersion struct {
 	ReviewableVersionBase
-	DisplayName string `json:"display_name"`
-	Enabled     bool   `json:"enabled"`
+	DisplayName                 string `json:"display_name"`
+	Enabled                     bool   `json:"enabled"`
+	ReleaseExpiryMinutes        *int32 `json:"release_expiry_minutes"`
+	OverrideConfirmationMinutes *int32 `json:"override_confirmation_minutes"`
 }
 
 //
@@ -37,9 +39,11 @@ type ApplicationVersion struct {
 
 func CreateApplicationVersion(version dbmodels.ApplicationVersion) ApplicationVersion {
 	return ApplicationVersion{
-		ReviewableVersionBase: createReviewableVersionBase(version.ReviewableVersionBase, version.Adjustment.ReviewableAdjustmentBase),
-		DisplayName:           version.Adjustment.DisplayName,
-		Enabled:               version.Adjustment.IsEnabled(),
+		ReviewableVersionBase:       createReviewableVersionBase(version.ReviewableVersionBase, version.Adjustment.ReviewableAdjustmentBase),
+		DisplayName:                 version.Adjustment.DisplayName,
+		Enabled:                     version.Adjustment.IsEnabled(),
+		ReleaseExpiryMinutes:        getSqlInt32ContentsOrNil(version.Adjustment.ReleaseExpiryMinutes),
+		OverrideConfirmationMinutes: getSqlInt32ContentsOrNil(version.Adjustment.OverrideConfirmationMinutes),
 	}
 }
 
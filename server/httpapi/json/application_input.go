@@ -1,6 +1,8 @@
 package json
 
 import (
+	"database/sql"
+
 	"github.com/fullstaq-labs/sqedule/lib"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
 )
@@ -18,6 +20,10 @@ type ApplicationVersionInput struct {
 	ReviewableVersionInputBase
 	DisplayName *string `json:"display_name"`
 	Enabled     *bool   `json:"enabled"`
+	// ReleaseExpiryMinutes disables release expiry if set to 0.
+	ReleaseExpiryMinutes *int32 `json:"release_expiry_minutes"`
+	// OverrideConfirmationMinutes disables override confirmation if set to 0.
+	OverrideConfirmationMinutes *int32 `json:"override_confirmation_minutes"`
 }
 
 //
@@ -37,4 +43,18 @@ func PatchApplicationAdjustment(organizationID string, adjustment *dbmodels.Appl
 	if input.Enabled != nil {
 		adjustment.Enabled = lib.CopyBoolPtr(input.Enabled)
 	}
+	if input.ReleaseExpiryMinutes != nil {
+		if *input.ReleaseExpiryMinutes > 0 {
+			adjustment.ReleaseExpiryMinutes = int32PointerToSqlInt32(input.ReleaseExpiryMinutes)
+		} else {
+			adjustment.ReleaseExpiryMinutes = sql.NullInt32{}
+		}
+	}
+	if input.OverrideConfirmationMinutes != nil {
+		if *input.OverrideConfirmationMinutes > 0 {
+			adjustment.OverrideConfirmationMinutes = int32PointerToSqlInt32(input.OverrideConfirmationMinutes)
+		} else {
+			adjustment.OverrideConfirmationMinutes = sql.NullInt32{}
+		}
+	}
 }
@@ -12,17 +12,18 @@ import (
 // ******** Types, constants & variables ********
 //
 
+// ApprovalRuleEnum is the JSON representation of a rule of any type.
 type ApprovalRuleEnum struct {
-	*HTTPApiApprovalRule
-	*ScheduleApprovalRule
-	*ManualApprovalRule
+	// Rule is the JSON representation of a specific rule type, e.g. ScheduleApprovalRule.
+	Rule interface{}
 }
 
 type ApprovalRuleBase struct {
-	Type      string    `json:"type"`
-	ID        uint64    `json:"id"`
-	Enabled   bool      `json:"enabled"`
-	CreatedAt time.Time `json:"created_at"`
+	Type            string    `json:"type"`
+	ID              uint64    `json:"id"`
+	Enabled         bool      `json:"enabled"`
+	EvaluationOrder int32     `json:"evaluation_order"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 type HTTPApiApprovalRule struct {
@@ -39,9 +40,13 @@ type ScheduleApprovalRule struct {
 	ApprovalRuleBase
 	BeginTime    *string `json:"begin_time"`
 	EndTime      *string `json:"end_time"`
+	TimeRanges   *string `json:"time_ranges"`
 	DaysOfWeek   *string `json:"days_of_week"`
 	DaysOfMonth  *string `json:"days_of_month"`
 	MonthsOfYear *string `json:"months_of_year"`
+	TimeZone     *string `json:"time_zone"`
+
+	DeferUntilWindow bool `json:"defer_until_window"`
 }
 
 type ManualApprovalRule struct {
@@ -50,34 +55,73 @@ type ManualApprovalRule struct {
 	Minimum        *int32 `json:"minimum"`
 }
 
+type CalendarApprovalRule struct {
+	ApprovalRuleBase
+	CalendarID string `json:"calendar_id"`
+}
+
+type DependencyApprovalRule struct {
+	ApprovalRuleBase
+	DependencyApplicationID string `json:"dependency_application_id"`
+	MaxAgeHours             *int32 `json:"max_age_hours"`
+	SameSourceIdentity      bool   `json:"same_source_identity"`
+}
+
+type QuotaApprovalRule struct {
+	ApprovalRuleBase
+	MaxReleases int32 `json:"max_releases"`
+	WindowHours int32 `json:"window_hours"`
+}
+
+type CallbackApprovalRule struct {
+	ApprovalRuleBase
+	URL              string  `json:"url"`
+	Username         *string `json:"username"`
+	Password         *string `json:"password"`
+	TLSCaCertificate *string `json:"tls_ca_certificate"`
+	TimeoutMinutes   int32   `json:"timeout_minutes"`
+}
+
+type ExpressionApprovalRule struct {
+	ApprovalRuleBase
+	Expression string `json:"expression"`
+}
+
+type PromotionApprovalRule struct {
+	ApprovalRuleBase
+	SourceApplicationID string `json:"source_application_id"`
+	MinSoakHours        int32  `json:"min_soak_hours"`
+}
+
 //
 // ******** ApprovalRuleEnum methods ********
 //
 
-// Works around a bug in encoding/json where it doesn't include
-// deeply nested anonymous structs.
 func (enum ApprovalRuleEnum) MarshalJSON() ([]byte, error) {
-	if enum.HTTPApiApprovalRule != nil {
-		return encjson.Marshal(enum.HTTPApiApprovalRule)
-	} else if enum.ScheduleApprovalRule != nil {
-		return encjson.Marshal(enum.ScheduleApprovalRule)
-	} else if enum.ManualApprovalRule != nil {
-		return encjson.Marshal(enum.ManualApprovalRule)
-	} else {
-		panic("Exactly one ApprovalRuleEnum field must be set")
+	if enum.Rule == nil {
+		panic("ApprovalRuleEnum.Rule must be set")
 	}
+	return encjson.Marshal(enum.Rule)
 }
 
 //
 // ******** Constructor functions ********
 //
 
+// CreateApprovalRuleEnum returns the JSON representation of `rule`, which is a pointer to a
+// rule of any type.
+func CreateApprovalRuleEnum(rule dbmodels.IApprovalRule) ApprovalRuleEnum {
+	codec := mustFindApprovalRuleTypeCodec(rule.Type())
+	return ApprovalRuleEnum{Rule: codec.CreateApprovalRuleJSON(rule)}
+}
+
 func createApprovalRuleBase(theType dbmodels.ApprovalRuleType, rule dbmodels.ApprovalRule) ApprovalRuleBase {
 	return ApprovalRuleBase{
-		Type:      string(theType),
-		ID:        rule.ID,
-		Enabled:   lib.DerefBoolPtrWithDefault(rule.Enabled, true),
-		CreatedAt: rule.CreatedAt,
+		Type:            string(theType),
+		ID:              rule.ID,
+		Enabled:         lib.DerefBoolPtrWithDefault(rule.Enabled, true),
+		EvaluationOrder: rule.EvaluationOrder,
+		CreatedAt:       rule.CreatedAt,
 	}
 }
 
@@ -97,9 +141,12 @@ func CreateScheduleApprovalRule(rule dbmodels.ScheduleApprovalRule) ScheduleAppr
 		ApprovalRuleBase: createApprovalRuleBase(dbmodels.ScheduleApprovalRuleType, rule.ApprovalRule),
 		BeginTime:        getSqlStringContentsOrNil(rule.BeginTime),
 		EndTime:          getSqlStringContentsOrNil(rule.EndTime),
+		TimeRanges:       getSqlStringContentsOrNil(rule.TimeRanges),
 		DaysOfWeek:       getSqlStringContentsOrNil(rule.DaysOfWeek),
 		DaysOfMonth:      getSqlStringContentsOrNil(rule.DaysOfMonth),
 		MonthsOfYear:     getSqlStringContentsOrNil(rule.MonthsOfYear),
+		TimeZone:         getSqlStringContentsOrNil(rule.TimeZone),
+		DeferUntilWindow: rule.DeferUntilWindow,
 	}
 }
 
@@ -113,3 +160,55 @@ func CreateManualApprovalRule(rule dbmodels.ManualApprovalRule) ManualApprovalRu
 	}
 	return result
 }
+
+func CreateCalendarApprovalRule(rule dbmodels.CalendarApprovalRule) CalendarApprovalRule {
+	return CalendarApprovalRule{
+		ApprovalRuleBase: createApprovalRuleBase(dbmodels.CalendarApprovalRuleType, rule.ApprovalRule),
+		CalendarID:       rule.CalendarID,
+	}
+}
+
+func CreateDependencyApprovalRule(rule dbmodels.DependencyApprovalRule) DependencyApprovalRule {
+	result := DependencyApprovalRule{
+		ApprovalRuleBase:        createApprovalRuleBase(dbmodels.DependencyApprovalRuleType, rule.ApprovalRule),
+		DependencyApplicationID: rule.DependencyApplicationID,
+		SameSourceIdentity:      rule.SameSourceIdentity,
+	}
+	if rule.MaxAgeHours.Valid {
+		result.MaxAgeHours = &rule.MaxAgeHours.Int32
+	}
+	return result
+}
+
+func CreateQuotaApprovalRule(rule dbmodels.QuotaApprovalRule) QuotaApprovalRule {
+	return QuotaApprovalRule{
+		ApprovalRuleBase: createApprovalRuleBase(dbmodels.QuotaApprovalRuleType, rule.ApprovalRule),
+		MaxReleases:      rule.MaxReleases,
+		WindowHours:      rule.WindowHours,
+	}
+}
+
+func CreateCallbackApprovalRule(rule dbmodels.CallbackApprovalRule) CallbackApprovalRule {
+	return CallbackApprovalRule{
+		ApprovalRuleBase: createApprovalRuleBase(dbmodels.CallbackApprovalRuleType, rule.ApprovalRule),
+		URL:              rule.URL,
+		Username:         getSqlStringContentsOrNil(rule.Username),
+		TLSCaCertificate: getSqlStringContentsOrNil(rule.TLSCaCertificate),
+		TimeoutMinutes:   rule.TimeoutMinutes,
+	}
+}
+
+func CreateExpressionApprovalRule(rule dbmodels.ExpressionApprovalRule) ExpressionApprovalRule {
+	return ExpressionApprovalRule{
+		ApprovalRuleBase: createApprovalRuleBase(dbmodels.ExpressionApprovalRuleType, rule.ApprovalRule),
+		Expression:       rule.Expression,
+	}
+}
+
+func CreatePromotionApprovalRule(rule dbmodels.PromotionApprovalRule) PromotionApprovalRule {
+	return PromotionApprovalRule{
+		ApprovalRuleBase:    createApprovalRuleBase(dbmodels.PromotionApprovalRuleType, rule.ApprovalRule),
+		SourceApplicationID: rule.SourceApplicationID,
+		MinSoakHours:        rule.MinSoakHours,
+	}
+}
@@ -3,10 +3,14 @@ package json
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/fullstaq-labs/sqedule/server/dbmodels"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/approvalpolicy"
 	"github.com/fullstaq-labs/sqedule/server/dbmodels/retrypolicy"
+	"github.com/fullstaq-labs/sqedule/server/expression"
+	"github.com/fullstaq-labs/sqedule/server/schedule"
 )
 
 //
@@ -16,13 +20,14 @@ import (
 type ApprovalRuleInput struct {
 	Type dbmodels.ApprovalRuleType
 	ApprovalRuleInputBase
-	HTTPApiApprovalRuleInput
-	ScheduleApprovalRuleInput
-	ManualApprovalRuleInput
+
+	// TypeSpecific contains the fields that are specific to Type.
+	TypeSpecific ApprovalRuleTypeInput `json:"-"`
 }
 
 type ApprovalRuleInputBase struct {
-	Enabled *bool `json:"enabled"`
+	Enabled         *bool  `json:"enabled"`
+	EvaluationOrder *int32 `json:"evaluation_order"`
 }
 
 type HTTPApiApprovalRuleInput struct {
@@ -37,9 +42,13 @@ type HTTPApiApprovalRuleInput struct {
 type ScheduleApprovalRuleInput struct {
 	BeginTime    *string `json:"begin_time"`
 	EndTime      *string `json:"end_time"`
+	TimeRanges   *string `json:"time_ranges"`
 	DaysOfWeek   *string `json:"days_of_week"`
 	DaysOfMonth  *string `json:"days_of_month"`
 	MonthsOfYear *string `json:"months_of_year"`
+	TimeZone     *string `json:"time_zone"`
+
+	DeferUntilWindow *bool `json:"defer_until_window"`
 }
 
 type ManualApprovalRuleInput struct {
@@ -47,6 +56,38 @@ type ManualApprovalRuleInput struct {
 	Minimum        *int32                `json:"minimum"`
 }
 
+type CalendarApprovalRuleInput struct {
+	CalendarID string `json:"calendar_id"`
+}
+
+type DependencyApprovalRuleInput struct {
+	DependencyApplicationID string `json:"dependency_application_id"`
+	MaxAgeHours             *int32 `json:"max_age_hours"`
+	SameSourceIdentity      *bool  `json:"same_source_identity"`
+}
+
+type QuotaApprovalRuleInput struct {
+	MaxReleases int32 `json:"max_releases"`
+	WindowHours int32 `json:"window_hours"`
+}
+
+type CallbackApprovalRuleInput struct {
+	URL              string  `json:"url"`
+	Username         *string `json:"username"`
+	Password         *string `json:"password"`
+	TLSCaCertificate *string `json:"tls_ca_certificate"`
+	TimeoutMinutes   *int32  `json:"timeout_minutes"`
+}
+
+type ExpressionApprovalRuleInput struct {
+	Expression string `json:"expression"`
+}
+
+type PromotionApprovalRuleInput struct {
+	SourceApplicationID string `json:"source_application_id"`
+	MinSoakHours        int32  `json:"min_soak_hours"`
+}
+
 //
 // ******** ApprovalRuleInput methods ********
 //
@@ -63,16 +104,17 @@ func (input *ApprovalRuleInput) UnmarshalJSON(b []byte) error {
 	}
 
 	input.Type = dbmodels.ApprovalRuleType(object["type"].(string))
-	switch input.Type {
-	case dbmodels.HTTPApiApprovalRuleType:
-		return json.Unmarshal(b, &input.HTTPApiApprovalRuleInput)
-	case dbmodels.ScheduleApprovalRuleType:
-		return json.Unmarshal(b, &input.ScheduleApprovalRuleInput)
-	case dbmodels.ManualApprovalRuleType:
-		return json.Unmarshal(b, &input.ManualApprovalRuleInput)
-	default:
-		panic("Unsupported approval rule type " + input.Type)
+	codec := findApprovalRuleTypeCodec(input.Type)
+	if codec == nil {
+		return errors.New("Unsupported approval rule type '" + string(input.Type) + "'")
+	}
+
+	err = json.Unmarshal(b, &input.ApprovalRuleInputBase)
+	if err != nil {
+		return err
 	}
+	input.TypeSpecific, err = codec.UnmarshalApprovalRuleInput(b)
+	return err
 }
 
 func (input ApprovalRuleInput) AppendToDbmodelsApprovalRulesetContents(organizationID string, contents *dbmodels.ApprovalRulesetContents) {
@@ -81,29 +123,8 @@ func (input ApprovalRuleInput) AppendToDbmodelsApprovalRulesetContents(organizat
 			OrganizationID: organizationID,
 		},
 	}
-
-	switch input.Type {
-	case dbmodels.HTTPApiApprovalRuleType:
-		model := dbmodels.HTTPApiApprovalRule{ApprovalRule: base}
-		input.ApprovalRuleInputBase.PopulateDbmodel(&model.ApprovalRule)
-		input.HTTPApiApprovalRuleInput.PopulateDbmodel(&model)
-		contents.HTTPApiApprovalRules = append(contents.HTTPApiApprovalRules, model)
-
-	case dbmodels.ScheduleApprovalRuleType:
-		model := dbmodels.ScheduleApprovalRule{ApprovalRule: base}
-		input.ApprovalRuleInputBase.PopulateDbmodel(&model.ApprovalRule)
-		input.ScheduleApprovalRuleInput.PopulateDbmodel(&model)
-		contents.ScheduleApprovalRules = append(contents.ScheduleApprovalRules, model)
-
-	case dbmodels.ManualApprovalRuleType:
-		model := dbmodels.ManualApprovalRule{ApprovalRule: base}
-		input.ApprovalRuleInputBase.PopulateDbmodel(&model.ApprovalRule)
-		input.ManualApprovalRuleInput.PopulateDbmodel(&model)
-		contents.ManualApprovalRules = append(contents.ManualApprovalRules, model)
-
-	default:
-		panic("Unsupported approval rule type " + input.Type)
-	}
+	input.ApprovalRuleInputBase.PopulateDbmodel(&base)
+	contents.Rules = append(contents.Rules, input.TypeSpecific.CreateDbmodel(base))
 }
 
 //
@@ -112,6 +133,9 @@ func (input ApprovalRuleInput) AppendToDbmodelsApprovalRulesetContents(organizat
 
 func (input ApprovalRuleInputBase) PopulateDbmodel(model *dbmodels.ApprovalRule) {
 	model.Enabled = input.Enabled
+	if input.EvaluationOrder != nil {
+		model.EvaluationOrder = *input.EvaluationOrder
+	}
 }
 
 //
@@ -134,9 +158,49 @@ func (input HTTPApiApprovalRuleInput) PopulateDbmodel(model *dbmodels.HTTPApiApp
 func (input ScheduleApprovalRuleInput) PopulateDbmodel(model *dbmodels.ScheduleApprovalRule) {
 	model.BeginTime = stringPointerToSqlString(input.BeginTime)
 	model.EndTime = stringPointerToSqlString(input.EndTime)
+	model.TimeRanges = stringPointerToSqlString(input.TimeRanges)
 	model.DaysOfWeek = stringPointerToSqlString(input.DaysOfWeek)
 	model.DaysOfMonth = stringPointerToSqlString(input.DaysOfMonth)
 	model.MonthsOfYear = stringPointerToSqlString(input.MonthsOfYear)
+	model.TimeZone = stringPointerToSqlString(input.TimeZone)
+	if input.DeferUntilWindow != nil {
+		model.DeferUntilWindow = *input.DeferUntilWindow
+	}
+}
+
+func (input ScheduleApprovalRuleInput) Validate() error {
+	if (input.BeginTime == nil) != (input.EndTime == nil) {
+		return errors.New("Schedule approval rule: 'begin_time' and 'end_time' must be set together")
+	}
+	if input.BeginTime != nil {
+		if _, err := schedule.ParseTime(time.Time{}, *input.BeginTime); err != nil {
+			return fmt.Errorf("Schedule approval rule: invalid 'begin_time': %w", err)
+		}
+		if _, err := schedule.ParseTime(time.Time{}, *input.EndTime); err != nil {
+			return fmt.Errorf("Schedule approval rule: invalid 'end_time': %w", err)
+		}
+	}
+	if input.TimeRanges != nil {
+		if _, err := schedule.ParseTimeRanges(*input.TimeRanges); err != nil {
+			return fmt.Errorf("Schedule approval rule: invalid 'time_ranges': %w", err)
+		}
+	}
+	if input.DaysOfWeek != nil {
+		if _, err := schedule.ParseWeekDays(*input.DaysOfWeek); err != nil {
+			return fmt.Errorf("Schedule approval rule: invalid 'days_of_week': %w", err)
+		}
+	}
+	if input.DaysOfMonth != nil {
+		if _, err := schedule.ParseMonthDays(*input.DaysOfMonth); err != nil {
+			return fmt.Errorf("Schedule approval rule: invalid 'days_of_month': %w", err)
+		}
+	}
+	if input.MonthsOfYear != nil {
+		if _, err := schedule.ParseMonths(*input.MonthsOfYear); err != nil {
+			return fmt.Errorf("Schedule approval rule: invalid 'months_of_year': %w", err)
+		}
+	}
+	return validateTimeZone(input.TimeZone)
 }
 
 //
@@ -147,3 +211,122 @@ func (input ManualApprovalRuleInput) PopulateDbmodel(model *dbmodels.ManualAppro
 	model.ApprovalPolicy = input.ApprovalPolicy
 	model.Minimum = int32PointerToSqlInt32(input.Minimum)
 }
+
+//
+// ******** CalendarApprovalRuleInput methods ********
+//
+
+func (input CalendarApprovalRuleInput) PopulateDbmodel(model *dbmodels.CalendarApprovalRule) {
+	model.CalendarID = input.CalendarID
+}
+
+func (input CalendarApprovalRuleInput) Validate() error {
+	if len(input.CalendarID) == 0 {
+		return errors.New("Calendar approval rule: 'calendar_id' must be set")
+	}
+	return nil
+}
+
+//
+// ******** DependencyApprovalRuleInput methods ********
+//
+
+func (input DependencyApprovalRuleInput) PopulateDbmodel(model *dbmodels.DependencyApprovalRule) {
+	model.DependencyApplicationID = input.DependencyApplicationID
+	model.MaxAgeHours = int32PointerToSqlInt32(input.MaxAgeHours)
+	if input.SameSourceIdentity != nil {
+		model.SameSourceIdentity = *input.SameSourceIdentity
+	}
+}
+
+func (input DependencyApprovalRuleInput) Validate() error {
+	if len(input.DependencyApplicationID) == 0 {
+		return errors.New("Dependency approval rule: 'dependency_application_id' must be set")
+	}
+	if input.MaxAgeHours != nil && *input.MaxAgeHours <= 0 {
+		return errors.New("Dependency approval rule: 'max_age_hours' must be greater than 0")
+	}
+	return nil
+}
+
+//
+// ******** QuotaApprovalRuleInput methods ********
+//
+
+func (input QuotaApprovalRuleInput) PopulateDbmodel(model *dbmodels.QuotaApprovalRule) {
+	model.MaxReleases = input.MaxReleases
+	model.WindowHours = input.WindowHours
+}
+
+func (input QuotaApprovalRuleInput) Validate() error {
+	if input.MaxReleases <= 0 {
+		return errors.New("Quota approval rule: 'max_releases' must be greater than 0")
+	}
+	if input.WindowHours <= 0 {
+		return errors.New("Quota approval rule: 'window_hours' must be greater than 0")
+	}
+	return nil
+}
+
+//
+// ******** CallbackApprovalRuleInput methods ********
+//
+
+func (input CallbackApprovalRuleInput) PopulateDbmodel(model *dbmodels.CallbackApprovalRule) {
+	model.URL = input.URL
+	model.Username = stringPointerToSqlString(input.Username)
+	model.Password = stringPointerToSqlString(input.Password)
+	model.TLSCaCertificate = stringPointerToSqlString(input.TLSCaCertificate)
+	if input.TimeoutMinutes != nil {
+		model.TimeoutMinutes = *input.TimeoutMinutes
+	} else {
+		model.TimeoutMinutes = 60
+	}
+}
+
+func (input CallbackApprovalRuleInput) Validate() error {
+	if len(input.URL) == 0 {
+		return errors.New("Callback approval rule: 'url' must be set")
+	}
+	if input.TimeoutMinutes != nil && *input.TimeoutMinutes <= 0 {
+		return errors.New("Callback approval rule: 'timeout_minutes' must be greater than 0")
+	}
+	return nil
+}
+
+//
+// ******** ExpressionApprovalRuleInput methods ********
+//
+
+func (input ExpressionApprovalRuleInput) PopulateDbmodel(model *dbmodels.ExpressionApprovalRule) {
+	model.Expression = input.Expression
+}
+
+func (input ExpressionApprovalRuleInput) Validate() error {
+	if len(input.Expression) == 0 {
+		return errors.New("Expression approval rule: 'expression' must be set")
+	}
+	if _, err := expression.Compile(input.Expression); err != nil {
+		return fmt.Errorf("Expression approval rule: invalid expression: %w", err)
+	}
+	return nil
+}
+
+//
+// ******** PromotionApprovalRuleInput methods ********
+//
+
+func (input PromotionApprovalRuleInput) PopulateDbmodel(model *dbmodels.PromotionApprovalRule) {
+	model.SourceApplicationID = input.SourceApplicationID
+	model.MinSoakHours = input.MinSoakHours
+}
+
+func (input PromotionApprovalRuleInput) Validate() error {
+	if len(input.SourceApplicationID) == 0 {
+		return errors.New("Promotion approval rule: 'source_application_id' must be set")
+	}
+	if input.MinSoakHours < 0 {
+		return errors.New("Promotion approval rule: 'min_soak_hours' may not be negative")
+	}
+	return nil
+}
@@ -12,10 +12,10 @@ import (
 // ******** Types, constants & variables ********
 //
 
+// ApprovalRuleOutcomeEnum is the JSON representation of an outcome of any rule type.
 type ApprovalRuleOutcomeEnum struct {
-	*HTTPApiApprovalRuleOutcome
-	*ScheduleApprovalRuleOutcome
-	*ManualApprovalRuleOutcome
+	// Outcome is the JSON representation of a specific outcome type, e.g. ScheduleApprovalRuleOutcome.
+	Outcome interface{}
 }
 
 type ApprovalRuleOutcomeBase struct {
@@ -28,7 +28,7 @@ type ApprovalRuleOutcomeBase struct {
 type HTTPApiApprovalRuleOutcome struct {
 	ApprovalRuleOutcomeBase
 	Rule                HTTPApiApprovalRule `json:"rule"`
-	ResponseCode        uint8               `json:"response_code"`
+	ResponseCode        uint16              `json:"response_code"`
 	ResponseContentType string              `json:"response_content_type"`
 	ResponseBodyBase64  string              `json:"response_body_base64"`
 }
@@ -44,22 +44,52 @@ type ManualApprovalRuleOutcome struct {
 	Comments *string            `json:"comments"`
 }
 
+type CalendarApprovalRuleOutcome struct {
+	ApprovalRuleOutcomeBase
+	Rule                 CalendarApprovalRule `json:"rule"`
+	BlockingEntrySummary *string              `json:"blocking_entry_summary"`
+}
+
+type DependencyApprovalRuleOutcome struct {
+	ApprovalRuleOutcomeBase
+	Rule                DependencyApprovalRule `json:"rule"`
+	DependencyReleaseID *uint64                `json:"dependency_release_id"`
+}
+
+type QuotaApprovalRuleOutcome struct {
+	ApprovalRuleOutcomeBase
+	Rule           QuotaApprovalRule `json:"rule"`
+	ApprovedCount  int32             `json:"approved_count"`
+	QuotaFreesUpAt *time.Time        `json:"quota_frees_up_at"`
+}
+
+type CallbackApprovalRuleOutcome struct {
+	ApprovalRuleOutcomeBase
+	Rule    CallbackApprovalRule `json:"rule"`
+	Message *string              `json:"message"`
+}
+
+type ExpressionApprovalRuleOutcome struct {
+	ApprovalRuleOutcomeBase
+	Rule           ExpressionApprovalRule `json:"rule"`
+	FailureMessage *string                `json:"failure_message"`
+}
+
+type PromotionApprovalRuleOutcome struct {
+	ApprovalRuleOutcomeBase
+	Rule            PromotionApprovalRule `json:"rule"`
+	SourceReleaseID *uint64               `json:"source_release_id"`
+}
+
 //
 // ******** ApprovalRuleOutcomeEnum methods ********
 //
 
-// Works around a bug in encoding/json where it doesn't include
-// deeply nested anonymous structs.
 func (enum ApprovalRuleOutcomeEnum) MarshalJSON() ([]byte, error) {
-	if enum.HTTPApiApprovalRuleOutcome != nil {
-		return encjson.Marshal(enum.HTTPApiApprovalRuleOutcome)
-	} else if enum.ScheduleApprovalRuleOutcome != nil {
-		return encjson.Marshal(enum.ScheduleApprovalRuleOutcome)
-	} else if enum.ManualApprovalRuleOutcome != nil {
-		return encjson.Marshal(enum.ManualApprovalRuleOutcome)
-	} else {
-		panic("Exactly one ApprovalRuleOutcomeEnum field must be set")
+	if enum.Outcome == nil {
+		panic("ApprovalRuleOutcomeEnum.Outcome must be set")
 	}
+	return encjson.Marshal(enum.Outcome)
 }
 
 //
@@ -99,3 +129,63 @@ func CreateManualApprovalRuleOutcome(outcome dbmodels.ManualApprovalRuleOutcome)
 		Comments:                getSqlStringContentsOrNil(outcome.Comments),
 	}
 }
+
+func CreateCalendarApprovalRuleOutcome(outcome dbmodels.CalendarApprovalRuleOutcome) CalendarApprovalRuleOutcome {
+	return CalendarApprovalRuleOutcome{
+		ApprovalRuleOutcomeBase: createApprovalRuleOutcomeBase(dbmodels.CalendarApprovalRuleOutcomeType, outcome.ApprovalRuleOutcome),
+		Rule:                    CreateCalendarApprovalRule(outcome.CalendarApprovalRule),
+		BlockingEntrySummary:    getSqlStringContentsOrNil(outcome.BlockingEntrySummary),
+	}
+}
+
+func CreateDependencyApprovalRuleOutcome(outcome dbmodels.DependencyApprovalRuleOutcome) DependencyApprovalRuleOutcome {
+	result := DependencyApprovalRuleOutcome{
+		ApprovalRuleOutcomeBase: createApprovalRuleOutcomeBase(dbmodels.DependencyApprovalRuleOutcomeType, outcome.ApprovalRuleOutcome),
+		Rule:                    CreateDependencyApprovalRule(outcome.DependencyApprovalRule),
+	}
+	if outcome.DependencyReleaseID.Valid {
+		releaseID := uint64(outcome.DependencyReleaseID.Int64)
+		result.DependencyReleaseID = &releaseID
+	}
+	return result
+}
+
+func CreateQuotaApprovalRuleOutcome(outcome dbmodels.QuotaApprovalRuleOutcome) QuotaApprovalRuleOutcome {
+	result := QuotaApprovalRuleOutcome{
+		ApprovalRuleOutcomeBase: createApprovalRuleOutcomeBase(dbmodels.QuotaApprovalRuleOutcomeType, outcome.ApprovalRuleOutcome),
+		Rule:                    CreateQuotaApprovalRule(outcome.QuotaApprovalRule),
+		ApprovedCount:           outcome.ApprovedCount,
+	}
+	if outcome.QuotaFreesUpAt.Valid {
+		result.QuotaFreesUpAt = &outcome.QuotaFreesUpAt.Time
+	}
+	return result
+}
+
+func CreateCallbackApprovalRuleOutcome(outcome dbmodels.CallbackApprovalRuleOutcome) CallbackApprovalRuleOutcome {
+	return CallbackApprovalRuleOutcome{
+		ApprovalRuleOutcomeBase: createApprovalRuleOutcomeBase(dbmodels.CallbackApprovalRuleOutcomeType, outcome.ApprovalRuleOutcome),
+		Rule:                    CreateCallbackApprovalRule(outcome.CallbackApprovalRule),
+		Message:                 getSqlStringContentsOrNil(outcome.Message),
+	}
+}
+
+func CreateExpressionApprovalRuleOutcome(outcome dbmodels.ExpressionApprovalRuleOutcome) ExpressionApprovalRuleOutcome {
+	return ExpressionApprovalRuleOutcome{
+		ApprovalRuleOutcomeBase: createApprovalRuleOutcomeBase(dbmodels.ExpressionApprovalRuleOutcomeType, outcome.ApprovalRuleOutcome),
+		Rule:                    CreateExpressionApprovalRule(outcome.ExpressionApprovalRule),
+		FailureMessage:          getSqlStringContentsOrNil(outcome.FailureMessage),
+	}
+}
+
+func CreatePromotionApprovalRuleOutcome(outcome dbmodels.PromotionApprovalRuleOutcome) PromotionApprovalRuleOutcome {
+	result := PromotionApprovalRuleOutcome{
+		ApprovalRuleOutcomeBase: createApprovalRuleOutcomeBase(dbmodels.PromotionApprovalRuleOutcomeType, outcome.ApprovalRuleOutcome),
+		Rule:                    CreatePromotionApprovalRule(outcome.PromotionApprovalRule),
+	}
+	if outcome.SourceReleaseID.Valid {
+		releaseID := uint64(outcome.SourceReleaseID.Int64)
+		result.SourceReleaseID = &releaseID
+	}
+	return result
+}
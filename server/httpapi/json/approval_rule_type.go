@@ -0,0 +1,132 @@
+package json
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// ApprovalRuleTypeCodec tells the JSON layer how to convert the rules (and rule outcomes) of a
+// specific ApprovalRuleType from and to JSON, and how to parse and validate rule input.
+//
+// This is only the JSON part of a rule type. Rule types that aren't built in are registered
+// with approvalrulesprocessing.RegisterApprovalRuleType(), which requires their definition to
+// also implement this interface.
+type ApprovalRuleTypeCodec interface {
+	// CreateApprovalRuleJSON returns the JSON representation of `rule`, which is a pointer to a
+	// rule of this type.
+	CreateApprovalRuleJSON(rule dbmodels.IApprovalRule) interface{}
+
+	// CreateApprovalRuleOutcomeJSON returns the JSON representation of `outcome`, which is a
+	// pointer to an outcome of this type.
+	CreateApprovalRuleOutcomeJSON(outcome interface{}) interface{}
+
+	// UnmarshalApprovalRuleInput parses the type-specific fields in `b`, and validates them.
+	UnmarshalApprovalRuleInput(b []byte) (ApprovalRuleTypeInput, error)
+}
+
+// ApprovalRuleTypeInput contains the type-specific fields of an ApprovalRuleInput.
+type ApprovalRuleTypeInput interface {
+	// CreateDbmodel returns a pointer to an unsaved rule of this type, whose fields are
+	// populated from `base` and from this input.
+	CreateDbmodel(base dbmodels.ApprovalRule) dbmodels.IApprovalRule
+}
+
+// BasicApprovalRuleTypeCodec implements ApprovalRuleTypeCodec using reflection.
+//
+// The built-in rule types are defined with it, and registered rule types may embed it.
+type BasicApprovalRuleTypeCodec struct {
+	// CreateRule is a function that converts a rule model to JSON, e.g. CreateScheduleApprovalRule.
+	CreateRule interface{}
+
+	// CreateOutcome is a function that converts an outcome model to JSON, e.g.
+	// CreateScheduleApprovalRuleOutcome.
+	CreateOutcome interface{}
+
+	// Input is a zero-valued input struct, e.g. `ScheduleApprovalRuleInput{}`. It must have a
+	// method `PopulateDbmodel(model *<rule model>)`, and may have a method `Validate() error`.
+	Input interface{}
+}
+
+type basicApprovalRuleTypeInput struct {
+	value reflect.Value
+}
+
+var builtinApprovalRuleTypeCodecs = map[dbmodels.ApprovalRuleType]ApprovalRuleTypeCodec{
+	dbmodels.HTTPApiApprovalRuleType:    BasicApprovalRuleTypeCodec{CreateHTTPApiApprovalRule, CreateHTTPApiApprovalRuleOutcome, HTTPApiApprovalRuleInput{}},
+	dbmodels.ScheduleApprovalRuleType:   BasicApprovalRuleTypeCodec{CreateScheduleApprovalRule, CreateScheduleApprovalRuleOutcome, ScheduleApprovalRuleInput{}},
+	dbmodels.ManualApprovalRuleType:     BasicApprovalRuleTypeCodec{CreateManualApprovalRule, CreateManualApprovalRuleOutcome, ManualApprovalRuleInput{}},
+	dbmodels.CalendarApprovalRuleType:   BasicApprovalRuleTypeCodec{CreateCalendarApprovalRule, CreateCalendarApprovalRuleOutcome, CalendarApprovalRuleInput{}},
+	dbmodels.DependencyApprovalRuleType: BasicApprovalRuleTypeCodec{CreateDependencyApprovalRule, CreateDependencyApprovalRuleOutcome, DependencyApprovalRuleInput{}},
+	dbmodels.QuotaApprovalRuleType:      BasicApprovalRuleTypeCodec{CreateQuotaApprovalRule, CreateQuotaApprovalRuleOutcome, QuotaApprovalRuleInput{}},
+	dbmodels.CallbackApprovalRuleType:   BasicApprovalRuleTypeCodec{CreateCallbackApprovalRule, CreateCallbackApprovalRuleOutcome, CallbackApprovalRuleInput{}},
+	dbmodels.PromotionApprovalRuleType:  BasicApprovalRuleTypeCodec{CreatePromotionApprovalRule, CreatePromotionApprovalRuleOutcome, PromotionApprovalRuleInput{}},
+	dbmodels.ExpressionApprovalRuleType: BasicApprovalRuleTypeCodec{CreateExpressionApprovalRule, CreateExpressionApprovalRuleOutcome, ExpressionApprovalRuleInput{}},
+}
+
+//
+// ******** Lookup functions ********
+//
+
+// findApprovalRuleTypeCodec returns the codec of the given rule type, or nil if that rule type
+// isn't supported. The codecs of registered rule types are their dbmodels definitions.
+func findApprovalRuleTypeCodec(ruleType dbmodels.ApprovalRuleType) ApprovalRuleTypeCodec {
+	if codec, ok := builtinApprovalRuleTypeCodecs[ruleType]; ok {
+		return codec
+	}
+	codec, _ := dbmodels.FindApprovalRuleTypeDefinition(ruleType).(ApprovalRuleTypeCodec)
+	return codec
+}
+
+func mustFindApprovalRuleTypeCodec(ruleType dbmodels.ApprovalRuleType) ApprovalRuleTypeCodec {
+	codec := findApprovalRuleTypeCodec(ruleType)
+	if codec == nil {
+		panic("Bug: approval rule type " + string(ruleType) + " has no JSON codec")
+	}
+	return codec
+}
+
+//
+// ******** BasicApprovalRuleTypeCodec methods ********
+//
+
+func (c BasicApprovalRuleTypeCodec) CreateApprovalRuleJSON(rule dbmodels.IApprovalRule) interface{} {
+	return reflect.ValueOf(c.CreateRule).Call([]reflect.Value{reflect.ValueOf(rule).Elem()})[0].Interface()
+}
+
+func (c BasicApprovalRuleTypeCodec) CreateApprovalRuleOutcomeJSON(outcome interface{}) interface{} {
+	return reflect.ValueOf(c.CreateOutcome).Call([]reflect.Value{reflect.ValueOf(outcome).Elem()})[0].Interface()
+}
+
+func (c BasicApprovalRuleTypeCodec) UnmarshalApprovalRuleInput(b []byte) (ApprovalRuleTypeInput, error) {
+	input := reflect.New(reflect.TypeOf(c.Input))
+	err := json.Unmarshal(b, input.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	if validator, ok := input.Interface().(interface{ Validate() error }); ok {
+		err = validator.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return basicApprovalRuleTypeInput{value: input.Elem()}, nil
+}
+
+//
+// ******** basicApprovalRuleTypeInput methods ********
+//
+
+func (input basicApprovalRuleTypeInput) CreateDbmodel(base dbmodels.ApprovalRule) dbmodels.IApprovalRule {
+	populate := input.value.MethodByName("PopulateDbmodel")
+	model := reflect.New(populate.Type().In(0).Elem())
+	model.Elem().FieldByName("ApprovalRule").Set(reflect.ValueOf(base))
+	populate.Call([]reflect.Value{model})
+	return model.Interface().(dbmodels.IApprovalRule)
+}
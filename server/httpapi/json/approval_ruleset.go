@@ -77,34 +77,11 @@ func (version *ApprovalRulesetVersion) PopulateFromDbmodelsReleaseApprovalRulese
 }
 
 func (version *ApprovalRulesetVersion) PopulateFromDbmodelsApprovalRulesetContents(contents dbmodels.ApprovalRulesetContents) {
-	var ruleTypesProcessed uint = 0
-
-	version.ApprovalRules = &[]ApprovalRuleEnum{}
-
-	ruleTypesProcessed++
-	for _, rule := range contents.HTTPApiApprovalRules {
-		ruleJSON := CreateHTTPApiApprovalRule(rule)
-		enumJSON := ApprovalRuleEnum{HTTPApiApprovalRule: &ruleJSON}
-		*version.ApprovalRules = append(*version.ApprovalRules, enumJSON)
-	}
-
-	ruleTypesProcessed++
-	for _, rule := range contents.ScheduleApprovalRules {
-		ruleJSON := CreateScheduleApprovalRule(rule)
-		enumJSON := ApprovalRuleEnum{ScheduleApprovalRule: &ruleJSON}
-		*version.ApprovalRules = append(*version.ApprovalRules, enumJSON)
-	}
-
-	ruleTypesProcessed++
-	for _, rule := range contents.ManualApprovalRules {
-		ruleJSON := CreateManualApprovalRule(rule)
-		enumJSON := ApprovalRuleEnum{ManualApprovalRule: &ruleJSON}
-		*version.ApprovalRules = append(*version.ApprovalRules, enumJSON)
-	}
-
-	if ruleTypesProcessed != dbmodels.NumApprovalRuleTypes {
-		panic("Bug: code does not cover all approval rule types")
+	rules := make([]ApprovalRuleEnum, 0, len(contents.Rules))
+	for _, rule := range contents.Rules {
+		rules = append(rules, CreateApprovalRuleEnum(rule))
 	}
+	version.ApprovalRules = &rules
 }
 
 //
@@ -0,0 +1,108 @@
+package json
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+type Calendar struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	TimeZone    *string   `json:"time_zone"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CalendarWithEntries struct {
+	Calendar
+	Entries []CalendarEntry `json:"entries"`
+}
+
+type CalendarEntry struct {
+	ID             uint64    `json:"id"`
+	UID            *string   `json:"uid"`
+	Summary        string    `json:"summary"`
+	AllDay         bool      `json:"all_day"`
+	BeginAt        time.Time `json:"begin_at"`
+	EndAt          time.Time `json:"end_at"`
+	RecurrenceRule *string   `json:"recurrence_rule"`
+}
+
+type CalendarInput struct {
+	ID          *string `json:"id"`
+	DisplayName *string `json:"display_name"`
+	TimeZone    *string `json:"time_zone"`
+}
+
+//
+// ******** CalendarInput methods ********
+//
+
+func (input CalendarInput) Validate() error {
+	return validateTimeZone(input.TimeZone)
+}
+
+//
+// ******** Constructor functions ********
+//
+
+func CreateCalendar(calendar dbmodels.Calendar) Calendar {
+	return Calendar{
+		ID:          calendar.ID,
+		DisplayName: calendar.DisplayName,
+		TimeZone:    getSqlStringContentsOrNil(calendar.TimeZone),
+		CreatedAt:   calendar.CreatedAt,
+		UpdatedAt:   calendar.UpdatedAt,
+	}
+}
+
+func CreateCalendarWithEntries(calendar dbmodels.Calendar, entries []dbmodels.CalendarEntry) CalendarWithEntries {
+	result := CalendarWithEntries{
+		Calendar: CreateCalendar(calendar),
+		Entries:  make([]CalendarEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, CreateCalendarEntry(entry))
+	}
+	return result
+}
+
+func CreateCalendarEntry(entry dbmodels.CalendarEntry) CalendarEntry {
+	result := CalendarEntry{
+		ID:             entry.ID,
+		UID:            getSqlStringContentsOrNil(entry.UID),
+		Summary:        entry.Summary,
+		AllDay:         entry.AllDay,
+		BeginAt:        entry.BeginAt,
+		EndAt:          entry.EndAt,
+		RecurrenceRule: getSqlStringContentsOrNil(entry.RecurrenceRule),
+	}
+	if entry.AllDay {
+		result.BeginAt = entry.BeginAt.UTC()
+		result.EndAt = entry.EndAt.UTC()
+	}
+	return result
+}
+
+//
+// ******** Other functions ********
+//
+
+func PatchCalendar(calendar *dbmodels.Calendar, input CalendarInput) {
+	if input.DisplayName != nil {
+		calendar.DisplayName = *input.DisplayName
+	}
+	if input.TimeZone != nil {
+		if len(*input.TimeZone) == 0 {
+			calendar.TimeZone = sql.NullString{}
+		} else {
+			calendar.TimeZone = stringPointerToSqlString(input.TimeZone)
+		}
+	}
+}
@@ -1,14 +1,27 @@
 package json
 
-import "github.com/fullstaq-labs/sqedule/server/dbmodels"
+import (
+	"database/sql"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
 
 //
 // ******** Types, constants & variables ********
 //
 
 type Organization struct {
-	ID          *string `json:"id"`
-	DisplayName *string `json:"display_name"`
+	ID              *string `json:"id"`
+	DisplayName     *string `json:"display_name"`
+	DefaultTimeZone *string `json:"default_time_zone"`
+}
+
+//
+// ******** Organization methods ********
+//
+
+func (input Organization) Validate() error {
+	return validateTimeZone(input.DefaultTimeZone)
 }
 
 //
@@ -17,8 +30,9 @@ type Organization struct {
 
 func CreateFromDbOrganization(organization dbmodels.Organization) Organization {
 	return Organization{
-		ID:          &organization.ID,
-		DisplayName: &organization.DisplayName,
+		ID:              &organization.ID,
+		DisplayName:     &organization.DisplayName,
+		DefaultTimeZone: getSqlStringContentsOrNil(organization.DefaultTimeZone),
 	}
 }
 
@@ -33,4 +47,11 @@ func PatchDbOrganization(organization *dbmodels.Organization, json Organization)
 	if json.DisplayName != nil {
 		organization.DisplayName = *json.DisplayName
 	}
+	if json.DefaultTimeZone != nil {
+		if len(*json.DefaultTimeZone) == 0 {
+			organization.DefaultTimeZone = sql.NullString{}
+		} else {
+			organization.DefaultTimeZone = stringPointerToSqlString(json.DefaultTimeZone)
+		}
+	}
 }
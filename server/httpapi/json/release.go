@@ -19,6 +19,10 @@ type Release struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	FinalizedAt *time.Time `json:"finalized_at"`
+
+	NextEligibleAt  *time.Time `json:"next_eligible_at"`
+	DeploymentState *string    `json:"deployment_state"`
+	Overridden      bool       `json:"overridden"`
 }
 
 type ReleasePatchablePart struct {
@@ -36,6 +40,29 @@ type ReleaseWithAssociations struct {
 	Release
 	Application             *ApplicationWithLatestApprovedVersion                  `json:"application,omitempty"`
 	ApprovalRulesetBindings *[]ReleaseApprovalRulesetBindingWithRulesetAssociation `json:"approval_ruleset_bindings,omitempty"`
+	BackgroundJob           *ReleaseBackgroundJob                                  `json:"background_job,omitempty"`
+}
+
+// ReleaseBackgroundJob describes the processing state of a Release that hasn't been finalized yet.
+type ReleaseBackgroundJob struct {
+	State     string     `json:"state"`
+	Attempts  uint32     `json:"attempts"`
+	LastError *string    `json:"last_error"`
+	NextRunAt *time.Time `json:"next_run_at"`
+	DeadAt    *time.Time `json:"dead_at"`
+}
+
+// ReleaseDryRunResult describes how a Release would be evaluated, without the Release
+// actually being created.
+type ReleaseDryRunResult struct {
+	State                   string                                                `json:"state"`
+	NextEligibleAt          *time.Time                                            `json:"next_eligible_at"`
+	ApprovalRulesetBindings []ReleaseApprovalRulesetBindingWithRulesetAssociation `json:"approval_ruleset_bindings"`
+	Events                  []ReleaseEventEnum                                    `json:"events"`
+
+	// NotEvaluatedRules are the rules that weren't evaluated because that requires
+	// contacting an external system, e.g. HTTP API rules.
+	NotEvaluatedRules []ApprovalRuleEnum `json:"not_evaluated_rules"`
 }
 
 //
@@ -72,6 +99,11 @@ func CreateFromDbRelease(release dbmodels.Release) Release {
 	if release.FinalizedAt.Valid {
 		result.FinalizedAt = &release.FinalizedAt.Time
 	}
+	if release.NextEligibleAt.Valid {
+		result.NextEligibleAt = &release.NextEligibleAt.Time
+	}
+	result.DeploymentState = getSqlStringContentsOrNil(release.DeploymentState)
+	result.Overridden = release.Overridden
 	return result
 }
 
@@ -122,6 +154,23 @@ func CreateFromDbReleaseWithAssociations(release dbmodels.Release, includeApplic
 	return result
 }
 
+func CreateFromDbReleaseBackgroundJob(job dbmodels.ReleaseBackgroundJob) ReleaseBackgroundJob {
+	result := ReleaseBackgroundJob{
+		State:    string(job.State()),
+		Attempts: job.Attempts,
+	}
+	if job.LastError.Valid {
+		result.LastError = &job.LastError.String
+	}
+	if job.NextRunAt.Valid {
+		result.NextRunAt = &job.NextRunAt.Time
+	}
+	if job.DeadAt.Valid {
+		result.DeadAt = &job.DeadAt.Time
+	}
+	return result
+}
+
 //
 // ******** Other functions ********
 //
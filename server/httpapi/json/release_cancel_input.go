@@ -0,0 +1,10 @@
+package json
+
+//
+// ******** Types, constants & variables ********
+//
+
+type ReleaseCancelInput struct {
+	// Reason optionally explains why the Release is cancelled.
+	Reason *string `json:"reason"`
+}
@@ -0,0 +1,32 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels/deploymentstate"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+type ReleaseDeploymentInput struct {
+	State *string `json:"state"`
+	// Comments optionally describes the deployment, e.g. a link to the deployment pipeline's job.
+	Comments *string `json:"comments"`
+}
+
+//
+// ******** ReleaseDeploymentInput methods ********
+//
+
+func (input ReleaseDeploymentInput) Validate() error {
+	if input.State == nil {
+		return errors.New("'state' field must be set")
+	}
+	if !deploymentstate.State(*input.State).IsValid() {
+		return fmt.Errorf("'state' must be one of %v", deploymentstate.All)
+	}
+	return nil
+}
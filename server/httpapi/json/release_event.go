@@ -15,6 +15,10 @@ type ReleaseEventEnum struct {
 	*ReleaseCreatedEvent
 	*ReleaseCancelledEvent
 	*ReleaseRuleProcessedEvent
+	*ReleaseRuleSkippedEvent
+	*ReleaseDeploymentEvent
+	*ReleaseOverriddenEvent
+	*ReleaseOverrideConfirmedEvent
 }
 
 type ReleaseEventBase struct {
@@ -29,6 +33,7 @@ type ReleaseCreatedEvent struct {
 
 type ReleaseCancelledEvent struct {
 	ReleaseEventBase
+	Reason *string `json:"reason"`
 }
 
 type ReleaseRuleProcessedEvent struct {
@@ -38,6 +43,31 @@ type ReleaseRuleProcessedEvent struct {
 	ApprovalRuleOutcome ApprovalRuleOutcomeEnum `json:"approval_rule_outcome"`
 }
 
+type ReleaseRuleSkippedEvent struct {
+	ReleaseEventBase
+	ApprovalRulesetID *string `json:"approval_ruleset_id"`
+	ApprovalRuleType  *string `json:"approval_rule_type"`
+	ApprovalRuleID    *uint64 `json:"approval_rule_id"`
+	Reason            string  `json:"reason"`
+}
+
+type ReleaseDeploymentEvent struct {
+	ReleaseEventBase
+	State    string  `json:"state"`
+	Comments *string `json:"comments"`
+}
+
+type ReleaseOverriddenEvent struct {
+	ReleaseEventBase
+	Justification        string     `json:"justification"`
+	ConfirmationDeadline *time.Time `json:"confirmation_deadline"`
+}
+
+type ReleaseOverrideConfirmedEvent struct {
+	ReleaseEventBase
+	ReleaseOverriddenEventID uint64 `json:"overridden_event_id"`
+}
+
 //
 // ******** ApprovalRuleOutcomeEnum methods ********
 //
@@ -51,6 +81,14 @@ func (enum ReleaseEventEnum) MarshalJSON() ([]byte, error) {
 		return encjson.Marshal(enum.ReleaseCancelledEvent)
 	} else if enum.ReleaseRuleProcessedEvent != nil {
 		return encjson.Marshal(enum.ReleaseRuleProcessedEvent)
+	} else if enum.ReleaseRuleSkippedEvent != nil {
+		return encjson.Marshal(enum.ReleaseRuleSkippedEvent)
+	} else if enum.ReleaseDeploymentEvent != nil {
+		return encjson.Marshal(enum.ReleaseDeploymentEvent)
+	} else if enum.ReleaseOverriddenEvent != nil {
+		return encjson.Marshal(enum.ReleaseOverriddenEvent)
+	} else if enum.ReleaseOverrideConfirmedEvent != nil {
+		return encjson.Marshal(enum.ReleaseOverrideConfirmedEvent)
 	} else {
 		panic("Exactly one ReleaseEventEnum field must be set")
 	}
@@ -77,6 +115,7 @@ func CreateReleaseCreatedEvent(event dbmodels.ReleaseCreatedEvent) ReleaseCreate
 func CreateReleaseCancelledEvent(event dbmodels.ReleaseCancelledEvent) ReleaseCancelledEvent {
 	return ReleaseCancelledEvent{
 		ReleaseEventBase: createReleaseEventBase(dbmodels.ReleaseCancelledEventType, event.ReleaseEvent),
+		Reason:           getSqlStringContentsOrNil(event.Reason),
 	}
 }
 
@@ -89,21 +128,48 @@ func CreateReleaseRuleProcessedEvent(event dbmodels.ReleaseRuleProcessedEvent) R
 	}
 }
 
-func createApprovalRuleOutcomeEnumFromDbmodelsReleaseRuleProcessedEvent(event dbmodels.ReleaseRuleProcessedEvent) ApprovalRuleOutcomeEnum {
-	if event.HTTPApiApprovalRuleOutcome != nil {
-		outcomeJSON := CreateHTTPApiApprovalRuleOutcome(*event.HTTPApiApprovalRuleOutcome)
-		return ApprovalRuleOutcomeEnum{HTTPApiApprovalRuleOutcome: &outcomeJSON}
+func CreateReleaseRuleSkippedEvent(event dbmodels.ReleaseRuleSkippedEvent) ReleaseRuleSkippedEvent {
+	result := ReleaseRuleSkippedEvent{
+		ReleaseEventBase:  createReleaseEventBase(dbmodels.ReleaseRuleSkippedEventType, event.ReleaseEvent),
+		ApprovalRulesetID: getSqlStringContentsOrNil(event.ApprovalRulesetID),
+		ApprovalRuleType:  getSqlStringContentsOrNil(event.ApprovalRuleType),
+		Reason:            event.Reason,
+	}
+	if event.ApprovalRuleID.Valid {
+		id := uint64(event.ApprovalRuleID.Int64)
+		result.ApprovalRuleID = &id
 	}
+	return result
+}
 
-	if event.ScheduleApprovalRuleOutcome != nil {
-		outcomeJSON := CreateScheduleApprovalRuleOutcome(*event.ScheduleApprovalRuleOutcome)
-		return ApprovalRuleOutcomeEnum{ScheduleApprovalRuleOutcome: &outcomeJSON}
+func CreateReleaseDeploymentEvent(event dbmodels.ReleaseDeploymentEvent) ReleaseDeploymentEvent {
+	return ReleaseDeploymentEvent{
+		ReleaseEventBase: createReleaseEventBase(dbmodels.ReleaseDeploymentEventType, event.ReleaseEvent),
+		State:            string(event.State),
+		Comments:         getSqlStringContentsOrNil(event.Comments),
 	}
+}
 
-	if event.ManualApprovalRuleOutcome != nil {
-		outcomeJSON := CreateManualApprovalRuleOutcome(*event.ManualApprovalRuleOutcome)
-		return ApprovalRuleOutcomeEnum{ManualApprovalRuleOutcome: &outcomeJSON}
+func CreateReleaseOverriddenEvent(event dbmodels.ReleaseOverriddenEvent) ReleaseOverriddenEvent {
+	return ReleaseOverriddenEvent{
+		ReleaseEventBase:     createReleaseEventBase(dbmodels.ReleaseOverriddenEventType, event.ReleaseEvent),
+		Justification:        event.Justification,
+		ConfirmationDeadline: getSqlTimeContentsOrNil(event.ConfirmationDeadline),
+	}
+}
+
+func CreateReleaseOverrideConfirmedEvent(event dbmodels.ReleaseOverrideConfirmedEvent) ReleaseOverrideConfirmedEvent {
+	return ReleaseOverrideConfirmedEvent{
+		ReleaseEventBase:         createReleaseEventBase(dbmodels.ReleaseOverrideConfirmedEventType, event.ReleaseEvent),
+		ReleaseOverriddenEventID: event.ReleaseOverriddenEventID,
+	}
+}
+
+func createApprovalRuleOutcomeEnumFromDbmodelsReleaseRuleProcessedEvent(event dbmodels.ReleaseRuleProcessedEvent) ApprovalRuleOutcomeEnum {
+	if event.ApprovalRuleOutcome == nil {
+		panic("ReleaseRuleProcessedEvent is not associated with an ApprovalRuleOutcome")
 	}
 
-	panic("ReleaseRuleProcessedEvent is not associated with an ApprovalRuleOutcome")
+	codec := mustFindApprovalRuleTypeCodec(event.ApprovalRuleType)
+	return ApprovalRuleOutcomeEnum{Outcome: codec.CreateApprovalRuleOutcomeJSON(event.ApprovalRuleOutcome)}
 }
@@ -0,0 +1,30 @@
+package json
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+type ReleaseManualApprovalInput struct {
+	// RuleID specifies which ManualApprovalRule to approve or reject. May be omitted
+	// if the Release is bound to exactly one ManualApprovalRule.
+	RuleID   *uint64 `json:"rule_id"`
+	Approved *bool   `json:"approved" binding:"required"`
+	Comments *string `json:"comments"`
+}
+
+//
+// ******** Other functions ********
+//
+
+func PatchDbManualApprovalRuleOutcome(outcome *dbmodels.ManualApprovalRuleOutcome, input ReleaseManualApprovalInput) {
+	if input.Approved != nil {
+		outcome.Success = *input.Approved
+	}
+	if input.Comments != nil && len(*input.Comments) > 0 {
+		outcome.Comments = stringPointerToSqlString(input.Comments)
+	}
+}
@@ -0,0 +1,45 @@
+package json
+
+import (
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// ReleaseNotification is sent by the release event streaming endpoints whenever a release
+// event is created, or a release's state changes.
+type ReleaseNotification struct {
+	ApplicationID string `json:"application_id"`
+	ReleaseID     uint64 `json:"release_id"`
+
+	// EventType and EventID are set if a release event was created.
+	EventType *string `json:"event_type"`
+	EventID   *uint64 `json:"event_id"`
+
+	// State is set if the release's state changed.
+	State *string `json:"state"`
+}
+
+//
+// ******** Constructor functions ********
+//
+
+func CreateFromDbReleaseNotification(notification dbmodels.ReleaseNotification) ReleaseNotification {
+	result := ReleaseNotification{
+		ApplicationID: notification.ApplicationID,
+		ReleaseID:     notification.ReleaseID,
+	}
+	if len(notification.EventType) > 0 {
+		eventType := string(notification.EventType)
+		eventID := notification.EventID
+		result.EventType = &eventType
+		result.EventID = &eventID
+	}
+	if len(notification.State) > 0 {
+		state := string(notification.State)
+		result.State = &state
+	}
+	return result
+}
@@ -0,0 +1,26 @@
+package json
+
+import (
+	"errors"
+	"strings"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+type ReleaseOverrideInput struct {
+	// Justification explains why the Release's approval rules are overridden.
+	Justification *string `json:"justification"`
+}
+
+//
+// ******** ReleaseOverrideInput methods ********
+//
+
+func (input ReleaseOverrideInput) Validate() error {
+	if input.Justification == nil || len(strings.TrimSpace(*input.Justification)) == 0 {
+		return errors.New("'justification' field must be set")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package json
+
+import (
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+)
+
+//
+// ******** Types, constants & variables ********
+//
+
+// RuleCallbackInput is the body with which an external system reports the result
+// of a CallbackApprovalRule's job.
+type RuleCallbackInput struct {
+	Success *bool   `json:"success" binding:"required"`
+	Message *string `json:"message"`
+}
+
+type CallbackApprovalRuleRequest struct {
+	ID            uint64     `json:"id"`
+	ApplicationID string     `json:"application_id"`
+	ReleaseID     uint64     `json:"release_id"`
+	RuleID        uint64     `json:"rule_id"`
+	Success       *bool      `json:"success"`
+	Message       *string    `json:"message"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+}
+
+//
+// ******** Constructor functions ********
+//
+
+func CreateCallbackApprovalRuleRequest(request dbmodels.CallbackApprovalRuleRequest) CallbackApprovalRuleRequest {
+	result := CallbackApprovalRuleRequest{
+		ID:            request.ID,
+		ApplicationID: request.ApplicationID,
+		ReleaseID:     request.ReleaseID,
+		RuleID:        request.CallbackApprovalRuleID,
+		Message:       getSqlStringContentsOrNil(request.Message),
+		CreatedAt:     request.CreatedAt,
+		ExpiresAt:     request.ExpiresAt,
+	}
+	if request.Success.Valid {
+		result.Success = &request.Success.Bool
+	}
+	if request.CompletedAt.Valid {
+		result.CompletedAt = &request.CompletedAt.Time
+	}
+	return result
+}
@@ -2,6 +2,7 @@ package json
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -19,6 +20,13 @@ func getSqlTimeContentsOrNil(t sql.NullTime) *time.Time {
 	return nil
 }
 
+func getSqlInt32ContentsOrNil(i sql.NullInt32) *int32 {
+	if i.Valid {
+		return &i.Int32
+	}
+	return nil
+}
+
 func stringPointerToSqlString(str *string) sql.NullString {
 	if str == nil {
 		return sql.NullString{String: "", Valid: false}
@@ -34,3 +42,15 @@ func int32PointerToSqlInt32(i *int32) sql.NullInt32 {
 		return sql.NullInt32{Int32: *i, Valid: true}
 	}
 }
+
+// validateTimeZone checks whether the given string, if non-nil and non-empty,
+// is a valid IANA time zone name such as "Europe/Amsterdam".
+func validateTimeZone(name *string) error {
+	if name == nil || len(*name) == 0 {
+		return nil
+	}
+	if _, err := time.LoadLocation(*name); err != nil {
+		return fmt.Errorf("Invalid time zone '%s': %w", *name, err)
+	}
+	return nil
+}
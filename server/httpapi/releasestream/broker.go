@@ -0,0 +1,143 @@
+// Package releasestream distributes ReleaseNotifications to HTTP clients that stream
+// release events.
+package releasestream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/fullstaq-labs/sqedule/server/dbutils"
+	"gorm.io/gorm"
+)
+
+const (
+	// SubscriptionBufferSize is the number of notifications that are buffered per Subscription.
+	// If a subscriber falls further behind, then notifications are dropped for that subscriber.
+	SubscriptionBufferSize = 100
+
+	listenRetryDelay = 5 * time.Second
+)
+
+// Broker listens for ReleaseNotifications (see dbmodels.NotifyRelease), which may be sent by any
+// Sqedule instance, and passes them to the Subscriptions that they match.
+// A single Broker should be shared by all HTTP requests, so that only one database connection
+// is used for listening.
+type Broker struct {
+	Db *gorm.DB
+
+	mutex         sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	stopped       bool
+}
+
+// Filter specifies which ReleaseNotifications a Subscription receives. An empty ApplicationID
+// matches all Applications in the organization, and a zero ReleaseID matches all Releases.
+type Filter struct {
+	OrganizationID string
+	ApplicationID  string
+	ReleaseID      uint64
+}
+
+// Subscription receives matching ReleaseNotifications on C. C is closed when the Broker stops.
+type Subscription struct {
+	C <-chan dbmodels.ReleaseNotification
+
+	broker *Broker
+	filter Filter
+	c      chan dbmodels.ReleaseNotification
+}
+
+func NewBroker(db *gorm.DB) *Broker {
+	return &Broker{
+		Db:            db,
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Run listens for ReleaseNotifications until `ctx` is done. Then it closes all Subscriptions.
+func (broker *Broker) Run(ctx context.Context) {
+	defer broker.stop()
+
+	for {
+		err := dbutils.Listen(ctx, broker.Db, dbmodels.ReleaseNotificationsChannel, broker.dispatch)
+		if err == nil {
+			return
+		}
+
+		broker.Db.Logger.Warn(context.Background(),
+			"Error listening for release notifications; will retry in %.0f seconds: %s",
+			listenRetryDelay.Seconds(), err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// Subscribe returns a Subscription for ReleaseNotifications that match the filter.
+// The caller must call Close() when done with it.
+func (broker *Broker) Subscribe(filter Filter) *Subscription {
+	c := make(chan dbmodels.ReleaseNotification, SubscriptionBufferSize)
+	subscription := &Subscription{C: c, broker: broker, filter: filter, c: c}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if broker.stopped {
+		close(c)
+	} else {
+		broker.subscriptions[subscription] = struct{}{}
+	}
+	return subscription
+}
+
+// Close stops the Subscription from receiving further notifications.
+func (subscription *Subscription) Close() {
+	broker := subscription.broker
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	if _, ok := broker.subscriptions[subscription]; ok {
+		delete(broker.subscriptions, subscription)
+		close(subscription.c)
+	}
+}
+
+func (filter Filter) Matches(notification dbmodels.ReleaseNotification) bool {
+	return notification.OrganizationID == filter.OrganizationID &&
+		(len(filter.ApplicationID) == 0 || notification.ApplicationID == filter.ApplicationID) &&
+		(filter.ReleaseID == 0 || notification.ReleaseID == filter.ReleaseID)
+}
+
+func (broker *Broker) dispatch(payload string) {
+	notification, err := dbmodels.ParseReleaseNotification(payload)
+	if err != nil {
+		broker.Db.Logger.Warn(context.Background(), "Error parsing release notification: %s", err.Error())
+		return
+	}
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	for subscription := range broker.subscriptions {
+		if !subscription.filter.Matches(notification) {
+			continue
+		}
+		select {
+		case subscription.c <- notification:
+		default:
+			broker.Db.Logger.Warn(context.Background(),
+				"Release event stream subscriber is falling behind; dropping notification")
+		}
+	}
+}
+
+func (broker *Broker) stop() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	broker.stopped = true
+	for subscription := range broker.subscriptions {
+		close(subscription.c)
+	}
+	broker.subscriptions = make(map[*Subscription]struct{})
+}
@@ -0,0 +1,56 @@
+package releasestream
+
+import (
+	"testing"
+
+	"github.com/fullstaq-labs/sqedule/server/dbmodels"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestBroker() *Broker {
+	return NewBroker(&gorm.DB{Config: &gorm.Config{Logger: gormlogger.Discard}})
+}
+
+func TestFilterMatches(t *testing.T) {
+	notification := dbmodels.ReleaseNotification{OrganizationID: "org1", ApplicationID: "app1", ReleaseID: 2}
+
+	assert.True(t, Filter{OrganizationID: "org1"}.Matches(notification))
+	assert.True(t, Filter{OrganizationID: "org1", ApplicationID: "app1"}.Matches(notification))
+	assert.True(t, Filter{OrganizationID: "org1", ApplicationID: "app1", ReleaseID: 2}.Matches(notification))
+	assert.False(t, Filter{OrganizationID: "org2"}.Matches(notification))
+	assert.False(t, Filter{OrganizationID: "org1", ApplicationID: "app2"}.Matches(notification))
+	assert.False(t, Filter{OrganizationID: "org1", ApplicationID: "app1", ReleaseID: 3}.Matches(notification))
+}
+
+func TestBrokerDispatchesToMatchingSubscriptions(t *testing.T) {
+	broker := newTestBroker()
+	matching := broker.Subscribe(Filter{OrganizationID: "org1", ApplicationID: "app1"})
+	defer matching.Close()
+	other := broker.Subscribe(Filter{OrganizationID: "org1", ApplicationID: "app2"})
+	defer other.Close()
+
+	broker.dispatch(`{"organization_id":"org1","application_id":"app1","release_id":2,"state":"approved"}`)
+
+	if assert.Equal(t, 1, len(matching.C)) {
+		notification := <-matching.C
+		assert.Equal(t, uint64(2), notification.ReleaseID)
+		assert.Equal(t, "approved", string(notification.State))
+	}
+	assert.Equal(t, 0, len(other.C))
+}
+
+func TestBrokerClosesSubscriptionsWhenStopped(t *testing.T) {
+	broker := newTestBroker()
+	subscription := broker.Subscribe(Filter{OrganizationID: "org1"})
+
+	broker.stop()
+	_, ok := <-subscription.C
+	assert.False(t, ok)
+	subscription.Close()
+
+	subscription = broker.Subscribe(Filter{OrganizationID: "org1"})
+	_, ok = <-subscription.C
+	assert.False(t, ok)
+}
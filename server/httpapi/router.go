@@ -13,6 +13,11 @@ import (
 
 func (ctx Context) SetupRouter(engine *gin.Engine, logger gormlogger.Interface) error {
 	controllerCtx := controllers.NewContext(ctx.Db, ctx.WaitGroup)
+	controllerCtx.AutoProcessReleaseInBackground = ctx.AutoProcessReleaseInBackground
+	controllerCtx.ReleaseStreamBroker = ctx.ReleaseStreamBroker
+	if ctx.BackgroundProcessingContext != nil {
+		controllerCtx.BackgroundProcessingContext = ctx.BackgroundProcessingContext
+	}
 	jwtAuthMiddleware, orgMemberLookupMiddleware, err := ctx.newAuthMiddlewares()
 	if err != nil {
 		return err
@@ -0,0 +1,337 @@
+// Package icalendar implements just enough of iCalendar (RFC 5545) to import
+// events from .ics files into Calendars.
+//
+// Only VEVENT components are parsed. Recurring events are supported as long as
+// their RRULE only uses the FREQ, INTERVAL, COUNT and UNTIL parts. Events with
+// features that would change which dates are covered, but that this package
+// doesn't understand (e.g. EXDATE), are rejected with an error instead of being
+// imported incorrectly.
+package icalendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT. If AllDay is true, then Begin and End are dates
+// (midnight UTC) instead of points in time. End is exclusive.
+type Event struct {
+	UID        string
+	Summary    string
+	AllDay     bool
+	Begin      time.Time
+	End        time.Time
+	Recurrence *RecurrenceRule
+}
+
+type contentLine struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+type eventBuilder struct {
+	event      Event
+	hasBegin   bool
+	hasEnd     bool
+	duration   *duration
+	cancelled  bool
+	lineNumber int
+}
+
+type duration struct {
+	Days int
+	Time time.Duration
+}
+
+// Parse parses an iCalendar stream and returns its (non-cancelled) events.
+// Date-times without a time zone ("floating" times) are interpreted in `location`.
+func Parse(r io.Reader, location *time.Location) ([]Event, error) {
+	lines, err := readContentLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Event
+	var componentStack []string
+	var builder *eventBuilder
+	var foundCalendar bool
+
+	for i, line := range lines {
+		lineNumber := i + 1
+
+		switch line.Name {
+		case "BEGIN":
+			componentStack = append(componentStack, strings.ToUpper(line.Value))
+			if strings.ToUpper(line.Value) == "VCALENDAR" {
+				foundCalendar = true
+			}
+			if len(componentStack) == 2 && componentStack[1] == "VEVENT" {
+				builder = &eventBuilder{lineNumber: lineNumber}
+			}
+			continue
+		case "END":
+			if len(componentStack) == 0 || componentStack[len(componentStack)-1] != strings.ToUpper(line.Value) {
+				return nil, fmt.Errorf("Line %d: unexpected END:%s", lineNumber, line.Value)
+			}
+			if len(componentStack) == 2 && componentStack[1] == "VEVENT" {
+				event, err := builder.finish()
+				if err != nil {
+					return nil, fmt.Errorf("Event starting at line %d: %w", builder.lineNumber, err)
+				}
+				if !builder.cancelled {
+					result = append(result, event)
+				}
+				builder = nil
+			}
+			componentStack = componentStack[:len(componentStack)-1]
+			continue
+		}
+
+		// Only process properties that belong directly to a VEVENT, not to
+		// nested components such as VALARM.
+		if builder == nil || len(componentStack) != 2 {
+			continue
+		}
+		err = builder.processProperty(line, location)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d (%s): %w", lineNumber, line.Name, err)
+		}
+	}
+
+	if !foundCalendar {
+		return nil, errors.New("No VCALENDAR component found")
+	}
+	if len(componentStack) > 0 {
+		return nil, fmt.Errorf("Unterminated %s component", componentStack[len(componentStack)-1])
+	}
+	return result, nil
+}
+
+// readContentLines splits the input into unfolded content lines.
+func readContentLines(r io.Reader) ([]contentLine, error) {
+	var rawLines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(rawLines) > 0 {
+			rawLines[len(rawLines)-1] += line[1:]
+		} else if len(line) > 0 {
+			rawLines = append(rawLines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error reading iCalendar data: %w", err)
+	}
+
+	result := make([]contentLine, 0, len(rawLines))
+	for i, rawLine := range rawLines {
+		line, err := parseContentLine(rawLine)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %w", i+1, err)
+		}
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+// parseContentLine parses a line in the format of `NAME[;PARAM=VALUE...]:VALUE`.
+// Parameter values may be quoted, in which case they may contain ':' and ';'.
+func parseContentLine(str string) (contentLine, error) {
+	var quoted bool
+	var separators []int
+	colon := -1
+
+	for i, c := range str {
+		if c == '"' {
+			quoted = !quoted
+		} else if !quoted && c == ';' {
+			separators = append(separators, i)
+		} else if !quoted && c == ':' {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return contentLine{}, fmt.Errorf("Invalid content line '%s' (no ':' found)", str)
+	}
+
+	result := contentLine{Params: make(map[string]string), Value: str[colon+1:]}
+	bounds := append(separators, colon)
+	result.Name = strings.ToUpper(str[:bounds[0]])
+	for i := 0; i < len(bounds)-1; i++ {
+		param := str[bounds[i]+1 : bounds[i+1]]
+		components := strings.SplitN(param, "=", 2)
+		if len(components) != 2 {
+			return contentLine{}, fmt.Errorf("Invalid parameter '%s'", param)
+		}
+		result.Params[strings.ToUpper(components[0])] = strings.Trim(components[1], `"`)
+	}
+	return result, nil
+}
+
+func (b *eventBuilder) processProperty(line contentLine, location *time.Location) error {
+	var err error
+
+	switch line.Name {
+	case "UID":
+		b.event.UID = line.Value
+	case "SUMMARY":
+		b.event.Summary = unescapeText(line.Value)
+	case "STATUS":
+		b.cancelled = strings.ToUpper(line.Value) == "CANCELLED"
+	case "DTSTART":
+		b.event.Begin, b.event.AllDay, err = parseDateOrDateTime(line, location)
+		b.hasBegin = err == nil
+	case "DTEND":
+		var allDay bool
+		b.event.End, allDay, err = parseDateOrDateTime(line, location)
+		if err == nil && b.hasBegin && allDay != b.event.AllDay {
+			err = errors.New("DTSTART and DTEND must both be either dates or date-times")
+		}
+		b.hasEnd = err == nil
+	case "DURATION":
+		var d duration
+		d, err = parseDuration(line.Value)
+		b.duration = &d
+	case "RRULE":
+		var rule RecurrenceRule
+		rule, err = ParseRecurrenceRule(line.Value)
+		b.event.Recurrence = &rule
+	case "EXDATE", "RDATE", "EXRULE", "RECURRENCE-ID":
+		err = errors.New("Not supported")
+	}
+
+	return err
+}
+
+func (b *eventBuilder) finish() (Event, error) {
+	if !b.hasBegin {
+		return Event{}, errors.New("DTSTART missing")
+	}
+
+	if !b.hasEnd {
+		if b.duration != nil {
+			b.event.End = b.event.Begin.AddDate(0, 0, b.duration.Days).Add(b.duration.Time)
+		} else if b.event.AllDay {
+			b.event.End = b.event.Begin.AddDate(0, 0, 1)
+		} else {
+			b.event.End = b.event.Begin
+		}
+	}
+
+	if b.event.End.Before(b.event.Begin) {
+		return Event{}, errors.New("Event ends before it begins")
+	}
+	return b.event, nil
+}
+
+// parseDateOrDateTime parses a DATE or DATE-TIME value. It returns whether the value is a DATE.
+func parseDateOrDateTime(line contentLine, location *time.Location) (time.Time, bool, error) {
+	if strings.ToUpper(line.Params["VALUE"]) == "DATE" || len(line.Value) == 8 {
+		result, err := time.Parse("20060102", line.Value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Invalid date '%s'", line.Value)
+		}
+		return result, true, nil
+	}
+
+	if strings.HasSuffix(line.Value, "Z") {
+		result, err := time.Parse("20060102T150405Z", line.Value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Invalid date-time '%s'", line.Value)
+		}
+		return result, false, nil
+	}
+
+	if tzid, ok := line.Params["TZID"]; ok {
+		var err error
+		location, err = time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Unknown time zone '%s'", tzid)
+		}
+	}
+	result, err := time.ParseInLocation("20060102T150405", line.Value, location)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Invalid date-time '%s'", line.Value)
+	}
+	return result, false, nil
+}
+
+// parseDuration parses a DURATION value such as `P1D`, `PT1H30M` or `P2W`.
+func parseDuration(str string) (duration, error) {
+	var result duration
+	var number string
+	var inTimePart bool
+
+	rest := strings.TrimPrefix(str, "+")
+	if !strings.HasPrefix(rest, "P") {
+		return duration{}, fmt.Errorf("Invalid duration '%s'", str)
+	}
+
+	for _, c := range rest[1:] {
+		if c >= '0' && c <= '9' {
+			number += string(c)
+			continue
+		}
+		if c == 'T' {
+			inTimePart = true
+			continue
+		}
+		if len(number) == 0 {
+			return duration{}, fmt.Errorf("Invalid duration '%s'", str)
+		}
+		value, err := strconv.Atoi(number)
+		if err != nil {
+			return duration{}, fmt.Errorf("Invalid duration '%s'", str)
+		}
+		number = ""
+
+		switch {
+		case c == 'W' && !inTimePart:
+			result.Days += 7 * value
+		case c == 'D' && !inTimePart:
+			result.Days += value
+		case c == 'H' && inTimePart:
+			result.Time += time.Duration(value) * time.Hour
+		case c == 'M' && inTimePart:
+			result.Time += time.Duration(value) * time.Minute
+		case c == 'S' && inTimePart:
+			result.Time += time.Duration(value) * time.Second
+		default:
+			return duration{}, fmt.Errorf("Invalid duration '%s'", str)
+		}
+	}
+	if len(number) > 0 {
+		return duration{}, fmt.Errorf("Invalid duration '%s'", str)
+	}
+
+	return result, nil
+}
+
+func unescapeText(str string) string {
+	var result strings.Builder
+	var escaped bool
+
+	for _, c := range str {
+		if escaped {
+			if c == 'n' || c == 'N' {
+				result.WriteRune('\n')
+			} else {
+				result.WriteRune(c)
+			}
+			escaped = false
+		} else if c == '\\' {
+			escaped = true
+		} else {
+			result.WriteRune(c)
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,179 @@
+package icalendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseString(str string) ([]Event, error) {
+	return Parse(strings.NewReader(strings.ReplaceAll(str, "\n", "\r\n")), time.UTC)
+}
+
+func TestParseAllDayEvents(t *testing.T) {
+	events, err := parseString(`BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:christmas
+SUMMARY:Christmas\, freeze
+DTSTART;VALUE=DATE:20211225
+DTEND;VALUE=DATE:20211227
+END:VEVENT
+BEGIN:VEVENT
+UID:newyear
+SUMMARY:New Year
+DTSTART;VALUE=DATE:20220101
+END:VEVENT
+END:VCALENDAR
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, events, 2) {
+		return
+	}
+
+	assert.Equal(t, "christmas", events[0].UID)
+	assert.Equal(t, "Christmas, freeze", events[0].Summary)
+	assert.True(t, events[0].AllDay)
+	assert.Equal(t, time.Date(2021, time.December, 25, 0, 0, 0, 0, time.UTC), events[0].Begin)
+	assert.Equal(t, time.Date(2021, time.December, 27, 0, 0, 0, 0, time.UTC), events[0].End)
+	assert.Nil(t, events[0].Recurrence)
+
+	// An all-day event without DTEND lasts one day
+	assert.Equal(t, time.Date(2022, time.January, 2, 0, 0, 0, 0, time.UTC), events[1].End)
+}
+
+func TestParseDateTimeEvents(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	events, err := Parse(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:UTC
+DTSTART:20210301T100000Z
+DTEND:20210301T120000Z
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:With TZID
+DTSTART;TZID="America/New_York":20210301T100000
+DURATION:PT1H30M
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Floating
+DTSTART:20210301T100000
+DTEND:20210301T110000
+BEGIN:VALARM
+DTSTART:20000101T000000Z
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`), amsterdam)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, events, 3) {
+		return
+	}
+
+	assert.False(t, events[0].AllDay)
+	assert.True(t, events[0].Begin.Equal(time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)))
+	assert.True(t, events[0].End.Equal(time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)))
+
+	assert.True(t, events[1].Begin.Equal(time.Date(2021, time.March, 1, 15, 0, 0, 0, time.UTC)))
+	assert.True(t, events[1].End.Equal(time.Date(2021, time.March, 1, 16, 30, 0, 0, time.UTC)))
+
+	assert.True(t, events[2].Begin.Equal(time.Date(2021, time.March, 1, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, events[2].End.Equal(time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestParseFoldedLines(t *testing.T) {
+	events, err := parseString(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Company-wide
+  change freeze
+DTSTART;VALUE=DATE:20211220
+END:VEVENT
+END:VCALENDAR
+`)
+	if assert.NoError(t, err) && assert.Len(t, events, 1) {
+		assert.Equal(t, "Company-wide change freeze", events[0].Summary)
+	}
+}
+
+func TestParseRecurringEvent(t *testing.T) {
+	events, err := parseString(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Christmas
+DTSTART;VALUE=DATE:20201225
+RRULE:FREQ=YEARLY
+END:VEVENT
+END:VCALENDAR
+`)
+	if assert.NoError(t, err) && assert.Len(t, events, 1) && assert.NotNil(t, events[0].Recurrence) {
+		assert.Equal(t, Yearly, events[0].Recurrence.Frequency)
+		assert.Equal(t, 1, events[0].Recurrence.Interval)
+	}
+}
+
+func TestParseSkipsCancelledEvents(t *testing.T) {
+	events, err := parseString(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Cancelled
+STATUS:CANCELLED
+DTSTART;VALUE=DATE:20201225
+END:VEVENT
+END:VCALENDAR
+`)
+	if assert.NoError(t, err) {
+		assert.Empty(t, events)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := map[string]string{
+		"":                                      "No VCALENDAR component found",
+		"BEGIN:VCALENDAR\n":                     "Unterminated VCALENDAR",
+		"BEGIN:VCALENDAR\nfoo\nEND:VCALENDAR\n": "no ':' found",
+		"BEGIN:VCALENDAR\nEND:VEVENT\n":         "unexpected END:VEVENT",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR\n":                                     "DTSTART missing",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2021\nEND:VEVENT\nEND:VCALENDAR\n":                                  "Invalid date-time",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;TZID=Nowhere:20210101T000000\nEND:VEVENT\nEND:VCALENDAR\n":          "Unknown time zone",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20210101\nDTEND:20210102T000000Z\nEND:VEVENT\nEND:VCALENDAR\n":      "must both be",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20210102\nDTEND:20210101\nEND:VEVENT\nEND:VCALENDAR\n":              "ends before it begins",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20210101\nEXDATE:20220101\nEND:VEVENT\nEND:VCALENDAR\n":             "EXDATE",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20210101\nRRULE:FREQ=YEARLY;BYMONTH=1\nEND:VEVENT\nEND:VCALENDAR\n": "BYMONTH",
+	}
+	for input, expectedError := range inputs {
+		_, err := parseString(input)
+		if assert.Error(t, err, "Input=%q", input) {
+			assert.Contains(t, err.Error(), expectedError, "Input=%q", input)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	inputs := map[string]duration{
+		"P1D":       {Days: 1},
+		"P2W":       {Days: 14},
+		"PT1H30M":   {Time: 90 * time.Minute},
+		"P1DT12H":   {Days: 1, Time: 12 * time.Hour},
+		"+PT15S":    {Time: 15 * time.Second},
+		"P1DT1M30S": {Days: 1, Time: 90 * time.Second},
+	}
+	for input, expected := range inputs {
+		result, err := parseDuration(input)
+		if assert.NoError(t, err, "Input=%s", input) {
+			assert.Equal(t, expected, result, "Input=%s", input)
+		}
+	}
+
+	for _, input := range []string{"", "1D", "PD", "P1H", "PT1D", "P1"} {
+		_, err := parseDuration(input)
+		assert.Error(t, err, "Input=%s", input)
+	}
+}
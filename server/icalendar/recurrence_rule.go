@@ -0,0 +1,157 @@
+package icalendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// RecurrenceRule is the supported subset of an RRULE. Count is 0 and Until is
+// the zero time if the rule recurs forever.
+type RecurrenceRule struct {
+	Frequency Frequency
+	Interval  int
+	Count     int
+	Until     time.Time
+}
+
+// ParseRecurrenceRule parses an RRULE value such as `FREQ=YEARLY;COUNT=10`.
+func ParseRecurrenceRule(str string) (RecurrenceRule, error) {
+	result := RecurrenceRule{Interval: 1}
+
+	for _, part := range strings.Split(str, ";") {
+		if len(part) == 0 {
+			continue
+		}
+
+		components := strings.SplitN(part, "=", 2)
+		if len(components) != 2 {
+			return RecurrenceRule{}, fmt.Errorf("Invalid recurrence rule part '%s'", part)
+		}
+		name := strings.ToUpper(components[0])
+		value := components[1]
+
+		switch name {
+		case "FREQ":
+			result.Frequency = Frequency(strings.ToUpper(value))
+			switch result.Frequency {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				return RecurrenceRule{}, fmt.Errorf("Unsupported recurrence frequency '%s'", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return RecurrenceRule{}, fmt.Errorf("Invalid recurrence interval '%s'", value)
+			}
+			result.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return RecurrenceRule{}, fmt.Errorf("Invalid recurrence count '%s'", value)
+			}
+			result.Count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return RecurrenceRule{}, err
+			}
+			result.Until = until
+		case "WKST":
+			// Only relevant in combination with BYxxx parts, which we don't support.
+		default:
+			return RecurrenceRule{}, fmt.Errorf("Unsupported recurrence rule part '%s'", name)
+		}
+	}
+
+	if len(result.Frequency) == 0 {
+		return RecurrenceRule{}, fmt.Errorf("Recurrence rule '%s' has no FREQ", str)
+	}
+	return result, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	var layouts = []string{"20060102T150405Z", "20060102T150405", "20060102"}
+	for _, layout := range layouts {
+		result, err := time.Parse(layout, value)
+		if err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes the entire day.
+				result = result.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			return result, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid recurrence end '%s'", value)
+}
+
+// String formats the rule as an RRULE value, which can be parsed again with ParseRecurrenceRule.
+func (r RecurrenceRule) String() string {
+	result := fmt.Sprintf("FREQ=%s;INTERVAL=%d", r.Frequency, r.Interval)
+	if r.Count > 0 {
+		result += fmt.Sprintf(";COUNT=%d", r.Count)
+	}
+	if !r.Until.IsZero() {
+		result += ";UNTIL=" + r.Until.UTC().Format("20060102T150405Z")
+	}
+	return result
+}
+
+// Contains checks whether `t` falls within one of the occurrences of an event that
+// recurs according to this rule, and whose first occurrence spans from `begin` (inclusive)
+// to `end` (exclusive). Occurrences are calculated in the time zone of `begin`.
+func (r RecurrenceRule) Contains(begin time.Time, end time.Time, t time.Time) bool {
+	length := end.Sub(begin)
+	var count int
+
+	for n := 0; ; n++ {
+		occurrence, valid := r.occurrence(begin, n)
+		if occurrence.After(t) || (!r.Until.IsZero() && occurrence.After(r.Until)) {
+			return false
+		}
+		if !valid {
+			// For example the 31st of a month that only has 30 days. Such occurrences
+			// are skipped, and don't count towards Count.
+			continue
+		}
+
+		count++
+		if r.Count > 0 && count > r.Count {
+			return false
+		}
+		if t.Before(occurrence.Add(length)) {
+			return true
+		}
+	}
+}
+
+// occurrence returns the begin time of the nth occurrence (counting from 0). It also returns
+// whether that occurrence exists: monthly and yearly rules skip dates that don't exist.
+func (r RecurrenceRule) occurrence(begin time.Time, n int) (time.Time, bool) {
+	steps := n * r.Interval
+
+	switch r.Frequency {
+	case Daily:
+		return begin.AddDate(0, 0, steps), true
+	case Weekly:
+		return begin.AddDate(0, 0, 7*steps), true
+	case Monthly:
+		result := begin.AddDate(0, steps, 0)
+		return result, result.Day() == begin.Day()
+	case Yearly:
+		result := begin.AddDate(steps, 0, 0)
+		return result, result.Day() == begin.Day()
+	default:
+		panic("Unsupported recurrence frequency " + string(r.Frequency))
+	}
+}
@@ -0,0 +1,88 @@
+package icalendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRecurrenceRule(t *testing.T) {
+	rule, err := ParseRecurrenceRule("FREQ=MONTHLY;INTERVAL=3;COUNT=4;WKST=MO")
+	if assert.NoError(t, err) {
+		assert.Equal(t, RecurrenceRule{Frequency: Monthly, Interval: 3, Count: 4}, rule)
+		assert.Equal(t, "FREQ=MONTHLY;INTERVAL=3;COUNT=4", rule.String())
+	}
+
+	rule, err = ParseRecurrenceRule("FREQ=YEARLY;UNTIL=20231231")
+	if assert.NoError(t, err) {
+		assert.Equal(t, date(2024, time.January, 1).Add(-time.Nanosecond), rule.Until)
+	}
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	inputs := map[string]string{
+		"":                      "has no FREQ",
+		"INTERVAL=2":            "has no FREQ",
+		"FREQ=HOURLY":           "Unsupported recurrence frequency",
+		"FREQ=DAILY;INTERVAL=0": "Invalid recurrence interval",
+		"FREQ=DAILY;COUNT=x":    "Invalid recurrence count",
+		"FREQ=DAILY;UNTIL=2021": "Invalid recurrence end",
+		"FREQ=WEEKLY;BYDAY=MO":  "Unsupported recurrence rule part 'BYDAY'",
+		"FREQ=WEEKLY;INTERVAL":  "Invalid recurrence rule part",
+	}
+	for input, expectedError := range inputs {
+		_, err := ParseRecurrenceRule(input)
+		if assert.Error(t, err, "Input=%s", input) {
+			assert.Contains(t, err.Error(), expectedError, "Input=%s", input)
+		}
+	}
+}
+
+func TestRecurrenceRuleContainsYearly(t *testing.T) {
+	rule := RecurrenceRule{Frequency: Yearly, Interval: 1}
+	begin := date(2020, time.December, 25)
+	end := date(2020, time.December, 27)
+
+	assert.False(t, rule.Contains(begin, end, date(2020, time.December, 24)))
+	assert.True(t, rule.Contains(begin, end, date(2020, time.December, 25)))
+	assert.True(t, rule.Contains(begin, end, date(2026, time.December, 26)))
+	assert.False(t, rule.Contains(begin, end, date(2026, time.December, 27)))
+	assert.False(t, rule.Contains(begin, end, date(2019, time.December, 25)))
+}
+
+func TestRecurrenceRuleContainsWithCount(t *testing.T) {
+	rule := RecurrenceRule{Frequency: Weekly, Interval: 2, Count: 3}
+	begin := date(2021, time.March, 1)
+	end := date(2021, time.March, 2)
+
+	assert.True(t, rule.Contains(begin, end, date(2021, time.March, 1)))
+	assert.False(t, rule.Contains(begin, end, date(2021, time.March, 8)))
+	assert.True(t, rule.Contains(begin, end, date(2021, time.March, 15)))
+	assert.True(t, rule.Contains(begin, end, date(2021, time.March, 29)))
+	assert.False(t, rule.Contains(begin, end, date(2021, time.April, 12)))
+}
+
+func TestRecurrenceRuleContainsWithUntil(t *testing.T) {
+	rule := RecurrenceRule{Frequency: Daily, Interval: 1, Until: date(2021, time.March, 3)}
+	begin := date(2021, time.March, 1)
+	end := date(2021, time.March, 2)
+
+	assert.True(t, rule.Contains(begin, end, date(2021, time.March, 3)))
+	assert.False(t, rule.Contains(begin, end, date(2021, time.March, 4)))
+}
+
+func TestRecurrenceRuleContainsSkipsNonExistingDates(t *testing.T) {
+	rule := RecurrenceRule{Frequency: Monthly, Interval: 1, Count: 2}
+	begin := date(2021, time.January, 31)
+	end := date(2021, time.February, 1)
+
+	// February 31 doesn't exist, so the second occurrence is on March 31.
+	assert.False(t, rule.Contains(begin, end, date(2021, time.March, 3)))
+	assert.True(t, rule.Contains(begin, end, date(2021, time.March, 31)))
+	assert.False(t, rule.Contains(begin, end, date(2021, time.May, 31)))
+}
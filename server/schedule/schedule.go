@@ -0,0 +1,182 @@
+// Package schedule parses the time, day and month specifications of ScheduleApprovalRules.
+// It's used both for evaluating those rules and for validating them when they're submitted
+// through the API.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRange is a pair of ScheduleApprovalRule time strings, in the format of `HH:MM[:SS]`.
+type TimeRange struct {
+	Begin string
+	End   string
+}
+
+// ParseTime parses a ScheduleApprovalRule time string. It returns a `time.Time`
+// whose date is equal to `date`, but whose time equals that of the time string.
+//
+// `str` has the format of `HH:MM[:SS]`.
+//
+// Example:
+//
+//	ParseTime(time.Date(2021, 2, 19, 0, 0, 0), "12:32") // => 2021-02-19 12:32
+func ParseTime(date time.Time, str string) (time.Time, error) {
+	components := strings.SplitN(str, ":", 3)
+	if len(components) < 2 {
+		return time.Time{}, errors.New("Invalid time format (HH:MM[:SS] expected)")
+	}
+
+	var hour, minute, second int64
+	var err error
+
+	hour, err = strconv.ParseInt(components[0], 10, 8)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Error parsing hour component: %w", err)
+	}
+	if hour < 0 || hour > 24 {
+		return time.Time{}, fmt.Errorf("Error parsing hour component: %d is not a valid value", hour)
+	}
+
+	minute, err = strconv.ParseInt(components[1], 10, 8)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Error parsing minute component: %w", err)
+	}
+	if minute < 0 || minute > 60 {
+		return time.Time{}, fmt.Errorf("Error parsing minute component: %d is not a valid value", minute)
+	}
+
+	if len(components) == 3 {
+		second, err = strconv.ParseInt(components[2], 10, 8)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Error parsing second component: %w", err)
+		}
+		if second < 0 || second > 60 {
+			return time.Time{}, fmt.Errorf("Error parsing second component: %d is not a valid value", second)
+		}
+	} else {
+		second = 0
+	}
+
+	result := time.Date(date.Year(), date.Month(), date.Day(), int(hour), int(minute), int(second), 0, date.Location())
+	return result, nil
+}
+
+// ParseTimeRanges parses a space-separated list of time ranges, each in the format
+// of `HH:MM[:SS]-HH:MM[:SS]`. The individual times are validated.
+func ParseTimeRanges(str string) ([]TimeRange, error) {
+	var result []TimeRange
+	for _, timeRangeStr := range strings.Split(str, " ") {
+		if len(timeRangeStr) == 0 {
+			continue
+		}
+
+		components := strings.SplitN(timeRangeStr, "-", 2)
+		if len(components) != 2 {
+			return nil, fmt.Errorf("'%s' is not a valid time range (HH:MM[:SS]-HH:MM[:SS] expected)", timeRangeStr)
+		}
+
+		timeRange := TimeRange{Begin: components[0], End: components[1]}
+		if _, err := ParseTime(time.Time{}, timeRange.Begin); err != nil {
+			return nil, fmt.Errorf("Error parsing begin time '%s': %w", timeRange.Begin, err)
+		}
+		if _, err := ParseTime(time.Time{}, timeRange.End); err != nil {
+			return nil, fmt.Errorf("Error parsing end time '%s': %w", timeRange.End, err)
+		}
+
+		result = append(result, timeRange)
+	}
+	return result, nil
+}
+
+// ParseWeekDays parses a space-separated list of weekdays, by name, abbreviation or number.
+func ParseWeekDays(str string) (map[time.Weekday]bool, error) {
+	result := make(map[time.Weekday]bool)
+	for _, day := range strings.Split(str, " ") {
+		switch strings.ToLower(day) {
+		case "1", "mon", "monday":
+			result[time.Monday] = true
+		case "2", "tue", "tuesday":
+			result[time.Tuesday] = true
+		case "3", "wed", "wednesday":
+			result[time.Wednesday] = true
+		case "4", "thu", "thursday":
+			result[time.Thursday] = true
+		case "5", "fri", "friday":
+			result[time.Friday] = true
+		case "6", "sat", "saturday":
+			result[time.Saturday] = true
+		case "0", "7", "sun", "sunday":
+			result[time.Sunday] = true
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("'%s' is not a recognized weekday", day)
+		}
+	}
+	return result, nil
+}
+
+// ParseMonthDays parses a space-separated list of days of month.
+func ParseMonthDays(str string) (map[int]bool, error) {
+	result := make(map[int]bool)
+	for _, day := range strings.Split(str, " ") {
+		if len(day) == 0 {
+			continue
+		}
+
+		dayInt, err := strconv.Atoi(day)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing month day '%s': %w", day, err)
+		}
+
+		if dayInt < 0 || dayInt > 31 {
+			return nil, fmt.Errorf("Month day '%s' is not a valid day", day)
+		}
+
+		result[int(dayInt)] = true
+	}
+	return result, nil
+}
+
+// ParseMonths parses a space-separated list of months, by name, abbreviation or number.
+func ParseMonths(str string) (map[time.Month]bool, error) {
+	result := make(map[time.Month]bool)
+	for _, month := range strings.Split(str, " ") {
+		switch strings.ToLower(month) {
+		case "1", "jan", "january":
+			result[time.January] = true
+		case "2", "feb", "february":
+			result[time.February] = true
+		case "3", "mar", "march":
+			result[time.March] = true
+		case "4", "apr", "april":
+			result[time.April] = true
+		case "5", "may":
+			result[time.May] = true
+		case "6", "jun", "june":
+			result[time.June] = true
+		case "7", "jul", "july":
+			result[time.July] = true
+		case "8", "aug", "august":
+			result[time.August] = true
+		case "9", "sep", "september":
+			result[time.September] = true
+		case "10", "oct", "october":
+			result[time.October] = true
+		case "11", "nov", "november":
+			result[time.November] = true
+		case "12", "dec", "december":
+			result[time.December] = true
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("'%s' is not a recognized month", month)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,511 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test ParseTimeRanges()
+
+func TestParseTimeRanges(t *testing.T) {
+	result, err := ParseTimeRanges(" 22:00-02:00  09:00:30-12:00 ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []TimeRange{
+			{Begin: "22:00", End: "02:00"},
+			{Begin: "09:00:30", End: "12:00"},
+		}, result)
+	}
+}
+
+func TestParseTimeRangesInvalidInput(t *testing.T) {
+	inputs := map[string]string{
+		"22:00":       "not a valid time range",
+		"22:00-":      "Error parsing end time",
+		"-02:00":      "Error parsing begin time",
+		"25:00-02:00": "Error parsing begin time",
+		"22:00-2:99":  "Error parsing end time",
+	}
+	for input, expectedError := range inputs {
+		_, err := ParseTimeRanges(input)
+		if assert.Error(t, err, "Input=%s", input) {
+			assert.Contains(t, err.Error(), expectedError, "Input=%s", input)
+		}
+	}
+}
+
+// Test ParseTime()
+
+func TestParseTimeTooFewComponents(t *testing.T) {
+	inputs := []string{"", "1"}
+	for _, input := range inputs {
+		_, err := ParseTime(time.Now(), input)
+		if assert.Error(t, err, "Input=%s", input) {
+			assert.Regexp(t, "Invalid time format", err.Error(), "Input=%s", input)
+		}
+	}
+}
+
+func TestParseTimeInvalidValues(t *testing.T) {
+	type Input struct {
+		Value     string
+		Component string
+	}
+
+	inputs := []Input{
+		{Value: ":", Component: "hour"},
+		{Value: "a:", Component: "hour"},
+		{Value: "-1:", Component: "hour"},
+		{Value: "25:", Component: "hour"},
+
+		{Value: "1:", Component: "minute"},
+		{Value: "1:b", Component: "minute"},
+		{Value: "1:-1", Component: "minute"},
+		{Value: "1:61", Component: "minute"},
+
+		{Value: "1:30:", Component: "second"},
+		{Value: "1:30:c", Component: "second"},
+		{Value: "1:30:-1", Component: "second"},
+		{Value: "1:30:61", Component: "second"},
+	}
+
+	for _, input := range inputs {
+		_, err := ParseTime(time.Now(), input.Value)
+		if assert.Error(t, err, "Input=%#v", input) {
+			assert.Regexp(t, "Error parsing "+input.Component+" component",
+				err.Error(), "Input=%#v", input)
+		}
+	}
+}
+
+func TestParseTimeValidValues(t *testing.T) {
+	var err error
+	var parsed time.Time
+	now := time.Now()
+
+	parsed, err = ParseTime(now, "1:20")
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed.Hour(), 1)
+		assert.Equal(t, parsed.Minute(), 20)
+		assert.Equal(t, parsed.Second(), 0)
+	}
+
+	parsed, err = ParseTime(now, "01:20")
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed.Hour(), 1)
+		assert.Equal(t, parsed.Minute(), 20)
+		assert.Equal(t, parsed.Second(), 0)
+	}
+
+	parsed, err = ParseTime(now, "16:5")
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed.Hour(), 16)
+		assert.Equal(t, parsed.Minute(), 5)
+		assert.Equal(t, parsed.Second(), 0)
+	}
+
+	parsed, err = ParseTime(now, "16:05")
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed.Hour(), 16)
+		assert.Equal(t, parsed.Minute(), 5)
+		assert.Equal(t, parsed.Second(), 0)
+	}
+
+	parsed, err = ParseTime(now, "8:47:1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed.Hour(), 8)
+		assert.Equal(t, parsed.Minute(), 47)
+		assert.Equal(t, parsed.Second(), 1)
+	}
+
+	parsed, err = ParseTime(now, "8:47:01")
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed.Hour(), 8)
+		assert.Equal(t, parsed.Minute(), 47)
+		assert.Equal(t, parsed.Second(), 1)
+	}
+}
+
+// Test ParseWeekDays()
+
+func TestParseWeekDaysEmpty(t *testing.T) {
+	var parsed map[time.Weekday]bool
+	var err error
+
+	parsed, err = ParseWeekDays("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(parsed))
+	}
+}
+
+func TestParseWeekDaysFull(t *testing.T) {
+	var parsed map[time.Weekday]bool
+	var err error
+
+	parsed, err = ParseWeekDays("monday")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.Monday])
+	}
+
+	parsed, err = ParseWeekDays("monday tuesday wednesday")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[time.Monday])
+		assert.True(t, parsed[time.Tuesday])
+		assert.True(t, parsed[time.Wednesday])
+	}
+
+	parsed, err = ParseWeekDays("sunday thursday friday saturday")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, len(parsed))
+		assert.True(t, parsed[time.Thursday])
+		assert.True(t, parsed[time.Friday])
+		assert.True(t, parsed[time.Saturday])
+		assert.True(t, parsed[time.Sunday])
+	}
+}
+
+func TestParseWeekDaysAbbrev(t *testing.T) {
+	var parsed map[time.Weekday]bool
+	var err error
+
+	parsed, err = ParseWeekDays("mon")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.Monday])
+	}
+
+	parsed, err = ParseWeekDays("mon tue wed")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[time.Monday])
+		assert.True(t, parsed[time.Tuesday])
+		assert.True(t, parsed[time.Wednesday])
+	}
+
+	parsed, err = ParseWeekDays("sun thu fri sat")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, len(parsed))
+		assert.True(t, parsed[time.Thursday])
+		assert.True(t, parsed[time.Friday])
+		assert.True(t, parsed[time.Saturday])
+		assert.True(t, parsed[time.Sunday])
+	}
+}
+
+func TestParseWeekDaysNumbers(t *testing.T) {
+	var parsed map[time.Weekday]bool
+	var err error
+
+	parsed, err = ParseWeekDays("1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.Monday])
+	}
+
+	parsed, err = ParseWeekDays("1 2 3")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[time.Monday])
+		assert.True(t, parsed[time.Tuesday])
+		assert.True(t, parsed[time.Wednesday])
+	}
+
+	parsed, err = ParseWeekDays("7 4 5 6")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, len(parsed))
+		assert.True(t, parsed[time.Thursday])
+		assert.True(t, parsed[time.Friday])
+		assert.True(t, parsed[time.Saturday])
+		assert.True(t, parsed[time.Sunday])
+	}
+
+	parsed, err = ParseWeekDays("0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.Sunday])
+	}
+}
+
+func TestParseWeekDaysExcessiveSpaces(t *testing.T) {
+	var parsed map[time.Weekday]bool
+	var err error
+
+	parsed, err = ParseWeekDays("  mon  wed    ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(parsed))
+		assert.True(t, parsed[time.Monday])
+		assert.True(t, parsed[time.Wednesday])
+	}
+}
+
+func TestParseWeekDaysCaseInsensitive(t *testing.T) {
+	var parsed map[time.Weekday]bool
+	var err error
+
+	inputs := []string{
+		"Mon Tue Wed Thu Fri Sat Sun",
+		"MON TUE WED THU FRI SAT SUN",
+		"monDay tuesDay wednesDay thursDay friDay saturDay sunDay",
+		"MONDAY TUESDAY WEDNESDAY THURSDAY FRIDAY SATURDAY SUNDAY",
+	}
+
+	for _, input := range inputs {
+		parsed, err = ParseWeekDays(input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 7, len(parsed))
+			assert.True(t, parsed[time.Monday], "Input=%s", input)
+			assert.True(t, parsed[time.Tuesday], "Input=%s", input)
+			assert.True(t, parsed[time.Wednesday], "Input=%s", input)
+			assert.True(t, parsed[time.Thursday], "Input=%s", input)
+			assert.True(t, parsed[time.Friday], "Input=%s", input)
+			assert.True(t, parsed[time.Saturday], "Input=%s", input)
+			assert.True(t, parsed[time.Sunday], "Input=%s", input)
+		}
+	}
+}
+
+func TestParseWeekDaysUnknownInput(t *testing.T) {
+	var err error
+
+	_, err = ParseWeekDays("today")
+	assert.Error(t, err)
+
+	_, err = ParseWeekDays("mon:tue")
+	assert.Error(t, err)
+}
+
+// Test ParseMonthDays()
+
+func TestParseMonthDaysEmpty(t *testing.T) {
+	var parsed map[int]bool
+	var err error
+
+	parsed, err = ParseMonthDays("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(parsed))
+	}
+}
+
+func TestParseMonthDays(t *testing.T) {
+	var parsed map[int]bool
+	var err error
+
+	parsed, err = ParseMonthDays("1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[1])
+	}
+
+	parsed, err = ParseMonthDays("1 2 3")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[1])
+		assert.True(t, parsed[2])
+		assert.True(t, parsed[3])
+	}
+
+	var input = ""
+	for i := 1; i <= 31; i++ {
+		input += fmt.Sprintf("%d", i) + " "
+	}
+	parsed, err = ParseMonthDays(input)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 31, len(parsed))
+		for i := 1; i <= 31; i++ {
+			assert.True(t, parsed[i])
+		}
+	}
+}
+
+func TestParseMonthDaysExcessiveSpaces(t *testing.T) {
+	var parsed map[int]bool
+	var err error
+
+	parsed, err = ParseMonthDays("  1  15  30    ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[1])
+		assert.True(t, parsed[15])
+		assert.True(t, parsed[30])
+	}
+}
+
+func TestParseMonthDaysInvalidInput(t *testing.T) {
+	var err error
+
+	_, err = ParseMonthDays("aa")
+	assert.Error(t, err)
+
+	_, err = ParseMonthDays("1:2")
+	assert.Error(t, err)
+
+	_, err = ParseMonthDays("32")
+	assert.Error(t, err)
+
+	_, err = ParseMonthDays("-1")
+	assert.Error(t, err)
+}
+
+// Test ParseMonths()
+
+func TestParseMonthsFull(t *testing.T) {
+	var parsed map[time.Month]bool
+	var err error
+
+	parsed, err = ParseMonths("january")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.January])
+	}
+
+	parsed, err = ParseMonths("january february march")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[time.January])
+		assert.True(t, parsed[time.February])
+		assert.True(t, parsed[time.March])
+	}
+
+	parsed, err = ParseMonths("december april may june july august september october november")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 9, len(parsed))
+		assert.True(t, parsed[time.April])
+		assert.True(t, parsed[time.May])
+		assert.True(t, parsed[time.June])
+		assert.True(t, parsed[time.July])
+		assert.True(t, parsed[time.August])
+		assert.True(t, parsed[time.September])
+		assert.True(t, parsed[time.October])
+		assert.True(t, parsed[time.November])
+		assert.True(t, parsed[time.December])
+	}
+}
+
+func TestParseMonthsAbbrev(t *testing.T) {
+	var parsed map[time.Month]bool
+	var err error
+
+	parsed, err = ParseMonths("jan")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.January])
+	}
+
+	parsed, err = ParseMonths("jan feb mar")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[time.January])
+		assert.True(t, parsed[time.February])
+		assert.True(t, parsed[time.March])
+	}
+
+	parsed, err = ParseMonths("dec apr may jun jul aug sep oct nov")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 9, len(parsed))
+		assert.True(t, parsed[time.April])
+		assert.True(t, parsed[time.May])
+		assert.True(t, parsed[time.June])
+		assert.True(t, parsed[time.July])
+		assert.True(t, parsed[time.August])
+		assert.True(t, parsed[time.September])
+		assert.True(t, parsed[time.October])
+		assert.True(t, parsed[time.November])
+		assert.True(t, parsed[time.December])
+	}
+}
+
+func TestParseMonthsNumbers(t *testing.T) {
+	var parsed map[time.Month]bool
+	var err error
+
+	parsed, err = ParseMonths("1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(parsed))
+		assert.True(t, parsed[time.January])
+	}
+
+	parsed, err = ParseMonths("1 2 3")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(parsed))
+		assert.True(t, parsed[time.January])
+		assert.True(t, parsed[time.February])
+		assert.True(t, parsed[time.March])
+	}
+
+	parsed, err = ParseMonths("7 4 5 6 7 8 9 10 11 12")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 9, len(parsed))
+		assert.True(t, parsed[time.April])
+		assert.True(t, parsed[time.May])
+		assert.True(t, parsed[time.June])
+		assert.True(t, parsed[time.July])
+		assert.True(t, parsed[time.August])
+		assert.True(t, parsed[time.September])
+		assert.True(t, parsed[time.October])
+		assert.True(t, parsed[time.November])
+		assert.True(t, parsed[time.December])
+	}
+}
+
+func TestParseMonthsExcessiveSpaces(t *testing.T) {
+	var parsed map[time.Month]bool
+	var err error
+
+	parsed, err = ParseMonths("  jan  feb    ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(parsed))
+		assert.True(t, parsed[time.January])
+		assert.True(t, parsed[time.February])
+	}
+}
+
+func TestParseMonthsCaseInsensitive(t *testing.T) {
+	var parsed map[time.Month]bool
+	var err error
+
+	inputs := []string{
+		"Jan Feb Mar Apr May Jun Jul Aug Sep Oct Nov Dec",
+		"JAN FEB MAR APR MAY JUN JUL AUG SEP OCT NOV DEC",
+		"januAry februAry marCh apRil maY juNe juLy auGust sepTember ocTober noVember deCember",
+		"JANUARY FEBRUARY MARCH APRIL MAY JUNE JULY AUGUST SEPTEMBER OCTOBER NOVEMBER DECEMBER",
+	}
+
+	for _, input := range inputs {
+		parsed, err = ParseMonths(input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 12, len(parsed))
+			assert.True(t, parsed[time.January], "Input=%s", input)
+			assert.True(t, parsed[time.February], "Input=%s", input)
+			assert.True(t, parsed[time.March], "Input=%s", input)
+			assert.True(t, parsed[time.April], "Input=%s", input)
+			assert.True(t, parsed[time.May], "Input=%s", input)
+			assert.True(t, parsed[time.June], "Input=%s", input)
+			assert.True(t, parsed[time.July], "Input=%s", input)
+			assert.True(t, parsed[time.August], "Input=%s", input)
+			assert.True(t, parsed[time.September], "Input=%s", input)
+			assert.True(t, parsed[time.October], "Input=%s", input)
+			assert.True(t, parsed[time.November], "Input=%s", input)
+			assert.True(t, parsed[time.December], "Input=%s", input)
+		}
+	}
+}
+
+func TestParseMonthsUnknownInput(t *testing.T) {
+	var err error
+
+	_, err = ParseMonths("today")
+	assert.Error(t, err)
+
+	_, err = ParseMonths("jan:feb")
+	assert.Error(t, err)
+
+	_, err = ParseMonths("-1")
+	assert.Error(t, err)
+
+	_, err = ParseMonths("13")
+	assert.Error(t, err)
+}
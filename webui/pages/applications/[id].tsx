@@ -391,8 +391,8 @@ const RELEASE_COLUMNS: ColDef[] = [
   {
     field: 'state',
     headerName: 'State',
-    width: 150,
-    valueFormatter: ({ value }) => formatReleaseStateString(value as string),
+    width: 200,
+    valueFormatter: ({ value, row }) => formatReleaseStateString(value as string, row.overridden),
   },
   {
     field: 'created_at',
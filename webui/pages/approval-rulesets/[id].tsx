@@ -524,9 +524,9 @@ const RELEASE_APPROVAL_RULESET_BINDING_COLUMNS: ColDef[] = [
   {
     field: 'state',
     headerName: 'State',
-    width: 150,
+    width: 200,
     valueGetter: ({ row }) => row.release.state,
-    valueFormatter: ({ value }) => formatReleaseStateString(value as string),
+    valueFormatter: ({ value, row }) => formatReleaseStateString(value as string, row.release.overridden),
   },
   {
     field: 'created_at',
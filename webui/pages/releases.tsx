@@ -56,8 +56,8 @@ const COLUMNS: ColDef[] = [
   {
     field: 'state',
     headerName: 'State',
-    width: 150,
-    valueFormatter: ({ value }) => formatStateString(value as string),
+    width: 200,
+    valueFormatter: ({ value, row }) => formatStateString(value as string, row.overridden),
   },
   {
     field: 'created_at',
@@ -153,13 +153,16 @@ ReleasesPage.navigationSection = NavigationSection.Releases;
 ReleasesPage.pageTitle = 'Releases';
 
 
-export function formatStateString(state: string): string | undefined {
+export function formatStateString(state: string, overridden?: boolean): string | undefined {
   switch (state) {
     case 'in_progress':
       return '🕐\xa0 In progress';
     case 'cancelled':
       return '❕\xa0 Cancelled';
     case 'approved':
+      if (overridden) {
+        return '⚠️\xa0 Approved (overridden)';
+      }
       return '✅\xa0 Approved';
     case 'rejected':
       return '❌\xa0 Rejected';
@@ -31,10 +31,14 @@ import Divider from '@material-ui/core/Divider';
 import Badge from '@material-ui/core/Badge';
 import AddCircleOutlineIcon from '@material-ui/icons/AddCircleOutline';
 import CancelIcon from '@material-ui/icons/Cancel';
+import RemoveCircleOutlineIcon from '@material-ui/icons/RemoveCircleOutline';
 import CloudIcon from '@material-ui/icons/Cloud';
+import CloudUploadIcon from '@material-ui/icons/CloudUpload';
 import AccessTimeIcon from '@material-ui/icons/AccessTime';
 import ThumbsUpDownIcon from '@material-ui/icons/ThumbsUpDown';
 import GavelIcon from '@material-ui/icons/Gavel';
+import WarningIcon from '@material-ui/icons/Warning';
+import VerifiedUserIcon from '@material-ui/icons/VerifiedUser';
 import Container from '@material-ui/core/Container';
 import { ColDef } from '@material-ui/data-grid';
 import styles from '../../../common/tables.module.scss';
@@ -231,7 +235,11 @@ function GeneralTabContents(props: IGeneralTabContentsProps) {
             </TableRow>
             <TableRow>
               <TableCell component="th" scope="row">State</TableCell>
-              <TableCell>{formatStateString(releaseData.state as string)}</TableCell>
+              <TableCell>{formatStateString(releaseData.state as string, releaseData.overridden)}</TableCell>
+            </TableRow>
+            <TableRow>
+              <TableCell component="th" scope="row">Deployment state</TableCell>
+              <TableCell>{humanizeUnderscoreString(releaseData.deployment_state) ?? 'N/A'}</TableCell>
             </TableRow>
             <TableRow>
               <TableCell component="th" scope="row">Created at</TableCell>
@@ -432,6 +440,18 @@ function EventsTabContents(props: IEventsTabContentsProps) {
       case 'rule_processed':
         itemContent = <ReleaseRuleProcessedEvent event={event} />;
         break;
+      case 'rule_skipped':
+        itemContent = <ReleaseRuleSkippedEvent event={event} />;
+        break;
+      case 'deployment':
+        itemContent = <ReleaseDeploymentEvent event={event} />;
+        break;
+      case 'overridden':
+        itemContent = <ReleaseOverriddenEvent event={event} />;
+        break;
+      case 'override_confirmed':
+        itemContent = <ReleaseOverrideConfirmedEvent event={event} />;
+        break;
       }
 
       if (typeof itemContent !== 'undefined') {
@@ -491,6 +511,81 @@ function ReleaseCancelledEvent(props: any): JSX.Element {
   );
 }
 
+function ReleaseRuleSkippedEvent(props: any): JSX.Element {
+  const { event } = props;
+  let subject: string;
+  if (event.approval_rule_type) {
+    subject = `${humanizeUnderscoreString(event.approval_rule_type)} rule skipped`;
+  } else {
+    subject = `Ruleset ${event.approval_ruleset_id} skipped`;
+  }
+
+  return (
+    <>
+      <ListItemAvatar><RemoveCircleOutlineIcon style={{ fontSize: '2.8rem' }} /></ListItemAvatar>
+      <ListItemText
+        primary={<Typography variant="h6">{subject}</Typography>}
+        secondary={<>{event.reason}<br />{formatDateTimeString(event.created_at)}</>} />
+    </>
+  );
+}
+
+function ReleaseDeploymentEvent(props: any): JSX.Element {
+  const { event } = props;
+  let badgeType: BadgeType;
+
+  switch (event.state) {
+  case 'deployed':
+    badgeType = 'success';
+    break;
+  case 'deploy_failed':
+  case 'rolled_back':
+    badgeType = 'error';
+    break;
+  default:
+    badgeType = 'neutral';
+    break;
+  }
+
+  return (
+    <>
+      <ListItemAvatar><CloudUploadIcon style={{ fontSize: '2.8rem' }} /></ListItemAvatar>
+      <ListItemText
+        primary={<Typography variant="h6"><TextWithBadge text="Deployment reported" badgeText={humanizeUnderscoreString(event.state) as string} badgeType={badgeType} /></Typography>}
+        secondary={<>{event.comments && <>{event.comments}<br /></>}{formatDateTimeString(event.created_at)}</>} />
+    </>
+  );
+}
+
+function ReleaseOverriddenEvent(props: any): JSX.Element {
+  const { event } = props;
+  const badgeText = event.confirmation_deadline ? 'awaiting confirmation' : 'approved';
+
+  return (
+    <>
+      <ListItemAvatar><WarningIcon style={{ fontSize: '2.8rem' }} /></ListItemAvatar>
+      <ListItemText
+        primary={<Typography variant="h6"><TextWithBadge text="Approval rules overridden" badgeText={badgeText} badgeType="warning" /></Typography>}
+        secondary={<>
+          {event.justification}<br />
+          {event.confirmation_deadline && <>Must be confirmed before {formatDateTimeString(event.confirmation_deadline)}<br /></>}
+          {formatDateTimeString(event.created_at)}
+        </>} />
+    </>
+  );
+}
+
+function ReleaseOverrideConfirmedEvent(props: any): JSX.Element {
+  return (
+    <>
+      <ListItemAvatar><VerifiedUserIcon style={{ fontSize: '2.8rem' }} /></ListItemAvatar>
+      <ListItemText
+        primary={<Typography variant="h6"><TextWithBadge text="Override confirmed" badgeText="approved" badgeType="warning" /></Typography>}
+        secondary={formatDateTimeString(props.event.created_at)} />
+    </>
+  );
+}
+
 function ReleaseRuleProcessedEvent(props: any): JSX.Element {
   const { event } = props;
 
@@ -578,7 +673,7 @@ function ReleaseRuleProcessedHeadline(props: any): JSX.Element {
 }
 
 
-type BadgeType = 'neutral' | 'success' | 'error';
+type BadgeType = 'neutral' | 'success' | 'warning' | 'error';
 
 interface ITextWithBadgeProps {
   text: string;